@@ -46,13 +46,13 @@ func main() {
 
 	// more examples here: https://developer.tdameritrade.com/content/place-order-samples
 	resp, err := c.Account.CreateSavedOrder(ctx, accountID, &tdameritrade.Order{
-		Session:           "NORMAL",
-		Duration:          "DAY",
-		OrderType:         "MARKET",
-		OrderStrategyType: "SINGLE",
+		Session:           tdameritrade.SessionNormal,
+		Duration:          tdameritrade.DurationDay,
+		OrderType:         tdameritrade.OrderTypeMarket,
+		OrderStrategyType: tdameritrade.OrderStrategyTypeSingle,
 		OrderLegCollection: []*tdameritrade.OrderLegCollection{
 			{
-				Instruction: "Sell",
+				Instruction: tdameritrade.InstructionSell,
 				Quantity:    1,
 				Instrument: tdameritrade.Instrument{
 					AssetType: "EQUITY",
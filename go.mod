@@ -1,8 +1,11 @@
 module github.com/glacialspring/go-tdameritrade
 
-go 1.12
+go 1.18
 
 require (
 	github.com/google/go-querystring v1.0.0
+	github.com/gorilla/websocket v1.4.2
 	golang.org/x/oauth2 v0.0.0-20191202225959-858c2ad4c8b6
 )
+
+require golang.org/x/net v0.0.0-20190108225652-1e06a53dbb7e // indirect
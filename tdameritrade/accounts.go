@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -31,11 +32,11 @@ type OptionA struct {
 	Cusip              string               `json:"cusip,omitempty"`
 	Symbol             string               `json:"symbol"`
 	Description        string               `json:"description,omitempty"`
-	Type               string               `json:"type"`
-	PutCall            string               `json:"putCall"`
-	UnderlyingSymbol   string               `json:"underlyingSymbol"`
-	OptionMultiplier   float64              `json:"optionMultiplier"`
-	OptionDeliverables []*OptionDeliverable `json:"optionDeliverables"`
+	Type               string               `json:"type,omitempty"`
+	PutCall            string               `json:"putCall,omitempty"`
+	UnderlyingSymbol   string               `json:"underlyingSymbol,omitempty"`
+	OptionMultiplier   float64              `json:"optionMultiplier,omitempty"`
+	OptionDeliverables []*OptionDeliverable `json:"optionDeliverables,omitempty"`
 }
 
 type MutualFund struct {
@@ -68,170 +69,33 @@ type FixedIncome struct {
 }
 
 type SecuritiesAccount struct {
-	Type                    string  `json:"type"`
-	AccountID               string  `json:"accountId"`
-	RoundTrips              float64 `json:"roundTrips"`
-	IsDayTrader             bool    `json:"isDayTrader"`
-	IsClosingOnlyRestricted bool    `json:"isClosingOnlyRestricted"`
-	Positions               []struct {
-		ShortQuantity                  float64    `json:"shortQuantity"`
-		AveragePrice                   float64    `json:"averagePrice"`
-		CurrentDayProfitLoss           float64    `json:"currentDayProfitLoss"`
-		CurrentDayProfitLossPercentage float64    `json:"currentDayProfitLossPercentage"`
-		LongQuantity                   float64    `json:"longQuantity"`
-		SettledLongQuantity            float64    `json:"settledLongQuantity"`
-		SettledShortQuantity           float64    `json:"settledShortQuantity"`
-		AgedQuantity                   float64    `json:"agedQuantity"`
-		Instrument                     Instrument `json:"instrument"`
-		MarketValue                    float64    `json:"marketValue"`
-	} `json:"positions"`
-	OrderStrategies []struct {
-		Session    string `json:"session"`
-		Duration   string `json:"duration"`
-		OrderType  string `json:"orderType"`
-		CancelTime struct {
-			Date        string `json:"date"`
-			ShortFormat bool   `json:"shortFormat"`
-		} `json:"cancelTime"`
-		ComplexOrderStrategyType string  `json:"complexOrderStrategyType"`
-		Quantity                 float64 `json:"quantity"`
-		FilledQuantity           float64 `json:"filledQuantity"`
-		RemainingQuantity        float64 `json:"remainingQuantity"`
-		RequestedDestination     string  `json:"requestedDestination"`
-		DestinationLinkName      string  `json:"destinationLinkName"`
-		ReleaseTime              string  `json:"releaseTime"`
-		StopPrice                float64 `json:"stopPrice"`
-		StopPriceLinkBasis       string  `json:"stopPriceLinkBasis"`
-		StopPriceLinkType        string  `json:"stopPriceLinkType"`
-		StopPriceOffset          float64 `json:"stopPriceOffset"`
-		StopType                 string  `json:"stopType"`
-		PriceLinkBasis           string  `json:"priceLinkBasis"`
-		PriceLinkType            string  `json:"priceLinkType"`
-		Price                    float64 `json:"price"`
-		TaxLotMethod             string  `json:"taxLotMethod"`
-		OrderLegCollection       []struct {
-			OrderLegType   string  `json:"orderLegType"`
-			LegID          int64   `json:"legId"`
-			Instrument     string  `json:"instrument"`
-			Instruction    string  `json:"instruction"`
-			PositionEffect string  `json:"positionEffect"`
-			Quantity       float64 `json:"quantity"`
-			QuantityType   string  `json:"quantityType"`
-		} `json:"orderLegCollection"`
-		ActivationPrice          float64  `json:"activationPrice"`
-		SpecialInstruction       string   `json:"specialInstruction"`
-		OrderStrategyType        string   `json:"orderStrategyType"`
-		OrderID                  int64    `json:"orderId"`
-		Cancelable               bool     `json:"cancelable"`
-		Editable                 bool     `json:"editable"`
-		Status                   string   `json:"status"`
-		EnteredTime              string   `json:"enteredTime"`
-		CloseTime                string   `json:"closeTime"`
-		Tag                      string   `json:"tag"`
-		AccountID                int64    `json:"accountId, string"`
-		OrderActivityCollection  []string `json:"orderActivityCollection"`
-		ReplacingOrderCollection []struct {
-		} `json:"replacingOrderCollection"`
-		ChildOrderStrategies []struct {
-		} `json:"childOrderStrategies"`
-		StatusDescription string `json:"statusDescription"`
-	} `json:"orderStrategies"`
-	InitialBalances   Balance `json:"initialBalances"`
-	CurrentBalances   Balance `json:"currentBalances"`
-	ProjectedBalances Balance `json:"projectedBalances"`
-}
-
-type Balance struct {
-	AccruedInterest              float64 `json:"accruedInterest"`
-	CashBalance                  float64 `json:"cashBalance"`
-	CashReceipts                 float64 `json:"cashReceipts"`
-	LongOptionMarketValue        float64 `json:"longOptionMarketValue"`
-	LiquidationValue             float64 `json:"liquidationValue"`
-	LongMarketValue              float64 `json:"longMarketValue"`
-	MoneyMarketFund              float64 `json:"moneyMarketFund"`
-	Savings                      float64 `json:"savings"`
-	ShortMarketValue             float64 `json:"shortMarketValue"`
-	PendingDeposits              float64 `json:"pendingDeposits"`
-	CashAvailableForTrading      float64 `json:"cashAvailableForTrading"`
-	CashAvailableForWithdrawal   float64 `json:"cashAvailableForWithdrawal"`
-	CashCall                     float64 `json:"cashCall"`
-	LongNonMarginableMarketValue float64 `json:"longNonMarginableMarketValue"`
-	TotalCash                    float64 `json:"totalCash"`
-	ShortOptionMarketValue       float64 `json:"shortOptionMarketValue"`
-	MutualFundValue              float64 `json:"mutualFundValue"`
-	BondValue                    float64 `json:"bondValue"`
-	CashDebitCallValue           float64 `json:"cashDebitCallValue"`
-	UnsettledCash                float64 `json:"unsettledCash"`
-}
-
-type OrderLegCollection struct {
-	OrderLegType   string     `json:"orderLegType,omitempty"`
-	LegID          int        `json:"legId,omitempty"`
-	Instrument     Instrument `json:"instrument"`
-	Instruction    string     `json:"instruction"`
-	PositionEffect string     `json:"positionEffect,omitempty"`
-	Quantity       int        `json:"quantity"`
-	QuantityType   string     `json:"quantityType,omitempty"`
-}
-
-type CancelTime struct {
-	Date        string `json:"date,omitempty"`
-	ShortFormat bool   `json:"shortFormat,omitempty"`
-}
-
-type Order struct {
-	Session                  string                `json:"session"`
-	Duration                 string                `json:"duration"`
-	OrderType                string                `json:"orderType"`
-	CancelTime               *CancelTime           `json:"cancelTime,omitempty"`
-	ComplexOrderStrategyType string                `json:"complexOrderStrategyType,omitempty"`
-	Quantity                 float64               `json:"quantity,omitempty"`
-	FilledQuantity           float64               `json:"filledQuantity,omitempty"`
-	RemainingQuantity        float64               `json:"remainingQuantity,omitempty"`
-	RequestedDestination     string                `json:"requestedDestination,omitempty"`
-	DestinationLinkName      string                `json:"destinationLinkName,omitempty"`
-	ReleaseTime              string                `json:"releaseTime,omitempty"`
-	StopPrice                float64               `json:"stopPrice,omitempty"`
-	StopPriceLinkBasis       string                `json:"stopPriceLinkBasis,omitempty"`
-	StopPriceLinkType        string                `json:"stopPriceLinkType,omitempty"`
-	StopPriceOffset          float64               `json:"stopPriceOffset,omitempty"`
-	StopType                 string                `json:"stopType,omitempty"`
-	PriceLinkBasis           string                `json:"priceLinkBasis,omitempty"`
-	PriceLinkType            string                `json:"priceLinkType,omitempty"`
-	Price                    float64               `json:"price,omitempty"`
-	TaxLotMethod             string                `json:"taxLotMethod,omitempty"`
-	OrderLegCollection       []*OrderLegCollection `json:"orderLegCollection"`
-	ActivationPrice          float64               `json:"activationPrice,omitempty"`
-	SpecialInstruction       string                `json:"specialInstruction,omitempty"`
-	OrderStrategyType        string                `json:"orderStrategyType"`
-	OrderID                  int64                 `json:"orderId,omitempty"`
-	Cancelable               bool                  `json:"cancelable,omitempty"`
-	Editable                 bool                  `json:"editable,omitempty"`
-	Status                   string                `json:"status,omitempty"`
-	EnteredTime              string                `json:"enteredTime,omitempty"`
-	CloseTime                string                `json:"closeTime,omitempty"`
-	Tag                      string                `json:"tag,omitempty"`
-	AccountID                float64               `json:"accountId,omitempty"`
-	OrderActivityCollection  []*Execution          `json:"orderActivityCollection,omitempty"`
-	ReplacingOrderCollection []*Order              `json:"replacingOrderCollection,omitempty"`
-	ChildOrderStrategies     []*Order              `json:"childOrderStrategies,omitempty"`
-	StatusDescription        string                `json:"statusDescription,omitempty"`
-}
-
-type ExecutionLeg struct {
-	LegID             int64   `json:"legId"`
-	Quantity          float64 `json:"quantity"`
-	MismarkedQuantity float64 `json:"mismarkedQuantity"`
-	Price             float64 `json:"price"`
-	Time              string  `json:"time"`
-}
-
-type Execution struct {
-	ActivityType           string          `json:"activityType"`  //"'EXECUTION' or 'ORDER_ACTION'",
-	ExecutionType          string          `json:"executionType"` //"'FILL'",
-	Quantity               float64         `json:"quantity"`
-	OrderRemainingQuantity float64         `json:"orderRemainingQuantity"`
-	ExecutionLegs          []*ExecutionLeg `json:"executionLegs"`
+	Type                    string      `json:"type"`
+	AccountID               string      `json:"accountId"`
+	RoundTrips              float64     `json:"roundTrips"`
+	IsDayTrader             bool        `json:"isDayTrader"`
+	IsClosingOnlyRestricted bool        `json:"isClosingOnlyRestricted"`
+	Positions               []*Position `json:"positions"`
+	OrderStrategies         []*Order    `json:"orderStrategies"`
+	InitialBalances         Balances    `json:"initialBalances"`
+	CurrentBalances         Balances    `json:"currentBalances"`
+	ProjectedBalances       Balances    `json:"projectedBalances"`
+}
+
+// Position is a holding of an account, returned when AccountOptions.Position
+// is set.
+type Position struct {
+	ShortQuantity                  float64    `json:"shortQuantity"`
+	AveragePrice                   float64    `json:"averagePrice"`
+	CurrentDayProfitLoss           float64    `json:"currentDayProfitLoss"`
+	CurrentDayProfitLossPercentage float64    `json:"currentDayProfitLossPercentage"`
+	LongQuantity                   float64    `json:"longQuantity"`
+	SettledLongQuantity            float64    `json:"settledLongQuantity"`
+	SettledShortQuantity           float64    `json:"settledShortQuantity"`
+	AgedQuantity                   float64    `json:"agedQuantity"`
+	Instrument                     Instrument `json:"instrument"`
+	MarketValue                    float64    `json:"marketValue"`
+	MaintenanceRequirement         float64    `json:"maintenanceRequirement"`
+	PreviousSessionLongQuantity    float64    `json:"previousSessionLongQuantity"`
 }
 
 // AccountsService handles communication with the account related methods of
@@ -242,11 +106,30 @@ type AccountsService struct {
 	client *Client
 }
 
+// AccountOptions select the optional fields returned with an account.
 type AccountOptions struct {
-	Position bool
-	Orders   bool
+	Position bool // include the account's positions
+	Orders   bool // include the account's orders as orderStrategies
+}
+
+// fields returns the value of the fields query parameter, or "" if no
+// optional field is selected.
+func (opts *AccountOptions) fields() string {
+	if opts == nil {
+		return ""
+	}
+	var fields []string
+	if opts.Position {
+		fields = append(fields, "positions")
+	}
+	if opts.Orders {
+		fields = append(fields, "orders")
+	}
+	return strings.Join(fields, ",")
 }
 
+// OrderParams are the parameters of the deprecated order lookups of
+// AccountsService; see OrdersOptions.
 type OrderParams struct {
 	MaxResults int
 	From       time.Time
@@ -329,17 +212,11 @@ func (i *Instrument) MarshalJSON() ([]byte, error) {
 	}
 }
 
+// GetAccounts returns every account linked to the authenticated user, with
+// the optional fields selected by opts.
+// TDAmeritrade API Docs: https://developer.tdameritrade.com/account-access/apis/get/accounts-0
 func (s *AccountsService) GetAccounts(ctx context.Context, opts *AccountOptions) (*Accounts, *Response, error) {
-	u := "accounts"
-	if opts != nil {
-		if opts.Position {
-			u = fmt.Sprintf("%s?fields=%s", u, "positions")
-		}
-		if opts.Orders {
-			u = fmt.Sprintf("%s,%s", u, "orders")
-		}
-	}
-	req, err := s.client.NewRequest("GET", u, nil)
+	req, err := s.client.NewRequest("GET", accountsURL("", opts), nil)
 
 	if err != nil {
 		return nil, nil, err
@@ -353,17 +230,11 @@ func (s *AccountsService) GetAccounts(ctx context.Context, opts *AccountOptions)
 	return accounts, resp, err
 }
 
+// GetAccount returns the account accountID, with the optional fields selected
+// by opts.
+// TDAmeritrade API Docs: https://developer.tdameritrade.com/account-access/apis/get/accounts/%7BaccountId%7D-0
 func (s *AccountsService) GetAccount(ctx context.Context, accountID string, opts *AccountOptions) (*Account, *Response, error) {
-	u := fmt.Sprintf("accounts/%s", accountID)
-	if opts != nil {
-		if opts.Position {
-			u = fmt.Sprintf("%s?fields=%s", u, "positions")
-		}
-		if opts.Orders {
-			u = fmt.Sprintf("%s,%s", u, "orders")
-		}
-	}
-	req, err := s.client.NewRequest("GET", u, nil)
+	req, err := s.client.NewRequest("GET", accountsURL(accountID, opts), nil)
 
 	if err != nil {
 		return nil, nil, err
@@ -377,6 +248,30 @@ func (s *AccountsService) GetAccount(ctx context.Context, accountID string, opts
 	return account, resp, err
 }
 
+// GetAccountsRaw is GetAccounts returning the undecoded response body.
+func (s *AccountsService) GetAccountsRaw(ctx context.Context, opts *AccountOptions) (json.RawMessage, *Response, error) {
+	return s.client.getRaw(ctx, accountsURL("", opts))
+}
+
+// GetAccountRaw is GetAccount returning the undecoded response body.
+func (s *AccountsService) GetAccountRaw(ctx context.Context, accountID string, opts *AccountOptions) (json.RawMessage, *Response, error) {
+	return s.client.getRaw(ctx, accountsURL(accountID, opts))
+}
+
+// accountsURL returns the URL of the account accountID, or of all accounts
+// if accountID is empty, with the fields selected by opts.
+func accountsURL(accountID string, opts *AccountOptions) string {
+	u := "accounts"
+	if accountID != "" {
+		u = fmt.Sprintf("accounts/%s", accountID)
+	}
+	if fields := opts.fields(); fields != "" {
+		u = fmt.Sprintf("%s?fields=%s", u, fields)
+	}
+	return u
+}
+
+// Deprecated: use OrdersService.PlaceOrder.
 func (s *AccountsService) PlaceOrder(ctx context.Context, accountID string, order *Order) (*Response, error) {
 	u := fmt.Sprintf("accounts/%s/orders", accountID)
 	if order == nil {
@@ -391,6 +286,7 @@ func (s *AccountsService) PlaceOrder(ctx context.Context, accountID string, orde
 	return s.client.Do(ctx, req, nil)
 }
 
+// Deprecated: use OrdersService.CancelOrder.
 func (s *AccountsService) CancelOrder(ctx context.Context, accountID, orderID string) (*Response, error) {
 	u := fmt.Sprintf("accounts/%s/orders/%s", accountID, orderID)
 	req, err := s.client.NewRequest("DELETE", u, nil)
@@ -400,6 +296,7 @@ func (s *AccountsService) CancelOrder(ctx context.Context, accountID, orderID st
 	return s.client.Do(ctx, req, nil)
 }
 
+// Deprecated: use OrdersService.ReplaceOrder.
 func (s *AccountsService) ReplaceOrder(ctx context.Context, accountID string, orderID string, order *Order) (*Response, error) {
 	u := fmt.Sprintf("accounts/%s/orders/%s", accountID, orderID)
 	if order == nil {
@@ -413,6 +310,7 @@ func (s *AccountsService) ReplaceOrder(ctx context.Context, accountID string, or
 	return s.client.Do(ctx, req, nil)
 }
 
+// Deprecated: use OrdersService.GetOrder, which also decodes the order.
 func (s *AccountsService) GetOrder(ctx context.Context, accountID, orderID string) (*Response, error) {
 	u := fmt.Sprintf("accounts/%s/orders/%s", accountID, orderID)
 	req, err := s.client.NewRequest("GET", u, nil)
@@ -422,6 +320,8 @@ func (s *AccountsService) GetOrder(ctx context.Context, accountID, orderID strin
 	return s.client.Do(ctx, req, nil)
 }
 
+// Deprecated: use OrdersService.GetOrdersByPath, which also decodes the
+// orders and sends the query parameters.
 func (s *AccountsService) GetOrderByPath(ctx context.Context, accountID string, orderParams *OrderParams) (*Response, error) {
 	u := fmt.Sprintf("accounts/%s/orders", accountID)
 	req, err := s.client.NewRequest("GET", u, nil)
@@ -431,6 +331,8 @@ func (s *AccountsService) GetOrderByPath(ctx context.Context, accountID string,
 	return s.client.Do(ctx, req, nil)
 }
 
+// Deprecated: use OrdersService.GetOrdersByQuery, which also decodes the
+// orders and sends the query parameters.
 func (s *AccountsService) GetOrderByQuery(ctx context.Context, accountID string, orderParams *OrderParams) (*Response, error) {
 	u := fmt.Sprintf("accounts/%s/orders", accountID)
 	req, err := s.client.NewRequest("GET", u, nil)
@@ -440,6 +342,8 @@ func (s *AccountsService) GetOrderByQuery(ctx context.Context, accountID string,
 	return s.client.Do(ctx, req, nil)
 }
 
+// Deprecated: use SavedOrdersService.CreateSavedOrder, which also returns the
+// saved order id.
 func (s *AccountsService) CreateSavedOrder(ctx context.Context, accountID string, order *Order) (*Response, error) {
 	u := fmt.Sprintf("accounts/%s/savedorders", accountID)
 	if order == nil {
@@ -454,6 +358,7 @@ func (s *AccountsService) CreateSavedOrder(ctx context.Context, accountID string
 	return s.client.Do(ctx, req, nil)
 }
 
+// Deprecated: use SavedOrdersService.DeleteSavedOrder.
 func (s *AccountsService) DeleteSavedOrder(ctx context.Context, accountID, savedOrderID string) (*Response, error) {
 	u := fmt.Sprintf("accounts/%s/savedorders/%s", accountID, savedOrderID)
 	req, err := s.client.NewRequest("DELETE", u, nil)
@@ -463,7 +368,9 @@ func (s *AccountsService) DeleteSavedOrder(ctx context.Context, accountID, saved
 	return s.client.Do(ctx, req, nil)
 }
 
-func (s *AccountsService) GetSavedOrder(ctx context.Context, accountID ,savedOrderID string, orderParams *OrderParams) (*Response, error) {
+// Deprecated: use SavedOrdersService.GetSavedOrder, which also decodes the
+// order.
+func (s *AccountsService) GetSavedOrder(ctx context.Context, accountID, savedOrderID string, orderParams *OrderParams) (*Response, error) {
 	u := fmt.Sprintf("accounts/%s/savedorders/%s", accountID, savedOrderID)
 	req, err := s.client.NewRequest("GET", u, nil)
 	if err != nil {
@@ -472,6 +379,7 @@ func (s *AccountsService) GetSavedOrder(ctx context.Context, accountID ,savedOrd
 	return s.client.Do(ctx, req, nil)
 }
 
+// Deprecated: use SavedOrdersService.ReplaceSavedOrder.
 func (s *AccountsService) ReplaceSavedOrder(ctx context.Context, accountID, savedOrderID string, order *Order) (*Response, error) {
 	u := fmt.Sprintf("accounts/%s/savedorders/%s", accountID, savedOrderID)
 	if order == nil {
@@ -485,4 +393,3 @@ func (s *AccountsService) ReplaceSavedOrder(ctx context.Context, accountID, save
 	req.Header.Set("Content-Type", "application/json")
 	return s.client.Do(ctx, req, nil)
 }
-
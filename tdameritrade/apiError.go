@@ -0,0 +1,127 @@
+package tdameritrade
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIError is returned when the API answers with a non-2xx status code. It
+// carries the status, the error code and message the API put in the body,
+// and the request that failed, so callers can branch on the cause:
+//
+//	if apiErr, ok := err.(*APIError); ok && apiErr.IsRateLimited() {
+//		...
+//	}
+type APIError struct {
+	Response   *http.Response // the response, whose body has already been read
+	StatusCode int
+
+	// Code is the error code of the body, if it has one, such as
+	// invalid_grant for a rejected refresh token.
+	Code string
+
+	// Message is the error message of the body, or the body itself if it
+	// holds none, or else the status text.
+	Message string
+
+	Body   string // the response body
+	Method string // the method of the request
+	Path   string // the path of the request
+
+	// CorrelationID is the correlation id sent with the request, if any.
+	CorrelationID string
+}
+
+// ErrorResponse is the former name of APIError.
+//
+// Deprecated: use APIError.
+type ErrorResponse = APIError
+
+// newAPIError returns the error of resp, whose body is body.
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	e := &APIError{
+		Response:   resp,
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+	}
+	if resp.Request != nil {
+		e.Method = resp.Request.Method
+		e.Path = resp.Request.URL.Path
+		e.CorrelationID = resp.Request.Header.Get(CorrelationIDHeader)
+	}
+
+	var payload struct {
+		Code             json.RawMessage `json:"code"`
+		Error            string          `json:"error"`
+		ErrorDescription string          `json:"error_description"`
+	}
+	if json.Unmarshal(body, &payload) == nil {
+		switch {
+		case payload.ErrorDescription != "":
+			// An OAuth error, whose error field is the code.
+			e.Code, e.Message = payload.Error, payload.ErrorDescription
+		case len(payload.Code) > 0:
+			var s string
+			if json.Unmarshal(payload.Code, &s) != nil {
+				s = string(payload.Code)
+			}
+			e.Code = s
+		}
+	}
+	if e.Message == "" {
+		e.Message = strings.Join(errorMessages(e.Body), "; ")
+	}
+	if e.Message == "" {
+		e.Message = http.StatusText(resp.StatusCode)
+	}
+	return e
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if e.Code != "" {
+		msg = e.Code + ": " + msg
+	}
+	if e.Path == "" {
+		return fmt.Sprintf("%d %s", e.StatusCode, msg)
+	}
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.Path, e.StatusCode, msg)
+}
+
+// IsRateLimited reports whether the request was refused for exceeding the
+// rate limit. Retry it later, after the Retry-After delay if the response
+// has one.
+func (e *APIError) IsRateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests
+}
+
+// IsUnauthorized reports whether the access token was missing, invalid or
+// expired.
+func (e *APIError) IsUnauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized
+}
+
+// IsForbidden reports whether the user may not access the resource, such
+// as an account of another login.
+func (e *APIError) IsForbidden() bool {
+	return e.StatusCode == http.StatusForbidden
+}
+
+// IsNotFound reports whether the resource, such as an order, doesn't exist.
+func (e *APIError) IsNotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
+
+// IsBadRequest reports whether the API refused the request as invalid,
+// e.g. an order it rejects.
+func (e *APIError) IsBadRequest() bool {
+	return e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity
+}
+
+// IsServerError reports whether the API failed to serve the request. It may
+// succeed if tried again.
+func (e *APIError) IsServerError() bool {
+	return e.StatusCode >= 500
+}
@@ -1 +1,153 @@
 package tdameritrade
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// The OAuth2 endpoints of the TDAmeritrade API.
+// TDAmeritrade API docs: https://developer.tdameritrade.com/content/authentication-faq
+const (
+	AuthURL  = "https://auth.tdameritrade.com/auth"
+	TokenURL = "https://api.tdameritrade.com/v1/oauth2/token"
+)
+
+// clientIDSuffix is appended to the consumer key of an app to form the
+// OAuth client id.
+const clientIDSuffix = "@AMER.OAUTHAP"
+
+// OAuthConfig returns the OAuth2 configuration of an app with the consumer
+// key clientID, with or without its @AMER.OAUTHAP suffix, and the callback
+// URL redirectURL registered for it. Its Client method returns an
+// http.Client for NewClient that refreshes the access token as needed.
+func OAuthConfig(clientID, redirectURL string) *oauth2.Config {
+	return TDAmeritradeEndpoints.OAuthConfig(clientID, "", redirectURL)
+}
+
+// AuthCodeURL returns the URL to send the user to, to log in and authorize
+// the app. After that, the user is redirected to redirectURL with the
+// authorization code in the code query parameter and state in the state
+// parameter.
+func AuthCodeURL(clientID, redirectURL, state string) string {
+	return TDAmeritradeEndpoints.AuthCodeURL(clientID, redirectURL, state)
+}
+
+// AuthCodeURL is AuthCodeURL for an app of the deployment e.
+func (e Endpoints) AuthCodeURL(clientID, redirectURL, state string) string {
+	return e.OAuthConfig(clientID, "", redirectURL).AuthCodeURL(state)
+}
+
+// ExchangeCode trades an authorization code for an access token and a
+// refresh token, which is valid for 90 days. code may be given as it
+// appears in the redirect URL, still URL-encoded.
+func ExchangeCode(ctx context.Context, clientID, redirectURL, code string) (*oauth2.Token, error) {
+	return TDAmeritradeEndpoints.ExchangeCode(ctx, clientID, "", redirectURL, code)
+}
+
+// ExchangeCode is ExchangeCode for an app of the deployment e with the
+// secret clientSecret, if it has one.
+func (e Endpoints) ExchangeCode(ctx context.Context, clientID, clientSecret, redirectURL, code string) (*oauth2.Token, error) {
+	if code == "" {
+		return nil, fmt.Errorf("no code present")
+	}
+	if decoded, err := url.PathUnescape(code); err == nil {
+		code = decoded
+	}
+	return e.OAuthConfig(clientID, clientSecret, redirectURL).Exchange(ctx, code, oauth2.SetAuthURLParam("access_type", "offline"))
+}
+
+// tokenResponse is the body of a token endpoint response.
+type tokenResponse struct {
+	AccessToken           string `json:"access_token"`
+	RefreshToken          string `json:"refresh_token"`
+	TokenType             string `json:"token_type"`
+	ExpiresIn             int64  `json:"expires_in"`
+	RefreshTokenExpiresIn int64  `json:"refresh_token_expires_in"`
+	Scope                 string `json:"scope"`
+}
+
+// RefreshToken trades refreshToken for a new access token. If renew is
+// true a new refresh token, valid for another 90 days, is issued too;
+// otherwise the returned token keeps refreshToken. The expiry of the
+// refresh token, when known, is in the token's refresh_token_expires_in
+// extra, in seconds. The http.Client in ctx under oauth2.HTTPClient, if
+// any, makes the request.
+func RefreshToken(ctx context.Context, clientID, refreshToken string, renew bool) (*oauth2.Token, error) {
+	return TDAmeritradeEndpoints.RefreshToken(ctx, clientID, "", refreshToken, renew)
+}
+
+// RefreshToken is RefreshToken for an app of the deployment e with the
+// secret clientSecret, if it has one, which authenticates the request.
+func (e Endpoints) RefreshToken(ctx context.Context, clientID, clientSecret, refreshToken string, renew bool) (*oauth2.Token, error) {
+	if refreshToken == "" {
+		return nil, fmt.Errorf("no refresh token present")
+	}
+	conf := e.OAuthConfig(clientID, clientSecret, "")
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	}
+	if clientSecret == "" {
+		form.Set("client_id", conf.ClientID)
+	}
+	if renew {
+		form.Set("access_type", "offline")
+	}
+	req, err := http.NewRequest("POST", conf.Endpoint.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(conf.ClientID), url.QueryEscape(clientSecret))
+	}
+
+	hc := http.DefaultClient
+	if c, ok := ctx.Value(oauth2.HTTPClient).(*http.Client); ok {
+		hc = c
+	}
+	resp, err := hc.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, newAPIError(resp, body)
+	}
+	var tr tokenResponse
+	if err := json.Unmarshal(body, &tr); err != nil {
+		return nil, err
+	}
+	if tr.AccessToken == "" {
+		return nil, fmt.Errorf("token response has no access token")
+	}
+
+	token := &oauth2.Token{
+		AccessToken:  tr.AccessToken,
+		TokenType:    tr.TokenType,
+		RefreshToken: tr.RefreshToken,
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+	if tr.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+	return token.WithExtra(map[string]interface{}{
+		"refresh_token_expires_in": tr.RefreshTokenExpiresIn,
+		"scope":                    tr.Scope,
+	}), nil
+}
@@ -0,0 +1,163 @@
+package tdameritrade
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Balances is one set of balances of an account. The API reports different
+// balances for cash and margin accounts, so exactly one of Cash and Margin is
+// set, following the account's Type.
+type Balances struct {
+	Cash   *CashBalances
+	Margin *MarginBalances
+}
+
+// CashBalances are the balances of a CASH account. Not every field is sent in
+// every set: projected balances only carry the cash available for trading
+// and withdrawal.
+type CashBalances struct {
+	AccruedInterest              float64 `json:"accruedInterest"`
+	AccountValue                 float64 `json:"accountValue"`
+	BondValue                    float64 `json:"bondValue"`
+	CashAvailableForTrading      float64 `json:"cashAvailableForTrading"`
+	CashAvailableForWithdrawal   float64 `json:"cashAvailableForWithdrawal"`
+	CashBalance                  float64 `json:"cashBalance"`
+	CashCall                     float64 `json:"cashCall"`
+	CashDebitCallValue           float64 `json:"cashDebitCallValue"`
+	CashReceipts                 float64 `json:"cashReceipts"`
+	IsInCall                     bool    `json:"isInCall"`
+	LiquidationValue             float64 `json:"liquidationValue"`
+	LongMarketValue              float64 `json:"longMarketValue"`
+	LongNonMarginableMarketValue float64 `json:"longNonMarginableMarketValue"`
+	LongOptionMarketValue        float64 `json:"longOptionMarketValue"`
+	LongStockValue               float64 `json:"longStockValue"`
+	MoneyMarketFund              float64 `json:"moneyMarketFund"`
+	MutualFundValue              float64 `json:"mutualFundValue"`
+	PendingDeposits              float64 `json:"pendingDeposits"`
+	Savings                      float64 `json:"savings"`
+	ShortMarketValue             float64 `json:"shortMarketValue"`
+	ShortOptionMarketValue       float64 `json:"shortOptionMarketValue"`
+	ShortStockValue              float64 `json:"shortStockValue"`
+	TotalCash                    float64 `json:"totalCash"`
+	UnsettledCash                float64 `json:"unsettledCash"`
+}
+
+// Balance is the former type of the balances of an account, which held the
+// fields of a cash account.
+//
+// Deprecated: use Balances, which also decodes margin balances.
+type Balance = CashBalances
+
+// MarginBalances are the balances of a MARGIN account. Not every field is
+// sent in every set: projected balances only carry the buying power and
+// call figures.
+type MarginBalances struct {
+	AccruedInterest                  float64 `json:"accruedInterest"`
+	AccountValue                     float64 `json:"accountValue"`
+	AvailableFunds                   float64 `json:"availableFunds"`
+	AvailableFundsNonMarginableTrade float64 `json:"availableFundsNonMarginableTrade"`
+	BondValue                        float64 `json:"bondValue"`
+	BuyingPower                      float64 `json:"buyingPower"`
+	BuyingPowerNonMarginableTrade    float64 `json:"buyingPowerNonMarginableTrade"`
+	CashAvailableForTrading          float64 `json:"cashAvailableForTrading"`
+	CashBalance                      float64 `json:"cashBalance"`
+	CashReceipts                     float64 `json:"cashReceipts"`
+	DayTradingBuyingPower            float64 `json:"dayTradingBuyingPower"`
+	DayTradingBuyingPowerCall        float64 `json:"dayTradingBuyingPowerCall"`
+	DayTradingEquityCall             float64 `json:"dayTradingEquityCall"`
+	Equity                           float64 `json:"equity"`
+	EquityPercentage                 float64 `json:"equityPercentage"`
+	IsInCall                         bool    `json:"isInCall"`
+	LiquidationValue                 float64 `json:"liquidationValue"`
+	LongMarginValue                  float64 `json:"longMarginValue"`
+	LongMarketValue                  float64 `json:"longMarketValue"`
+	LongOptionMarketValue            float64 `json:"longOptionMarketValue"`
+	LongStockValue                   float64 `json:"longStockValue"`
+	MaintenanceCall                  float64 `json:"maintenanceCall"`
+	MaintenanceRequirement           float64 `json:"maintenanceRequirement"`
+	Margin                           float64 `json:"margin"`
+	MarginBalance                    float64 `json:"marginBalance"`
+	MarginEquity                     float64 `json:"marginEquity"`
+	MoneyMarketFund                  float64 `json:"moneyMarketFund"`
+	MutualFundValue                  float64 `json:"mutualFundValue"`
+	OptionBuyingPower                float64 `json:"optionBuyingPower"`
+	PendingDeposits                  float64 `json:"pendingDeposits"`
+	RegTCall                         float64 `json:"regTCall"`
+	Savings                          float64 `json:"savings"`
+	ShortBalance                     float64 `json:"shortBalance"`
+	ShortMarginValue                 float64 `json:"shortMarginValue"`
+	ShortMarketValue                 float64 `json:"shortMarketValue"`
+	ShortOptionMarketValue           float64 `json:"shortOptionMarketValue"`
+	ShortStockValue                  float64 `json:"shortStockValue"`
+	SMA                              float64 `json:"sma"`
+	StockBuyingPower                 float64 `json:"stockBuyingPower"`
+	TotalCash                        float64 `json:"totalCash"`
+	UnsettledCash                    float64 `json:"unsettledCash"`
+}
+
+func (b Balances) MarshalJSON() ([]byte, error) {
+	switch {
+	case b.Cash != nil:
+		return json.Marshal(b.Cash)
+	case b.Margin != nil:
+		return json.Marshal(b.Margin)
+	}
+	return []byte("null"), nil
+}
+
+type _SecuritiesAccount SecuritiesAccount
+
+// UnmarshalJSON decodes the balances of the account as CashBalances or
+// MarginBalances depending on its type.
+func (a *SecuritiesAccount) UnmarshalJSON(bs []byte) error {
+	var raw struct {
+		_SecuritiesAccount
+		InitialBalances   json.RawMessage `json:"initialBalances"`
+		CurrentBalances   json.RawMessage `json:"currentBalances"`
+		ProjectedBalances json.RawMessage `json:"projectedBalances"`
+	}
+	if err := json.Unmarshal(bs, &raw); err != nil {
+		return err
+	}
+	account := SecuritiesAccount(raw._SecuritiesAccount)
+
+	for _, b := range []struct {
+		raw      json.RawMessage
+		balances *Balances
+	}{
+		{raw.InitialBalances, &account.InitialBalances},
+		{raw.CurrentBalances, &account.CurrentBalances},
+		{raw.ProjectedBalances, &account.ProjectedBalances},
+	} {
+		if len(b.raw) == 0 || string(b.raw) == "null" {
+			continue
+		}
+		var v interface{}
+		switch account.Type {
+		case "CASH":
+			b.balances.Cash = &CashBalances{}
+			v = b.balances.Cash
+		case "MARGIN":
+			b.balances.Margin = &MarginBalances{}
+			v = b.balances.Margin
+		default:
+			return fmt.Errorf("unsupported account type %s", account.Type)
+		}
+		if err := json.Unmarshal(b.raw, v); err != nil {
+			return err
+		}
+	}
+
+	*a = account
+	return nil
+}
+
+// UnmarshalJSON keeps the UnmarshalJSON of the embedded SecuritiesAccount
+// from being promoted to Account, which would skip the securitiesAccount key.
+func (a *Account) UnmarshalJSON(bs []byte) error {
+	raw := struct {
+		SecuritiesAccount *SecuritiesAccount `json:"securitiesAccount"`
+	}{&a.SecuritiesAccount}
+	return json.Unmarshal(bs, &raw)
+}
@@ -0,0 +1,245 @@
+package tdameritrade
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// The payloads are GET accounts/{accountId} responses, in the shape the API
+// sends for each account type.
+const (
+	cashAccountPayload = `{
+		"securitiesAccount": {
+			"type": "CASH",
+			"accountId": "123456789",
+			"roundTrips": 0,
+			"isDayTrader": false,
+			"isClosingOnlyRestricted": false,
+			"initialBalances": {
+				"accruedInterest": 0,
+				"cashAvailableForTrading": 1500.25,
+				"cashAvailableForWithdrawal": 1500.25,
+				"cashBalance": 1500.25,
+				"bondValue": 0,
+				"cashReceipts": 0,
+				"liquidationValue": 4210.75,
+				"longOptionMarketValue": 0,
+				"longStockValue": 2710.5,
+				"moneyMarketFund": 0,
+				"mutualFundValue": 0,
+				"shortOptionMarketValue": 0,
+				"shortStockValue": 0,
+				"isInCall": false,
+				"unsettledCash": 0,
+				"cashDebitCallValue": 0,
+				"pendingDeposits": 0,
+				"accountValue": 4210.75
+			},
+			"currentBalances": {
+				"accruedInterest": 0,
+				"cashBalance": 1400.25,
+				"cashReceipts": 0,
+				"longOptionMarketValue": 0,
+				"liquidationValue": 4190.75,
+				"longMarketValue": 2790.5,
+				"moneyMarketFund": 0,
+				"savings": 0,
+				"shortMarketValue": 0,
+				"pendingDeposits": 0,
+				"cashAvailableForTrading": 1300.25,
+				"cashAvailableForWithdrawal": 1300.25,
+				"cashCall": 0,
+				"longNonMarginableMarketValue": 0,
+				"totalCash": 1400.25,
+				"shortOptionMarketValue": 0,
+				"mutualFundValue": 0,
+				"bondValue": 0,
+				"cashDebitCallValue": 0,
+				"unsettledCash": 100
+			},
+			"projectedBalances": {
+				"cashAvailableForTrading": 1250.25,
+				"cashAvailableForWithdrawal": 1200.25
+			}
+		}
+	}`
+
+	marginAccountPayload = `{
+		"securitiesAccount": {
+			"type": "MARGIN",
+			"accountId": "987654321",
+			"roundTrips": 1,
+			"isDayTrader": false,
+			"isClosingOnlyRestricted": false,
+			"initialBalances": {
+				"accruedInterest": 0,
+				"availableFundsNonMarginableTrade": 20000,
+				"bondValue": 0,
+				"buyingPower": 40000,
+				"cashBalance": 20000,
+				"cashAvailableForTrading": 0,
+				"cashReceipts": 0,
+				"dayTradingBuyingPower": 80000,
+				"dayTradingBuyingPowerCall": 0,
+				"dayTradingEquityCall": 0,
+				"equity": 35000,
+				"equityPercentage": 100,
+				"liquidationValue": 35000,
+				"longMarginValue": 15000,
+				"longOptionMarketValue": 0,
+				"longStockValue": 15000,
+				"maintenanceCall": 0,
+				"maintenanceRequirement": 4500,
+				"margin": 20000,
+				"marginEquity": 35000,
+				"moneyMarketFund": 0,
+				"mutualFundValue": 0,
+				"regTCall": 0,
+				"shortMarginValue": 0,
+				"shortOptionMarketValue": 0,
+				"shortStockValue": 0,
+				"totalCash": 20000,
+				"isInCall": false,
+				"pendingDeposits": 0,
+				"marginBalance": 0,
+				"shortBalance": 0,
+				"accountValue": 35000
+			},
+			"currentBalances": {
+				"accruedInterest": 0,
+				"cashBalance": 19000,
+				"cashReceipts": 0,
+				"longOptionMarketValue": 0,
+				"liquidationValue": 35200,
+				"longMarketValue": 16200,
+				"moneyMarketFund": 0,
+				"savings": 0,
+				"shortMarketValue": 0,
+				"pendingDeposits": 0,
+				"availableFunds": 18500,
+				"availableFundsNonMarginableTrade": 18500,
+				"buyingPower": 37000,
+				"buyingPowerNonMarginableTrade": 18500,
+				"dayTradingBuyingPower": 74000,
+				"equity": 35200,
+				"equityPercentage": 100,
+				"longMarginValue": 16200,
+				"maintenanceCall": 0,
+				"maintenanceRequirement": 4860,
+				"marginBalance": 0,
+				"regTCall": 0,
+				"shortBalance": 0,
+				"shortMarginValue": 0,
+				"shortOptionMarketValue": 0,
+				"sma": 18500,
+				"mutualFundValue": 0,
+				"bondValue": 0
+			},
+			"projectedBalances": {
+				"availableFunds": 18400,
+				"availableFundsNonMarginableTrade": 18400,
+				"buyingPower": 36800,
+				"dayTradingBuyingPower": 74000,
+				"dayTradingBuyingPowerCall": 0,
+				"maintenanceCall": 0,
+				"regTCall": 0,
+				"isInCall": false,
+				"stockBuyingPower": 36800
+			}
+		}
+	}`
+)
+
+func TestCashAccountBalances(t *testing.T) {
+	var account Account
+	if err := json.Unmarshal([]byte(cashAccountPayload), &account); err != nil {
+		t.Fatal(err)
+	}
+	a := account.SecuritiesAccount
+	if a.Type != "CASH" || a.AccountID != "123456789" {
+		t.Fatalf("Type, AccountID = %s, %s, want CASH, 123456789", a.Type, a.AccountID)
+	}
+	for name, b := range map[string]Balances{
+		"initial":   a.InitialBalances,
+		"current":   a.CurrentBalances,
+		"projected": a.ProjectedBalances,
+	} {
+		if b.Cash == nil || b.Margin != nil {
+			t.Fatalf("%s balances: Cash = %v, Margin = %v, want cash balances only", name, b.Cash, b.Margin)
+		}
+	}
+
+	for name, tt := range map[string]struct{ got, want float64 }{
+		"initial CashBalance":                     {a.InitialBalances.Cash.CashBalance, 1500.25},
+		"initial LongStockValue":                  {a.InitialBalances.Cash.LongStockValue, 2710.5},
+		"initial AccountValue":                    {a.InitialBalances.Cash.AccountValue, 4210.75},
+		"current LongMarketValue":                 {a.CurrentBalances.Cash.LongMarketValue, 2790.5},
+		"current TotalCash":                       {a.CurrentBalances.Cash.TotalCash, 1400.25},
+		"current UnsettledCash":                   {a.CurrentBalances.Cash.UnsettledCash, 100},
+		"projected CashAvailableForTrading":       {a.ProjectedBalances.Cash.CashAvailableForTrading, 1250.25},
+		"projected CashAvailableForWithdrawal":    {a.ProjectedBalances.Cash.CashAvailableForWithdrawal, 1200.25},
+		"projected CashBalance, which isn't sent": {a.ProjectedBalances.Cash.CashBalance, 0},
+	} {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestMarginAccountBalances(t *testing.T) {
+	var account Account
+	if err := json.Unmarshal([]byte(marginAccountPayload), &account); err != nil {
+		t.Fatal(err)
+	}
+	a := account.SecuritiesAccount
+	if a.Type != "MARGIN" || a.AccountID != "987654321" || a.RoundTrips != 1 {
+		t.Fatalf("Type, AccountID, RoundTrips = %s, %s, %v, want MARGIN, 987654321, 1", a.Type, a.AccountID, a.RoundTrips)
+	}
+	for name, b := range map[string]Balances{
+		"initial":   a.InitialBalances,
+		"current":   a.CurrentBalances,
+		"projected": a.ProjectedBalances,
+	} {
+		if b.Margin == nil || b.Cash != nil {
+			t.Fatalf("%s balances: Cash = %v, Margin = %v, want margin balances only", name, b.Cash, b.Margin)
+		}
+	}
+
+	for name, tt := range map[string]struct{ got, want float64 }{
+		"initial BuyingPower":             {a.InitialBalances.Margin.BuyingPower, 40000},
+		"initial MaintenanceRequirement":  {a.InitialBalances.Margin.MaintenanceRequirement, 4500},
+		"initial Margin":                  {a.InitialBalances.Margin.Margin, 20000},
+		"current AvailableFunds":          {a.CurrentBalances.Margin.AvailableFunds, 18500},
+		"current SMA":                     {a.CurrentBalances.Margin.SMA, 18500},
+		"current Equity":                  {a.CurrentBalances.Margin.Equity, 35200},
+		"projected BuyingPower":           {a.ProjectedBalances.Margin.BuyingPower, 36800},
+		"projected StockBuyingPower":      {a.ProjectedBalances.Margin.StockBuyingPower, 36800},
+		"projected DayTradingBuyingPower": {a.ProjectedBalances.Margin.DayTradingBuyingPower, 74000},
+	} {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestBalancesRoundTrip(t *testing.T) {
+	for _, payload := range []string{cashAccountPayload, marginAccountPayload} {
+		var account Account
+		if err := json.Unmarshal([]byte(payload), &account); err != nil {
+			t.Fatal(err)
+		}
+		b, err := json.Marshal(account.SecuritiesAccount)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var again SecuritiesAccount
+		if err := json.Unmarshal(b, &again); err != nil {
+			t.Fatal(err)
+		}
+		first, _ := json.Marshal(account.SecuritiesAccount)
+		second, _ := json.Marshal(again)
+		if string(first) != string(second) {
+			t.Errorf("%s account changed over a round trip:\n%s\n%s", account.SecuritiesAccount.Type, first, second)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package tdameritrade
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BulkProgress reports that BulkPriceHistory finished a symbol. Done counts
+// the symbols finished so far, including this one, out of Total.
+type BulkProgress struct {
+	Symbol string
+	Done   int
+	Total  int
+	Err    error // nil if the download succeeded
+}
+
+// BulkPriceHistory downloads the price history of many symbols, by default
+// one year of daily bars. opts select other bars the way they do for
+// PriceHistory and are copied for every request. Requests are spread over
+// the service's Concurrency workers and started at most once per
+// RequestInterval, or as the client's Limiter allows if it has one; symbols
+// rejected with 429 Too Many Requests are retried up to MaxRetries times,
+// after the Retry-After delay when the API sends one and an exponential
+// backoff otherwise, unless the client's RetryPolicy retries them already.
+// If progress is non-nil it is called after every symbol, one call at a
+// time.
+//
+// Candles are keyed by symbol. Symbols that fail are left out and reported
+// in the returned SymbolErrors; the candles of the others are still
+// returned.
+func (s *PriceHistoryService) BulkPriceHistory(ctx context.Context, symbols []string, opts *PriceHistoryOptions, progress func(BulkProgress)) (map[string][]Candle, error) {
+	base := PriceHistoryOptions{
+		PeriodType:    "year",
+		Period:        1,
+		FrequencyType: "daily",
+		Frequency:     1,
+	}
+	if opts != nil {
+		base = *opts
+	}
+	if err := base.validate(); err != nil {
+		return nil, err
+	}
+	concurrency := s.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultHistoryConcurrency
+	}
+	interval := s.RequestInterval
+	if interval <= 0 {
+		interval = defaultHistoryRequestInterval
+	}
+	maxRetries := s.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = defaultHistoryMaxRetries
+	}
+	limiter := s.client.helperLimiter(interval)
+	maxRetries = s.client.helperRetries(maxRetries)
+
+	seen := make(map[string]bool, len(symbols))
+	var unique []string
+	for _, symbol := range symbols {
+		if !seen[symbol] {
+			seen[symbol] = true
+			unique = append(unique, symbol)
+		}
+	}
+
+	jobs := make(chan string)
+	go func() {
+		defer close(jobs)
+		for _, symbol := range unique {
+			select {
+			case jobs <- symbol:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		done    int
+		candles = make(map[string][]Candle, len(unique))
+		errs    = make(SymbolErrors)
+	)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for symbol := range jobs {
+				// validate writes into opts, so every request gets its own
+				// copy.
+				o := base
+				bars, err := s.historyWithRetry(ctx, symbol, &o, limiter, interval, maxRetries)
+
+				mu.Lock()
+				if err != nil {
+					errs[symbol] = err
+				} else {
+					candles[symbol] = bars
+				}
+				done++
+				if progress != nil {
+					progress(BulkProgress{Symbol: symbol, Done: done, Total: len(unique), Err: err})
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, symbol := range unique {
+		if _, ok := candles[symbol]; !ok && errs[symbol] == nil {
+			errs[symbol] = ctx.Err() // never started before ctx was done
+		}
+	}
+	if len(errs) > 0 {
+		return candles, errs
+	}
+	return candles, nil
+}
+
+func (s *PriceHistoryService) historyWithRetry(ctx context.Context, symbol string, opts *PriceHistoryOptions, limiter Limiter, interval time.Duration, maxRetries int) ([]Candle, error) {
+	var candles []Candle
+	err := retryRateLimited(ctx, limiter, interval, maxRetries, func() error {
+		history, _, err := s.PriceHistory(ctx, symbol, opts)
+		if err == nil {
+			candles = history.Candles
+		}
+		return err
+	})
+	return candles, err
+}
+
+// retryRateLimited calls fn once limiter, if not nil, allows it, and again
+// while the API rejects it with 429 Too Many Requests, up to maxRetries
+// times. Between attempts it waits the Retry-After delay when the API sends
+// one and an exponential backoff from interval otherwise, at most the
+// RetryPolicy default of 30 seconds.
+func retryRateLimited(ctx context.Context, limiter Limiter, interval time.Duration, maxRetries int, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		if err := waitLimiter(ctx, limiter); err != nil {
+			return err
+		}
+
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		apiErr, ok := err.(*APIError)
+		if !ok || !apiErr.IsRateLimited() || attempt >= maxRetries {
+			return err
+		}
+
+		wait, ok := retryAfter(apiErr.Response)
+		if !ok || wait <= 0 {
+			wait = interval << uint(attempt+1)
+		}
+		if wait <= 0 || wait > defaultRetryMaxDelay {
+			wait = defaultRetryMaxDelay
+		}
+		if err := sleepCtx(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,277 @@
+// Package calendar knows when US markets trade: the NYSE and CME holiday and
+// half-day schedules, computed by rule for any year, with helpers to count
+// and step through trading days.
+//
+// A Calendar implements tdameritrade.MarketCalendar, so it can be handed to
+// FindGaps and RepairGaps in place of the holiday-blind WeekdayCalendar. The
+// rules can be corrected with the hours the API reports, see Refresh.
+package calendar
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/glacialspring/go-tdameritrade/tdameritrade"
+)
+
+// Holiday is a day a market is closed or closes early.
+type Holiday struct {
+	Date time.Time // midnight Eastern
+	Name string
+
+	// EarlyClose is the time of day, Eastern, the market closes on a half
+	// day, and zero if it is closed all day.
+	EarlyClose time.Duration
+}
+
+// HalfDay reports whether the market trades part of the day.
+func (h Holiday) HalfDay() bool {
+	return h.EarlyClose > 0
+}
+
+// Calendar is the trading schedule of a market. Its methods take times in
+// any location and work on the trading day whose session they fall in: the
+// Eastern calendar day, or for markets such as CME that open the evening
+// before, the next day from the evening open on. A Calendar is safe for
+// concurrent use.
+type Calendar struct {
+	market tdameritrade.MarketType
+
+	// open and close are the regular session bounds as offsets from
+	// midnight Eastern of the trading day; open is negative for sessions
+	// that start the evening before.
+	open  time.Duration
+	close time.Duration
+	rules func(year int) []Holiday
+
+	mu        sync.Mutex
+	years     map[int]map[string]Holiday
+	overrides map[string]session
+}
+
+// session is a day whose hours were reported by the API.
+type session struct {
+	open, close time.Time
+	closed      bool
+}
+
+var _ tdameritrade.MarketCalendar = (*Calendar)(nil)
+
+const dateKey = "2006-01-02"
+
+func newCalendar(market tdameritrade.MarketType, open, close time.Duration, rules func(int) []Holiday) *Calendar {
+	return &Calendar{
+		market:    market,
+		open:      open,
+		close:     close,
+		rules:     rules,
+		years:     make(map[int]map[string]Holiday),
+		overrides: make(map[string]session),
+	}
+}
+
+// day returns midnight Eastern of the day of t.
+func day(t time.Time) time.Time {
+	t = t.In(tdameritrade.Eastern())
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, tdameritrade.Eastern())
+}
+
+// tradingDay returns midnight Eastern of the trading day of t.
+func (c *Calendar) tradingDay(t time.Time) time.Time {
+	d := day(t)
+	if next := d.AddDate(0, 0, 1); c.open < 0 && !t.Before(next.Add(c.open)) {
+		return next
+	}
+	return d
+}
+
+// ruleHoliday returns the holiday the rules put on d, a midnight Eastern.
+// c.mu must be held.
+func (c *Calendar) ruleHoliday(d time.Time) (Holiday, bool) {
+	holidays, ok := c.years[d.Year()]
+	if !ok {
+		holidays = make(map[string]Holiday)
+		for _, h := range c.rules(d.Year()) {
+			holidays[h.Date.Format(dateKey)] = h
+		}
+		c.years[d.Year()] = holidays
+	}
+	h, ok := holidays[d.Format(dateKey)]
+	return h, ok
+}
+
+// holiday returns the holiday on d, a midnight Eastern, from the hours
+// Refresh loaded for d if any and from the rules otherwise. c.mu must be
+// held.
+func (c *Calendar) holiday(d time.Time) (Holiday, bool) {
+	h, ok := c.ruleHoliday(d)
+	s, refreshed := c.overrides[d.Format(dateKey)]
+	if !refreshed {
+		return h, ok
+	}
+	if wd := d.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		return Holiday{}, false
+	}
+	switch {
+	case s.closed:
+		h.EarlyClose = 0
+	case s.close.Before(d.Add(c.close)):
+		h.EarlyClose = s.close.Sub(d)
+	default:
+		return Holiday{}, false
+	}
+	if !ok {
+		h.Name = "Unscheduled closure"
+		if h.HalfDay() {
+			h.Name = "Unscheduled early close"
+		}
+	}
+	h.Date = d
+	return h, true
+}
+
+// Session returns the regular session open and close of the trading day of
+// t, or ok false if the market does not trade that day.
+func (c *Calendar) Session(t time.Time) (open, close time.Time, ok bool) {
+	d := c.tradingDay(t)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s, ok := c.overrides[d.Format(dateKey)]; ok {
+		return s.open, s.close, !s.closed
+	}
+	if wd := d.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		return time.Time{}, time.Time{}, false
+	}
+	end := c.close
+	if h, ok := c.ruleHoliday(d); ok {
+		if !h.HalfDay() {
+			return time.Time{}, time.Time{}, false
+		}
+		end = h.EarlyClose
+	}
+	return d.Add(c.open), d.Add(end), true
+}
+
+// IsTradingDay reports whether the market trades on the trading day of t.
+func (c *Calendar) IsTradingDay(t time.Time) bool {
+	_, _, ok := c.Session(t)
+	return ok
+}
+
+// IsHoliday returns the holiday on the trading day of t, if any, taking the
+// hours loaded by Refresh into account. Half days are holidays too; check
+// Holiday.HalfDay.
+func (c *Calendar) IsHoliday(t time.Time) (Holiday, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.holiday(c.tradingDay(t))
+}
+
+// IsHalfDay reports whether the market closes early on the trading day of t.
+func (c *Calendar) IsHalfDay(t time.Time) bool {
+	h, ok := c.IsHoliday(t)
+	return ok && h.HalfDay()
+}
+
+// Holidays returns the holidays and half days of year, in date order,
+// taking the hours loaded by Refresh into account.
+func (c *Calendar) Holidays(year int) []Holiday {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var holidays []Holiday
+	seen := make(map[string]bool)
+	add := func(d time.Time) {
+		if key := d.Format(dateKey); !seen[key] {
+			seen[key] = true
+			if h, ok := c.holiday(d); ok {
+				holidays = append(holidays, h)
+			}
+		}
+	}
+	for _, h := range c.rules(year) {
+		add(h.Date)
+	}
+	for key := range c.overrides {
+		if d, _ := time.ParseInLocation(dateKey, key, tdameritrade.Eastern()); d.Year() == year {
+			add(d)
+		}
+	}
+	sort.Slice(holidays, func(i, j int) bool { return holidays[i].Date.Before(holidays[j].Date) })
+	return holidays
+}
+
+// TradingDays returns midnight Eastern of every trading day from the trading
+// day of from through that of to.
+func (c *Calendar) TradingDays(from, to time.Time) []time.Time {
+	var days []time.Time
+	for d, end := c.tradingDay(from), c.tradingDay(to); !d.After(end); d = d.AddDate(0, 0, 1) {
+		if c.IsTradingDay(d) {
+			days = append(days, d)
+		}
+	}
+	return days
+}
+
+// TradingDaysBetween counts the trading days from the trading day of from
+// through that of to, or returns 0 if to is before from.
+func (c *Calendar) TradingDaysBetween(from, to time.Time) int {
+	return len(c.TradingDays(from, to))
+}
+
+// NextTradingDay returns midnight Eastern of the first trading day after the
+// trading day of t.
+func (c *Calendar) NextTradingDay(t time.Time) time.Time {
+	d := c.tradingDay(t).AddDate(0, 0, 1)
+	for !c.IsTradingDay(d) {
+		d = d.AddDate(0, 0, 1)
+	}
+	return d
+}
+
+// PrevTradingDay returns midnight Eastern of the last trading day before the
+// trading day of t.
+func (c *Calendar) PrevTradingDay(t time.Time) time.Time {
+	d := c.tradingDay(t).AddDate(0, 0, -1)
+	for !c.IsTradingDay(d) {
+		d = d.AddDate(0, 0, -1)
+	}
+	return d
+}
+
+// Refresh replaces the computed schedule of the days from the trading day of
+// from through that of to with the regular session hours the API reports for
+// the calendar's market, which catches special closures and early closes
+// the rules don't know about. It makes one request per day, so it is meant
+// for short ranges such as the coming weeks. Days refreshed before the
+// request of a later day fails are kept.
+func (c *Calendar) Refresh(ctx context.Context, hours *tdameritrade.MarketHoursService, from, to time.Time) error {
+	for d, end := c.tradingDay(from), c.tradingDay(to); !d.After(end); d = d.AddDate(0, 0, 1) {
+		markets, _, err := hours.GetHours(ctx, []tdameritrade.MarketType{c.market}, d)
+		if err != nil {
+			return err
+		}
+		s := session{closed: true}
+		for _, products := range markets {
+			for _, h := range products {
+				if !h.IsOpen {
+					continue
+				}
+				for _, p := range h.RegularMarket {
+					if s.closed || p.Start.Before(s.open) {
+						s.open = p.Start
+					}
+					if s.closed || p.End.After(s.close) {
+						s.close = p.End
+					}
+					s.closed = false
+				}
+			}
+		}
+		c.mu.Lock()
+		c.overrides[d.Format(dateKey)] = s
+		c.mu.Unlock()
+	}
+	return nil
+}
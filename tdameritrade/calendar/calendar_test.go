@@ -0,0 +1,172 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/glacialspring/go-tdameritrade/tdameritrade"
+)
+
+func at(year int, month time.Month, d, hour, min int) time.Time {
+	return time.Date(year, month, d, hour, min, 0, 0, tdameritrade.Eastern())
+}
+
+func TestNYSESession(t *testing.T) {
+	cal := NYSE()
+	for _, tt := range []struct {
+		name        string
+		t           time.Time
+		open, close time.Time
+		ok          bool
+	}{
+		{"regular day", at(2024, time.March, 4, 12, 0), at(2024, time.March, 4, 9, 30), at(2024, time.March, 4, 16, 0), true},
+		{"weekend", at(2024, time.March, 2, 12, 0), time.Time{}, time.Time{}, false},
+		{"Good Friday", at(2024, time.March, 29, 12, 0), time.Time{}, time.Time{}, false},
+		{"Juneteenth", at(2024, time.June, 19, 12, 0), time.Time{}, time.Time{}, false},
+		{"Christmas Eve", at(2024, time.December, 24, 12, 0), at(2024, time.December, 24, 9, 30), at(2024, time.December, 24, 13, 0), true},
+		{"evening", at(2024, time.March, 4, 20, 0), at(2024, time.March, 4, 9, 30), at(2024, time.March, 4, 16, 0), true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			open, close, ok := cal.Session(tt.t)
+			if ok != tt.ok || !open.Equal(tt.open) || !close.Equal(tt.close) {
+				t.Errorf("Session(%v) = %v, %v, %v, want %v, %v, %v", tt.t, open, close, ok, tt.open, tt.close, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCMESession(t *testing.T) {
+	cal := CME()
+	for _, tt := range []struct {
+		name        string
+		t           time.Time
+		open, close time.Time
+		ok          bool
+	}{
+		{"Sunday evening", at(2024, time.March, 3, 20, 0), at(2024, time.March, 3, 18, 0), at(2024, time.March, 4, 17, 0), true},
+		{"Monday", at(2024, time.March, 4, 10, 0), at(2024, time.March, 3, 18, 0), at(2024, time.March, 4, 17, 0), true},
+		{"Monday evening", at(2024, time.March, 4, 18, 0), at(2024, time.March, 4, 18, 0), at(2024, time.March, 5, 17, 0), true},
+		{"Friday evening", at(2024, time.March, 8, 19, 0), time.Time{}, time.Time{}, false},
+		{"Sunday afternoon", at(2024, time.March, 3, 12, 0), time.Time{}, time.Time{}, false},
+		{"Good Friday", at(2024, time.March, 28, 20, 0), time.Time{}, time.Time{}, false},
+		{"Labor Day", at(2024, time.September, 1, 19, 0), at(2024, time.September, 1, 18, 0), at(2024, time.September, 2, 13, 0), true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			open, close, ok := cal.Session(tt.t)
+			if ok != tt.ok || !open.Equal(tt.open) || !close.Equal(tt.close) {
+				t.Errorf("Session(%v) = %v, %v, %v, want %v, %v, %v", tt.t, open, close, ok, tt.open, tt.close, tt.ok)
+			}
+		})
+	}
+
+	if h, ok := cal.IsHoliday(at(2024, time.March, 28, 20, 0)); !ok || h.Name != "Good Friday" {
+		t.Errorf("IsHoliday(Thursday evening before Good Friday) = %+v, %v, want Good Friday", h, ok)
+	}
+	if got, want := cal.NextTradingDay(at(2024, time.March, 3, 20, 0)), at(2024, time.March, 5, 0, 0); !got.Equal(want) {
+		t.Errorf("NextTradingDay(Sunday evening) = %v, want %v", got, want)
+	}
+}
+
+func TestCMEEveningGap(t *testing.T) {
+	var candles []tdameritrade.Candle
+	gapStart, gapEnd := at(2024, time.March, 3, 20, 0), at(2024, time.March, 3, 20, 30)
+	for ts := at(2024, time.March, 3, 18, 0); ts.Before(at(2024, time.March, 4, 17, 0)); ts = ts.Add(time.Minute) {
+		if ts.Before(gapStart) || !ts.Before(gapEnd) {
+			candles = append(candles, tdameritrade.Candle{Datetime: ts, Close: 1})
+		}
+	}
+
+	gaps := tdameritrade.FindGaps(candles, time.Minute, CME())
+	want := tdameritrade.Gap{Start: gapStart, End: gapEnd.Add(-time.Minute), Bars: 30}
+	if len(gaps) != 1 || !gaps[0].Start.Equal(want.Start) || !gaps[0].End.Equal(want.End) || gaps[0].Bars != want.Bars {
+		t.Errorf("gaps = %+v, want %+v", gaps, want)
+	}
+}
+
+func TestHolidays(t *testing.T) {
+	var got []string
+	for _, h := range NYSE().Holidays(2024) {
+		got = append(got, fmt.Sprintf("%s %v", h.Date.Format(dateKey), h.HalfDay()))
+	}
+	want := []string{
+		"2024-01-01 false", "2024-01-15 false", "2024-02-19 false", "2024-03-29 false",
+		"2024-05-27 false", "2024-06-19 false", "2024-07-03 true", "2024-07-04 false",
+		"2024-09-02 false", "2024-11-28 false", "2024-11-29 true", "2024-12-24 true",
+		"2024-12-25 false",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("holidays = %v, want %v", got, want)
+	}
+}
+
+// hoursServer answers market hours requests for the equity market with the
+// regular session of sessions, keyed by date; dates not listed are closed.
+func hoursServer(t *testing.T, sessions map[string][2]string) *tdameritrade.Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s, ok := sessions[r.URL.Query().Get("date")]
+		if !ok {
+			fmt.Fprint(w, `{"equity": {"equity": {"date": "", "isOpen": false, "marketType": "EQUITY"}}}`)
+			return
+		}
+		fmt.Fprintf(w, `{"equity": {"EQ": {"isOpen": true, "marketType": "EQUITY", "product": "EQ",
+			"sessionHours": {"regularMarket": [{"start": %q, "end": %q}]}}}}`, s[0], s[1])
+	}))
+	t.Cleanup(srv.Close)
+	c, err := tdameritrade.NewClient(srv.Client(), tdameritrade.WithBaseURL(srv.URL+"/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestRefresh(t *testing.T) {
+	client := hoursServer(t, map[string][2]string{
+		"2024-07-04": {"2024-07-04T09:30:00-04:00", "2024-07-04T16:00:00-04:00"},
+		"2024-07-05": {"2024-07-05T09:30:00-04:00", "2024-07-05T13:00:00-04:00"},
+	})
+	cal := NYSE()
+	if err := cal.Refresh(context.Background(), client.MarketHours, at(2024, time.July, 4, 0, 0), at(2024, time.July, 8, 0, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		day     time.Time
+		close   time.Time // zero if closed
+		holiday string
+		halfDay bool
+	}{
+		{"holiday reported open", at(2024, time.July, 4, 12, 0), at(2024, time.July, 4, 16, 0), "", false},
+		{"unscheduled early close", at(2024, time.July, 5, 12, 0), at(2024, time.July, 5, 13, 0), "Unscheduled early close", true},
+		{"weekend", at(2024, time.July, 6, 12, 0), time.Time{}, "", false},
+		{"unscheduled closure", at(2024, time.July, 8, 12, 0), time.Time{}, "Unscheduled closure", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, close, ok := cal.Session(tt.day)
+			if ok != !tt.close.IsZero() || !close.Equal(tt.close) {
+				t.Errorf("Session close = %v, %v, want %v", close, ok, tt.close)
+			}
+			h, ok := cal.IsHoliday(tt.day)
+			if ok != (tt.holiday != "") || h.Name != tt.holiday || h.HalfDay() != tt.halfDay {
+				t.Errorf("IsHoliday = %+v, %v, want %q, half day %v", h, ok, tt.holiday, tt.halfDay)
+			}
+			if got := cal.IsHalfDay(tt.day); got != tt.halfDay {
+				t.Errorf("IsHalfDay = %v, want %v", got, tt.halfDay)
+			}
+		})
+	}
+
+	var july []string
+	for _, h := range cal.Holidays(2024) {
+		if h.Date.Month() == time.July {
+			july = append(july, h.Date.Format(dateKey))
+		}
+	}
+	if want := []string{"2024-07-03", "2024-07-05", "2024-07-08"}; fmt.Sprint(july) != fmt.Sprint(want) {
+		t.Errorf("July holidays = %v, want %v", july, want)
+	}
+}
@@ -0,0 +1,179 @@
+package calendar
+
+import (
+	"time"
+
+	"github.com/glacialspring/go-tdameritrade/tdameritrade"
+)
+
+// NYSE returns the calendar of the NYSE and Nasdaq regular session, 9:30 to
+// 16:00 Eastern, and of the options on their listings. Half days close at
+// 13:00. Closures for national days of mourning and emergencies since 2001
+// are included.
+func NYSE() *Calendar {
+	return newCalendar(tdameritrade.MarketTypeEquity, 9*time.Hour+30*time.Minute, 16*time.Hour, nyseHolidays)
+}
+
+// CME returns an approximate calendar of CME Globex equity index futures,
+// whose session for a day runs from 18:00 Eastern the evening before to
+// 17:00. They close on New Year's Day, Good Friday and Christmas, halt at
+// 13:00 on the other exchange holidays and at 13:15 on the day after
+// Thanksgiving and Christmas Eve. Other products keep other hours; Refresh
+// corrects the schedule where it matters.
+func CME() *Calendar {
+	return newCalendar(tdameritrade.MarketTypeFuture, -6*time.Hour, 17*time.Hour, cmeHolidays)
+}
+
+const (
+	nyseEarlyClose       = 13 * time.Hour
+	cmeHolidayClose      = 13 * time.Hour
+	cmeShortSessionClose = 13*time.Hour + 15*time.Minute
+)
+
+// nyseClosures are the unscheduled full-day closures of the NYSE.
+var nyseClosures = []struct {
+	date string
+	name string
+}{
+	{"2001-09-11", "September 11 attacks"},
+	{"2001-09-12", "September 11 attacks"},
+	{"2001-09-13", "September 11 attacks"},
+	{"2001-09-14", "September 11 attacks"},
+	{"2004-06-11", "National Day of Mourning for Ronald Reagan"},
+	{"2007-01-02", "National Day of Mourning for Gerald Ford"},
+	{"2012-10-29", "Hurricane Sandy"},
+	{"2012-10-30", "Hurricane Sandy"},
+	{"2018-12-05", "National Day of Mourning for George H. W. Bush"},
+	{"2025-01-09", "National Day of Mourning for Jimmy Carter"},
+}
+
+func nyseHolidays(year int) []Holiday {
+	var holidays []Holiday
+	add := func(d time.Time, name string) {
+		if wd := d.Weekday(); wd != time.Saturday && wd != time.Sunday {
+			holidays = append(holidays, Holiday{Date: d, Name: name})
+		}
+	}
+	early := func(d time.Time, name string) {
+		holidays = append(holidays, Holiday{Date: d, Name: name, EarlyClose: nyseEarlyClose})
+	}
+
+	// New Year's Day on a Saturday is not observed on the Friday before,
+	// which ends the previous year.
+	add(observedSunday(date(year, time.January, 1)), "New Year's Day")
+	if year >= 1998 {
+		add(nthWeekday(year, time.January, time.Monday, 3), "Martin Luther King Jr. Day")
+	}
+	add(nthWeekday(year, time.February, time.Monday, 3), "Washington's Birthday")
+	add(easter(year).AddDate(0, 0, -2), "Good Friday")
+	add(lastWeekday(year, time.May, time.Monday), "Memorial Day")
+	if year >= 2022 {
+		add(observed(date(year, time.June, 19)), "Juneteenth")
+	}
+	add(observed(date(year, time.July, 4)), "Independence Day")
+	add(nthWeekday(year, time.September, time.Monday, 1), "Labor Day")
+	thanksgiving := nthWeekday(year, time.November, time.Thursday, 4)
+	add(thanksgiving, "Thanksgiving Day")
+	add(observed(date(year, time.December, 25)), "Christmas Day")
+
+	if d := date(year, time.July, 3); d.Weekday() >= time.Monday && d.Weekday() <= time.Thursday {
+		early(d, "Independence Day")
+	}
+	early(thanksgiving.AddDate(0, 0, 1), "Day after Thanksgiving")
+	if d := date(year, time.December, 24); d.Weekday() >= time.Monday && d.Weekday() <= time.Thursday {
+		early(d, "Christmas Eve")
+	}
+
+	for _, c := range nyseClosures {
+		d, _ := time.ParseInLocation(dateKey, c.date, tdameritrade.Eastern())
+		if d.Year() == year {
+			add(d, c.name)
+		}
+	}
+	return holidays
+}
+
+func cmeHolidays(year int) []Holiday {
+	var holidays []Holiday
+	add := func(d time.Time, name string, close time.Duration) {
+		if wd := d.Weekday(); wd != time.Saturday && wd != time.Sunday {
+			holidays = append(holidays, Holiday{Date: d, Name: name, EarlyClose: close})
+		}
+	}
+
+	add(observedSunday(date(year, time.January, 1)), "New Year's Day", 0)
+	add(easter(year).AddDate(0, 0, -2), "Good Friday", 0)
+	add(observed(date(year, time.December, 25)), "Christmas Day", 0)
+
+	if year >= 1998 {
+		add(nthWeekday(year, time.January, time.Monday, 3), "Martin Luther King Jr. Day", cmeHolidayClose)
+	}
+	add(nthWeekday(year, time.February, time.Monday, 3), "Washington's Birthday", cmeHolidayClose)
+	add(lastWeekday(year, time.May, time.Monday), "Memorial Day", cmeHolidayClose)
+	if year >= 2022 {
+		add(observed(date(year, time.June, 19)), "Juneteenth", cmeHolidayClose)
+	}
+	add(observed(date(year, time.July, 4)), "Independence Day", cmeHolidayClose)
+	add(nthWeekday(year, time.September, time.Monday, 1), "Labor Day", cmeHolidayClose)
+	thanksgiving := nthWeekday(year, time.November, time.Thursday, 4)
+	add(thanksgiving, "Thanksgiving Day", cmeHolidayClose)
+	add(thanksgiving.AddDate(0, 0, 1), "Day after Thanksgiving", cmeShortSessionClose)
+	if d := date(year, time.December, 24); d.Weekday() >= time.Monday && d.Weekday() <= time.Thursday {
+		add(d, "Christmas Eve", cmeShortSessionClose)
+	}
+	return holidays
+}
+
+func date(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, tdameritrade.Eastern())
+}
+
+// observed moves a holiday on a Saturday to the Friday before and one on a
+// Sunday to the Monday after.
+func observed(d time.Time) time.Time {
+	switch d.Weekday() {
+	case time.Saturday:
+		return d.AddDate(0, 0, -1)
+	case time.Sunday:
+		return d.AddDate(0, 0, 1)
+	}
+	return d
+}
+
+// observedSunday moves a holiday on a Sunday to the Monday after and leaves
+// one on a Saturday unobserved.
+func observedSunday(d time.Time) time.Time {
+	if d.Weekday() == time.Sunday {
+		return d.AddDate(0, 0, 1)
+	}
+	return d
+}
+
+// nthWeekday returns the nth weekday wd of month.
+func nthWeekday(year int, month time.Month, wd time.Weekday, n int) time.Time {
+	d := date(year, month, 1)
+	d = d.AddDate(0, 0, (int(wd)-int(d.Weekday())+7)%7)
+	return d.AddDate(0, 0, 7*(n-1))
+}
+
+// lastWeekday returns the last weekday wd of month.
+func lastWeekday(year int, month time.Month, wd time.Weekday) time.Time {
+	d := date(year, month+1, 0)
+	return d.AddDate(0, 0, -((int(d.Weekday()) - int(wd) + 7) % 7))
+}
+
+// easter returns Easter Sunday of year, by the anonymous Gregorian
+// algorithm.
+func easter(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	return date(year, time.Month(month), (h+l-7*m+114)%31+1)
+}
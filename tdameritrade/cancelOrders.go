@@ -0,0 +1,139 @@
+package tdameritrade
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// OrderSide is the direction an order trades in.
+type OrderSide string
+
+const (
+	OrderSideBuy  OrderSide = "BUY"
+	OrderSideSell OrderSide = "SELL"
+)
+
+// CancelFilter selects the orders canceled by CancelAllOrders. Empty fields
+// match every order.
+type CancelFilter struct {
+	// Symbol only matches orders with a leg, or a child order with a leg,
+	// trading the symbol.
+	Symbol string
+	// Side only matches orders with a leg buying, for OrderSideBuy, or
+	// selling, for OrderSideSell.
+	Side OrderSide
+}
+
+func (f *CancelFilter) match(order *Order) bool {
+	if f == nil {
+		return true
+	}
+	for _, leg := range order.OrderLegCollection {
+		if f.Symbol != "" && leg.Instrument.Symbol() != f.Symbol {
+			continue
+		}
+		if f.Side != "" && leg.Instruction.isBuy() != (f.Side == OrderSideBuy) {
+			continue
+		}
+		return true
+	}
+	for _, child := range order.ChildOrderStrategies {
+		if f.match(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// CancelResult reports the cancellation of one order.
+type CancelResult struct {
+	Order *Order
+	Err   error // nil if the order was canceled
+}
+
+// CancelAllOrders cancels the open orders of the account accountID matching
+// filter, which may be nil to cancel them all. The orders are canceled
+// concurrently, honoring the service's Concurrency, and a result is returned
+// for each. Canceling an OCO or TRIGGER order also cancels its children,
+// which are then not canceled on their own.
+//
+// The error is non-nil if the orders could not be listed, in which case
+// nothing was canceled, or if any cancellation failed.
+func (s *OrdersService) CancelAllOrders(ctx context.Context, accountID string, filter *CancelFilter) ([]*CancelResult, error) {
+	if filter != nil && filter.Side != "" && filter.Side != OrderSideBuy && filter.Side != OrderSideSell {
+		return nil, fmt.Errorf("invalid side %q", filter.Side)
+	}
+
+	// Good till canceled orders can be months old, so request as far back
+	// as the API allows.
+	now := time.Now()
+	orders, _, err := s.GetOrdersByPath(ctx, accountID, &OrdersOptions{
+		FromEnteredTime: Date{now.AddDate(0, 0, -60)},
+		ToEnteredTime:   Date{now},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var results []*CancelResult
+	for _, order := range cancelableOrders(orders) {
+		if filter.match(order) {
+			results = append(results, &CancelResult{Order: order})
+		}
+	}
+
+	concurrency := s.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultOrdersConcurrency
+	}
+	jobs := make(chan *CancelResult)
+	go func() {
+		defer close(jobs)
+		for _, r := range results {
+			jobs <- r
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range jobs {
+				if err := ctx.Err(); err != nil {
+					r.Err = err
+					continue
+				}
+				_, r.Err = s.CancelOrder(ctx, accountID, r.Order.OrderID)
+			}
+		}()
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return results, fmt.Errorf("%d of %d order(s) could not be canceled", failed, len(results))
+	}
+	return results, nil
+}
+
+// cancelableOrders returns the cancelable orders among orders and their
+// children, leaving out the children of cancelable orders. OCO orders
+// without an id of their own are reached through their children.
+func cancelableOrders(orders []*Order) []*Order {
+	var cancelable []*Order
+	for _, order := range orders {
+		if order.Cancelable && order.OrderID != 0 {
+			cancelable = append(cancelable, order)
+			continue
+		}
+		cancelable = append(cancelable, cancelableOrders(order.ChildOrderStrategies)...)
+	}
+	return cancelable
+}
@@ -0,0 +1,256 @@
+package tdameritrade
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/glacialspring/go-tdameritrade/tdameritrade/internal/parquet"
+)
+
+// CandleField is a value of a Candle that can be exported.
+type CandleField int
+
+const (
+	// CandleFieldDatetime is the bar time, as RFC 3339 in Eastern time in
+	// CSV and as a TIMESTAMP_MILLIS column in Parquet.
+	CandleFieldDatetime CandleField = iota
+	// CandleFieldDate is the Eastern calendar date of the bar, yyyy-MM-dd.
+	CandleFieldDate
+	// CandleFieldEpochMillis is the bar time in epoch milliseconds, as
+	// returned by the API.
+	CandleFieldEpochMillis
+	CandleFieldOpen
+	CandleFieldHigh
+	CandleFieldLow
+	CandleFieldClose
+	CandleFieldVolume
+)
+
+// CandleColumn is an exported column: Field written under the header Name.
+type CandleColumn struct {
+	Name  string
+	Field CandleField
+}
+
+// DefaultCandleLayout is the column layout used when none is given. It
+// reads directly into pandas and DuckDB.
+var DefaultCandleLayout = []CandleColumn{
+	{"datetime", CandleFieldDatetime},
+	{"open", CandleFieldOpen},
+	{"high", CandleFieldHigh},
+	{"low", CandleFieldLow},
+	{"close", CandleFieldClose},
+	{"volume", CandleFieldVolume},
+}
+
+func (f CandleField) float(c *Candle) (float64, bool) {
+	switch f {
+	case CandleFieldOpen:
+		return c.Open, true
+	case CandleFieldHigh:
+		return c.High, true
+	case CandleFieldLow:
+		return c.Low, true
+	case CandleFieldClose:
+		return c.Close, true
+	case CandleFieldVolume:
+		return c.Volume, true
+	}
+	return 0, false
+}
+
+func (f CandleField) text(c *Candle) (string, error) {
+	switch f {
+	case CandleFieldDatetime:
+		return c.Datetime.In(Eastern()).Format(time.RFC3339), nil
+	case CandleFieldDate:
+		return c.Datetime.In(Eastern()).Format(dateLayout), nil
+	case CandleFieldEpochMillis:
+		return strconv.FormatInt(toMillis(c.Datetime), 10), nil
+	}
+	if v, ok := f.float(c); ok {
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("unknown candle field %d", f)
+}
+
+// CandleCSVWriter streams candles to a CSV file, one row per candle,
+// preceded by a header row of the column names.
+type CandleCSVWriter struct {
+	w       *csv.Writer
+	layout  []CandleColumn
+	started bool
+}
+
+// NewCandleCSVWriter returns a writer of candles to w with the given column
+// layout, or DefaultCandleLayout if layout is nil.
+func NewCandleCSVWriter(w io.Writer, layout []CandleColumn) *CandleCSVWriter {
+	if layout == nil {
+		layout = DefaultCandleLayout
+	}
+	return &CandleCSVWriter{w: csv.NewWriter(w), layout: layout}
+}
+
+// Write writes one candle, and the header row before the first one.
+func (cw *CandleCSVWriter) Write(c Candle) error {
+	if !cw.started {
+		header := make([]string, len(cw.layout))
+		for i, col := range cw.layout {
+			header[i] = col.Name
+		}
+		if err := cw.w.Write(header); err != nil {
+			return err
+		}
+		cw.started = true
+	}
+
+	row := make([]string, len(cw.layout))
+	for i, col := range cw.layout {
+		v, err := col.Field.text(&c)
+		if err != nil {
+			return err
+		}
+		row[i] = v
+	}
+	return cw.w.Write(row)
+}
+
+// Flush writes any buffered rows to the underlying writer.
+func (cw *CandleCSVWriter) Flush() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// WriteCandlesCSV writes candles to w as CSV with the given column layout,
+// or DefaultCandleLayout if layout is nil.
+func WriteCandlesCSV(w io.Writer, candles []Candle, layout []CandleColumn) error {
+	cw := NewCandleCSVWriter(w, layout)
+	for _, c := range candles {
+		if err := cw.Write(c); err != nil {
+			return err
+		}
+	}
+	return cw.Flush()
+}
+
+// candleRowGroupSize is the number of candles in a row group of the Parquet
+// files written by CandleParquetWriter, which buffers that many.
+const candleRowGroupSize = 10000
+
+// CandleParquetWriter streams candles to an Apache Parquet file, a row group
+// of candles at a time. Prices and volume are DOUBLE columns,
+// CandleFieldDatetime is a TIMESTAMP_MILLIS column, CandleFieldEpochMillis
+// is INT64 and CandleFieldDate is a UTF-8 string. Close must be called to
+// complete the file.
+type CandleParquetWriter struct {
+	w            *parquet.Writer
+	layout       []CandleColumn
+	buf          []Candle
+	rowGroupSize int
+}
+
+// NewCandleParquetWriter returns a writer of candles to w with the given
+// column layout, or DefaultCandleLayout if layout is nil.
+func NewCandleParquetWriter(w io.Writer, layout []CandleColumn) (*CandleParquetWriter, error) {
+	if layout == nil {
+		layout = DefaultCandleLayout
+	}
+	fields := make([]parquet.Field, len(layout))
+	for i, col := range layout {
+		fields[i].Name = col.Name
+		switch col.Field {
+		case CandleFieldDatetime:
+			fields[i].Type = parquet.TimestampMillis
+		case CandleFieldEpochMillis:
+			fields[i].Type = parquet.Int64
+		case CandleFieldDate:
+			fields[i].Type = parquet.String
+		default:
+			if _, ok := col.Field.float(&Candle{}); !ok {
+				return nil, fmt.Errorf("unknown candle field %d", col.Field)
+			}
+			fields[i].Type = parquet.Double
+		}
+	}
+	pw, err := parquet.NewWriter(w, fields)
+	if err != nil {
+		return nil, err
+	}
+	return &CandleParquetWriter{w: pw, layout: layout, rowGroupSize: candleRowGroupSize}, nil
+}
+
+// Write writes one candle, writing out a row group once enough are
+// buffered.
+func (cw *CandleParquetWriter) Write(c Candle) error {
+	cw.buf = append(cw.buf, c)
+	if len(cw.buf) < cw.rowGroupSize {
+		return nil
+	}
+	return cw.flush()
+}
+
+// Close writes the buffered candles and the footer of the file. It does not
+// close the underlying writer.
+func (cw *CandleParquetWriter) Close() error {
+	if err := cw.flush(); err != nil {
+		return err
+	}
+	return cw.w.Close()
+}
+
+// flush writes the buffered candles as a row group.
+func (cw *CandleParquetWriter) flush() error {
+	candles := cw.buf
+	columns := make([]parquet.Column, len(cw.layout))
+	for i, col := range cw.layout {
+		pc := parquet.Column{Name: col.Name}
+		switch col.Field {
+		case CandleFieldDatetime, CandleFieldEpochMillis:
+			pc.Type = parquet.Int64
+			if col.Field == CandleFieldDatetime {
+				pc.Type = parquet.TimestampMillis
+			}
+			values := make([]int64, len(candles))
+			for j := range candles {
+				values[j] = toMillis(candles[j].Datetime)
+			}
+			pc.Values = values
+		case CandleFieldDate:
+			pc.Type = parquet.String
+			values := make([]string, len(candles))
+			for j := range candles {
+				values[j] = candles[j].Datetime.In(Eastern()).Format(dateLayout)
+			}
+			pc.Values = values
+		default:
+			pc.Type = parquet.Double
+			values := make([]float64, len(candles))
+			for j := range candles {
+				values[j], _ = col.Field.float(&candles[j])
+			}
+			pc.Values = values
+		}
+		columns[i] = pc
+	}
+	cw.buf = cw.buf[:0]
+	return cw.w.WriteRowGroup(columns)
+}
+
+// WriteCandlesParquet writes candles to w as an Apache Parquet file with the
+// given column layout, or DefaultCandleLayout if layout is nil, the way
+// CandleParquetWriter does.
+func WriteCandlesParquet(w io.Writer, candles []Candle, layout []CandleColumn) error {
+	cw, err := NewCandleParquetWriter(w, layout)
+	if err != nil {
+		return err
+	}
+	for _, c := range candles {
+		if err := cw.Write(c); err != nil {
+			return err
+		}
+	}
+	return cw.Close()
+}
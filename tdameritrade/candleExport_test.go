@@ -0,0 +1,42 @@
+package tdameritrade
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestCandleParquetWriterStreams(t *testing.T) {
+	var buf bytes.Buffer
+	cw, err := NewCandleParquetWriter(&buf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cw.rowGroupSize = 2
+
+	start := time.Date(2021, 2, 1, 9, 30, 0, 0, Eastern())
+	for i := 0; i < 5; i++ {
+		c := Candle{Open: 1, High: 2, Low: 0.5, Close: 1.5, Volume: 100, Datetime: start.Add(time.Duration(i) * time.Minute)}
+		if err := cw.Write(c); err != nil {
+			t.Fatal(err)
+		}
+		if i == 1 && buf.Len() == 0 {
+			t.Fatal("first row group not written out after 2 candles")
+		}
+	}
+	written := buf.Len()
+	if err := cw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() <= written {
+		t.Error("Close wrote nothing")
+	}
+	b := buf.Bytes()
+	if !bytes.HasPrefix(b, []byte("PAR1")) || !bytes.HasSuffix(b, []byte("PAR1")) {
+		t.Error("file does not start and end with PAR1")
+	}
+
+	if _, err := NewCandleParquetWriter(&buf, []CandleColumn{{"x", CandleField(99)}}); err == nil {
+		t.Error("unknown candle field: got no error")
+	}
+}
@@ -0,0 +1,237 @@
+package tdameritrade
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// MarketCalendar tells the gap detector when the market trades.
+type MarketCalendar interface {
+	// Session returns the regular session open and close of the trading
+	// day t falls in, which is the day of t unless the session starts the
+	// evening before, or ok false if the market is closed that day.
+	Session(t time.Time) (open, close time.Time, ok bool)
+}
+
+// WeekdayCalendar is a MarketCalendar that trades 9:30 to 16:00 Eastern on
+// every weekday. It knows nothing about holidays or half days; the calendar
+// package has calendars that do.
+type WeekdayCalendar struct{}
+
+func (WeekdayCalendar) Session(day time.Time) (time.Time, time.Time, bool) {
+	d := startOfDay(day)
+	if wd := d.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		return time.Time{}, time.Time{}, false
+	}
+	return d.Add(regularOpen), d.Add(regularClose), true
+}
+
+// Gap is a run of consecutive bars missing from a series. Start and End are
+// the times of the first and last missing bar.
+type Gap struct {
+	Start time.Time
+	End   time.Time
+	Bars  int
+}
+
+// GapReport describes what RepairGaps found and did.
+type GapReport struct {
+	Found     []Gap // gaps in the input series
+	Refetched int   // bars recovered by requesting them again
+	Filled    int   // bars synthesized by forward filling
+	Remaining []Gap // gaps left in the returned series
+}
+
+// RepairOptions select how RepairGaps closes gaps. Refetching is tried
+// first, forward filling covers whatever is still missing afterwards.
+type RepairOptions struct {
+	// Refetch requests the missing ranges from the API again.
+	Refetch bool
+	// ForwardFill inserts flat bars at the previous close with no volume,
+	// which is the usual treatment of trading halts.
+	ForwardFill bool
+}
+
+// FindGaps returns the bars missing from candles, a series of barSize bars
+// ordered by time, between the days of its first and last candle. Only the
+// regular session of days cal reports as open is checked, so extended hours
+// bars are neither required nor counted. Bar sizes of a day or more are
+// checked once per trading day.
+func FindGaps(candles []Candle, barSize time.Duration, cal MarketCalendar) []Gap {
+	missing := missingBars(candles, barSize, cal)
+	var gaps []Gap
+	for _, t := range missing {
+		if n := len(gaps); n > 0 && nextBar(gaps[n-1].End, barSize, cal).Equal(t) {
+			gaps[n-1].End = t
+			gaps[n-1].Bars++
+			continue
+		}
+		gaps = append(gaps, Gap{Start: t, End: t, Bars: 1})
+	}
+	return gaps
+}
+
+// expectedBars returns the bar times cal expects between the days of first
+// and last.
+func expectedBars(first, last time.Time, barSize time.Duration, cal MarketCalendar) []time.Time {
+	var bars []time.Time
+	end := startOfDay(last)
+	for day := startOfDay(first); !day.After(end); day = nextDay(day) {
+		open, close, ok := cal.Session(day)
+		if !ok {
+			continue
+		}
+		if barSize >= 24*time.Hour {
+			bars = append(bars, day)
+			continue
+		}
+		for t := open; t.Before(close); t = t.Add(barSize) {
+			bars = append(bars, t)
+		}
+	}
+	return bars
+}
+
+func nextDay(day time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, day.Location())
+}
+
+// maxClosedDays bounds the search for the next session, so that a calendar
+// that never opens again cannot hang the gap detector.
+const maxClosedDays = 30
+
+// nextBar returns the bar cal expects after t, or the zero time if cal has
+// no session in the maxClosedDays days after t.
+func nextBar(t time.Time, barSize time.Duration, cal MarketCalendar) time.Time {
+	if barSize < 24*time.Hour {
+		if _, close, ok := cal.Session(t); ok && t.Add(barSize).Before(close) {
+			return t.Add(barSize)
+		}
+	}
+	day := startOfDay(t)
+	for i := 0; i < maxClosedDays; i++ {
+		day = nextDay(day)
+		if open, _, ok := cal.Session(day); ok && open.After(t) {
+			if barSize >= 24*time.Hour {
+				return day
+			}
+			return open
+		}
+	}
+	return time.Time{}
+}
+
+// barKey identifies the bar a time belongs to: its exact time for intraday
+// bars and its day for daily bars, which the API stamps inconsistently.
+func barKey(t time.Time, barSize time.Duration) int64 {
+	if barSize >= 24*time.Hour {
+		return toMillis(startOfDay(t))
+	}
+	return toMillis(t)
+}
+
+func missingBars(candles []Candle, barSize time.Duration, cal MarketCalendar) []time.Time {
+	if len(candles) == 0 {
+		return nil
+	}
+	have := make(map[int64]bool, len(candles))
+	for _, c := range candles {
+		have[barKey(c.Datetime, barSize)] = true
+	}
+	var missing []time.Time
+	for _, t := range expectedBars(candles[0].Datetime, candles[len(candles)-1].Datetime, barSize, cal) {
+		if !have[barKey(t, barSize)] {
+			missing = append(missing, t)
+		}
+	}
+	return missing
+}
+
+// RepairGaps finds the gaps in candles, a series of symbol's barSize bars
+// ordered by time, and closes them as selected by opts. It returns the
+// repaired series and a report of what was repaired. Refetching supports
+// the minute bar sizes of DownloadHistory and daily bars.
+func (s *PriceHistoryService) RepairGaps(ctx context.Context, symbol string, candles []Candle, barSize time.Duration, cal MarketCalendar, opts RepairOptions) ([]Candle, *GapReport, error) {
+	report := &GapReport{Found: FindGaps(candles, barSize, cal)}
+	out := append([]Candle(nil), candles...)
+
+	if opts.Refetch && len(report.Found) > 0 {
+		missing := make(map[int64]bool)
+		for _, t := range missingBars(out, barSize, cal) {
+			missing[barKey(t, barSize)] = true
+		}
+		for _, gap := range report.Found {
+			fetched, err := s.fetchRange(ctx, symbol, gap.Start, gap.End.Add(barSize), barSize)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, c := range fetched {
+				if key := barKey(c.Datetime, barSize); missing[key] {
+					delete(missing, key)
+					out = append(out, c)
+					report.Refetched++
+				}
+			}
+		}
+		sort.Slice(out, func(i, j int) bool {
+			return out[i].Datetime.Before(out[j].Datetime)
+		})
+	}
+
+	if opts.ForwardFill {
+		out, report.Filled = forwardFill(out, missingBars(out, barSize, cal), barSize)
+	}
+
+	report.Remaining = FindGaps(out, barSize, cal)
+	return out, report, nil
+}
+
+func (s *PriceHistoryService) fetchRange(ctx context.Context, symbol string, from, to time.Time, barSize time.Duration) ([]Candle, error) {
+	if barSize >= 24*time.Hour {
+		history, _, err := s.PriceHistory(ctx, symbol, &PriceHistoryOptions{
+			PeriodType:    "month",
+			FrequencyType: "daily",
+			Frequency:     1,
+			StartDate:     from,
+			EndDate:       to,
+		})
+		if err != nil {
+			if history != nil && history.Empty {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return history.Candles, nil
+	}
+	if barSize%time.Minute != 0 {
+		return nil, fmt.Errorf("cannot refetch bars of %v", barSize)
+	}
+	return s.DownloadHistory(ctx, symbol, from, to, int(barSize/time.Minute))
+}
+
+// forwardFill inserts a flat bar at the previous close for every missing
+// time that has a previous bar. missing must be ordered by time.
+func forwardFill(candles []Candle, missing []time.Time, barSize time.Duration) ([]Candle, int) {
+	if len(missing) == 0 {
+		return candles, 0
+	}
+	out := make([]Candle, 0, len(candles)+len(missing))
+	filled := 0
+	i := 0
+	for _, t := range missing {
+		for i < len(candles) && barKey(candles[i].Datetime, barSize) < barKey(t, barSize) {
+			out = append(out, candles[i])
+			i++
+		}
+		if len(out) == 0 {
+			continue // nothing to carry forward yet
+		}
+		prev := out[len(out)-1].Close
+		out = append(out, Candle{Datetime: t, Open: prev, High: prev, Low: prev, Close: prev})
+		filled++
+	}
+	out = append(out, candles[i:]...)
+	return out, filled
+}
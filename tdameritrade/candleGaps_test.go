@@ -0,0 +1,29 @@
+package tdameritrade
+
+import (
+	"testing"
+	"time"
+)
+
+// closedCalendar is a market that never trades.
+type closedCalendar struct{}
+
+func (closedCalendar) Session(time.Time) (time.Time, time.Time, bool) {
+	return time.Time{}, time.Time{}, false
+}
+
+func TestNextBarClosedCalendar(t *testing.T) {
+	start := time.Date(2024, time.March, 4, 15, 59, 0, 0, Eastern())
+	for _, barSize := range []time.Duration{time.Minute, 24 * time.Hour} {
+		done := make(chan time.Time)
+		go func() { done <- nextBar(start, barSize, closedCalendar{}) }()
+		select {
+		case next := <-done:
+			if !next.IsZero() {
+				t.Errorf("nextBar(%v) = %v, want the zero time", barSize, next)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("nextBar(%v) did not return", barSize)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package tdameritrade
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// regularOpen and regularClose bound the regular US equity session, as
+	// offsets from midnight Eastern time.
+	regularOpen  = 9*time.Hour + 30*time.Minute
+	regularClose = 16 * time.Hour
+)
+
+// Resample aggregates candles into bars of size d, e.g. 1-minute bars into
+// 5m, 15m or 1h bars. Buckets are aligned to the 9:30 Eastern session open
+// and never span two trading days, so 1h bars run 9:30-10:30, 10:30-11:30 and
+// so on. Each bar opens at the first candle's open, closes at the last
+// candle's close, takes the extreme high and low and sums the volume, and is
+// stamped with the start of its bucket. candles must be ordered by time;
+// buckets without candles produce no bar. d must be positive and shorter
+// than a day; use ResampleDaily for daily bars.
+func Resample(candles []Candle, d time.Duration) ([]Candle, error) {
+	if d <= 0 || d >= 24*time.Hour {
+		return nil, fmt.Errorf("invalid bar size %v, must be between 0 and 24h", d)
+	}
+	return aggregate(candles, func(t time.Time) time.Time {
+		anchor := startOfDay(t).Add(regularOpen)
+		offset := t.Sub(anchor)
+		n := offset / d
+		if offset < 0 && offset%d != 0 {
+			n-- // floor division for pre-market bars
+		}
+		bucket := anchor.Add(n * d)
+		if day := startOfDay(t); bucket.Before(day) {
+			bucket = day
+		}
+		return bucket
+	}), nil
+}
+
+// ResampleDaily aggregates candles into one bar per Eastern calendar day,
+// stamped at midnight. Pass the result of RegularHours to leave extended
+// hours trading out of the daily bars. candles must be ordered by time.
+func ResampleDaily(candles []Candle) []Candle {
+	return aggregate(candles, startOfDay)
+}
+
+// RegularHours returns the candles that start within the regular session,
+// 9:30 to 16:00 Eastern.
+func RegularHours(candles []Candle) []Candle {
+	var out []Candle
+	for _, c := range candles {
+		offset := c.Datetime.In(Eastern()).Sub(startOfDay(c.Datetime))
+		if offset >= regularOpen && offset < regularClose {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// startOfDay returns midnight Eastern of the day t falls on.
+func startOfDay(t time.Time) time.Time {
+	t = t.In(Eastern())
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, Eastern())
+}
+
+func aggregate(candles []Candle, bucketOf func(time.Time) time.Time) []Candle {
+	var out []Candle
+	for _, c := range candles {
+		bucket := bucketOf(c.Datetime)
+		if n := len(out); n > 0 && out[n-1].Datetime.Equal(bucket) {
+			bar := &out[n-1]
+			if c.High > bar.High {
+				bar.High = c.High
+			}
+			if c.Low < bar.Low {
+				bar.Low = c.Low
+			}
+			bar.Close = c.Close
+			bar.Volume += c.Volume
+			continue
+		}
+		c.Datetime = bucket
+		out = append(out, c)
+	}
+	return out
+}
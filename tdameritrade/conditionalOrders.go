@@ -0,0 +1,77 @@
+package tdameritrade
+
+import "fmt"
+
+// closingInstructions maps each opening instruction to the one closing the
+// position it opens.
+var closingInstructions = map[Instruction]Instruction{
+	InstructionBuy:        InstructionSell,
+	InstructionSellShort:  InstructionBuyToCover,
+	InstructionBuyToOpen:  InstructionSellToClose,
+	InstructionSellToOpen: InstructionBuyToClose,
+}
+
+// Triggers makes the order a TRIGGER order: children are only sent once it
+// has filled. Children can themselves be TRIGGER or OCO orders.
+func (b *OrderBuilder) Triggers(children ...*Order) *OrderBuilder {
+	b.order.OrderStrategyType = OrderStrategyTypeTrigger
+	b.order.ChildOrderStrategies = append(b.order.ChildOrderStrategies, children...)
+	return b
+}
+
+// NewOCOOrder returns a one-cancels-other order: once any of orders fills,
+// the others are canceled.
+func NewOCOOrder(orders ...*Order) (*Order, error) {
+	if len(orders) < 2 {
+		return nil, fmt.Errorf("OCO order needs at least 2 orders, has %d", len(orders))
+	}
+	for _, o := range orders {
+		if o == nil {
+			return nil, fmt.Errorf("order is nil")
+		}
+	}
+	return &Order{
+		OrderStrategyType:    OrderStrategyTypeOCO,
+		ChildOrderStrategies: append([]*Order(nil), orders...),
+	}, nil
+}
+
+// NewBracketOrder returns entry, a single-leg opening order, as a TRIGGER
+// order that once filled places a limit order at target and a stop order at
+// stop, both good until canceled, to close the position. Whichever of the two
+// fills first cancels the other.
+func NewBracketOrder(entry *OrderBuilder, target, stop float64) (*Order, error) {
+	order, err := entry.Build()
+	if err != nil {
+		return nil, err
+	}
+	if len(order.OrderLegCollection) != 1 {
+		return nil, fmt.Errorf("bracket order entry needs a single leg, has %d", len(order.OrderLegCollection))
+	}
+	leg := order.OrderLegCollection[0]
+	instruction, ok := closingInstructions[leg.Instruction]
+	if !ok {
+		return nil, fmt.Errorf("bracket order entry must open a position, not %s", leg.Instruction)
+	}
+
+	exit := func() *OrderBuilder {
+		b := newOrderBuilder().Session(order.Session).GTC()
+		return b.setLeg(instruction, leg.Instrument, leg.Quantity)
+	}
+	takeProfit, err := exit().Limit(target).Build()
+	if err != nil {
+		return nil, fmt.Errorf("target: %v", err)
+	}
+	stopLoss, err := exit().Stop(stop).Build()
+	if err != nil {
+		return nil, fmt.Errorf("stop: %v", err)
+	}
+	oco, err := NewOCOOrder(takeProfit, stopLoss)
+	if err != nil {
+		return nil, err
+	}
+
+	order.OrderStrategyType = OrderStrategyTypeTrigger
+	order.ChildOrderStrategies = []*Order{oco}
+	return order, nil
+}
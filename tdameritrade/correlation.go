@@ -0,0 +1,62 @@
+package tdameritrade
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+)
+
+// CorrelationIDHeader is the request header carrying correlation ids.
+const CorrelationIDHeader = "X-Correlation-Id"
+
+type correlationIDKey struct{}
+
+// WithCorrelationID returns a copy of ctx making the calls made with it
+// send the correlation id id, e.g. the id of a trade decision in the logs
+// of a bot, to find its order placement in the logs of the API.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// WithCorrelationIDs makes the client send a random correlation id with
+// every call whose context has none set by WithCorrelationID. The id of a
+// call is in Response.CorrelationID and APIError.CorrelationID, and in the
+// log messages of the call.
+func WithCorrelationIDs() ClientOption {
+	return func(c *Client) error {
+		c.correlationIDs = true
+		return nil
+	}
+}
+
+// correlationID returns the correlation id of a call made with ctx, or ""
+// if it gets none.
+func (c *Client) correlationID(ctx context.Context) (string, error) {
+	if id, ok := ctx.Value(correlationIDKey{}).(string); ok && id != "" {
+		return id, nil
+	}
+	if !c.correlationIDs {
+		return "", nil
+	}
+	return newCorrelationID()
+}
+
+// newCorrelationID returns a random version 4 UUID.
+func newCorrelationID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// describe names req in log messages: its method, path and correlation id.
+func describe(req *http.Request) string {
+	if id := req.Header.Get(CorrelationIDHeader); id != "" {
+		return fmt.Sprintf("%s %s [%s]", req.Method, req.URL.Path, id)
+	}
+	return req.Method + " " + req.URL.Path
+}
@@ -0,0 +1,66 @@
+package tdameritrade
+
+import (
+	"net/url"
+	"sync"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// timestampLayout is the layout of the times the API sends as text, such as
+// Order.EnteredTime and StreamerInfo.TokenTimestamp, e.g.
+// 2020-06-15T14:07:02+0000.
+const timestampLayout = "2006-01-02T15:04:05-0700"
+
+var (
+	easternOnce sync.Once
+	eastern     *time.Location
+)
+
+// Eastern returns the America/New_York location the US markets keep time
+// in. If the system has no timezone database it falls back to a fixed
+// UTC-5 zone, which is an hour off during daylight saving time.
+func Eastern() *time.Location {
+	easternOnce.Do(func() {
+		loc, err := time.LoadLocation("America/New_York")
+		if err != nil {
+			loc = time.FixedZone("EST", -5*60*60)
+		}
+		eastern = loc
+	})
+	return eastern
+}
+
+// fromMillis converts the epoch milliseconds used throughout the API.
+func fromMillis(ms int64) time.Time {
+	return time.Unix(0, ms*int64(time.Millisecond)).In(Eastern())
+}
+
+func toMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// Date is a calendar date. It is sent to the API as yyyy-MM-dd, which is the
+// only format the date-only query parameters accept; a zero Date is omitted.
+type Date struct {
+	time.Time
+}
+
+// NewDate returns the Date of t.
+func NewDate(t time.Time) Date {
+	return Date{t}
+}
+
+func (d Date) String() string {
+	return d.Format(dateLayout)
+}
+
+// EncodeValues implements query.Encoder.
+func (d Date) EncodeValues(key string, v *url.Values) error {
+	if d.IsZero() {
+		return nil
+	}
+	v.Set(key, d.String())
+	return nil
+}
@@ -0,0 +1,100 @@
+package tdameritrade
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDateRequestURLs(t *testing.T) {
+	// Times of day and zones are dropped: the API only takes yyyy-MM-dd.
+	from := NewDate(time.Date(2021, 2, 1, 9, 30, 0, 0, time.FixedZone("EST", -5*60*60)))
+	to := NewDate(time.Date(2021, 3, 19, 23, 59, 59, 0, time.UTC))
+
+	for _, tt := range []struct {
+		name string
+		send func(context.Context, *Client) error
+		want string
+	}{
+		{
+			"option chain",
+			func(ctx context.Context, c *Client) error {
+				_, _, err := c.OptionChain.OptionChain(ctx, "AAPL", &OptionChainOptions{Strategy: StrategySingle, FromDate: from, ToDate: to})
+				return err
+			},
+			"/marketdata/chains?contractType=ALL&expMonth=ALL&fromDate=2021-02-01&optionType=ALL&range=ALL&strategy=SINGLE&symbol=AAPL&toDate=2021-03-19",
+		},
+		{
+			"option chain without dates",
+			func(ctx context.Context, c *Client) error {
+				_, _, err := c.OptionChain.OptionChain(ctx, "AAPL", &OptionChainOptions{Strategy: StrategySingle})
+				return err
+			},
+			"/marketdata/chains?contractType=ALL&expMonth=ALL&optionType=ALL&range=ALL&strategy=SINGLE&symbol=AAPL",
+		},
+		{
+			"market hours",
+			func(ctx context.Context, c *Client) error {
+				_, _, err := c.MarketHours.GetMarketHours(ctx, "EQUITY", from.Time)
+				return err
+			},
+			"/marketdata/EQUITY/hours?date=2021-02-01",
+		},
+		{
+			"orders",
+			func(ctx context.Context, c *Client) error {
+				_, _, err := c.Orders.GetOrdersByPath(ctx, "123", &OrdersOptions{MaxResults: 10, FromEnteredTime: from, ToEnteredTime: to})
+				return err
+			},
+			"/accounts/123/orders?fromEnteredTime=2021-02-01&maxResults=10&toEnteredTime=2021-03-19",
+		},
+		{
+			"orders from a date",
+			func(ctx context.Context, c *Client) error {
+				_, _, err := c.Orders.GetOrdersByQuery(ctx, &OrdersOptions{AccountID: "123", FromEnteredTime: from})
+				return err
+			},
+			"/orders?accountId=123&fromEnteredTime=2021-02-01",
+		},
+		{
+			"transactions",
+			func(ctx context.Context, c *Client) error {
+				_, _, err := c.TransactionHistory.GetTransactions(ctx, "123", &TransactionHistoryOptions{Type: TransactionTypeTrade, StartDate: from, EndDate: to})
+				return err
+			},
+			"/accounts/123/transactions?endDate=2021-03-19&startDate=2021-02-01&type=TRADE",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.URL.RequestURI()
+				switch r.URL.Path {
+				case "/marketdata/chains":
+					w.Write([]byte(`{"symbol":"AAPL","status":"SUCCESS"}`))
+				case "/marketdata/EQUITY/hours":
+					w.Write([]byte(`{}`))
+				default:
+					w.Write([]byte(`[]`))
+				}
+			}))
+			defer srv.Close()
+			client, err := NewClient(srv.Client())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := client.UpdateBaseURL(srv.URL + "/"); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tt.send(context.Background(), client); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("request URL = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
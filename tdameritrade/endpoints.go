@@ -0,0 +1,111 @@
+package tdameritrade
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// Endpoints are the URLs of an API deployment.
+type Endpoints struct {
+	// BaseURL is the root of the REST API, with a trailing slash.
+	BaseURL string
+
+	// MarketDataURL, if set, is the root of the market data API, with a
+	// trailing slash. Requests to marketdata/... go there instead of
+	// BaseURL.
+	MarketDataURL string
+
+	AuthURL  string
+	TokenURL string
+
+	// StreamerURL, if set, overrides the streamer URL the user principals
+	// report.
+	StreamerURL string
+
+	// ClientIDSuffix is appended to consumer keys to form OAuth client ids.
+	ClientIDSuffix string
+}
+
+// TDAmeritradeEndpoints are the endpoints of the TD Ameritrade API, the
+// default.
+var TDAmeritradeEndpoints = Endpoints{
+	BaseURL:        baseURL,
+	AuthURL:        AuthURL,
+	TokenURL:       TokenURL,
+	ClientIDSuffix: clientIDSuffix,
+}
+
+// SchwabEndpoints are the endpoints of the Schwab Trader API, the successor
+// of the TD Ameritrade API. The Schwab API takes the encrypted account
+// numbers of GET accounts/accountNumbers in place of account ids, and some
+// market data paths, such as price history, movers and market hours, moved;
+// the methods using them fail against it. Its apps have a secret, so get
+// and refresh their tokens with the methods of SchwabEndpoints, such as
+// NewTokenSource, rather than the functions of the package.
+var SchwabEndpoints = Endpoints{
+	BaseURL:       "https://api.schwabapi.com/trader/v1/",
+	MarketDataURL: "https://api.schwabapi.com/marketdata/v1/",
+	AuthURL:       "https://api.schwabapi.com/v1/oauth/authorize",
+	TokenURL:      "https://api.schwabapi.com/v1/oauth/token",
+}
+
+// OAuthConfig returns the OAuth2 configuration of an app of the deployment
+// with the consumer key clientID, the secret clientSecret, if it has one,
+// and the callback URL redirectURL.
+func (e Endpoints) OAuthConfig(clientID, clientSecret, redirectURL string) *oauth2.Config {
+	if e.ClientIDSuffix != "" && !strings.HasSuffix(clientID, e.ClientIDSuffix) {
+		clientID += e.ClientIDSuffix
+	}
+	style := oauth2.AuthStyleInParams
+	if clientSecret != "" {
+		style = oauth2.AuthStyleInHeader
+	}
+	return &oauth2.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   e.AuthURL,
+			TokenURL:  e.TokenURL,
+			AuthStyle: style,
+		},
+		RedirectURL: redirectURL,
+	}
+}
+
+// WithEndpoints makes the client talk to the deployment e, such as
+// SchwabEndpoints.
+func WithEndpoints(e Endpoints) ClientOption {
+	return func(c *Client) error {
+		base, err := parseRootURL(e.BaseURL)
+		if err != nil {
+			return err
+		}
+		c.BaseURL = base
+		c.MarketDataURL = nil
+		if e.MarketDataURL != "" {
+			u, err := parseRootURL(e.MarketDataURL)
+			if err != nil {
+				return err
+			}
+			c.MarketDataURL = u
+		}
+		c.StreamerURL = e.StreamerURL
+		return nil
+	}
+}
+
+// parseRootURL parses the root URL of an API, which must end with a slash
+// for paths to resolve under it.
+func parseRootURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		return nil, fmt.Errorf("URL must have a trailing slash, but %q does not", s)
+	}
+	return u, nil
+}
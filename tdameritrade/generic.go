@@ -0,0 +1,41 @@
+package tdameritrade
+
+import (
+	"context"
+	"net/http"
+)
+
+// Do sends req with c and decodes the response body into a new T, for
+// calling endpoints the services don't cover:
+//
+//	req, err := client.NewRequest("GET", "marketdata/$SPX.X/movers", nil)
+//	...
+//	movers, resp, err := tdameritrade.Do[[]tdameritrade.Mover](ctx, client, req)
+func Do[T any](ctx context.Context, c *Client, req *http.Request) (*T, *Response, error) {
+	v := new(T)
+	resp, err := c.Do(ctx, req, v)
+	if err != nil {
+		return nil, resp, err
+	}
+	return v, resp, nil
+}
+
+// Get GETs urlStr, relative to the BaseURL of c, and decodes the response
+// body into a new T.
+func Get[T any](ctx context.Context, c *Client, urlStr string) (*T, *Response, error) {
+	req, err := c.NewRequest("GET", urlStr, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return Do[T](ctx, c, req)
+}
+
+// Send sends body to urlStr, relative to the BaseURL of c, with method, and
+// decodes the response body into a new T.
+func Send[T any](ctx context.Context, c *Client, method, urlStr string, body interface{}) (*T, *Response, error) {
+	req, err := c.NewRequest(method, urlStr, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return Do[T](ctx, c, req)
+}
@@ -0,0 +1,175 @@
+// Package greeks recomputes option greeks and implied volatility with the
+// Black-Scholes model. It is meant for filling in the NaN or stale values the
+// TD Ameritrade API reports outside market hours.
+//
+// Greeks follow the API's conventions: theta is per calendar day, and vega
+// and rho are per one percentage point change in volatility and rate.
+package greeks
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/glacialspring/go-tdameritrade/tdameritrade"
+)
+
+var (
+	// ErrExpired is returned for contracts with no time left to expiration.
+	ErrExpired = errors.New("greeks: option has expired")
+	// ErrNoPrice is returned when a contract has no usable market price.
+	ErrNoPrice = errors.New("greeks: no market price")
+	// ErrNoConvergence is returned when no volatility reproduces the price,
+	// typically because the price is below intrinsic value.
+	ErrNoConvergence = errors.New("greeks: implied volatility did not converge")
+)
+
+const daysPerYear = 365
+
+// Params are the Black-Scholes model inputs. Rates, yields and volatility
+// are annualized decimals, so 5% is 0.05.
+type Params struct {
+	Call     bool
+	Spot     float64
+	Strike   float64
+	Years    float64 // time to expiration
+	Rate     float64 // risk-free rate
+	DivYield float64 // continuous dividend yield
+	Vol      float64
+}
+
+// Greeks are the sensitivities of an option's price.
+type Greeks struct {
+	Delta float64
+	Gamma float64
+	Theta float64 // per calendar day
+	Vega  float64 // per volatility point
+	Rho   float64 // per rate point
+}
+
+func cdf(x float64) float64 { return 0.5 * math.Erfc(-x/math.Sqrt2) }
+
+func pdf(x float64) float64 { return math.Exp(-x*x/2) / math.Sqrt(2*math.Pi) }
+
+func (p Params) d1d2() (float64, float64) {
+	sqrtT := math.Sqrt(p.Years)
+	d1 := (math.Log(p.Spot/p.Strike) + (p.Rate-p.DivYield+p.Vol*p.Vol/2)*p.Years) / (p.Vol * sqrtT)
+	return d1, d1 - p.Vol*sqrtT
+}
+
+// Price returns the Black-Scholes value of the option.
+func Price(p Params) float64 {
+	d1, d2 := p.d1d2()
+	dq := math.Exp(-p.DivYield * p.Years)
+	dr := math.Exp(-p.Rate * p.Years)
+	if p.Call {
+		return p.Spot*dq*cdf(d1) - p.Strike*dr*cdf(d2)
+	}
+	return p.Strike*dr*cdf(-d2) - p.Spot*dq*cdf(-d1)
+}
+
+// Compute returns the greeks of the option.
+func Compute(p Params) Greeks {
+	d1, d2 := p.d1d2()
+	sqrtT := math.Sqrt(p.Years)
+	dq := math.Exp(-p.DivYield * p.Years)
+	dr := math.Exp(-p.Rate * p.Years)
+
+	g := Greeks{
+		Gamma: dq * pdf(d1) / (p.Spot * p.Vol * sqrtT),
+		Vega:  p.Spot * dq * pdf(d1) * sqrtT / 100,
+	}
+	decay := -p.Spot * dq * pdf(d1) * p.Vol / (2 * sqrtT)
+	if p.Call {
+		g.Delta = dq * cdf(d1)
+		g.Theta = (decay - p.Rate*p.Strike*dr*cdf(d2) + p.DivYield*p.Spot*dq*cdf(d1)) / daysPerYear
+		g.Rho = p.Strike * p.Years * dr * cdf(d2) / 100
+	} else {
+		g.Delta = -dq * cdf(-d1)
+		g.Theta = (decay + p.Rate*p.Strike*dr*cdf(-d2) - p.DivYield*p.Spot*dq*cdf(-d1)) / daysPerYear
+		g.Rho = -p.Strike * p.Years * dr * cdf(-d2) / 100
+	}
+	return g
+}
+
+// ImpliedVolatility returns the volatility at which the option is worth
+// price. p.Vol is ignored.
+func ImpliedVolatility(price float64, p Params) (float64, error) {
+	if p.Years <= 0 {
+		return 0, ErrExpired
+	}
+	if !(price > 0) {
+		return 0, ErrNoPrice
+	}
+
+	const (
+		tolerance = 1e-8
+		maxIter   = 100
+	)
+	lo, hi := 1e-6, 5.0
+	p.Vol = lo
+	if Price(p) > price {
+		return 0, ErrNoConvergence
+	}
+	p.Vol = hi
+	if Price(p) < price {
+		return 0, ErrNoConvergence
+	}
+
+	// Newton's method, falling back to bisection whenever a step leaves
+	// the bracket or vega vanishes.
+	vol := 0.3
+	for i := 0; i < maxIter; i++ {
+		p.Vol = vol
+		diff := Price(p) - price
+		if math.Abs(diff) < tolerance {
+			return vol, nil
+		}
+		if diff > 0 {
+			hi = vol
+		} else {
+			lo = vol
+		}
+		vega := Compute(p).Vega * 100
+		next := vol - diff/vega
+		if vega <= 0 || next <= lo || next >= hi {
+			next = (lo + hi) / 2
+		}
+		vol = next
+	}
+	return 0, ErrNoConvergence
+}
+
+// MarketPrice returns the price used to back out volatility for o: the
+// bid/ask midpoint when both sides are quoted, otherwise the mark, otherwise
+// the close.
+func MarketPrice(o *tdameritrade.OptionData) float64 {
+	switch {
+	case o.BidPrice > 0 && o.AskPrice > 0:
+		return (o.BidPrice + o.AskPrice) / 2
+	case o.MarkPrice > 0:
+		return o.MarkPrice
+	}
+	return o.ClosePrice
+}
+
+// Recompute derives the implied volatility, in percent like
+// OptionData.Volatility, and the greeks of o at time now. underlying is the
+// price of the underlying and rate the annualized risk-free rate as a
+// decimal.
+func Recompute(o *tdameritrade.OptionData, underlying, rate float64, now time.Time) (float64, Greeks, error) {
+	exp := time.Unix(0, o.ExpirationDate*int64(time.Millisecond))
+	p := Params{
+		Call:   o.PutCall == "CALL",
+		Spot:   underlying,
+		Strike: o.StrikePrice,
+		Years:  exp.Sub(now).Hours() / 24 / daysPerYear,
+		Rate:   rate,
+	}
+	vol, err := ImpliedVolatility(MarketPrice(o), p)
+	if err != nil {
+		return 0, Greeks{}, err
+	}
+	p.Vol = vol
+	return vol * 100, Compute(p), nil
+}
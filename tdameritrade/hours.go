@@ -3,15 +3,40 @@ package tdameritrade
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"strings"
 	"time"
 )
 
+// MarketType is a market whose hours can be requested.
+type MarketType string
+
+const (
+	MarketTypeEquity MarketType = "EQUITY"
+	MarketTypeOption MarketType = "OPTION"
+	MarketTypeFuture MarketType = "FUTURE"
+	MarketTypeBond   MarketType = "BOND"
+	MarketTypeForex  MarketType = "FOREX"
+)
+
+func (m MarketType) String() string { return string(m) }
+
+func (m MarketType) valid() bool {
+	switch m {
+	case MarketTypeEquity, MarketTypeOption, MarketTypeFuture, MarketTypeBond, MarketTypeForex:
+		return true
+	}
+	return false
+}
+
 // MarketHoursService handles communication with the marketdata related methods of
 // the TDAmeritrade API.
 //
 // TDAmeritrade API docs: https://developer.tdameritrade.com/market-hours/apis
 type MarketHoursService struct {
 	client *Client
+
+	cache hoursCache
 }
 
 type MarketHours map[string]map[string]*Hours
@@ -45,7 +70,7 @@ func (s *MarketHoursService) GetMarketHoursMulti(ctx context.Context, markets st
 	}
 	u = fmt.Sprintf("%s?markets=%s", u, markets)
 	if !date.IsZero() {
-		u = fmt.Sprintf("%s&date=%s", u, date.Format("2006-01-02"))
+		u = fmt.Sprintf("%s&date=%s", u, NewDate(date))
 	}
 
 	req, err := s.client.NewRequest("GET", u, nil)
@@ -68,7 +93,7 @@ func (s *MarketHoursService) GetMarketHours(ctx context.Context, market string,
 	u := fmt.Sprintf("marketdata/%s/hours", market)
 
 	if !date.IsZero() {
-		u = fmt.Sprintf("%s?date=%s", u, date.Format("2006-01-02"))
+		u = fmt.Sprintf("%s?date=%s", u, NewDate(date))
 	}
 
 	req, err := s.client.NewRequest("GET", u, nil)
@@ -86,3 +111,124 @@ func (s *MarketHoursService) GetMarketHours(ctx context.Context, market string,
 
 	return hours, resp, nil
 }
+
+// SessionPeriod is a period a session is open, [Start, End).
+type SessionPeriod struct {
+	Start time.Time
+	End   time.Time
+}
+
+// ProductHours are the hours of a product of a market on one day, with the
+// session times parsed. The sessions are empty on days the product does not
+// trade, and a session may have several periods, e.g. for futures that
+// pause trading during the day.
+type ProductHours struct {
+	Market      MarketType
+	Product     string
+	ProductName string
+	Exchange    string
+	Category    string
+	Date        string // yyyy-MM-dd
+	IsOpen      bool
+
+	PreMarket     []SessionPeriod
+	RegularMarket []SessionPeriod
+	PostMarket    []SessionPeriod
+}
+
+// GetHours returns the hours of markets on the day of date, or today if date
+// is zero, keyed by market and then by product, e.g. hours[MarketTypeOption]
+// holds both the equity (EQO) and index (IND) option products.
+// TDAmeritrade API Docs: https://developer.tdameritrade.com/market-hours/apis/get/marketdata/hours
+func (s *MarketHoursService) GetHours(ctx context.Context, markets []MarketType, date time.Time) (map[MarketType]map[string]*ProductHours, *Response, error) {
+	if len(markets) == 0 {
+		return nil, nil, fmt.Errorf("no markets present")
+	}
+	names := make([]string, len(markets))
+	for i, m := range markets {
+		if !m.valid() {
+			return nil, nil, fmt.Errorf("invalid market type %q", m)
+		}
+		names[i] = m.String()
+	}
+	q := url.Values{"markets": {strings.Join(names, ",")}}
+	if !date.IsZero() {
+		q.Set("date", NewDate(date).String())
+	}
+	u := fmt.Sprintf("marketdata/hours?%s", q.Encode())
+
+	req, err := s.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var raw MarketHours
+	resp, err := s.client.Do(ctx, req, &raw)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	hours := make(map[MarketType]map[string]*ProductHours, len(raw))
+	for key, products := range raw {
+		market := MarketType(strings.ToUpper(key))
+		byProduct := make(map[string]*ProductHours, len(products))
+		for product, h := range products {
+			if h == nil {
+				continue
+			}
+			ph, err := h.parse(market, product)
+			if err != nil {
+				return nil, resp, fmt.Errorf("%s hours of %s: %v", market, product, err)
+			}
+			byProduct[ph.Product] = ph
+		}
+		hours[market] = byProduct
+	}
+	return hours, resp, nil
+}
+
+// parse returns the typed hours of the product with the given key of market.
+func (h *Hours) parse(market MarketType, product string) (*ProductHours, error) {
+	ph := &ProductHours{
+		Market:      market,
+		Product:     h.Product,
+		ProductName: h.ProductName,
+		Exchange:    h.Exchange,
+		Category:    h.Category,
+		Date:        h.Date,
+		IsOpen:      h.IsOpen,
+	}
+	if ph.Product == "" {
+		ph.Product = product
+	}
+	if h.MarketType != "" {
+		ph.Market = MarketType(h.MarketType)
+	}
+	var err error
+	if ph.PreMarket, err = parsePeriods(h.SessionHours.PreMarket); err != nil {
+		return nil, err
+	}
+	if ph.RegularMarket, err = parsePeriods(h.SessionHours.RegularMarket); err != nil {
+		return nil, err
+	}
+	if ph.PostMarket, err = parsePeriods(h.SessionHours.PostMarket); err != nil {
+		return nil, err
+	}
+	return ph, nil
+}
+
+func parsePeriods(periods []Period) ([]SessionPeriod, error) {
+	var parsed []SessionPeriod
+	for _, p := range periods {
+		start, err := time.Parse(time.RFC3339, p.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := time.Parse(time.RFC3339, p.End)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, SessionPeriod{Start: start, End: end})
+	}
+	return parsed, nil
+}
@@ -0,0 +1,122 @@
+package tdameritrade
+
+import (
+	"fmt"
+	"strings"
+)
+
+// IncomePeriod is the length of the periods income is summarized by.
+type IncomePeriod int
+
+const (
+	IncomeByMonth   IncomePeriod = iota // keyed "2006-01"
+	IncomeByQuarter                     // keyed "2006-Q1"
+)
+
+// IncomeSummary sums the income of some transactions. Withholding is the
+// tax withheld, as a positive amount, and Net is what was received after
+// it.
+type IncomeSummary struct {
+	Dividends   float64
+	Interest    float64
+	Withholding float64
+	Net         float64
+}
+
+func (s *IncomeSummary) add(kind incomeKind, amount float64) {
+	switch kind {
+	case incomeDividend:
+		s.Dividends += amount
+	case incomeInterest:
+		s.Interest += amount
+	case incomeWithholding:
+		s.Withholding -= amount
+	}
+	s.Net += amount
+}
+
+// IncomeReport is the outcome of SummarizeIncome. Income without a symbol,
+// such as interest on cash, is reported under the symbol "".
+type IncomeReport struct {
+	Total          IncomeSummary
+	BySymbol       map[string]*IncomeSummary
+	ByPeriod       map[string]*IncomeSummary
+	BySymbolPeriod map[string]map[string]*IncomeSummary
+}
+
+type incomeKind int
+
+const (
+	incomeNone incomeKind = iota
+	incomeDividend
+	incomeInterest
+	incomeWithholding
+)
+
+// incomeKindOf classifies t, a DIVIDEND_OR_INTEREST transaction or a journal
+// entry withholding tax on one.
+func incomeKindOf(t *Transaction) incomeKind {
+	description := strings.ToUpper(t.Description)
+	withholding := strings.Contains(description, "WITHH") || strings.Contains(description, "FOREIGN TAX")
+	switch {
+	case (t.Type == "DIVIDEND_OR_INTEREST" || t.Type == "JOURNAL") && withholding:
+		return incomeWithholding
+	case t.Type != "DIVIDEND_OR_INTEREST":
+		return incomeNone
+	case strings.Contains(description, "INTEREST"):
+		return incomeInterest
+	}
+	return incomeDividend
+}
+
+// SummarizeIncome aggregates the dividends, interest and tax withholding of
+// transactions by symbol, by period and by both.
+func SummarizeIncome(transactions []*Transaction, period IncomePeriod) (*IncomeReport, error) {
+	if period != IncomeByMonth && period != IncomeByQuarter {
+		return nil, fmt.Errorf("invalid income period %d", period)
+	}
+	report := &IncomeReport{
+		BySymbol:       make(map[string]*IncomeSummary),
+		ByPeriod:       make(map[string]*IncomeSummary),
+		BySymbolPeriod: make(map[string]map[string]*IncomeSummary),
+	}
+	for _, t := range transactions {
+		kind := incomeKindOf(t)
+		if kind == incomeNone {
+			continue
+		}
+		tm, err := t.Time()
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", t.TransactionID, err)
+		}
+		tm = tm.In(Eastern())
+		key := tm.Format("2006-01")
+		if period == IncomeByQuarter {
+			key = fmt.Sprintf("%d-Q%d", tm.Year(), (int(tm.Month())-1)/3+1)
+		}
+		var symbol string
+		if t.TransactionItem != nil && t.TransactionItem.Instrument != nil {
+			symbol = t.TransactionItem.Instrument.Symbol
+		}
+
+		report.Total.add(kind, t.NetAmount)
+		summary(report.BySymbol, symbol).add(kind, t.NetAmount)
+		summary(report.ByPeriod, key).add(kind, t.NetAmount)
+		periods := report.BySymbolPeriod[symbol]
+		if periods == nil {
+			periods = make(map[string]*IncomeSummary)
+			report.BySymbolPeriod[symbol] = periods
+		}
+		summary(periods, key).add(kind, t.NetAmount)
+	}
+	return report, nil
+}
+
+func summary(m map[string]*IncomeSummary, key string) *IncomeSummary {
+	s := m[key]
+	if s == nil {
+		s = new(IncomeSummary)
+		m[key] = s
+	}
+	return s
+}
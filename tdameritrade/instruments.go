@@ -3,6 +3,9 @@ package tdameritrade
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"sync"
+	"time"
 )
 
 // InstrumentService handles communication with the marketdata related methods of
@@ -11,8 +14,36 @@ import (
 // TDAmeritrade API docs: https://developer.tdameritrade.com/instruments/apis
 type InstrumentService struct {
 	client *Client
+
+	// RequestInterval is the minimum time between two requests made by
+	// BulkFundamentals. Defaults to 500ms, which stays within the 120
+	// requests per minute allowed by the API. It is ignored if the client
+	// has a Limiter, which paces them instead.
+	RequestInterval time.Duration
+
+	// Concurrency bounds the number of requests BulkFundamentals keeps in
+	// flight. Defaults to 4.
+	Concurrency int
+
+	// MaxRetries is the number of times BulkFundamentals retries a symbol
+	// the API rejected with 429 Too Many Requests. Defaults to 3. It is
+	// ignored if the client has a RetryPolicy, which retries them instead.
+	MaxRetries int
+
+	// SymbolCacheTTL is how long ValidateSymbol remembers a symbol, found
+	// or not. Defaults to 24 hours.
+	SymbolCacheTTL time.Duration
+
+	symbols symbolCache
 }
 
+const (
+	defaultInstrumentRequestInterval = 500 * time.Millisecond
+	defaultInstrumentConcurrency     = 4
+	defaultInstrumentMaxRetries      = 3
+	defaultSymbolCacheTTL            = 24 * time.Hour
+)
+
 type Instruments map[string]*InstrumentInfo
 
 type InstrumentInfo struct {
@@ -21,6 +52,60 @@ type InstrumentInfo struct {
 	Description string `json:"description,omitempty"`
 	Type        string `json:"assetType"` //"'NOT_APPLICABLE' or 'OPEN_END_NON_TAXABLE' or 'OPEN_END_TAXABLE' or 'NO_LOAD_NON_TAXABLE' or 'NO_LOAD_TAXABLE'"
 	Exchange    string `json:"exchange"`
+
+	// Fundamental is only set by the fundamental projection.
+	Fundamental *Fundamental `json:"fundamental,omitempty"`
+}
+
+// Fundamental is the fundamental data of an instrument. Ratios and margins
+// are in percent.
+type Fundamental struct {
+	Symbol              string  `json:"symbol"`
+	High52              float64 `json:"high52"`
+	Low52               float64 `json:"low52"`
+	DividendAmount      float64 `json:"dividendAmount"`
+	DividendYield       float64 `json:"dividendYield"`
+	DividendDate        string  `json:"dividendDate"`
+	PeRatio             float64 `json:"peRatio"`
+	PegRatio            float64 `json:"pegRatio"`
+	PbRatio             float64 `json:"pbRatio"`
+	PrRatio             float64 `json:"prRatio"`
+	PcfRatio            float64 `json:"pcfRatio"`
+	GrossMarginTTM      float64 `json:"grossMarginTTM"`
+	GrossMarginMRQ      float64 `json:"grossMarginMRQ"`
+	NetProfitMarginTTM  float64 `json:"netProfitMarginTTM"`
+	NetProfitMarginMRQ  float64 `json:"netProfitMarginMRQ"`
+	OperatingMarginTTM  float64 `json:"operatingMarginTTM"`
+	OperatingMarginMRQ  float64 `json:"operatingMarginMRQ"`
+	ReturnOnEquity      float64 `json:"returnOnEquity"`
+	ReturnOnAssets      float64 `json:"returnOnAssets"`
+	ReturnOnInvestment  float64 `json:"returnOnInvestment"`
+	QuickRatio          float64 `json:"quickRatio"`
+	CurrentRatio        float64 `json:"currentRatio"`
+	InterestCoverage    float64 `json:"interestCoverage"`
+	TotalDebtToCapital  float64 `json:"totalDebtToCapital"`
+	LtDebtToEquity      float64 `json:"ltDebtToEquity"`
+	TotalDebtToEquity   float64 `json:"totalDebtToEquity"`
+	EpsTTM              float64 `json:"epsTTM"`
+	EpsChangePercentTTM float64 `json:"epsChangePercentTTM"`
+	EpsChangeYear       float64 `json:"epsChangeYear"`
+	EpsChange           float64 `json:"epsChange"`
+	RevChangeYear       float64 `json:"revChangeYear"`
+	RevChangeTTM        float64 `json:"revChangeTTM"`
+	RevChangeIn         float64 `json:"revChangeIn"`
+	SharesOutstanding   float64 `json:"sharesOutstanding"`
+	MarketCapFloat      float64 `json:"marketCapFloat"`
+	MarketCap           float64 `json:"marketCap"`
+	BookValuePerShare   float64 `json:"bookValuePerShare"`
+	ShortIntToFloat     float64 `json:"shortIntToFloat"`
+	ShortIntDayToCover  float64 `json:"shortIntDayToCover"`
+	DivGrowthRate3Year  float64 `json:"divGrowthRate3Year"`
+	DividendPayAmount   float64 `json:"dividendPayAmount"`
+	DividendPayDate     string  `json:"dividendPayDate"`
+	Beta                float64 `json:"beta"`
+	Vol1DayAvg          float64 `json:"vol1DayAvg"`
+	Vol10DayAvg         float64 `json:"vol10DayAvg"`
+	Vol3MonthAvg        float64 `json:"vol3MonthAvg"`
 }
 
 func (s *InstrumentService) GetInstrument(ctx context.Context, cusip string) (*Instruments, *Response, error) {
@@ -68,3 +153,117 @@ func (s *InstrumentService) SearchInstruments(ctx context.Context, symbol, proje
 
 	return instruments, resp, nil
 }
+
+// GetFundamental returns the fundamental data of the instrument symbol, or
+// ErrSymbolNotFound if the API knows no such instrument.
+// TDAmeritrade API Docs: https://developer.tdameritrade.com/instruments/apis/get/instruments
+func (s *InstrumentService) GetFundamental(ctx context.Context, symbol string) (*Fundamental, *Response, error) {
+	if symbol == "" {
+		return nil, nil, fmt.Errorf("no symbol present")
+	}
+	q := url.Values{"symbol": {symbol}, "projection": {"fundamental"}}
+	u := fmt.Sprintf("instruments?%s", q.Encode())
+
+	req, err := s.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	instruments := make(Instruments)
+	resp, err := s.client.Do(ctx, req, &instruments)
+	if err != nil {
+		return nil, resp, err
+	}
+	info, ok := instruments[symbol]
+	if !ok || info == nil || info.Fundamental == nil {
+		return nil, resp, ErrSymbolNotFound
+	}
+	return info.Fundamental, resp, nil
+}
+
+// BulkFundamentals fetches the fundamental data of many symbols, one request
+// per symbol. Requests are spread over the service's Concurrency workers and
+// started at most once per RequestInterval, or as the client's Limiter
+// allows if it has one; symbols rejected with 429 Too Many Requests are
+// retried up to MaxRetries times, like BulkPriceHistory does.
+//
+// Fundamentals are keyed by symbol. Symbols that fail are left out and
+// reported in the returned SymbolErrors; the fundamentals of the others are
+// still returned.
+func (s *InstrumentService) BulkFundamentals(ctx context.Context, symbols []string) (map[string]*Fundamental, error) {
+	concurrency := s.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultInstrumentConcurrency
+	}
+	interval := s.RequestInterval
+	if interval <= 0 {
+		interval = defaultInstrumentRequestInterval
+	}
+	maxRetries := s.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = defaultInstrumentMaxRetries
+	}
+	limiter := s.client.helperLimiter(interval)
+	maxRetries = s.client.helperRetries(maxRetries)
+
+	seen := make(map[string]bool, len(symbols))
+	var unique []string
+	for _, symbol := range symbols {
+		if !seen[symbol] {
+			seen[symbol] = true
+			unique = append(unique, symbol)
+		}
+	}
+
+	jobs := make(chan string)
+	go func() {
+		defer close(jobs)
+		for _, symbol := range unique {
+			select {
+			case jobs <- symbol:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var (
+		mu           sync.Mutex
+		wg           sync.WaitGroup
+		fundamentals = make(map[string]*Fundamental, len(unique))
+		errs         = make(SymbolErrors)
+	)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for symbol := range jobs {
+				var fundamental *Fundamental
+				err := retryRateLimited(ctx, limiter, interval, maxRetries, func() error {
+					var err error
+					fundamental, _, err = s.GetFundamental(ctx, symbol)
+					return err
+				})
+
+				mu.Lock()
+				if err != nil {
+					errs[symbol] = err
+				} else {
+					fundamentals[symbol] = fundamental
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, symbol := range unique {
+		if _, ok := fundamentals[symbol]; !ok && errs[symbol] == nil {
+			errs[symbol] = ctx.Err() // never started before ctx was done
+		}
+	}
+	if len(errs) > 0 {
+		return fundamentals, errs
+	}
+	return fundamentals, nil
+}
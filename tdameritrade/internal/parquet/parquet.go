@@ -0,0 +1,319 @@
+// Package parquet writes flat tables to Apache Parquet files.
+//
+// It implements just enough of the format for exporting market data: row
+// groups of required (non-null) columns, PLAIN encoded and uncompressed,
+// which every Parquet reader understands.
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Type is the type of a column.
+type Type int
+
+const (
+	Int64           Type = iota // Values is []int64
+	Double                      // Values is []float64
+	String                      // Values is []string, stored as UTF-8
+	TimestampMillis             // Values is []int64 of epoch milliseconds
+)
+
+// Field is the name and type of a column of a file.
+type Field struct {
+	Name string
+	Type Type
+}
+
+// Column is a named column of values.
+type Column struct {
+	Name   string
+	Type   Type
+	Values interface{}
+}
+
+// Parquet enum values.
+const (
+	physicalInt64     = 2
+	physicalDouble    = 5
+	physicalByteArray = 6
+
+	convertedUTF8            = 0
+	convertedTimestampMillis = 9
+
+	repetitionRequired = 0
+	encodingPlain      = 0
+	encodingRLE        = 3
+	pageTypeData       = 0
+	codecUncompressed  = 0
+)
+
+var magic = []byte("PAR1")
+
+type chunkMeta struct {
+	field     Field
+	numValues int
+	offset    int64
+	size      int64
+}
+
+type rowGroupMeta struct {
+	chunks  []chunkMeta
+	numRows int
+}
+
+// Writer writes a Parquet file to an io.Writer one row group at a time, so
+// that only the rows of the current group need to be in memory. The footer
+// is written by Close.
+type Writer struct {
+	w       io.Writer
+	fields  []Field
+	offset  int64
+	groups  []rowGroupMeta
+	numRows int
+	err     error
+}
+
+// NewWriter returns a writer of a file with the columns fields to w.
+// Nothing is written before the first row group or Close.
+func NewWriter(w io.Writer, fields []Field) (*Writer, error) {
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("parquet: no columns")
+	}
+	return &Writer{w: w, fields: fields}, nil
+}
+
+// write writes b to the underlying writer, keeping track of the offset and
+// of the first error.
+func (pw *Writer) write(b []byte) {
+	if pw.err != nil {
+		return
+	}
+	var n int
+	n, pw.err = pw.w.Write(b)
+	pw.offset += int64(n)
+}
+
+// WriteRowGroup writes columns as a row group. They must match the fields
+// of the file, in order, and all hold the same number of values. Groups
+// without rows are skipped.
+func (pw *Writer) WriteRowGroup(columns []Column) error {
+	if pw.err != nil {
+		return pw.err
+	}
+	if len(columns) != len(pw.fields) {
+		return fmt.Errorf("parquet: row group has %d columns, want %d", len(columns), len(pw.fields))
+	}
+	numRows := -1
+	for i, c := range columns {
+		if f := pw.fields[i]; c.Name != f.Name || c.Type != f.Type {
+			return fmt.Errorf("parquet: column %d is %s, want %s", i, c.Name, f.Name)
+		}
+		n, err := columnLen(c)
+		if err != nil {
+			return err
+		}
+		if numRows >= 0 && n != numRows {
+			return fmt.Errorf("parquet: column %s has %d values, want %d", c.Name, n, numRows)
+		}
+		numRows = n
+	}
+	if numRows == 0 {
+		return nil
+	}
+
+	if pw.offset == 0 {
+		pw.write(magic)
+	}
+	group := rowGroupMeta{chunks: make([]chunkMeta, len(columns)), numRows: numRows}
+	for i, c := range columns {
+		values, err := plainValues(c)
+		if err != nil {
+			return err
+		}
+		meta := chunkMeta{field: pw.fields[i], numValues: numRows, offset: pw.offset}
+
+		var h compactWriter
+		h.structBegin()
+		h.i32Field(1, pageTypeData)
+		h.i32Field(2, int32(len(values)))
+		h.i32Field(3, int32(len(values)))
+		h.structField(5) // data_page_header
+		h.i32Field(1, int32(numRows))
+		h.i32Field(2, encodingPlain)
+		h.i32Field(3, encodingRLE)
+		h.i32Field(4, encodingRLE)
+		h.structEnd()
+		h.structEnd()
+
+		pw.write(h.buf.Bytes())
+		pw.write(values)
+		meta.size = pw.offset - meta.offset
+		group.chunks[i] = meta
+	}
+	if pw.err != nil {
+		return pw.err
+	}
+	pw.groups = append(pw.groups, group)
+	pw.numRows += numRows
+	return nil
+}
+
+// Close writes the footer of the file. It does not close the underlying
+// writer.
+func (pw *Writer) Close() error {
+	if pw.err != nil {
+		return pw.err
+	}
+	if pw.offset == 0 {
+		pw.write(magic)
+	}
+	footer := fileMetaData(pw.fields, pw.groups, pw.numRows)
+	pw.write(footer)
+	var n [4]byte
+	binary.LittleEndian.PutUint32(n[:], uint32(len(footer)))
+	pw.write(n[:])
+	pw.write(magic)
+
+	err := pw.err
+	if err == nil {
+		pw.err = fmt.Errorf("parquet: writer is closed")
+	}
+	return err
+}
+
+// Write writes columns, which must all hold the same number of values, as a
+// Parquet file of a single row group to w.
+func Write(w io.Writer, columns []Column) error {
+	fields := make([]Field, len(columns))
+	for i, c := range columns {
+		fields[i] = Field{Name: c.Name, Type: c.Type}
+	}
+	pw, err := NewWriter(w, fields)
+	if err != nil {
+		return err
+	}
+	if err := pw.WriteRowGroup(columns); err != nil {
+		return err
+	}
+	return pw.Close()
+}
+
+func columnLen(c Column) (int, error) {
+	switch v := c.Values.(type) {
+	case []int64:
+		if c.Type == Int64 || c.Type == TimestampMillis {
+			return len(v), nil
+		}
+	case []float64:
+		if c.Type == Double {
+			return len(v), nil
+		}
+	case []string:
+		if c.Type == String {
+			return len(v), nil
+		}
+	}
+	return 0, fmt.Errorf("parquet: column %s: values of type %T do not match column type", c.Name, c.Values)
+}
+
+func types(t Type) (physical, converted int32, hasConverted bool) {
+	switch t {
+	case Double:
+		return physicalDouble, 0, false
+	case String:
+		return physicalByteArray, convertedUTF8, true
+	case TimestampMillis:
+		return physicalInt64, convertedTimestampMillis, true
+	}
+	return physicalInt64, 0, false
+}
+
+func plainValues(c Column) ([]byte, error) {
+	var buf bytes.Buffer
+	var b [8]byte
+	switch v := c.Values.(type) {
+	case []int64:
+		for _, x := range v {
+			binary.LittleEndian.PutUint64(b[:], uint64(x))
+			buf.Write(b[:])
+		}
+	case []float64:
+		for _, x := range v {
+			binary.LittleEndian.PutUint64(b[:], math.Float64bits(x))
+			buf.Write(b[:])
+		}
+	case []string:
+		for _, x := range v {
+			binary.LittleEndian.PutUint32(b[:4], uint32(len(x)))
+			buf.Write(b[:4])
+			buf.WriteString(x)
+		}
+	default:
+		return nil, fmt.Errorf("parquet: unsupported values %T", c.Values)
+	}
+	return buf.Bytes(), nil
+}
+
+func fileMetaData(fields []Field, groups []rowGroupMeta, numRows int) []byte {
+	var w compactWriter
+	w.structBegin()
+	w.i32Field(1, 1) // version
+
+	w.listField(2, ctStruct, len(fields)+1) // schema
+	w.structBegin()
+	w.stringField(4, "schema")
+	w.i32Field(5, int32(len(fields)))
+	w.structEnd()
+	for _, f := range fields {
+		physical, converted, hasConverted := types(f.Type)
+		w.structBegin()
+		w.i32Field(1, physical)
+		w.i32Field(3, repetitionRequired)
+		w.stringField(4, f.Name)
+		if hasConverted {
+			w.i32Field(6, converted)
+		}
+		w.structEnd()
+	}
+
+	w.i64Field(3, int64(numRows))
+
+	w.listField(4, ctStruct, len(groups)) // row_groups
+	for _, g := range groups {
+		var total int64
+		w.structBegin()
+		w.listField(1, ctStruct, len(g.chunks))
+		for _, c := range g.chunks {
+			physical, _, _ := types(c.field.Type)
+			w.structBegin()
+			w.i64Field(2, c.offset) // file_offset
+			w.structField(3)        // meta_data
+			w.i32Field(1, physical)
+			w.listField(2, ctI32, 2)
+			w.zigzag(encodingPlain)
+			w.zigzag(encodingRLE)
+			w.listField(3, ctBinary, 1)
+			w.str(c.field.Name)
+			w.i32Field(4, codecUncompressed)
+			w.i64Field(5, int64(c.numValues))
+			w.i64Field(6, c.size)
+			w.i64Field(7, c.size)
+			w.i64Field(9, c.offset) // data_page_offset
+			w.structEnd()
+			w.structEnd()
+			total += c.size
+		}
+		w.i64Field(2, total)
+		w.i64Field(3, int64(g.numRows))
+		w.structEnd()
+	}
+
+	w.stringField(6, "go-tdameritrade")
+	w.structEnd()
+	return w.buf.Bytes()
+}
@@ -0,0 +1,250 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// compactReader reads the Thrift compact protocol into maps of field ids to
+// values: int64 for integers, string for binaries, []interface{} for lists
+// and map[int16]interface{} for structs.
+type compactReader struct {
+	b   []byte
+	pos int
+}
+
+func (r *compactReader) byte() byte {
+	b := r.b[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *compactReader) varint() uint64 {
+	v, n := binary.Uvarint(r.b[r.pos:])
+	if n <= 0 {
+		panic("bad varint")
+	}
+	r.pos += n
+	return v
+}
+
+func (r *compactReader) zigzag() int64 {
+	v := r.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *compactReader) value(typ byte) interface{} {
+	switch typ {
+	case ctI32, ctI64:
+		return r.zigzag()
+	case ctBinary:
+		n := int(r.varint())
+		s := string(r.b[r.pos : r.pos+n])
+		r.pos += n
+		return s
+	case ctList:
+		h := r.byte()
+		size := int(h >> 4)
+		if size == 15 {
+			size = int(r.varint())
+		}
+		list := make([]interface{}, size)
+		for i := range list {
+			list[i] = r.value(h & 0x0f)
+		}
+		return list
+	case ctStruct:
+		return r.structValue()
+	}
+	panic(fmt.Sprintf("unexpected thrift type %d", typ))
+}
+
+func (r *compactReader) structValue() map[int16]interface{} {
+	fields := make(map[int16]interface{})
+	var id int16
+	for {
+		h := r.byte()
+		if h == 0 {
+			return fields
+		}
+		if delta := int16(h >> 4); delta != 0 {
+			id += delta
+		} else {
+			id = int16(r.zigzag())
+		}
+		fields[id] = r.value(h & 0x0f)
+	}
+}
+
+// readFile decodes a file written by Writer, checking its framing, into its
+// fields and, for every row group, its columns.
+func readFile(t *testing.T, b []byte) ([]Field, [][]Column) {
+	t.Helper()
+	if len(b) < 12 || !bytes.Equal(b[:4], magic) || !bytes.Equal(b[len(b)-4:], magic) {
+		t.Fatalf("file does not start and end with %s", magic)
+	}
+	footerLen := int(binary.LittleEndian.Uint32(b[len(b)-8:]))
+	footerStart := len(b) - 8 - footerLen
+	if footerStart < 4 {
+		t.Fatalf("footer length %d overflows the file of %d bytes", footerLen, len(b))
+	}
+	r := &compactReader{b: b[footerStart : len(b)-8]}
+	meta := r.structValue()
+	if r.pos != footerLen {
+		t.Fatalf("footer metadata is %d bytes, footer length says %d", r.pos, footerLen)
+	}
+
+	schema := meta[2].([]interface{})
+	if root := schema[0].(map[int16]interface{}); root[5] != int64(len(schema)-1) {
+		t.Fatalf("schema root has %v children, want %d", root[5], len(schema)-1)
+	}
+	var fields []Field
+	for _, e := range schema[1:] {
+		el := e.(map[int16]interface{})
+		f := Field{Name: el[4].(string)}
+		switch converted, ok := el[6]; {
+		case el[1] == int64(physicalDouble):
+			f.Type = Double
+		case el[1] == int64(physicalByteArray) && ok && converted == int64(convertedUTF8):
+			f.Type = String
+		case el[1] == int64(physicalInt64) && ok && converted == int64(convertedTimestampMillis):
+			f.Type = TimestampMillis
+		case el[1] == int64(physicalInt64) && !ok:
+			f.Type = Int64
+		default:
+			t.Fatalf("unexpected schema element %v", el)
+		}
+		fields = append(fields, f)
+	}
+
+	var groups [][]Column
+	var totalRows int64
+	for _, g := range meta[4].([]interface{}) {
+		group := g.(map[int16]interface{})
+		numRows := group[3].(int64)
+		totalRows += numRows
+		var columns []Column
+		for i, c := range group[1].([]interface{}) {
+			chunk := c.(map[int16]interface{})[3].(map[int16]interface{})
+			if chunk[5] != numRows {
+				t.Fatalf("column %s has %v values, want %d", fields[i].Name, chunk[5], numRows)
+			}
+			page := &compactReader{b: b, pos: int(chunk[9].(int64))}
+			header := page.structValue()
+			if header[5].(map[int16]interface{})[1] != numRows {
+				t.Fatalf("page of column %s has %v values, want %d", fields[i].Name, header[5], numRows)
+			}
+			data := b[page.pos : page.pos+int(header[3].(int64))]
+			if end := page.pos + len(data); int64(end) != chunk[9].(int64)+chunk[7].(int64) {
+				t.Fatalf("column %s ends at %d, its metadata says %d", fields[i].Name, end, chunk[9].(int64)+chunk[7].(int64))
+			}
+			columns = append(columns, decodePlain(fields[i], data, int(numRows)))
+		}
+		groups = append(groups, columns)
+	}
+	if meta[3] != totalRows {
+		t.Fatalf("file has %v rows, its row groups %d", meta[3], totalRows)
+	}
+	return fields, groups
+}
+
+func decodePlain(f Field, data []byte, n int) Column {
+	c := Column{Name: f.Name, Type: f.Type}
+	switch f.Type {
+	case Double:
+		values := make([]float64, n)
+		for i := range values {
+			values[i] = math.Float64frombits(binary.LittleEndian.Uint64(data[8*i:]))
+		}
+		c.Values = values
+	case String:
+		values := make([]string, n)
+		for i := range values {
+			size := int(binary.LittleEndian.Uint32(data))
+			values[i] = string(data[4 : 4+size])
+			data = data[4+size:]
+		}
+		c.Values = values
+	default:
+		values := make([]int64, n)
+		for i := range values {
+			values[i] = int64(binary.LittleEndian.Uint64(data[8*i:]))
+		}
+		c.Values = values
+	}
+	return c
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	columns := []Column{
+		{Name: "datetime", Type: TimestampMillis, Values: []int64{1612188000000, 1612274400000, 1612360800000}},
+		{Name: "date", Type: String, Values: []string{"2021-02-01", "2021-02-02", "2021-02-03"}},
+		{Name: "close", Type: Double, Values: []float64{134.14, 135.37, -0.5}},
+		{Name: "count", Type: Int64, Values: []int64{7, -1, 1 << 40}},
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, columns); err != nil {
+		t.Fatal(err)
+	}
+
+	fields, groups := readFile(t, buf.Bytes())
+	wantFields := []Field{{"datetime", TimestampMillis}, {"date", String}, {"close", Double}, {"count", Int64}}
+	if !reflect.DeepEqual(fields, wantFields) {
+		t.Errorf("fields = %v, want %v", fields, wantFields)
+	}
+	if len(groups) != 1 || !reflect.DeepEqual(groups[0], columns) {
+		t.Errorf("row groups = %v, want %v", groups, columns)
+	}
+}
+
+func TestWriterRowGroups(t *testing.T) {
+	var w bytes.Buffer
+	pw, err := NewWriter(&w, []Field{{"close", Double}, {"symbol", String}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups := [][]Column{
+		{{Name: "close", Type: Double, Values: []float64{1, 2}}, {Name: "symbol", Type: String, Values: []string{"A", "BB"}}},
+		{{Name: "close", Type: Double, Values: []float64{}}, {Name: "symbol", Type: String, Values: []string{}}},
+		{{Name: "close", Type: Double, Values: []float64{3}}, {Name: "symbol", Type: String, Values: []string{"CCC"}}},
+	}
+	for _, g := range groups {
+		before := w.Len()
+		if err := pw.WriteRowGroup(g); err != nil {
+			t.Fatal(err)
+		}
+		if rows := len(g[0].Values.([]float64)); rows > 0 && w.Len() == before {
+			t.Error("row group not written out before Close")
+		}
+	}
+	if err := pw.WriteRowGroup([]Column{{Name: "symbol", Type: String, Values: []string{"D"}}}); err == nil {
+		t.Error("writing a row group missing a column: got no error")
+	}
+	if err := pw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := pw.WriteRowGroup(groups[0]); err == nil {
+		t.Error("writing a row group after Close: got no error")
+	}
+
+	_, got := readFile(t, w.Bytes())
+	want := [][]Column{groups[0], groups[2]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("row groups = %v, want %v", got, want)
+	}
+}
+
+func TestWriteEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, []Column{{Name: "close", Type: Double, Values: []float64{}}}); err != nil {
+		t.Fatal(err)
+	}
+	fields, groups := readFile(t, buf.Bytes())
+	if len(fields) != 1 || len(groups) != 0 {
+		t.Errorf("fields, row groups = %v, %v, want the close column and no rows", fields, groups)
+	}
+}
@@ -0,0 +1,187 @@
+// Package ivsurface builds implied volatility surfaces from TD Ameritrade
+// option chains.
+//
+// Volatilities are kept in the unit the API reports them in, percent, so a
+// value of 25.5 means 25.5% annualized.
+package ivsurface
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/glacialspring/go-tdameritrade/tdameritrade"
+)
+
+// Surface is an implied volatility grid indexed by expiration and strike.
+// IV[i][j] holds the volatility quoted for Expirations[i] at Strikes[j], or
+// NaN when the chain had no usable quote there. Use At, Skew and
+// TermStructure to read interpolated values.
+type Surface struct {
+	Underlying  float64
+	Expirations []time.Time
+	DaysToExp   []int
+	Strikes     []float64
+	IV          [][]float64
+}
+
+// SkewPoint is the volatility at one strike of an expiration.
+type SkewPoint struct {
+	Strike float64
+	IV     float64
+}
+
+// TermPoint is the volatility at one expiration for a fixed strike.
+type TermPoint struct {
+	ExpDate   time.Time
+	DaysToExp int
+	IV        float64
+}
+
+// New builds a surface from chain. side selects the contracts used:
+// ContractTypeCall or ContractTypePut use that side only, while
+// ContractTypeAll uses out-of-the-money contracts, puts below the underlying
+// price and calls at or above it, which is the usual market convention.
+// Mini and non-standard contracts, and contracts without a positive
+// volatility, are ignored.
+func New(chain *tdameritrade.OptionChain, side tdameritrade.ContractType) *Surface {
+	s := &Surface{Underlying: chain.UnderlyingPrice}
+
+	type key struct {
+		exp    time.Time
+		strike float64
+	}
+	quotes := make(map[key]float64)
+	days := make(map[time.Time]int)
+	strikes := make(map[float64]bool)
+
+	chain.EachContract(func(cc tdameritrade.ChainContract) bool {
+		if cc.IsMini || cc.IsNonStandard || !usable(cc.Volatility) {
+			return true
+		}
+		switch side {
+		case tdameritrade.ContractTypeCall, tdameritrade.ContractTypePut:
+			if cc.Side != side {
+				return true
+			}
+		default:
+			otmSide := tdameritrade.ContractTypeCall
+			if cc.StrikePrice < s.Underlying {
+				otmSide = tdameritrade.ContractTypePut
+			}
+			if cc.Side != otmSide {
+				return true
+			}
+		}
+		quotes[key{cc.ExpDate, cc.StrikePrice}] = cc.Volatility
+		days[cc.ExpDate] = cc.DaysTilExp
+		strikes[cc.StrikePrice] = true
+		return true
+	})
+
+	for exp := range days {
+		s.Expirations = append(s.Expirations, exp)
+	}
+	sort.Slice(s.Expirations, func(i, j int) bool {
+		return s.Expirations[i].Before(s.Expirations[j])
+	})
+	for strike := range strikes {
+		s.Strikes = append(s.Strikes, strike)
+	}
+	sort.Float64s(s.Strikes)
+
+	s.DaysToExp = make([]int, len(s.Expirations))
+	s.IV = make([][]float64, len(s.Expirations))
+	for i, exp := range s.Expirations {
+		s.DaysToExp[i] = days[exp]
+		s.IV[i] = make([]float64, len(s.Strikes))
+		for j, strike := range s.Strikes {
+			iv, ok := quotes[key{exp, strike}]
+			if !ok {
+				iv = math.NaN()
+			}
+			s.IV[i][j] = iv
+		}
+	}
+	return s
+}
+
+// usable reports whether v is a real volatility. The API reports NaN or
+// -999 for contracts it could not price.
+func usable(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0) && v > 0
+}
+
+// Skew returns the quoted volatilities of the expiration at index i, ordered
+// by strike. Strikes without a quote are omitted.
+func (s *Surface) Skew(i int) []SkewPoint {
+	var pts []SkewPoint
+	for j, strike := range s.Strikes {
+		if iv := s.IV[i][j]; usable(iv) {
+			pts = append(pts, SkewPoint{Strike: strike, IV: iv})
+		}
+	}
+	return pts
+}
+
+// TermStructure returns the volatility at strike for every expiration,
+// interpolated along each expiration's skew. Expirations without any quote
+// are omitted.
+func (s *Surface) TermStructure(strike float64) []TermPoint {
+	var pts []TermPoint
+	for i := range s.Expirations {
+		if iv := s.smileAt(i, strike); usable(iv) {
+			pts = append(pts, TermPoint{ExpDate: s.Expirations[i], DaysToExp: s.DaysToExp[i], IV: iv})
+		}
+	}
+	return pts
+}
+
+// ATMTermStructure is TermStructure at the underlying price.
+func (s *Surface) ATMTermStructure() []TermPoint {
+	return s.TermStructure(s.Underlying)
+}
+
+// At returns the volatility for an arbitrary days-to-expiration and strike.
+// Along strikes it interpolates linearly and extrapolates flat; across
+// expirations it interpolates linearly in total variance (iv² · t) and
+// extrapolates flat. It returns NaN if the surface is empty.
+func (s *Surface) At(days, strike float64) float64 {
+	term := s.TermStructure(strike)
+	switch {
+	case len(term) == 0:
+		return math.NaN()
+	case days <= float64(term[0].DaysToExp):
+		return term[0].IV
+	case days >= float64(term[len(term)-1].DaysToExp):
+		return term[len(term)-1].IV
+	}
+
+	k := sort.Search(len(term), func(i int) bool {
+		return float64(term[i].DaysToExp) >= days
+	})
+	lo, hi := term[k-1], term[k]
+	t0, t1 := float64(lo.DaysToExp), float64(hi.DaysToExp)
+	w0, w1 := lo.IV*lo.IV*t0, hi.IV*hi.IV*t1
+	w := w0 + (w1-w0)*(days-t0)/(t1-t0)
+	return math.Sqrt(w / days)
+}
+
+// smileAt interpolates the skew of expiration i at strike.
+func (s *Surface) smileAt(i int, strike float64) float64 {
+	pts := s.Skew(i)
+	switch {
+	case len(pts) == 0:
+		return math.NaN()
+	case strike <= pts[0].Strike:
+		return pts[0].IV
+	case strike >= pts[len(pts)-1].Strike:
+		return pts[len(pts)-1].IV
+	}
+
+	k := sort.Search(len(pts), func(j int) bool {
+		return pts[j].Strike >= strike
+	})
+	lo, hi := pts[k-1], pts[k]
+	return lo.IV + (hi.IV-lo.IV)*(strike-lo.Strike)/(hi.Strike-lo.Strike)
+}
@@ -0,0 +1,201 @@
+package tdameritrade
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// LocalAuthOptions customize AuthorizeLocal.
+type LocalAuthOptions struct {
+	// OpenBrowser sends the user to url. Defaults to opening the system
+	// browser.
+	OpenBrowser func(url string) error
+
+	// Prompt receives the URL to open by hand if OpenBrowser fails.
+	// Defaults to os.Stderr.
+	Prompt io.Writer
+
+	// Endpoints are those of the deployment of the app, such as
+	// SchwabEndpoints, and ClientSecret its secret, if it has one.
+	// Endpoints default to TDAmeritradeEndpoints.
+	Endpoints    *Endpoints
+	ClientSecret string
+}
+
+// AuthorizeLocal runs the first-run authorization of a desktop app with the
+// consumer key clientID: it serves redirectURL, the callback registered for
+// the app, which must be an https URL on localhost or 127.0.0.1 such as
+// https://127.0.0.1:8182/callback, opens the login page in the browser,
+// waits for the redirect, exchanges its code and returns a Client that
+// refreshes its token as needed, along with the token for storing it with
+// its RefreshTokenExpiry. The callback is served with a self-signed
+// certificate made on the fly, so the browser warns about it once before
+// redirecting. opts may be nil. It stops waiting when ctx is done.
+func AuthorizeLocal(ctx context.Context, clientID, redirectURL string, opts *LocalAuthOptions) (*Client, *oauth2.Token, error) {
+	var o LocalAuthOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.OpenBrowser == nil {
+		o.OpenBrowser = openBrowser
+	}
+	if o.Prompt == nil {
+		o.Prompt = os.Stderr
+	}
+	e := TDAmeritradeEndpoints
+	if o.Endpoints != nil {
+		e = *o.Endpoints
+	}
+
+	callback, err := url.Parse(redirectURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	host := callback.Hostname()
+	if callback.Scheme != "https" || (host != "localhost" && host != "127.0.0.1" && host != "::1") {
+		return nil, nil, fmt.Errorf("redirect URL %q is not an https URL on localhost", redirectURL)
+	}
+	port := callback.Port()
+	if port == "" {
+		port = "443"
+	}
+	path := callback.Path
+	if path == "" {
+		path = "/"
+	}
+
+	cert, err := selfSignedCert(host)
+	if err != nil {
+		return nil, nil, err
+	}
+	ln, err := tls.Listen("tcp", net.JoinHostPort(host, port), &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	state, err := randomState()
+	if err != nil {
+		ln.Close()
+		return nil, nil, err
+	}
+
+	type result struct {
+		code string
+		err  error
+	}
+	results := make(chan result, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var res result
+		switch {
+		case q.Get("state") != state:
+			http.Error(w, "Unexpected authorization state.", http.StatusBadRequest)
+			return
+		case q.Get("error") != "":
+			res.err = fmt.Errorf("authorization failed: %s", q.Get("error"))
+		case q.Get("code") == "":
+			res.err = fmt.Errorf("authorization failed: no code in the redirect")
+		default:
+			res.code = q.Get("code")
+		}
+		if res.err != nil {
+			http.Error(w, res.err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "Authorization complete. You can close this window.")
+		}
+		select {
+		case results <- res:
+		default:
+		}
+	})
+	srv := &http.Server{Handler: mux}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	authURL := e.AuthCodeURL(clientID, redirectURL, state)
+	if err := o.OpenBrowser(authURL); err != nil {
+		fmt.Fprintf(o.Prompt, "Open this URL in your browser to authorize the app:\n%s\n", authURL)
+	}
+
+	var res result
+	select {
+	case res = <-results:
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+	if res.err != nil {
+		return nil, nil, res.err
+	}
+
+	token, err := e.ExchangeCode(ctx, clientID, o.ClientSecret, redirectURL, res.code)
+	if err != nil {
+		return nil, nil, err
+	}
+	c, err := NewClient(e.NewTokenSource(ctx, clientID, o.ClientSecret, token).Client(), WithEndpoints(e))
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, token, nil
+}
+
+// openBrowser opens url in the system browser.
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
+
+// selfSignedCert returns a short-lived certificate for host.
+func selfSignedCert(host string) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 62))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: host},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{host}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
@@ -0,0 +1,143 @@
+package tdameritrade
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Manager holds the clients of several logins, possibly of different apps,
+// and routes work to the client of an account. Clients of the same app
+// share a RateLimiter, as the API limits the requests of an app, and every
+// login refreshes its token through a single TokenSource. A Manager is safe
+// for concurrent use.
+type Manager struct {
+	requestsPerMinute int
+
+	mu       sync.RWMutex
+	logins   map[string]*Client
+	accounts map[string]string       // login by account id
+	limiters map[string]*RateLimiter // by consumer key
+}
+
+// NewManager returns an empty manager whose apps may each make
+// requestsPerMinute requests a minute, or 120 if requestsPerMinute is not
+// positive.
+func NewManager(requestsPerMinute int) *Manager {
+	return &Manager{
+		requestsPerMinute: requestsPerMinute,
+		logins:            make(map[string]*Client),
+		accounts:          make(map[string]string),
+		limiters:          make(map[string]*RateLimiter),
+	}
+}
+
+// AddLogin adds the login name, authorized by ts, and returns its client,
+// which talks to the deployment ts gets its tokens from.
+func (m *Manager) AddLogin(name string, ts *TokenSource) (*Client, error) {
+	c, err := NewClient(ts.Client(), WithEndpoints(ts.endpoints))
+	if err != nil {
+		return nil, err
+	}
+	if err := m.AddClient(name, ts.clientID, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// AddClient adds the login name with a client built by the caller, for the
+// app with the consumer key clientID. The client's rate limiter is replaced
+// by the one shared by the app.
+func (m *Manager) AddClient(name, clientID string, c *Client) error {
+	if name == "" {
+		return fmt.Errorf("no login name present")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.logins[name]; ok {
+		return fmt.Errorf("login %q already added", name)
+	}
+	l, ok := m.limiters[clientID]
+	if !ok {
+		l = NewRateLimiter(m.requestsPerMinute)
+		m.limiters[clientID] = l
+	}
+	c.SetRateLimiter(l)
+	m.logins[name] = c
+	return nil
+}
+
+// Login returns the client of the login name.
+func (m *Manager) Login(name string) (*Client, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	c, ok := m.logins[name]
+	return c, ok
+}
+
+// Assign routes the account accountID to the login name.
+func (m *Manager) Assign(accountID, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.logins[name]; !ok {
+		return fmt.Errorf("unknown login %q", name)
+	}
+	m.accounts[accountID] = name
+	return nil
+}
+
+// DiscoverAccounts asks every login for its accounts and routes each of them
+// to it. Logins that fail are skipped, and the error of the first one is
+// returned once all logins were asked.
+func (m *Manager) DiscoverAccounts(ctx context.Context) error {
+	m.mu.RLock()
+	names := make([]string, 0, len(m.logins))
+	for name := range m.logins {
+		names = append(names, name)
+	}
+	m.mu.RUnlock()
+	sort.Strings(names)
+
+	var firstErr error
+	for _, name := range names {
+		c, _ := m.Login(name)
+		accounts, _, err := c.Account.GetAccounts(ctx, nil)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("login %s: %v", name, err)
+			}
+			continue
+		}
+		m.mu.Lock()
+		for _, a := range *accounts {
+			m.accounts[a.AccountID] = name
+		}
+		m.mu.Unlock()
+	}
+	return firstErr
+}
+
+// ForAccount returns the client of the login the account accountID is
+// routed to.
+func (m *Manager) ForAccount(accountID string) (*Client, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	name, ok := m.accounts[accountID]
+	if !ok {
+		return nil, fmt.Errorf("no login for account %s", accountID)
+	}
+	return m.logins[name], nil
+}
+
+// Accounts returns the ids of the routed accounts, sorted.
+func (m *Manager) Accounts() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ids := make([]string, 0, len(m.accounts))
+	for id := range m.accounts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,43 @@
+package tdameritrade
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestManagerAddLoginEndpoints(t *testing.T) {
+	var gotPath, gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotAuth = r.URL.Path, r.Header.Get("Authorization")
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	endpoints := Endpoints{
+		BaseURL:  srv.URL + "/trader/v1/",
+		TokenURL: srv.URL + "/oauth/token",
+	}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, srv.Client())
+	ts := endpoints.NewTokenSource(ctx, "APP", "SECRET", &oauth2.Token{
+		AccessToken:  "access",
+		RefreshToken: "refresh",
+		Expiry:       time.Now().Add(30 * time.Minute),
+	})
+
+	m := NewManager(0)
+	c, err := m.AddLogin("main", ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := c.Account.GetAccounts(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "/trader/v1/accounts" || gotAuth != "Bearer access" {
+		t.Errorf("request = %s with %q, want /trader/v1/accounts with the access token", gotPath, gotAuth)
+	}
+}
@@ -0,0 +1,114 @@
+package tdameritrade
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// marketSearchDays is how many days NextOpen and NextClose look ahead, which
+// covers any run of weekends and holidays.
+const marketSearchDays = 14
+
+// hoursCache keeps the regular sessions of past lookups by market and
+// Eastern day, so that schedulers polling IsOpen make one request a day.
+type hoursCache struct {
+	mu   sync.Mutex
+	days map[string][]SessionPeriod
+}
+
+func (c *hoursCache) get(key string) ([]SessionPeriod, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	periods, ok := c.days[key]
+	return periods, ok
+}
+
+func (c *hoursCache) put(key string, periods []SessionPeriod) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.days == nil || len(c.days) > 4*marketSearchDays {
+		c.days = make(map[string][]SessionPeriod)
+	}
+	c.days[key] = periods
+}
+
+// regularSessions returns the regular session periods of all products of
+// market on the Eastern day of day, ordered by start.
+func (s *MarketHoursService) regularSessions(ctx context.Context, market MarketType, day time.Time) ([]SessionPeriod, error) {
+	day = startOfDay(day)
+	key := market.String() + " " + NewDate(day).String()
+	if periods, ok := s.cache.get(key); ok {
+		return periods, nil
+	}
+
+	hours, _, err := s.GetHours(ctx, []MarketType{market}, day)
+	if err != nil {
+		return nil, err
+	}
+	var periods []SessionPeriod
+	for _, products := range hours {
+		for _, h := range products {
+			periods = append(periods, h.RegularMarket...)
+		}
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].Start.Before(periods[j].Start) })
+	s.cache.put(key, periods)
+	return periods, nil
+}
+
+// IsOpen reports whether the regular session of market is open at t, for
+// any of its products. The hours of each day are requested once and cached.
+func (s *MarketHoursService) IsOpen(ctx context.Context, market MarketType, t time.Time) (bool, error) {
+	// Sessions of futures and forex start the evening before their day.
+	for _, day := range []time.Time{t, startOfDay(t).AddDate(0, 0, 1)} {
+		periods, err := s.regularSessions(ctx, market, day)
+		if err != nil {
+			return false, err
+		}
+		for _, p := range periods {
+			if !t.Before(p.Start) && t.Before(p.End) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// NextOpen returns the first start of a regular session of market after t,
+// in the Eastern time zone.
+func (s *MarketHoursService) NextOpen(ctx context.Context, market MarketType, t time.Time) (time.Time, error) {
+	return s.nextSessionTime(ctx, market, t, func(p SessionPeriod) time.Time { return p.Start })
+}
+
+// NextClose returns the first end of a regular session of market after t,
+// in the Eastern time zone. If the market is open at t, that is the end of
+// the current session.
+func (s *MarketHoursService) NextClose(ctx context.Context, market MarketType, t time.Time) (time.Time, error) {
+	return s.nextSessionTime(ctx, market, t, func(p SessionPeriod) time.Time { return p.End })
+}
+
+func (s *MarketHoursService) nextSessionTime(ctx context.Context, market MarketType, t time.Time, bound func(SessionPeriod) time.Time) (time.Time, error) {
+	if !market.valid() {
+		return time.Time{}, fmt.Errorf("invalid market type %q", market)
+	}
+	day := startOfDay(t)
+	for i := 0; i <= marketSearchDays; i++ {
+		periods, err := s.regularSessions(ctx, market, day.AddDate(0, 0, i))
+		if err != nil {
+			return time.Time{}, err
+		}
+		var next time.Time
+		for _, p := range periods {
+			if b := bound(p); b.After(t) && (next.IsZero() || b.Before(next)) {
+				next = b
+			}
+		}
+		if !next.IsZero() {
+			return next.In(Eastern()), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("no %s session in the %d days after %s", market, marketSearchDays, t.Format(time.RFC3339))
+}
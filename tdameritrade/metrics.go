@@ -0,0 +1,83 @@
+package tdameritrade
+
+import (
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Metrics receives measurements of the requests of a client, to export them
+// to a monitoring system. The prommetrics package serves them to
+// Prometheus; to record them in vectors of the Prometheus client library
+// instead, implement Metrics with their WithLabelValues methods. Its
+// methods are called concurrently.
+type Metrics interface {
+	// ObserveRequest is called after every attempt of a request, with its
+	// method, its endpoint, a path template such as
+	// accounts/{id}/orders/{id}, the status code of the response, or 0 if
+	// none came, and how long the attempt took.
+	ObserveRequest(method, endpoint string, status int, d time.Duration)
+
+	// ObserveRateLimitWait is called with how long a request waited for the
+	// rate limiter.
+	ObserveRateLimitWait(d time.Duration)
+
+	// IncStreamerMessages is called for every message the streamer
+	// receives, with its service, such as QUOTE.
+	IncStreamerMessages(service string)
+}
+
+// WithMetrics makes the client report its requests to m.
+func WithMetrics(m Metrics) ClientOption {
+	return func(c *Client) error {
+		c.metrics = m
+		return nil
+	}
+}
+
+// idSegments are the path segments followed by an id.
+var idSegments = map[string]bool{
+	"accounts":     true,
+	"instruments":  true,
+	"orders":       true,
+	"savedorders":  true,
+	"transactions": true,
+	"watchlists":   true,
+}
+
+// marketDataPaths are the path segments following marketdata/ that are not
+// symbols.
+var marketDataPaths = map[string]bool{
+	"chains":          true,
+	"expirationchain": true,
+	"hours":           true,
+	"markets":         true,
+	"movers":          true,
+	"pricehistory":    true,
+	"quotes":          true,
+}
+
+// endpoint returns the path template of u, a URL of the API, with ids and
+// symbols replaced by {id} and {symbol}, so that it can serve as a metric
+// label.
+func (c *Client) endpoint(u *url.URL) string {
+	path := u.Path
+	switch {
+	case c.MarketDataURL != nil && strings.HasPrefix(path, c.MarketDataURL.Path):
+		path = "marketdata/" + strings.TrimPrefix(path, c.MarketDataURL.Path)
+	case strings.HasPrefix(path, c.BaseURL.Path):
+		path = strings.TrimPrefix(path, c.BaseURL.Path)
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 1; i < len(segments); i++ {
+		prev := segments[i-1]
+		switch {
+		case idSegments[prev]:
+			segments[i] = "{id}"
+		case prev == "marketdata" && !marketDataPaths[segments[i]]:
+			segments[i] = "{symbol}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,55 @@
+package tdameritrade
+
+import "net/http"
+
+// RoundTripperFunc sends a request and returns its response, like
+// http.RoundTripper.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// Middleware wraps the sending of every request of a client, to log,
+// measure, add headers or inject faults:
+//
+//	func logging(next tdameritrade.RoundTripperFunc) tdameritrade.RoundTripperFunc {
+//		return func(req *http.Request) (*http.Response, error) {
+//			start := time.Now()
+//			resp, err := next(req)
+//			log.Printf("%s %s: %v", req.Method, req.URL.Path, time.Since(start))
+//			return resp, err
+//		}
+//	}
+//
+// A middleware sees every attempt of a request, retries and replays after
+// a token refresh included, after the rate limiter let it through. The
+// Authorization header is set after it, by the http.Client of the client.
+// It may answer a request itself without calling next; a response it
+// returns must have a Body.
+type Middleware func(next RoundTripperFunc) RoundTripperFunc
+
+// WithMiddleware adds m to the client, as Use does.
+func WithMiddleware(m ...Middleware) ClientOption {
+	return func(c *Client) error {
+		c.Use(m...)
+		return nil
+	}
+}
+
+// Use adds m to the middleware of the client. The middleware added first
+// is outermost: it sees the request first and the response last.
+func (c *Client) Use(m ...Middleware) {
+	c.middlewareMu.Lock()
+	c.middleware = append(c.middleware[:len(c.middleware):len(c.middleware)], m...)
+	c.middlewareMu.Unlock()
+}
+
+// chain returns the middleware of the client wrapped around its transport.
+func (c *Client) chain() RoundTripperFunc {
+	c.middlewareMu.Lock()
+	middleware := c.middleware
+	c.middlewareMu.Unlock()
+
+	next := RoundTripperFunc(c.transport)
+	for i := len(middleware) - 1; i >= 0; i-- {
+		next = middleware[i](next)
+	}
+	return next
+}
@@ -3,6 +3,8 @@ package tdameritrade
 import (
 	"context"
 	"fmt"
+	"time"
+
 	"github.com/google/go-querystring/query"
 )
 
@@ -86,3 +88,80 @@ func (opts *MoverOptions) validate() error {
 
 	return nil
 }
+
+// MoverEvent reports how the movers of an index changed between two polls
+// of PollMovers. Entered holds the movers that were not on the previous
+// list, in the order of the new list, and Dropped the movers of the previous
+// list that left it, as last seen.
+type MoverEvent struct {
+	Time    time.Time
+	Entered []Mover
+	Dropped []Mover
+	Err     error // set if the request failed
+}
+
+// PollMovers fetches the movers of index every interval, plus a small
+// random jitter, and delivers an event on the returned channel whenever
+// symbols enter or leave the list, until ctx is done, at which point the
+// channel is closed. The first list is delivered as entirely Entered.
+// Intervals below 500ms are raised to 500ms. A failed request is delivered
+// as an event with Err set and polling continues against the last list that
+// was fetched. A slow reader delays the next request rather than piling up
+// events.
+func (s *MoverService) PollMovers(ctx context.Context, index string, opts *MoverOptions, interval time.Duration) <-chan MoverEvent {
+	ch := make(chan MoverEvent)
+	go func() {
+		defer close(ch)
+		var previous []Mover
+		poll(ctx, interval, func() bool {
+			// validate writes defaults into opts, so every request gets
+			// its own copy.
+			var o *MoverOptions
+			if opts != nil {
+				cp := *opts
+				o = &cp
+			}
+			movers, _, err := s.Mover(ctx, index, o)
+			if ctx.Err() != nil {
+				return false
+			}
+			event := MoverEvent{Time: time.Now(), Err: err}
+			if err == nil {
+				event.Entered, event.Dropped = diffMovers(previous, *movers)
+				previous = *movers
+			}
+
+			if event.Err != nil || len(event.Entered) > 0 || len(event.Dropped) > 0 {
+				select {
+				case ch <- event:
+				case <-ctx.Done():
+					return false
+				}
+			}
+			return true
+		})
+	}()
+	return ch
+}
+
+// diffMovers returns the movers of current whose symbol is not in previous,
+// and those of previous whose symbol is not in current.
+func diffMovers(previous, current []Mover) (entered, dropped []Mover) {
+	was := make(map[string]bool, len(previous))
+	for _, m := range previous {
+		was[m.Symbol] = true
+	}
+	is := make(map[string]bool, len(current))
+	for _, m := range current {
+		is[m.Symbol] = true
+		if !was[m.Symbol] {
+			entered = append(entered, m)
+		}
+	}
+	for _, m := range previous {
+		if !is[m.Symbol] {
+			dropped = append(dropped, m)
+		}
+	}
+	return entered, dropped
+}
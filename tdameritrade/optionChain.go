@@ -5,28 +5,180 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"net/url"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/go-querystring/query"
 )
 
-var (
-	validContractTypes = []string{"CALL", "PUT", "ALL"}
-	validStrategies    = []string{"SINGLE", "ANALYTICAL", "COVERED", "VERTICAL", "CALENDAR", "STRANGLE", "STRADDLE", "BUTTERFLY", "CONDOR", "DIAGONAL", "COLLAR", "ROLL"}
-	validRanges        = []string{"ITM", "NTM", "OTM", "SAK", "SBK", "SNK", "ALL"}
-	validExpMonths     = []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC", "ALL"}
-	validOptionTypes   = []string{"S", "NS", "ALL"}
+// ContractType is the type of contracts returned in an option chain.
+type ContractType string
+
+const (
+	ContractTypeCall ContractType = "CALL"
+	ContractTypePut  ContractType = "PUT"
+	ContractTypeAll  ContractType = "ALL"
 )
 
+func (t ContractType) String() string { return string(t) }
+
+// EncodeValues implements query.Encoder.
+func (t ContractType) EncodeValues(key string, v *url.Values) error {
+	v.Set(key, t.String())
+	return nil
+}
+
+func (t ContractType) valid() bool {
+	switch t {
+	case ContractTypeCall, ContractTypePut, ContractTypeAll:
+		return true
+	}
+	return false
+}
+
+// Strategy is the option chain strategy. Every strategy other than
+// StrategySingle and StrategyAnalytical returns a strategy chain.
+type Strategy string
+
 const (
-	defaultContractType = "ALL"
-	defaultStrategy     = "SINGLE"
-	defaultRange        = "ALL"
-	defaultExpMonth     = "ALL"
-	defaultOptionType   = "ALL"
+	StrategySingle     Strategy = "SINGLE"
+	StrategyAnalytical Strategy = "ANALYTICAL"
+	StrategyCovered    Strategy = "COVERED"
+	StrategyVertical   Strategy = "VERTICAL"
+	StrategyCalendar   Strategy = "CALENDAR"
+	StrategyStrangle   Strategy = "STRANGLE"
+	StrategyStraddle   Strategy = "STRADDLE"
+	StrategyButterfly  Strategy = "BUTTERFLY"
+	StrategyCondor     Strategy = "CONDOR"
+	StrategyDiagonal   Strategy = "DIAGONAL"
+	StrategyCollar     Strategy = "COLLAR"
+	StrategyRoll       Strategy = "ROLL"
+)
+
+func (s Strategy) String() string { return string(s) }
+
+// EncodeValues implements query.Encoder.
+func (s Strategy) EncodeValues(key string, v *url.Values) error {
+	v.Set(key, s.String())
+	return nil
+}
+
+func (s Strategy) valid() bool {
+	switch s {
+	case StrategySingle, StrategyAnalytical, StrategyCovered, StrategyVertical,
+		StrategyCalendar, StrategyStrangle, StrategyStraddle, StrategyButterfly,
+		StrategyCondor, StrategyDiagonal, StrategyCollar, StrategyRoll:
+		return true
+	}
+	return false
+}
+
+// IsSpread reports whether s returns a strategy chain of spreads rather
+// than single contracts.
+func (s Strategy) IsSpread() bool {
+	return s != StrategySingle && s != StrategyAnalytical
+}
+
+// Range restricts an option chain to in, near or out of the money strikes.
+type Range string
+
+const (
+	RangeITM Range = "ITM" // in-the-money
+	RangeNTM Range = "NTM" // near-the-money
+	RangeOTM Range = "OTM" // out-of-the-money
+	RangeSAK Range = "SAK" // strikes above market
+	RangeSBK Range = "SBK" // strikes below market
+	RangeSNK Range = "SNK" // strikes near market
+	RangeAll Range = "ALL"
+)
+
+func (r Range) String() string { return string(r) }
+
+// EncodeValues implements query.Encoder.
+func (r Range) EncodeValues(key string, v *url.Values) error {
+	v.Set(key, r.String())
+	return nil
+}
+
+func (r Range) valid() bool {
+	switch r {
+	case RangeITM, RangeNTM, RangeOTM, RangeSAK, RangeSBK, RangeSNK, RangeAll:
+		return true
+	}
+	return false
+}
+
+// ExpMonth restricts an option chain to a single expiration month.
+type ExpMonth string
+
+const (
+	ExpMonthJan ExpMonth = "JAN"
+	ExpMonthFeb ExpMonth = "FEB"
+	ExpMonthMar ExpMonth = "MAR"
+	ExpMonthApr ExpMonth = "APR"
+	ExpMonthMay ExpMonth = "MAY"
+	ExpMonthJun ExpMonth = "JUN"
+	ExpMonthJul ExpMonth = "JUL"
+	ExpMonthAug ExpMonth = "AUG"
+	ExpMonthSep ExpMonth = "SEP"
+	ExpMonthOct ExpMonth = "OCT"
+	ExpMonthNov ExpMonth = "NOV"
+	ExpMonthDec ExpMonth = "DEC"
+	ExpMonthAll ExpMonth = "ALL"
+)
+
+func (m ExpMonth) String() string { return string(m) }
+
+// EncodeValues implements query.Encoder.
+func (m ExpMonth) EncodeValues(key string, v *url.Values) error {
+	v.Set(key, m.String())
+	return nil
+}
+
+func (m ExpMonth) valid() bool {
+	switch m {
+	case ExpMonthJan, ExpMonthFeb, ExpMonthMar, ExpMonthApr, ExpMonthMay, ExpMonthJun,
+		ExpMonthJul, ExpMonthAug, ExpMonthSep, ExpMonthOct, ExpMonthNov, ExpMonthDec, ExpMonthAll:
+		return true
+	}
+	return false
+}
+
+// OptionType selects standard, non-standard or all contracts.
+type OptionType string
+
+const (
+	OptionTypeStandard    OptionType = "S"
+	OptionTypeNonStandard OptionType = "NS"
+	OptionTypeAll         OptionType = "ALL"
+)
+
+func (t OptionType) String() string { return string(t) }
+
+// EncodeValues implements query.Encoder.
+func (t OptionType) EncodeValues(key string, v *url.Values) error {
+	v.Set(key, t.String())
+	return nil
+}
+
+func (t OptionType) valid() bool {
+	switch t {
+	case OptionTypeStandard, OptionTypeNonStandard, OptionTypeAll:
+		return true
+	}
+	return false
+}
+
+const (
+	defaultContractType = ContractTypeAll
+	defaultStrategy     = StrategySingle
+	defaultRange        = RangeAll
+	defaultExpMonth     = ExpMonthAll
+	defaultOptionType   = OptionTypeAll
 )
 
 // OptionChainService handles communication with the optionChain related methods of
@@ -35,42 +187,117 @@ const (
 // TDAmeritrade API docs: https://developer.tdameritrade.com/option-chains/apis
 type OptionChainService struct {
 	client *Client
+
+	// Concurrency bounds the number of requests OptionChains keeps in
+	// flight. Defaults to 4.
+	Concurrency int
+
+	// RequestInterval is the minimum time between two requests started by
+	// OptionChains. Defaults to 500ms, which stays within the 120 requests
+	// per minute allowed by the API. It is ignored if the client has a
+	// Limiter, which paces them instead.
+	RequestInterval time.Duration
+
+	// CacheTTL enables caching of OptionChain responses, keyed by symbol and
+	// options, for the given duration. Cached chains are shared between
+	// callers and must not be modified. Zero disables caching.
+	CacheTTL time.Duration
+
+	cache chainCache
+}
+
+type chainCache struct {
+	mu      sync.Mutex
+	entries map[string]chainCacheEntry
+}
+
+type chainCacheEntry struct {
+	chain   *OptionChain
+	resp    *Response
+	expires time.Time
+}
+
+func (c *chainCache) get(key string) (*OptionChain, *Response, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || time.Now().After(e.expires) {
+		return nil, nil, false
+	}
+	return e.chain, e.resp, true
 }
 
+func (c *chainCache) put(key string, chain *OptionChain, resp *Response, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if c.entries == nil {
+		c.entries = make(map[string]chainCacheEntry)
+	}
+	for k, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = chainCacheEntry{chain: chain, resp: resp, expires: now.Add(ttl)}
+}
+
+// ClearCache drops every cached option chain.
+func (s *OptionChainService) ClearCache() {
+	s.cache.mu.Lock()
+	s.cache.entries = nil
+	s.cache.mu.Unlock()
+}
+
+const (
+	defaultChainConcurrency     = 4
+	defaultChainRequestInterval = 500 * time.Millisecond
+)
+
 // OptionChainOptions is parsed and translated to query options in the https request
 type OptionChainOptions struct {
-	ContractType     string    `url:"contractType,omitempty"`
-	StrikeCount      int       `url:"strikeCount,omitempty"`
-	IncludeQuotes    *bool     `url:"includeQuotes,omitempty"`
-	Strategy         string    `url:"strategy,omitempty"`
-	Interval         int       `url:"interval,omitempty"`
-	Strike           float64   `url:"strike,omitempty"`
-	Range            string    `url:"range,omitempty"`
-	FromDate         time.Time `url:"fromDate,omitempty"`
-	ToDate           time.Time `url:"toDate,omitempty"`
-	Volatility       float64   `url:"volatility,omitempty"`
-	UnderlyingPrice  float64   `url:"underlyingPrice,omitempty"`
-	InterestRate     float64   `url:"interestRate,omitempty"`
-	DaysToExpiration float64   `url:"daysToExpiration,omitempty"`
-	ExpMonth         string    `url:"expMonth,omitempty"`
-	OptionType       string    `url:"optionType,omitempty"`
+	ContractType     ContractType `url:"contractType,omitempty"`
+	StrikeCount      int          `url:"strikeCount,omitempty"`
+	IncludeQuotes    *bool        `url:"includeQuotes,omitempty"`
+	Strategy         Strategy     `url:"strategy,omitempty"`
+	Interval         int          `url:"interval,omitempty"`
+	Strike           float64      `url:"strike,omitempty"`
+	Range            Range        `url:"range,omitempty"`
+	FromDate         Date         `url:"fromDate,omitempty"`
+	ToDate           Date         `url:"toDate,omitempty"`
+	Volatility       float64      `url:"volatility,omitempty"`
+	UnderlyingPrice  float64      `url:"underlyingPrice,omitempty"`
+	InterestRate     float64      `url:"interestRate,omitempty"`
+	DaysToExpiration float64      `url:"daysToExpiration,omitempty"`
+	ExpMonth         ExpMonth     `url:"expMonth,omitempty"`
+	OptionType       OptionType   `url:"optionType,omitempty"`
 }
 
+// naNFloat decodes the numbers the API sends for option prices and greeks.
+// Contracts it cannot price carry the string "NaN" (or null) instead of a
+// number, which are decoded as math.NaN(); use math.IsNaN to detect them.
+// Other quoted numbers, such as "Infinity", are parsed as well.
 type naNFloat float64
 
 func (f *naNFloat) UnmarshalJSON(b []byte) error {
-	if string(b) == "\"NaN\"" {
+	s := string(b)
+	if s == "null" {
 		*f = naNFloat(math.NaN())
-	} else {
-		f_, err := strconv.ParseFloat(string(b), 64)
-		if err != nil {
-			return err
-		}
-		*f = naNFloat(f_)
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	f_, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("cannot decode %s as a number", b)
 	}
+	*f = naNFloat(f_)
 	return nil
 }
 
+// OptionData is a single contract of an OptionChain. Prices and greeks the
+// API could not compute, typically on illiquid contracts, are NaN.
 type OptionData struct {
 	PutCall                string
 	Symbol                 string
@@ -104,7 +331,7 @@ type OptionData struct {
 	IsMini                 bool
 	IsNonStandard          bool
 	OptionDeliverablesList []struct {
-		Symbol           string `json:"string"`
+		Symbol           string `json:"symbol"`
 		AssetType        string `json:"assetType"`
 		DeliverableUnits string `json:"deliverableUnits"`
 		CurrencyType     string `json:"currencyType"`
@@ -127,49 +354,49 @@ func (o *OptionData) UnmarshalJSON(b []byte) error {
 		Symbol                 string   `json:"symbol"`
 		Description            string   `json:"description"`
 		ExchangeName           string   `json:"exchangeName"`
-		BidPrice               float64  `json:"bidPrice"`
-		AskPrice               float64  `json:"askPrice"`
-		MarkPrice              float64  `json:"markPrice"`
+		BidPrice               naNFloat `json:"bidPrice"`
+		AskPrice               naNFloat `json:"askPrice"`
+		MarkPrice              naNFloat `json:"markPrice"`
 		BidSize                int      `json:"bidSize"`
 		AskSize                int      `json:"askSize"`
 		LastSize               int      `json:"lastSize"`
-		HighPrice              float64  `json:"highPrice"`
-		LowPrice               float64  `json:"lowPrice"`
-		OpenPrice              float64  `json:"openPrice"`
-		ClosePrice             float64  `json:"closePrice"`
+		HighPrice              naNFloat `json:"highPrice"`
+		LowPrice               naNFloat `json:"lowPrice"`
+		OpenPrice              naNFloat `json:"openPrice"`
+		ClosePrice             naNFloat `json:"closePrice"`
 		TotalVolume            int      `json:"totalVolume"`
 		QuoteTimeInLong        int      `json:"quoteTimeInLong"`
 		TradeTimeInLong        int      `json:"tradeTimeInLong"`
-		NetChange              float64  `json:"netChange"`
+		NetChange              naNFloat `json:"netChange"`
 		Volatility             naNFloat `json:"volatility"`
 		Delta                  naNFloat `json:"delta"`
 		Gamma                  naNFloat `json:"gamma"`
 		Theta                  naNFloat `json:"theta"`
 		Vega                   naNFloat `json:"vega"`
 		Rho                    naNFloat `json:"rho"`
-		TimeValue              float64  `json:"timeValue"`
-		OpenInterest           float64  `json:"openInterest"`
+		TimeValue              naNFloat `json:"timeValue"`
+		OpenInterest           naNFloat `json:"openInterest"`
 		IsInTheMoney           bool     `json:"isInTheMoney"`
 		TheoreticalOptionValue naNFloat `json:"theoreticalOptionValue"`
-		TheoreticalVolatility  float64  `json:"theoreticalVolatility"`
+		TheoreticalVolatility  naNFloat `json:"theoreticalVolatility"`
 		IsMini                 bool     `json:"isMini"`
 		IsNonStandard          bool     `json:"isNonStandard"`
 		OptionDeliverablesList []struct {
-			Symbol           string `json:"string"`
+			Symbol           string `json:"symbol"`
 			AssetType        string `json:"assetType"`
 			DeliverableUnits string `json:"deliverableUnits"`
 			CurrencyType     string `json:"currencyType"`
 		} `json:"optionDeliverablesList"`
-		StrikePrice       float64 `json:"strikePrice"`
-		ExpirationDate    int64   `json:"expirationDate"`
-		ExpirationType    string  `json:"expirationType"`
-		Multiplier        float64 `json:"multiplier"`
-		SettlementType    string  `json:"settlementType"`
-		DeliverableNote   string  `json:"deliverableNote"`
-		IsIndexOption     bool    `json:"isIndexOption"`
-		PercentChange     float64 `json:"percentChange"`
-		MarkChange        float64 `json:"markChange"`
-		MarkPercentChange float64 `json:"markPercentChange"`
+		StrikePrice       naNFloat `json:"strikePrice"`
+		ExpirationDate    int64    `json:"expirationDate"`
+		ExpirationType    string   `json:"expirationType"`
+		Multiplier        naNFloat `json:"multiplier"`
+		SettlementType    string   `json:"settlementType"`
+		DeliverableNote   string   `json:"deliverableNote"`
+		IsIndexOption     bool     `json:"isIndexOption"`
+		PercentChange     naNFloat `json:"percentChange"`
+		MarkChange        naNFloat `json:"markChange"`
+		MarkPercentChange naNFloat `json:"markPercentChange"`
 	}
 	if err := json.Unmarshal(b, &raw); err != nil {
 		return err
@@ -179,44 +406,44 @@ func (o *OptionData) UnmarshalJSON(b []byte) error {
 	o.Symbol = raw.Symbol
 	o.Description = raw.Description
 	o.ExchangeName = raw.ExchangeName
-	o.BidPrice = raw.BidPrice
-	o.AskPrice = raw.AskPrice
-	o.MarkPrice = raw.MarkPrice
+	o.BidPrice = float64(raw.BidPrice)
+	o.AskPrice = float64(raw.AskPrice)
+	o.MarkPrice = float64(raw.MarkPrice)
 	o.BidSize = raw.BidSize
 	o.AskSize = raw.AskSize
 	o.LastSize = raw.LastSize
-	o.HighPrice = raw.HighPrice
-	o.LowPrice = raw.LowPrice
-	o.OpenPrice = raw.OpenPrice
-	o.ClosePrice = raw.ClosePrice
+	o.HighPrice = float64(raw.HighPrice)
+	o.LowPrice = float64(raw.LowPrice)
+	o.OpenPrice = float64(raw.OpenPrice)
+	o.ClosePrice = float64(raw.ClosePrice)
 	o.TotalVolume = raw.TotalVolume
 	o.QuoteTimeInLong = raw.QuoteTimeInLong
 	o.TradeTimeInLong = raw.TradeTimeInLong
-	o.NetChange = raw.NetChange
+	o.NetChange = float64(raw.NetChange)
 	o.Volatility = float64(raw.Volatility)
 	o.Delta = float64(raw.Delta)
 	o.Gamma = float64(raw.Gamma)
 	o.Theta = float64(raw.Theta)
 	o.Vega = float64(raw.Vega)
 	o.Rho = float64(raw.Rho)
-	o.TimeValue = raw.TimeValue
-	o.OpenInterest = raw.OpenInterest
+	o.TimeValue = float64(raw.TimeValue)
+	o.OpenInterest = float64(raw.OpenInterest)
 	o.IsInTheMoney = raw.IsInTheMoney
 	o.TheoreticalOptionValue = float64(raw.TheoreticalOptionValue)
-	o.TheoreticalVolatility = raw.TheoreticalVolatility
+	o.TheoreticalVolatility = float64(raw.TheoreticalVolatility)
 	o.IsMini = raw.IsMini
 	o.IsNonStandard = raw.IsNonStandard
 	o.OptionDeliverablesList = raw.OptionDeliverablesList
-	o.StrikePrice = raw.StrikePrice
+	o.StrikePrice = float64(raw.StrikePrice)
 	o.ExpirationDate = raw.ExpirationDate
 	o.ExpirationType = raw.ExpirationType
-	o.Multiplier = raw.Multiplier
+	o.Multiplier = float64(raw.Multiplier)
 	o.SettlementType = raw.SettlementType
 	o.DeliverableNote = raw.DeliverableNote
 	o.IsIndexOption = raw.IsIndexOption
-	o.PercentChange = raw.PercentChange
-	o.MarkChange = raw.MarkChange
-	o.MarkPercentChange = raw.MarkPercentChange
+	o.PercentChange = float64(raw.PercentChange)
+	o.MarkChange = float64(raw.MarkChange)
+	o.MarkPercentChange = float64(raw.MarkPercentChange)
 	return nil
 }
 
@@ -256,16 +483,76 @@ type OptionChain struct {
 	InterestRate     float64
 	UnderlyingPrice  float64
 	Volatility       float64
-	Calls            []struct {
-		ExpDate    time.Time
-		DaysTilExp int
-		Strikes    []OptionData
-	}
-	Puts []struct {
-		ExpDate    time.Time
-		DaysTilExp int
-		Strikes    []OptionData
-	}
+	Calls            []OptionExpiration
+	Puts             []OptionExpiration
+
+	// MonthlyStrategyList is only populated for spread strategies
+	// (VERTICAL, CALENDAR, STRADDLE, ...); Calls and Puts are empty then.
+	MonthlyStrategyList []MonthlyStrategy
+}
+
+// MonthlyStrategy groups the priced spreads of a strategy chain by
+// expiration. Secondary fields describe the second expiration of calendar
+// and diagonal spreads and repeat the primary ones otherwise.
+type MonthlyStrategy struct {
+	Month              string           `json:"month"`
+	Year               int              `json:"year"`
+	Day                int              `json:"day"`
+	DaysToExp          int              `json:"daysToExp"`
+	SecondaryMonth     string           `json:"secondaryMonth"`
+	SecondaryYear      int              `json:"secondaryYear"`
+	SecondaryDay       int              `json:"secondaryDay"`
+	SecondaryDaysToExp int              `json:"secondaryDaysToExp"`
+	Type               string           `json:"type"`
+	SecondaryType      string           `json:"secondaryType"`
+	Leap               bool             `json:"leap"`
+	SecondaryLeap      bool             `json:"secondaryLeap"`
+	OptionStrategyList []OptionStrategy `json:"optionStrategyList"`
+}
+
+// ExpDate returns the expiration date of the primary legs.
+func (m *MonthlyStrategy) ExpDate() (time.Time, error) {
+	return parseStrategyDate(m.Year, m.Month, m.Day)
+}
+
+// SecondaryExpDate returns the expiration date of the secondary legs.
+func (m *MonthlyStrategy) SecondaryExpDate() (time.Time, error) {
+	return parseStrategyDate(m.SecondaryYear, m.SecondaryMonth, m.SecondaryDay)
+}
+
+func parseStrategyDate(year int, month string, day int) (time.Time, error) {
+	return time.Parse("2006 Jan 2", fmt.Sprintf("%d %s %d", year, month, day))
+}
+
+// OptionStrategy is a single priced spread, e.g. the 300/305 call vertical.
+type OptionStrategy struct {
+	PrimaryLeg     StrategyLeg `json:"primaryLeg"`
+	SecondaryLeg   StrategyLeg `json:"secondaryLeg"`
+	StrategyStrike string      `json:"strategyStrike"`
+	StrategyBid    float64     `json:"strategyBid"`
+	StrategyAsk    float64     `json:"strategyAsk"`
+}
+
+// StrategyLeg is one leg of an OptionStrategy.
+type StrategyLeg struct {
+	Symbol      string  `json:"symbol"`
+	PutCallInd  string  `json:"putCallInd"`
+	Description string  `json:"description"`
+	Bid         float64 `json:"bid"`
+	Ask         float64 `json:"ask"`
+	Range       string  `json:"range"`
+	StrikePrice float64 `json:"strikePrice"`
+	TotalVolume float64 `json:"totalVolume"`
+}
+
+// OptionExpiration holds every contract of one side of the chain that
+// expires on ExpDate. Strikes is ordered by strike price and may contain
+// several contracts per strike (e.g. mini and non-standard contracts).
+// Calls and Puts are always ordered by ascending expiration date.
+type OptionExpiration struct {
+	ExpDate    time.Time
+	DaysTilExp int
+	Strikes    []OptionData
 }
 
 func (c *OptionChain) UnmarshalJSON(b []byte) error {
@@ -298,15 +585,17 @@ func (c *OptionChain) UnmarshalJSON(b []byte) error {
 			TradeTime         int64   `json:"tradeTime"`
 		} `json:"underlying"`
 		Strategy         string                             `json:"strategy"`
-		Interval         float64                            `json:"interval"`
+		Interval         naNFloat                           `json:"interval"`
 		IsDelayed        bool                               `json:"isDelayed"`
 		IsIndex          bool                               `json:"isIndex"`
-		DaysToExpiration float64                            `json:"daysToExpiration"`
-		InterestRate     float64                            `json:"interestRate"`
-		UnderlyingPrice  float64                            `json:"underlyingPrice"`
-		Volatility       float64                            `json:"volatility"`
+		DaysToExpiration naNFloat                           `json:"daysToExpiration"`
+		InterestRate     naNFloat                           `json:"interestRate"`
+		UnderlyingPrice  naNFloat                           `json:"underlyingPrice"`
+		Volatility       naNFloat                           `json:"volatility"`
 		CallExpDateMap   map[string]map[string][]OptionData `json:"callExpDateMap"`
 		PutExpDateMap    map[string]map[string][]OptionData `json:"putExpDateMap"`
+
+		MonthlyStrategyList []MonthlyStrategy `json:"monthlyStrategyList"`
 	}
 	if err := json.Unmarshal(b, &raw); err != nil {
 		return err
@@ -337,89 +626,72 @@ func (c *OptionChain) UnmarshalJSON(b []byte) error {
 	c.Underlying.TotalVolume = raw.Underlying.TotalVolume
 	c.Underlying.TradeTime = raw.Underlying.TradeTime
 	c.Strategy = raw.Strategy
-	c.Interval = raw.Interval
+	c.Interval = float64(raw.Interval)
 	c.IsDelayed = raw.IsDelayed
 	c.IsIndex = raw.IsIndex
-	c.DaysToExpiration = raw.DaysToExpiration
-	c.InterestRate = raw.InterestRate
-	c.UnderlyingPrice = raw.UnderlyingPrice
-	c.Volatility = raw.Volatility
-	c.Calls = make([]struct {
-		ExpDate    time.Time
-		DaysTilExp int
-		Strikes    []OptionData
-	}, len(raw.CallExpDateMap))
-	c.Puts = make([]struct {
-		ExpDate    time.Time
-		DaysTilExp int
-		Strikes    []OptionData
-	}, len(raw.PutExpDateMap))
-	i := 0
+	c.DaysToExpiration = float64(raw.DaysToExpiration)
+	c.InterestRate = float64(raw.InterestRate)
+	c.UnderlyingPrice = float64(raw.UnderlyingPrice)
+	c.Volatility = float64(raw.Volatility)
+	c.MonthlyStrategyList = raw.MonthlyStrategyList
 	var err error
-	for dateStr, v := range raw.CallExpDateMap {
-		dateParts := strings.Split(dateStr, ":")
-		if c.Calls[i].ExpDate, err = time.Parse("2006-01-02", dateParts[0]); err != nil {
-			return err
-		}
-		if c.Calls[i].DaysTilExp, err = strconv.Atoi(dateParts[1]); err != nil {
-			return err
-		}
-		j := 0
-		strikes := make([]OptionData, len(v))
-		for _, optionData := range v {
-			strikes[j] = optionData[0]
-			j++
-		}
-		sort.Slice(strikes, func(i, j int) bool {
-			return strikes[i].StrikePrice < strikes[j].StrikePrice
-		})
-		c.Calls[i].Strikes = strikes
-		i++
+	if c.Calls, err = parseExpDateMap(raw.CallExpDateMap); err != nil {
+		return err
 	}
-	sort.Slice(c.Calls, func(i, j int) bool {
-		return c.Calls[i].DaysTilExp < c.Calls[j].DaysTilExp
-	})
-	i = 0
-	for dateStr, v := range raw.PutExpDateMap {
+	if c.Puts, err = parseExpDateMap(raw.PutExpDateMap); err != nil {
+		return err
+	}
+	return nil
+}
+
+// parseExpDateMap flattens a callExpDateMap or putExpDateMap, keyed by
+// "yyyy-MM-dd:daysToExpiration" and then by strike, into expirations.
+func parseExpDateMap(m map[string]map[string][]OptionData) ([]OptionExpiration, error) {
+	exps := make([]OptionExpiration, 0, len(m))
+	for dateStr, v := range m {
 		dateParts := strings.Split(dateStr, ":")
-		if c.Puts[i].ExpDate, err = time.Parse("2006-01-02", dateParts[0]); err != nil {
-			return err
+		if len(dateParts) != 2 {
+			return nil, fmt.Errorf("invalid expiration key %q", dateStr)
+		}
+		var exp OptionExpiration
+		var err error
+		if exp.ExpDate, err = time.Parse("2006-01-02", dateParts[0]); err != nil {
+			return nil, err
 		}
-		if c.Puts[i].DaysTilExp, err = strconv.Atoi(dateParts[1]); err != nil {
-			return err
+		if exp.DaysTilExp, err = strconv.Atoi(dateParts[1]); err != nil {
+			return nil, err
 		}
-		j := 0
-		strikes := make([]OptionData, len(v))
 		for _, optionData := range v {
-			strikes[j] = optionData[0]
-			j++
+			exp.Strikes = append(exp.Strikes, optionData...)
 		}
-		sort.Slice(strikes, func(i, j int) bool {
-			return strikes[i].StrikePrice < strikes[j].StrikePrice
+		// strikes are map keys, so the only ties left are contracts sharing a
+		// strike, which a stable sort keeps in the order the API sent them.
+		sort.SliceStable(exp.Strikes, func(i, j int) bool {
+			return exp.Strikes[i].StrikePrice < exp.Strikes[j].StrikePrice
 		})
-		c.Puts[i].Strikes = strikes
-		i++
+		exps = append(exps, exp)
 	}
-	sort.Slice(c.Puts, func(i, j int) bool {
-		return c.Puts[i].DaysTilExp < c.Puts[j].DaysTilExp
+	sort.Slice(exps, func(i, j int) bool {
+		if !exps[i].ExpDate.Equal(exps[j].ExpDate) {
+			return exps[i].ExpDate.Before(exps[j].ExpDate)
+		}
+		return exps[i].DaysTilExp < exps[j].DaysTilExp
 	})
-	return nil
+	return exps, nil
 }
 
 // OptionChange get the price history for a symbol
 // TDAmeritrade API Docs: https://developer.tdameritrade.com/option-chains/apis/get/marketdata/chains
 func (s *OptionChainService) OptionChain(ctx context.Context, symbol string, opts *OptionChainOptions) (*OptionChain, *Response, error) {
-	u := "marketdata/chains"
-	if opts != nil {
-		if err := opts.validate(); err != nil {
-			return nil, nil, err
-		}
-		q, err := query.Values(opts)
-		if err != nil {
-			return nil, nil, err
+	u, err := chainURL(symbol, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if s.CacheTTL > 0 {
+		if optionChain, resp, ok := s.cache.get(u); ok {
+			return optionChain, resp, nil
 		}
-		q.Add("symbol", symbol)
-		u = fmt.Sprintf("%s?%s", u, q.Encode())
 	}
 
 	req, err := s.client.NewRequest("GET", u, nil)
@@ -435,41 +707,198 @@ func (s *OptionChainService) OptionChain(ctx context.Context, symbol string, opt
 	if optionChain.Status != "SUCCESS" {
 		return optionChain, resp, fmt.Errorf("error: %s", optionChain.Status)
 	}
+	if s.CacheTTL > 0 {
+		s.cache.put(u, optionChain, resp, s.CacheTTL)
+	}
 	return optionChain, resp, nil
 }
 
+// OptionChainRaw is OptionChain returning the undecoded response body. It
+// bypasses the cache.
+func (s *OptionChainService) OptionChainRaw(ctx context.Context, symbol string, opts *OptionChainOptions) (json.RawMessage, *Response, error) {
+	u, err := chainURL(symbol, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.client.getRaw(ctx, u)
+}
+
+func chainURL(symbol string, opts *OptionChainOptions) (string, error) {
+	q := url.Values{}
+	if opts != nil {
+		if err := opts.validate(); err != nil {
+			return "", err
+		}
+		var err error
+		if q, err = query.Values(opts); err != nil {
+			return "", err
+		}
+	}
+	q.Add("symbol", symbol)
+	return fmt.Sprintf("marketdata/chains?%s", q.Encode()), nil
+}
+
+// OptionChains fetches the option chains of several symbols concurrently,
+// honoring the service's Concurrency and RequestInterval, or the client's
+// Limiter if it has one. Chains are keyed by
+// symbol. If any symbol fails the returned error is a SymbolErrors holding
+// every failure, and the chains that did succeed are still returned.
+func (s *OptionChainService) OptionChains(ctx context.Context, symbols []string, opts *OptionChainOptions) (map[string]*OptionChain, error) {
+	if opts != nil {
+		if err := opts.validate(); err != nil {
+			return nil, err
+		}
+	}
+	concurrency := s.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultChainConcurrency
+	}
+	interval := s.RequestInterval
+	if interval <= 0 {
+		interval = defaultChainRequestInterval
+	}
+
+	limiter := s.client.helperLimiter(interval)
+
+	jobs := make(chan string)
+	go func() {
+		defer close(jobs)
+		seen := make(map[string]bool, len(symbols))
+		for _, symbol := range symbols {
+			if seen[symbol] {
+				continue
+			}
+			seen[symbol] = true
+			jobs <- symbol
+		}
+	}()
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		chains = make(map[string]*OptionChain, len(symbols))
+		errs   = make(SymbolErrors)
+	)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for symbol := range jobs {
+				var chain *OptionChain
+				err := waitLimiter(ctx, limiter)
+				if err == nil {
+					// validate writes defaults into opts, so every request
+					// gets its own copy.
+					var o *OptionChainOptions
+					if opts != nil {
+						cp := *opts
+						o = &cp
+					}
+					chain, _, err = s.OptionChain(ctx, symbol, o)
+				}
+
+				mu.Lock()
+				if err != nil {
+					errs[symbol] = err
+				} else {
+					chains[symbol] = chain
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return chains, errs
+	}
+	return chains, nil
+}
+
 func (opts *OptionChainOptions) validate() error {
 	if opts.ContractType != "" {
-		if !contains(opts.ContractType, validContractTypes) {
-			return fmt.Errorf("invalid contractType, must have the value of one of the following %v", validContractTypes)
+		if !opts.ContractType.valid() {
+			return fmt.Errorf("invalid contractType %q", opts.ContractType)
 		}
 	} else {
 		opts.ContractType = defaultContractType
 	}
 
 	if opts.Strategy != "" {
-		if !contains(opts.Strategy, validStrategies) {
-			return fmt.Errorf("invalid strategy, must have the value of one of the following %v", validStrategies)
+		if !opts.Strategy.valid() {
+			return fmt.Errorf("invalid strategy %q", opts.Strategy)
 		}
 	} else {
 		opts.Strategy = defaultStrategy
 	}
 
+	if opts.Range != "" {
+		if !opts.Range.valid() {
+			return fmt.Errorf("invalid range %q", opts.Range)
+		}
+	} else {
+		opts.Range = defaultRange
+	}
+
 	if opts.ExpMonth != "" {
-		if !contains(opts.ExpMonth, validExpMonths) {
-			return fmt.Errorf("invalid expMonth, must have the value of one of the following %v", validExpMonths)
+		if !opts.ExpMonth.valid() {
+			return fmt.Errorf("invalid expMonth %q", opts.ExpMonth)
 		}
 	} else {
 		opts.ExpMonth = defaultExpMonth
 	}
 
 	if opts.OptionType != "" {
-		if !contains(opts.OptionType, validOptionTypes) {
-			return fmt.Errorf("invalid optionType, must have the value of one of the following %v", validOptionTypes)
+		if !opts.OptionType.valid() {
+			return fmt.Errorf("invalid optionType %q", opts.OptionType)
 		}
 	} else {
 		opts.OptionType = defaultOptionType
 	}
 
+	return opts.validateStrategyParams()
+}
+
+// validateStrategyParams rejects parameters the API does not accept for the
+// chosen strategy. The API answers those with an opaque 400.
+func (opts *OptionChainOptions) validateStrategyParams() error {
+	if opts.StrikeCount < 0 {
+		return fmt.Errorf("invalid strikeCount %d, must not be negative", opts.StrikeCount)
+	}
+	if opts.Strike < 0 {
+		return fmt.Errorf("invalid strike %v, must not be negative", opts.Strike)
+	}
+	if opts.Strike != 0 && opts.StrikeCount != 0 {
+		return fmt.Errorf("strike and strikeCount are mutually exclusive")
+	}
+
+	if opts.Interval < 0 {
+		return fmt.Errorf("invalid interval %d, must not be negative", opts.Interval)
+	}
+	if opts.Interval != 0 && !opts.Strategy.IsSpread() {
+		return fmt.Errorf("interval is only valid for spread strategies, not %s", opts.Strategy)
+	}
+
+	if opts.Strategy != StrategyAnalytical {
+		analytical := []struct {
+			name  string
+			value float64
+		}{
+			{"volatility", opts.Volatility},
+			{"underlyingPrice", opts.UnderlyingPrice},
+			{"interestRate", opts.InterestRate},
+			{"daysToExpiration", opts.DaysToExpiration},
+		}
+		for _, p := range analytical {
+			if p.value != 0 {
+				return fmt.Errorf("%s is only valid for the %s strategy, not %s", p.name, StrategyAnalytical, opts.Strategy)
+			}
+		}
+	}
+
+	if !opts.FromDate.IsZero() && !opts.ToDate.IsZero() && opts.ToDate.Before(opts.FromDate.Time) {
+		return fmt.Errorf("toDate %s is before fromDate %s", opts.ToDate, opts.FromDate)
+	}
+
 	return nil
 }
@@ -0,0 +1,112 @@
+package tdameritrade
+
+import (
+	"math"
+	"time"
+)
+
+// ChainContract is a single contract of an OptionChain together with the
+// expiration and side it was listed under.
+type ChainContract struct {
+	*OptionData
+	Side       ContractType // ContractTypeCall or ContractTypePut
+	ExpDate    time.Time
+	DaysTilExp int
+}
+
+// EachContract calls fn for every contract in the chain, calls first, in
+// expiration and then strike order. Iteration stops early if fn returns false.
+// The OptionData passed to fn points into the chain.
+func (c *OptionChain) EachContract(fn func(ChainContract) bool) {
+	sides := []struct {
+		side ContractType
+		exps []OptionExpiration
+	}{
+		{ContractTypeCall, c.Calls},
+		{ContractTypePut, c.Puts},
+	}
+	for _, s := range sides {
+		for i := range s.exps {
+			exp := &s.exps[i]
+			for j := range exp.Strikes {
+				cc := ChainContract{
+					OptionData: &exp.Strikes[j],
+					Side:       s.side,
+					ExpDate:    exp.ExpDate,
+					DaysTilExp: exp.DaysTilExp,
+				}
+				if !fn(cc) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// AllContracts returns every contract in the chain in the order used by
+// EachContract.
+func (c *OptionChain) AllContracts() []ChainContract {
+	var contracts []ChainContract
+	c.EachContract(func(cc ChainContract) bool {
+		contracts = append(contracts, cc)
+		return true
+	})
+	return contracts
+}
+
+// Filter returns a copy of the chain holding only the contracts for which
+// keep returns true. Expirations left without contracts are dropped. The
+// receiver is not modified.
+func (c *OptionChain) Filter(keep func(ChainContract) bool) *OptionChain {
+	out := *c
+	out.Calls = filterExpirations(ContractTypeCall, c.Calls, keep)
+	out.Puts = filterExpirations(ContractTypePut, c.Puts, keep)
+	return &out
+}
+
+func filterExpirations(side ContractType, exps []OptionExpiration, keep func(ChainContract) bool) []OptionExpiration {
+	var out []OptionExpiration
+	for _, exp := range exps {
+		var strikes []OptionData
+		for i := range exp.Strikes {
+			cc := ChainContract{
+				OptionData: &exp.Strikes[i],
+				Side:       side,
+				ExpDate:    exp.ExpDate,
+				DaysTilExp: exp.DaysTilExp,
+			}
+			if keep(cc) {
+				strikes = append(strikes, exp.Strikes[i])
+			}
+		}
+		if len(strikes) > 0 {
+			exp.Strikes = strikes
+			out = append(out, exp)
+		}
+	}
+	return out
+}
+
+// FilterByDelta keeps contracts whose absolute delta lies in [min, max], so
+// FilterByDelta(0.2, 0.3) keeps both 0.25 delta calls and -0.25 delta puts.
+// Contracts with a NaN delta are dropped.
+func (c *OptionChain) FilterByDelta(min, max float64) *OptionChain {
+	return c.Filter(func(cc ChainContract) bool {
+		d := math.Abs(cc.Delta)
+		return d >= min && d <= max
+	})
+}
+
+// FilterByDTE keeps contracts expiring in [min, max] days.
+func (c *OptionChain) FilterByDTE(min, max int) *OptionChain {
+	return c.Filter(func(cc ChainContract) bool {
+		return cc.DaysTilExp >= min && cc.DaysTilExp <= max
+	})
+}
+
+// FilterByStrikeRange keeps contracts with a strike price in [lo, hi].
+func (c *OptionChain) FilterByStrikeRange(lo, hi float64) *OptionChain {
+	return c.Filter(func(cc ChainContract) bool {
+		return cc.StrikePrice >= lo && cc.StrikePrice <= hi
+	})
+}
@@ -0,0 +1,164 @@
+package tdameritrade
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// chainPayload is a chain of the shape the API sends, with its expirations
+// and strikes keyed in no particular order. The strike keys sort
+// differently as strings and as numbers.
+const chainPayload = `{
+	"symbol": "AAPL",
+	"status": "SUCCESS",
+	"strategy": "SINGLE",
+	"underlyingPrice": 101.5,
+	"callExpDateMap": {
+		"2021-03-19:45": {
+			"100.0": [{"putCall": "CALL", "symbol": "AAPL_031921C100", "strikePrice": 100.0}],
+			"2.5": [{"putCall": "CALL", "symbol": "AAPL_031921C2.5", "strikePrice": 2.5}],
+			"10.0": [{"putCall": "CALL", "symbol": "AAPL_031921C10", "strikePrice": 10.0}]
+		},
+		"2021-02-05:3": {
+			"105.0": [{"putCall": "CALL", "symbol": "AAPL_020521C105", "strikePrice": 105.0}],
+			"95.0": [
+				{"putCall": "CALL", "symbol": "AAPL_020521C95", "strikePrice": 95.0},
+				{"putCall": "CALL", "symbol": "AAPL1_020521C95", "strikePrice": 95.0, "isNonStandard": true}
+			],
+			"100.0": [{"putCall": "CALL", "symbol": "AAPL_020521C100", "strikePrice": 100.0}]
+		},
+		"2021-02-12:10": {
+			"100.0": [{"putCall": "CALL", "symbol": "AAPL_021221C100", "strikePrice": 100.0}],
+			"97.5": [{"putCall": "CALL", "symbol": "AAPL_021221C97.5", "strikePrice": 97.5}]
+		}
+	},
+	"putExpDateMap": {
+		"2021-02-12:10": {
+			"97.5": [{"putCall": "PUT", "symbol": "AAPL_021221P97.5", "strikePrice": 97.5}],
+			"100.0": [{"putCall": "PUT", "symbol": "AAPL_021221P100", "strikePrice": 100.0}]
+		},
+		"2021-02-05:3": {
+			"100.0": [{"putCall": "PUT", "symbol": "AAPL_020521P100", "strikePrice": 100.0}],
+			"95.0": [{"putCall": "PUT", "symbol": "AAPL_020521P95", "strikePrice": 95.0}]
+		}
+	}
+}`
+
+// chainOrder lists the expirations and contract symbols of exps, in order.
+func chainOrder(exps []OptionExpiration) []string {
+	var order []string
+	for _, exp := range exps {
+		order = append(order, fmt.Sprintf("%s:%d", exp.ExpDate.Format("2006-01-02"), exp.DaysTilExp))
+		for _, o := range exp.Strikes {
+			order = append(order, o.Symbol)
+		}
+	}
+	return order
+}
+
+func TestOptionChainOrder(t *testing.T) {
+	wantCalls := []string{
+		"2021-02-05:3", "AAPL_020521C95", "AAPL1_020521C95", "AAPL_020521C100", "AAPL_020521C105",
+		"2021-02-12:10", "AAPL_021221C97.5", "AAPL_021221C100",
+		"2021-03-19:45", "AAPL_031921C2.5", "AAPL_031921C10", "AAPL_031921C100",
+	}
+	wantPuts := []string{
+		"2021-02-05:3", "AAPL_020521P95", "AAPL_020521P100",
+		"2021-02-12:10", "AAPL_021221P97.5", "AAPL_021221P100",
+	}
+
+	// Map iteration order changes from one decode to the next, so decode
+	// many times to catch an order depending on it.
+	for i := 0; i < 50; i++ {
+		var chain OptionChain
+		if err := json.Unmarshal([]byte(chainPayload), &chain); err != nil {
+			t.Fatal(err)
+		}
+		if got := chainOrder(chain.Calls); !reflect.DeepEqual(got, wantCalls) {
+			t.Fatalf("decode %d: calls in order\n%v\nwant\n%v", i, got, wantCalls)
+		}
+		if got := chainOrder(chain.Puts); !reflect.DeepEqual(got, wantPuts) {
+			t.Fatalf("decode %d: puts in order\n%v\nwant\n%v", i, got, wantPuts)
+		}
+	}
+}
+
+// illiquidContract is a contract of a real chain the API could not price,
+// with the "NaN" strings, nulls and quoted numbers it sends then.
+const illiquidContract = `{
+	"putCall": "CALL",
+	"symbol": "XYZ_011521C50",
+	"description": "XYZ Jan 15 2021 50 Call",
+	"exchangeName": "OPR",
+	"bid": 0.0,
+	"ask": 0.05,
+	"bidPrice": 0.0,
+	"askPrice": 0.05,
+	"markPrice": 0.03,
+	"bidSize": 0,
+	"askSize": 10,
+	"totalVolume": 0,
+	"netChange": 0.0,
+	"volatility": "NaN",
+	"delta": "NaN",
+	"gamma": "NaN",
+	"theta": null,
+	"vega": "-0.012",
+	"rho": "NaN",
+	"timeValue": 0.03,
+	"openInterest": 0,
+	"isInTheMoney": false,
+	"theoreticalOptionValue": "NaN",
+	"theoreticalVolatility": 29.0,
+	"isMini": false,
+	"isNonStandard": false,
+	"strikePrice": 50.0,
+	"expirationDate": 1610744400000,
+	"multiplier": 100.0,
+	"percentChange": "-100.0",
+	"markChange": "Infinity",
+	"markPercentChange": "NaN"
+}`
+
+func TestOptionDataNaN(t *testing.T) {
+	var o OptionData
+	if err := json.Unmarshal([]byte(illiquidContract), &o); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, v := range map[string]float64{
+		"Volatility":             o.Volatility,
+		"Delta":                  o.Delta,
+		"Gamma":                  o.Gamma,
+		"Theta":                  o.Theta,
+		"Rho":                    o.Rho,
+		"TheoreticalOptionValue": o.TheoreticalOptionValue,
+		"MarkPercentChange":      o.MarkPercentChange,
+	} {
+		if !math.IsNaN(v) {
+			t.Errorf("%s = %v, want NaN", name, v)
+		}
+	}
+	for name, tt := range map[string]struct{ got, want float64 }{
+		"AskPrice":              {o.AskPrice, 0.05},
+		"Vega":                  {o.Vega, -0.012},
+		"TheoreticalVolatility": {o.TheoreticalVolatility, 29},
+		"StrikePrice":           {o.StrikePrice, 50},
+		"PercentChange":         {o.PercentChange, -100},
+		"MarkChange":            {o.MarkChange, math.Inf(1)},
+	} {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", name, tt.got, tt.want)
+		}
+	}
+	if o.Symbol != "XYZ_011521C50" || o.ExpirationDate != 1610744400000 {
+		t.Errorf("Symbol, ExpirationDate = %s, %d, want XYZ_011521C50, 1610744400000", o.Symbol, o.ExpirationDate)
+	}
+
+	if err := json.Unmarshal([]byte(`{"delta": "n/a"}`), &o); err == nil {
+		t.Error("decoding a delta of \"n/a\": got no error")
+	}
+}
@@ -0,0 +1,68 @@
+package tdameritrade
+
+import "fmt"
+
+// NewOptionOrder starts a single-leg option order, which by default is a
+// market order for the regular session, good for the day. Set the contract
+// with BuyToOpen, SellToOpen, BuyToClose or SellToClose, e.g.
+//
+//	order, err := NewOptionOrder().BuyToOpen(contract.Symbol, 2).LimitAtMid(contract).Build()
+func NewOptionOrder() *OrderBuilder {
+	return newOrderBuilder()
+}
+
+// BuyToOpen buys contracts of the option symbol to open a long position.
+// symbol may be a TD Ameritrade symbol, such as OptionData.Symbol, or an
+// OCC symbol.
+func (b *OrderBuilder) BuyToOpen(symbol string, contracts float64) *OrderBuilder {
+	return b.optionLeg(InstructionBuyToOpen, symbol, contracts)
+}
+
+// SellToOpen sells contracts of the option symbol to open a short position.
+func (b *OrderBuilder) SellToOpen(symbol string, contracts float64) *OrderBuilder {
+	return b.optionLeg(InstructionSellToOpen, symbol, contracts)
+}
+
+// BuyToClose buys back contracts of the option symbol held short.
+func (b *OrderBuilder) BuyToClose(symbol string, contracts float64) *OrderBuilder {
+	return b.optionLeg(InstructionBuyToClose, symbol, contracts)
+}
+
+// SellToClose sells contracts of the option symbol held long.
+func (b *OrderBuilder) SellToClose(symbol string, contracts float64) *OrderBuilder {
+	return b.optionLeg(InstructionSellToClose, symbol, contracts)
+}
+
+func (b *OrderBuilder) optionLeg(instruction Instruction, symbol string, contracts float64) *OrderBuilder {
+	return b.setLeg(instruction, optionInstrument(symbol), contracts)
+}
+
+// optionInstrument returns the order instrument of the option symbol. A
+// symbol that cannot be parsed is kept as is and rejected by Build.
+func optionInstrument(symbol string) Instrument {
+	option := &OptionA{Symbol: symbol}
+	if s, err := parseOptionOrOCC(symbol); err == nil {
+		option.Symbol = s.String()
+		option.UnderlyingSymbol = s.Underlying
+		option.PutCall = s.PutCall.String()
+	}
+	return Instrument{AssetType: "OPTION", Data: option}
+}
+
+// LimitAtMid makes the order a limit order at the midpoint of the bid and
+// ask of contract, rounded to a cent.
+func (b *OrderBuilder) LimitAtMid(contract *OptionData) *OrderBuilder {
+	return b.Limit(roundCents((contract.BidPrice + contract.AskPrice) / 2))
+}
+
+// parseOptionOrOCC parses symbol as a TD Ameritrade option symbol, or failing
+// that as an OCC symbol.
+func parseOptionOrOCC(symbol string) (*OptionSymbol, error) {
+	if s, err := ParseOptionSymbol(symbol); err == nil {
+		return s, nil
+	}
+	if s, err := ParseOCCSymbol(symbol); err == nil {
+		return s, nil
+	}
+	return nil, fmt.Errorf("invalid option symbol %q", symbol)
+}
@@ -0,0 +1,122 @@
+package tdameritrade
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OptionSymbol is a parsed option contract symbol.
+type OptionSymbol struct {
+	Underlying string
+	Expiration time.Time
+	PutCall    ContractType // ContractTypeCall or ContractTypePut
+	Strike     float64
+}
+
+// ParseOptionSymbol parses a TD Ameritrade option symbol of the form
+// UNDERLYING_MMDDYY{C|P}STRIKE, e.g. AAPL_011924C150 or SPY_011924P412.5.
+func ParseOptionSymbol(symbol string) (*OptionSymbol, error) {
+	i := strings.LastIndex(symbol, "_")
+	if i <= 0 || len(symbol)-i-1 < 8 {
+		return nil, fmt.Errorf("invalid option symbol %q", symbol)
+	}
+	rest := symbol[i+1:]
+
+	exp, err := time.Parse("010206", rest[:6])
+	if err != nil {
+		return nil, fmt.Errorf("invalid expiration in option symbol %q: %v", symbol, err)
+	}
+	putCall, err := parsePutCall(rest[6])
+	if err != nil {
+		return nil, fmt.Errorf("invalid option symbol %q: %v", symbol, err)
+	}
+	strike, err := strconv.ParseFloat(rest[7:], 64)
+	if err != nil || strike <= 0 {
+		return nil, fmt.Errorf("invalid strike in option symbol %q", symbol)
+	}
+
+	return &OptionSymbol{
+		Underlying: symbol[:i],
+		Expiration: exp,
+		PutCall:    putCall,
+		Strike:     strike,
+	}, nil
+}
+
+// ParseOCCSymbol parses a 21 character OCC option symbol, e.g.
+// "AAPL  240119C00150000", where the strike is given in thousandths.
+func ParseOCCSymbol(symbol string) (*OptionSymbol, error) {
+	if len(symbol) != 21 {
+		return nil, fmt.Errorf("invalid OCC symbol %q: want 21 characters", symbol)
+	}
+	underlying := strings.TrimSpace(symbol[:6])
+	if underlying == "" {
+		return nil, fmt.Errorf("invalid OCC symbol %q: missing root", symbol)
+	}
+	exp, err := time.Parse("060102", symbol[6:12])
+	if err != nil {
+		return nil, fmt.Errorf("invalid expiration in OCC symbol %q: %v", symbol, err)
+	}
+	putCall, err := parsePutCall(symbol[12])
+	if err != nil {
+		return nil, fmt.Errorf("invalid OCC symbol %q: %v", symbol, err)
+	}
+	strike, err := strconv.ParseUint(symbol[13:], 10, 64)
+	if err != nil || strike == 0 {
+		return nil, fmt.Errorf("invalid strike in OCC symbol %q", symbol)
+	}
+
+	return &OptionSymbol{
+		Underlying: underlying,
+		Expiration: exp,
+		PutCall:    putCall,
+		Strike:     float64(strike) / 1000,
+	}, nil
+}
+
+func parsePutCall(c byte) (ContractType, error) {
+	switch c {
+	case 'C':
+		return ContractTypeCall, nil
+	case 'P':
+		return ContractTypePut, nil
+	}
+	return "", fmt.Errorf("put/call indicator must be C or P, got %q", c)
+}
+
+func (s *OptionSymbol) putCallChar() string {
+	if s.PutCall == ContractTypePut {
+		return "P"
+	}
+	return "C"
+}
+
+// String returns the symbol in TD Ameritrade format, e.g. AAPL_011924C150.
+func (s *OptionSymbol) String() string {
+	return fmt.Sprintf("%s_%s%s%s",
+		s.Underlying,
+		s.Expiration.Format("010206"),
+		s.putCallChar(),
+		strconv.FormatFloat(s.Strike, 'f', -1, 64),
+	)
+}
+
+// OCC returns the symbol in 21 character OCC format, e.g.
+// "AAPL  240119C00150000".
+func (s *OptionSymbol) OCC() string {
+	return fmt.Sprintf("%-6s%s%s%08d",
+		s.Underlying,
+		s.Expiration.Format("060102"),
+		s.putCallChar(),
+		int64(math.Round(s.Strike*1000)),
+	)
+}
+
+// ParseSymbol parses the contract's Symbol, so chain rows can be matched
+// against positions and transactions.
+func (o *OptionData) ParseSymbol() (*OptionSymbol, error) {
+	return ParseOptionSymbol(o.Symbol)
+}
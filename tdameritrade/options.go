@@ -0,0 +1,102 @@
+package tdameritrade
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// defaultUserAgent is the User-Agent of the requests of a client.
+const defaultUserAgent = "go-tdameritrade"
+
+// ClientOption configures a Client built by NewClient.
+type ClientOption func(*Client) error
+
+// Logger receives the log messages of a client, about retries and token
+// refreshes. *log.Logger is one.
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+// WithBaseURL makes the client send its requests to baseURL, e.g. a test
+// server.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) error {
+		u, err := parseRootURL(baseURL)
+		if err != nil {
+			return err
+		}
+		c.BaseURL = u
+		return nil
+	}
+}
+
+// WithUserAgent sets the User-Agent header of the requests of the client.
+func WithUserAgent(userAgent string) ClientOption {
+	return func(c *Client) error {
+		c.UserAgent = userAgent
+		return nil
+	}
+}
+
+// WithLogger makes the client log retries and token refreshes to l.
+func WithLogger(l Logger) ClientOption {
+	return func(c *Client) error {
+		c.logger = l
+		return nil
+	}
+}
+
+// WithDebugWriter makes the client dump its requests and responses to w, as
+// SetDebugWriter does.
+func WithDebugWriter(w io.Writer) ClientOption {
+	return func(c *Client) error {
+		c.SetDebugWriter(w)
+		return nil
+	}
+}
+
+// WithTimeout bounds every call of the client whose context has no
+// deadline to d, retries and the reading of the response included, so that
+// a forgotten context.Background can't hang it forever. Calls with a
+// deadline keep theirs, even if longer. A call running out of time fails
+// with context.DeadlineExceeded.
+func WithTimeout(d time.Duration) ClientOption {
+	return func(c *Client) error {
+		if d < 0 {
+			return fmt.Errorf("invalid timeout %v", d)
+		}
+		c.timeout = d
+		return nil
+	}
+}
+
+// WithStrictDecoding makes the client fail calls whose response has fields
+// the types of this package lack, to notice when the API adds or renames
+// fields. Types decoding themselves, such as Quote, keep ignoring unknown
+// fields. Leave it off in production: the API adds fields without notice.
+func WithStrictDecoding() ClientOption {
+	return func(c *Client) error {
+		c.strict = true
+		return nil
+	}
+}
+
+// WithHTTPClient makes the client send its requests with hc, in place of
+// the http.Client passed to NewClient.
+func WithHTTPClient(hc *http.Client) ClientOption {
+	return func(c *Client) error {
+		if hc == nil {
+			hc = &http.Client{}
+		}
+		c.client = hc
+		return nil
+	}
+}
+
+func (c *Client) logf(format string, args ...interface{}) {
+	if c.logger != nil {
+		c.logger.Printf(format, args...)
+	}
+}
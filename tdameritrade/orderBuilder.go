@@ -0,0 +1,284 @@
+package tdameritrade
+
+import (
+	"fmt"
+	"math"
+)
+
+// OrderBuilder builds a single order step by step, e.g.
+//
+//	order, err := NewEquityOrder().Buy("AAPL", 100).Limit(182.50).Day().Build()
+//
+// Methods can be called in any order and later calls override earlier ones.
+// Build checks that the order is complete before it is sent.
+type OrderBuilder struct {
+	order Order
+}
+
+// NewEquityOrder starts an equity order, which by default is a market order
+// for the regular session, good for the day.
+func NewEquityOrder() *OrderBuilder {
+	return newOrderBuilder()
+}
+
+func newOrderBuilder() *OrderBuilder {
+	return &OrderBuilder{order: Order{
+		Session:           SessionNormal,
+		Duration:          DurationDay,
+		OrderType:         OrderTypeMarket,
+		OrderStrategyType: OrderStrategyTypeSingle,
+	}}
+}
+
+// Buy buys quantity shares of symbol.
+func (b *OrderBuilder) Buy(symbol string, quantity float64) *OrderBuilder {
+	return b.equityLeg(InstructionBuy, symbol, quantity)
+}
+
+// Sell sells quantity shares of symbol held long.
+func (b *OrderBuilder) Sell(symbol string, quantity float64) *OrderBuilder {
+	return b.equityLeg(InstructionSell, symbol, quantity)
+}
+
+// SellShort sells quantity shares of symbol short.
+func (b *OrderBuilder) SellShort(symbol string, quantity float64) *OrderBuilder {
+	return b.equityLeg(InstructionSellShort, symbol, quantity)
+}
+
+// BuyToCover buys back quantity shares of symbol held short.
+func (b *OrderBuilder) BuyToCover(symbol string, quantity float64) *OrderBuilder {
+	return b.equityLeg(InstructionBuyToCover, symbol, quantity)
+}
+
+func (b *OrderBuilder) equityLeg(instruction Instruction, symbol string, quantity float64) *OrderBuilder {
+	return b.setLeg(instruction, Instrument{AssetType: "EQUITY", Data: &Equity{Symbol: symbol}}, quantity)
+}
+
+// setLeg makes the order trade quantity of instrument, replacing any leg set
+// before.
+func (b *OrderBuilder) setLeg(instruction Instruction, instrument Instrument, quantity float64) *OrderBuilder {
+	b.order.OrderLegCollection = []*OrderLeg{{
+		Instruction: instruction,
+		Quantity:    quantity,
+		Instrument:  instrument,
+	}}
+	return b
+}
+
+// Market makes the order a market order.
+func (b *OrderBuilder) Market() *OrderBuilder {
+	b.setType(OrderTypeMarket)
+	return b
+}
+
+// Limit makes the order a limit order at price.
+func (b *OrderBuilder) Limit(price float64) *OrderBuilder {
+	b.setType(OrderTypeLimit)
+	b.order.Price = price
+	return b
+}
+
+// Stop makes the order a stop order triggered at stop.
+func (b *OrderBuilder) Stop(stop float64) *OrderBuilder {
+	b.setType(OrderTypeStop)
+	b.order.StopPrice = stop
+	return b
+}
+
+// StopLimit makes the order a limit order at limit, triggered at stop.
+func (b *OrderBuilder) StopLimit(stop, limit float64) *OrderBuilder {
+	b.setType(OrderTypeStopLimit)
+	b.order.Price = limit
+	b.order.StopPrice = stop
+	return b
+}
+
+// TrailingStop makes the order a stop order whose stop trails the last
+// price by offset dollars.
+func (b *OrderBuilder) TrailingStop(offset float64) *OrderBuilder {
+	return b.trailingStop("VALUE", offset)
+}
+
+// TrailingStopPercent makes the order a stop order whose stop trails the
+// last price by percent percent.
+func (b *OrderBuilder) TrailingStopPercent(percent float64) *OrderBuilder {
+	return b.trailingStop("PERCENT", percent)
+}
+
+func (b *OrderBuilder) trailingStop(linkType string, offset float64) *OrderBuilder {
+	b.setType(OrderTypeTrailingStop)
+	b.order.StopPriceLinkBasis = "LAST"
+	b.order.StopPriceLinkType = linkType
+	b.order.StopPriceOffset = offset
+	return b
+}
+
+// StopLinkBasis sets the price a trailing stop trails: LAST, BID, ASK,
+// ASK_BID, MARK or AVERAGE. Call it after TrailingStop.
+func (b *OrderBuilder) StopLinkBasis(basis string) *OrderBuilder {
+	b.order.StopPriceLinkBasis = basis
+	return b
+}
+
+// StopTrigger sets the price that triggers a stop: STANDARD, BID, ASK, LAST
+// or MARK.
+func (b *OrderBuilder) StopTrigger(stopType string) *OrderBuilder {
+	b.order.StopType = stopType
+	return b
+}
+
+// setType sets the order type and clears the prices of the previous one.
+func (b *OrderBuilder) setType(orderType OrderType) {
+	b.order.OrderType = orderType
+	b.order.Price = 0
+	b.order.StopPrice = 0
+	b.order.StopPriceLinkBasis = ""
+	b.order.StopPriceLinkType = ""
+	b.order.StopPriceOffset = 0
+}
+
+// Day makes the order expire at the end of the day.
+func (b *OrderBuilder) Day() *OrderBuilder {
+	b.order.Duration = DurationDay
+	return b
+}
+
+// GTC makes the order good until canceled.
+func (b *OrderBuilder) GTC() *OrderBuilder {
+	b.order.Duration = DurationGoodTillCancel
+	return b
+}
+
+// FillOrKill makes the order fill completely right away or be canceled.
+func (b *OrderBuilder) FillOrKill() *OrderBuilder {
+	b.order.Duration = DurationFillOrKill
+	return b
+}
+
+// Session sets the session the order works in: SessionNormal, SessionAM
+// (pre-market), SessionPM (after hours) or SessionSeamless (all of them).
+func (b *OrderBuilder) Session(session Session) *OrderBuilder {
+	b.order.Session = session
+	return b
+}
+
+var (
+	validStopLinkBases = []string{"LAST", "BID", "ASK", "ASK_BID", "MARK", "AVERAGE"}
+	validStopLinkTypes = []string{"VALUE", "PERCENT", "TICK"}
+	validStopTypes     = []string{"STANDARD", "BID", "ASK", "LAST", "MARK"}
+)
+
+// Build checks the order and returns it, ready for OrdersService.PlaceOrder.
+// Prices are rounded to the increments the API accepts: cents for options,
+// and for stocks cents from $1 up and hundredths of a cent below.
+func (b *OrderBuilder) Build() (*Order, error) {
+	order := b.order
+	if len(order.OrderLegCollection) == 0 {
+		return nil, fmt.Errorf("order has no instrument, call Buy, Sell or similar")
+	}
+	for _, leg := range order.OrderLegCollection {
+		if leg.Instrument.Symbol() == "" {
+			return nil, fmt.Errorf("order leg has no symbol")
+		}
+		if leg.Quantity <= 0 {
+			return nil, fmt.Errorf("invalid quantity %v for %s, must be positive", leg.Quantity, leg.Instrument.Symbol())
+		}
+		if leg.Instrument.AssetType == "OPTION" {
+			if _, err := parseOptionOrOCC(leg.Instrument.Symbol()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if order.ComplexOrderStrategyType != "" && order.ComplexOrderStrategyType != ComplexOrderStrategyTypeNone {
+		if err := validateSpread(&order); err != nil {
+			return nil, err
+		}
+	}
+	if !order.Session.valid() {
+		return nil, fmt.Errorf("invalid session %q", order.Session)
+	}
+	if !order.Duration.valid() {
+		return nil, fmt.Errorf("invalid duration %q", order.Duration)
+	}
+	if err := validatePrices(&order); err != nil {
+		return nil, err
+	}
+	round := roundPrice
+	if order.OrderLegCollection[0].Instrument.AssetType == "OPTION" {
+		round = roundCents
+	}
+	order.Price = round(order.Price)
+	order.StopPrice = round(order.StopPrice)
+	if order.StopPriceLinkType == "VALUE" {
+		order.StopPriceOffset = round(order.StopPriceOffset)
+	}
+
+	legs := make([]*OrderLeg, len(order.OrderLegCollection))
+	for i, leg := range order.OrderLegCollection {
+		l := *leg
+		legs[i] = &l
+	}
+	order.OrderLegCollection = legs
+	return &order, nil
+}
+
+// validatePrices checks that the order carries the prices its type needs and
+// no stop fields that do not apply to it.
+func validatePrices(order *Order) error {
+	trailing := order.OrderType == OrderTypeTrailingStop
+	switch order.OrderType {
+	case OrderTypeLimit, OrderTypeNetDebit, OrderTypeNetCredit:
+		if order.Price <= 0 {
+			return fmt.Errorf("%s order needs a positive price", order.OrderType)
+		}
+	case OrderTypeStop:
+		if order.StopPrice <= 0 {
+			return fmt.Errorf("STOP order needs a positive stop price")
+		}
+	case OrderTypeStopLimit:
+		if order.Price <= 0 || order.StopPrice <= 0 {
+			return fmt.Errorf("STOP_LIMIT order needs a positive price and stop price")
+		}
+	case OrderTypeTrailingStop:
+		if order.Price != 0 || order.StopPrice != 0 {
+			return fmt.Errorf("TRAILING_STOP order takes an offset, not a price or stop price")
+		}
+		if !contains(order.StopPriceLinkBasis, validStopLinkBases) {
+			return fmt.Errorf("invalid stopPriceLinkBasis, must have the value of one of the following %v", validStopLinkBases)
+		}
+		if !contains(order.StopPriceLinkType, validStopLinkTypes) {
+			return fmt.Errorf("invalid stopPriceLinkType, must have the value of one of the following %v", validStopLinkTypes)
+		}
+		if order.StopPriceOffset <= 0 {
+			return fmt.Errorf("TRAILING_STOP order needs a positive stopPriceOffset")
+		}
+		if order.StopPriceLinkType == "PERCENT" && order.StopPriceOffset >= 100 {
+			return fmt.Errorf("invalid stopPriceOffset %v%%, must be below 100%%", order.StopPriceOffset)
+		}
+	}
+
+	if !trailing && (order.StopPriceLinkBasis != "" || order.StopPriceLinkType != "" || order.StopPriceOffset != 0) {
+		return fmt.Errorf("stopPriceLinkBasis, stopPriceLinkType and stopPriceOffset only apply to TRAILING_STOP orders")
+	}
+	if order.StopType != "" {
+		if order.OrderType != OrderTypeStop && order.OrderType != OrderTypeStopLimit && !trailing {
+			return fmt.Errorf("stopType does not apply to %s orders", order.OrderType)
+		}
+		if !contains(order.StopType, validStopTypes) {
+			return fmt.Errorf("invalid stopType, must have the value of one of the following %v", validStopTypes)
+		}
+	}
+	return nil
+}
+
+// roundPrice rounds p to a cent, or to a hundredth of a cent below $1.
+func roundPrice(p float64) float64 {
+	if p < 1 {
+		return math.Round(p*10000) / 10000
+	}
+	return roundCents(p)
+}
+
+func roundCents(p float64) float64 {
+	return math.Round(p*100) / 100
+}
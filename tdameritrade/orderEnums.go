@@ -0,0 +1,272 @@
+package tdameritrade
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// Session is the market session an order works in.
+type Session string
+
+const (
+	SessionNormal   Session = "NORMAL"
+	SessionAM       Session = "AM"
+	SessionPM       Session = "PM"
+	SessionSeamless Session = "SEAMLESS"
+)
+
+func (s Session) String() string { return string(s) }
+
+func (s Session) valid() bool {
+	switch s {
+	case SessionNormal, SessionAM, SessionPM, SessionSeamless:
+		return true
+	}
+	return false
+}
+
+// MarshalJSON implements json.Marshaler, rejecting unknown sessions.
+func (s Session) MarshalJSON() ([]byte, error) {
+	return marshalEnum("session", string(s), s.valid())
+}
+
+// Duration is how long an order stays working.
+type Duration string
+
+const (
+	DurationDay            Duration = "DAY"
+	DurationGoodTillCancel Duration = "GOOD_TILL_CANCEL"
+	DurationFillOrKill     Duration = "FILL_OR_KILL"
+)
+
+func (d Duration) String() string { return string(d) }
+
+func (d Duration) valid() bool {
+	switch d {
+	case DurationDay, DurationGoodTillCancel, DurationFillOrKill:
+		return true
+	}
+	return false
+}
+
+// MarshalJSON implements json.Marshaler, rejecting unknown durations.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return marshalEnum("duration", string(d), d.valid())
+}
+
+// OrderType is the type of an order, which decides the prices it needs.
+type OrderType string
+
+const (
+	OrderTypeMarket            OrderType = "MARKET"
+	OrderTypeLimit             OrderType = "LIMIT"
+	OrderTypeStop              OrderType = "STOP"
+	OrderTypeStopLimit         OrderType = "STOP_LIMIT"
+	OrderTypeTrailingStop      OrderType = "TRAILING_STOP"
+	OrderTypeTrailingStopLimit OrderType = "TRAILING_STOP_LIMIT"
+	OrderTypeMarketOnClose     OrderType = "MARKET_ON_CLOSE"
+	OrderTypeExercise          OrderType = "EXERCISE"
+	OrderTypeNetDebit          OrderType = "NET_DEBIT"
+	OrderTypeNetCredit         OrderType = "NET_CREDIT"
+	OrderTypeNetZero           OrderType = "NET_ZERO"
+)
+
+func (t OrderType) String() string { return string(t) }
+
+func (t OrderType) valid() bool {
+	switch t {
+	case OrderTypeMarket, OrderTypeLimit, OrderTypeStop, OrderTypeStopLimit,
+		OrderTypeTrailingStop, OrderTypeTrailingStopLimit, OrderTypeMarketOnClose,
+		OrderTypeExercise, OrderTypeNetDebit, OrderTypeNetCredit, OrderTypeNetZero:
+		return true
+	}
+	return false
+}
+
+// MarshalJSON implements json.Marshaler, rejecting unknown order types.
+func (t OrderType) MarshalJSON() ([]byte, error) {
+	return marshalEnum("orderType", string(t), t.valid())
+}
+
+// Instruction is what an order leg does with its instrument.
+type Instruction string
+
+const (
+	InstructionBuy         Instruction = "BUY"
+	InstructionSell        Instruction = "SELL"
+	InstructionBuyToCover  Instruction = "BUY_TO_COVER"
+	InstructionSellShort   Instruction = "SELL_SHORT"
+	InstructionBuyToOpen   Instruction = "BUY_TO_OPEN"
+	InstructionBuyToClose  Instruction = "BUY_TO_CLOSE"
+	InstructionSellToOpen  Instruction = "SELL_TO_OPEN"
+	InstructionSellToClose Instruction = "SELL_TO_CLOSE"
+	InstructionExchange    Instruction = "EXCHANGE"
+)
+
+func (i Instruction) String() string { return string(i) }
+
+func (i Instruction) valid() bool {
+	switch i {
+	case InstructionBuy, InstructionSell, InstructionBuyToCover, InstructionSellShort,
+		InstructionBuyToOpen, InstructionBuyToClose, InstructionSellToOpen,
+		InstructionSellToClose, InstructionExchange:
+		return true
+	}
+	return false
+}
+
+// isBuy reports whether the instruction buys its instrument.
+func (i Instruction) isBuy() bool {
+	switch i {
+	case InstructionBuy, InstructionBuyToCover, InstructionBuyToOpen, InstructionBuyToClose:
+		return true
+	}
+	return false
+}
+
+// MarshalJSON implements json.Marshaler, rejecting unknown instructions.
+func (i Instruction) MarshalJSON() ([]byte, error) {
+	return marshalEnum("instruction", string(i), i.valid())
+}
+
+// ComplexOrderStrategyType is the shape of a multi-leg option order.
+type ComplexOrderStrategyType string
+
+const (
+	ComplexOrderStrategyTypeNone                   ComplexOrderStrategyType = "NONE"
+	ComplexOrderStrategyTypeCovered                ComplexOrderStrategyType = "COVERED"
+	ComplexOrderStrategyTypeVertical               ComplexOrderStrategyType = "VERTICAL"
+	ComplexOrderStrategyTypeBackRatio              ComplexOrderStrategyType = "BACK_RATIO"
+	ComplexOrderStrategyTypeCalendar               ComplexOrderStrategyType = "CALENDAR"
+	ComplexOrderStrategyTypeDiagonal               ComplexOrderStrategyType = "DIAGONAL"
+	ComplexOrderStrategyTypeStraddle               ComplexOrderStrategyType = "STRADDLE"
+	ComplexOrderStrategyTypeStrangle               ComplexOrderStrategyType = "STRANGLE"
+	ComplexOrderStrategyTypeCollarSynthetic        ComplexOrderStrategyType = "COLLAR_SYNTHETIC"
+	ComplexOrderStrategyTypeButterfly              ComplexOrderStrategyType = "BUTTERFLY"
+	ComplexOrderStrategyTypeCondor                 ComplexOrderStrategyType = "CONDOR"
+	ComplexOrderStrategyTypeIronCondor             ComplexOrderStrategyType = "IRON_CONDOR"
+	ComplexOrderStrategyTypeVerticalRoll           ComplexOrderStrategyType = "VERTICAL_ROLL"
+	ComplexOrderStrategyTypeCollarWithStock        ComplexOrderStrategyType = "COLLAR_WITH_STOCK"
+	ComplexOrderStrategyTypeDoubleDiagonal         ComplexOrderStrategyType = "DOUBLE_DIAGONAL"
+	ComplexOrderStrategyTypeUnbalancedButterfly    ComplexOrderStrategyType = "UNBALANCED_BUTTERFLY"
+	ComplexOrderStrategyTypeUnbalancedCondor       ComplexOrderStrategyType = "UNBALANCED_CONDOR"
+	ComplexOrderStrategyTypeUnbalancedIronCondor   ComplexOrderStrategyType = "UNBALANCED_IRON_CONDOR"
+	ComplexOrderStrategyTypeUnbalancedVerticalRoll ComplexOrderStrategyType = "UNBALANCED_VERTICAL_ROLL"
+	ComplexOrderStrategyTypeCustom                 ComplexOrderStrategyType = "CUSTOM"
+)
+
+func (t ComplexOrderStrategyType) String() string { return string(t) }
+
+func (t ComplexOrderStrategyType) valid() bool {
+	switch t {
+	case ComplexOrderStrategyTypeNone, ComplexOrderStrategyTypeCovered,
+		ComplexOrderStrategyTypeVertical, ComplexOrderStrategyTypeBackRatio,
+		ComplexOrderStrategyTypeCalendar, ComplexOrderStrategyTypeDiagonal,
+		ComplexOrderStrategyTypeStraddle, ComplexOrderStrategyTypeStrangle,
+		ComplexOrderStrategyTypeCollarSynthetic, ComplexOrderStrategyTypeButterfly,
+		ComplexOrderStrategyTypeCondor, ComplexOrderStrategyTypeIronCondor,
+		ComplexOrderStrategyTypeVerticalRoll, ComplexOrderStrategyTypeCollarWithStock,
+		ComplexOrderStrategyTypeDoubleDiagonal, ComplexOrderStrategyTypeUnbalancedButterfly,
+		ComplexOrderStrategyTypeUnbalancedCondor, ComplexOrderStrategyTypeUnbalancedIronCondor,
+		ComplexOrderStrategyTypeUnbalancedVerticalRoll, ComplexOrderStrategyTypeCustom:
+		return true
+	}
+	return false
+}
+
+// MarshalJSON implements json.Marshaler, rejecting unknown strategy types.
+func (t ComplexOrderStrategyType) MarshalJSON() ([]byte, error) {
+	return marshalEnum("complexOrderStrategyType", string(t), t.valid())
+}
+
+// OrderStrategyType is how an order relates to the child orders in its
+// ChildOrderStrategies.
+type OrderStrategyType string
+
+const (
+	// OrderStrategyTypeSingle is an order of its own.
+	OrderStrategyTypeSingle OrderStrategyType = "SINGLE"
+	// OrderStrategyTypeOCO groups child orders that cancel each other.
+	OrderStrategyTypeOCO OrderStrategyType = "OCO"
+	// OrderStrategyTypeTrigger places its child orders once it fills.
+	OrderStrategyTypeTrigger OrderStrategyType = "TRIGGER"
+)
+
+func (t OrderStrategyType) String() string { return string(t) }
+
+func (t OrderStrategyType) valid() bool {
+	switch t {
+	case OrderStrategyTypeSingle, OrderStrategyTypeOCO, OrderStrategyTypeTrigger:
+		return true
+	}
+	return false
+}
+
+// MarshalJSON implements json.Marshaler, rejecting unknown strategy types.
+func (t OrderStrategyType) MarshalJSON() ([]byte, error) {
+	return marshalEnum("orderStrategyType", string(t), t.valid())
+}
+
+// OrderStatus is the state of an order. Statuses are set by the API, so
+// unlike the other order enums any value is encoded and decoded as is.
+type OrderStatus string
+
+const (
+	OrderStatusAwaitingParentOrder  OrderStatus = "AWAITING_PARENT_ORDER"
+	OrderStatusAwaitingCondition    OrderStatus = "AWAITING_CONDITION"
+	OrderStatusAwaitingManualReview OrderStatus = "AWAITING_MANUAL_REVIEW"
+	OrderStatusAccepted             OrderStatus = "ACCEPTED"
+	OrderStatusAwaitingUROut        OrderStatus = "AWAITING_UR_OUT"
+	OrderStatusPendingActivation    OrderStatus = "PENDING_ACTIVATION"
+	OrderStatusQueued               OrderStatus = "QUEUED"
+	OrderStatusWorking              OrderStatus = "WORKING"
+	OrderStatusRejected             OrderStatus = "REJECTED"
+	OrderStatusPendingCancel        OrderStatus = "PENDING_CANCEL"
+	OrderStatusCanceled             OrderStatus = "CANCELED"
+	OrderStatusPendingReplace       OrderStatus = "PENDING_REPLACE"
+	OrderStatusReplaced             OrderStatus = "REPLACED"
+	OrderStatusFilled               OrderStatus = "FILLED"
+	OrderStatusExpired              OrderStatus = "EXPIRED"
+)
+
+func (s OrderStatus) String() string { return string(s) }
+
+func (s OrderStatus) valid() bool {
+	switch s {
+	case OrderStatusAwaitingParentOrder, OrderStatusAwaitingCondition,
+		OrderStatusAwaitingManualReview, OrderStatusAccepted, OrderStatusAwaitingUROut,
+		OrderStatusPendingActivation, OrderStatusQueued, OrderStatusWorking,
+		OrderStatusRejected, OrderStatusPendingCancel, OrderStatusCanceled,
+		OrderStatusPendingReplace, OrderStatusReplaced, OrderStatusFilled,
+		OrderStatusExpired:
+		return true
+	}
+	return false
+}
+
+// terminal reports whether the order no longer changes once it has the
+// status.
+func (s OrderStatus) terminal() bool {
+	switch s {
+	case OrderStatusFilled, OrderStatusCanceled, OrderStatusRejected,
+		OrderStatusExpired, OrderStatusReplaced:
+		return true
+	}
+	return false
+}
+
+// EncodeValues implements query.Encoder.
+func (s OrderStatus) EncodeValues(key string, v *url.Values) error {
+	v.Set(key, s.String())
+	return nil
+}
+
+// marshalEnum encodes the enum value of the field name, which must be valid
+// unless it is empty.
+func marshalEnum(name, value string, valid bool) ([]byte, error) {
+	if value != "" && !valid {
+		return nil, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return json.Marshal(value)
+}
@@ -0,0 +1,162 @@
+package tdameritrade
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// clockSkew is how much earlier than the local send time an order may have
+// been entered according to the API's clock.
+const clockSkew = time.Minute
+
+// OrderGuard keeps retried order submissions from placing the same trade
+// twice. Every PlaceOrder call carries an idempotency key chosen by the
+// caller, such as a signal id: once an order was placed under a key, calls
+// with the same key return that order instead of placing another one.
+//
+// The API has no idempotency keys of its own, so when an attempt fails
+// without a clear answer, e.g. because it timed out, the next attempt under
+// the same key first looks for an order entered since then with the same
+// instruments, quantities and prices, and only places the order if there is
+// none. Keys are kept in memory for the lifetime of the OrderGuard.
+type OrderGuard struct {
+	orders *OrdersService
+
+	mu      sync.Mutex
+	entries map[string]*guardEntry
+	claimed map[int64]bool // orders found for an earlier key
+}
+
+type guardEntry struct {
+	mu     sync.Mutex // held while the key is being submitted
+	placed *PlacedOrder
+	sentAt time.Time // of the last attempt that may have placed the order
+}
+
+// NewOrderGuard returns an OrderGuard placing orders with orders.
+func NewOrderGuard(orders *OrdersService) *OrderGuard {
+	return &OrderGuard{
+		orders:  orders,
+		entries: make(map[string]*guardEntry),
+		claimed: make(map[int64]bool),
+	}
+}
+
+// PlaceOrder places order in the account accountID unless an order was
+// already placed under key, in which case that order is returned with a nil
+// Response. Calls with the same key wait for each other.
+func (g *OrderGuard) PlaceOrder(ctx context.Context, key, accountID string, order *Order) (*PlacedOrder, *Response, error) {
+	g.mu.Lock()
+	e, ok := g.entries[key]
+	if !ok {
+		e = new(guardEntry)
+		g.entries[key] = e
+	}
+	g.mu.Unlock()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.placed != nil {
+		return e.placed, nil, nil
+	}
+
+	if !e.sentAt.IsZero() {
+		placed, resp, err := g.find(ctx, accountID, order, e.sentAt)
+		if err != nil {
+			return nil, resp, err
+		}
+		if placed != nil {
+			e.placed = placed
+			return placed, resp, nil
+		}
+	}
+
+	sentAt := time.Now()
+	placed, resp, err := g.orders.PlaceOrder(ctx, accountID, order)
+	switch {
+	case err == nil:
+		e.placed = placed
+		g.claim(placed.OrderID)
+	case isRejection(err):
+		// The API refused it, so nothing was placed.
+		e.sentAt = time.Time{}
+	case e.sentAt.IsZero():
+		e.sentAt = sentAt
+	}
+	return placed, resp, err
+}
+
+// isRejection reports whether err is the API refusing the request, rather
+// than a failure that leaves open whether the order was placed. Only 4xx
+// answers are definite: a 5xx from the gateway may come after the order
+// went through.
+func isRejection(err error) bool {
+	switch err := err.(type) {
+	case *OrderRejectedError:
+		return true
+	case *APIError:
+		return err.StatusCode >= 400 && err.StatusCode < 500
+	}
+	return false
+}
+
+// find looks for an order matching order entered since sentAt and not
+// claimed by another key.
+func (g *OrderGuard) find(ctx context.Context, accountID string, order *Order, sentAt time.Time) (*PlacedOrder, *Response, error) {
+	from := sentAt.Add(-clockSkew)
+	orders, resp, err := g.orders.GetOrdersByPath(ctx, accountID, &OrdersOptions{
+		FromEnteredTime: Date{from},
+		ToEnteredTime:   Date{time.Now()},
+	})
+	if err != nil {
+		return nil, resp, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, o := range orders {
+		entered, err := time.Parse(timestampLayout, o.EnteredTime)
+		if err != nil || entered.Before(from) || g.claimed[o.OrderID] || !sameOrder(o, order) {
+			continue
+		}
+		g.claimed[o.OrderID] = true
+		return &PlacedOrder{OrderID: o.OrderID, AccountID: accountID}, resp, nil
+	}
+	return nil, resp, nil
+}
+
+func (g *OrderGuard) claim(orderID int64) {
+	g.mu.Lock()
+	g.claimed[orderID] = true
+	g.mu.Unlock()
+}
+
+// sameOrder reports whether the order a, as reported by the API, was placed
+// from b.
+func sameOrder(a, b *Order) bool {
+	if a.OrderType != b.OrderType || a.OrderStrategyType != b.OrderStrategyType ||
+		!samePrice(a.Price, b.Price) || !samePrice(a.StopPrice, b.StopPrice) ||
+		len(a.OrderLegCollection) != len(b.OrderLegCollection) ||
+		len(a.ChildOrderStrategies) != len(b.ChildOrderStrategies) {
+		return false
+	}
+	for i, leg := range a.OrderLegCollection {
+		other := b.OrderLegCollection[i]
+		if leg.Instrument.Symbol() != other.Instrument.Symbol() ||
+			leg.Instruction != other.Instruction || leg.Quantity != other.Quantity {
+			return false
+		}
+	}
+	for i, child := range a.ChildOrderStrategies {
+		if !sameOrder(child, b.ChildOrderStrategies[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func samePrice(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
@@ -0,0 +1,97 @@
+package tdameritrade
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gatewayTimeoutServer places the orders it receives, but answers the first
+// one with 504 Gateway Timeout, like a gateway giving up on a slow backend.
+type gatewayTimeoutServer struct {
+	mu     sync.Mutex
+	posts  int
+	orders []*Order
+}
+
+func (s *gatewayTimeoutServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.Method {
+	case http.MethodPost:
+		var o Order
+		if err := json.NewDecoder(r.Body).Decode(&o); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.posts++
+		o.OrderID = int64(1000 + len(s.orders))
+		o.EnteredTime = time.Now().Format(timestampLayout)
+		s.orders = append(s.orders, &o)
+		if s.posts == 1 {
+			w.WriteHeader(http.StatusGatewayTimeout)
+			return
+		}
+		w.Header().Set("Location", fmt.Sprintf("%s/%d", r.URL.Path, o.OrderID))
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodGet:
+		json.NewEncoder(w).Encode(s.orders)
+	}
+}
+
+func TestOrderGuardRetryAfterGatewayTimeout(t *testing.T) {
+	fake := new(gatewayTimeoutServer)
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	client, err := NewClient(srv.Client(), WithBaseURL(srv.URL+"/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := NewEquityOrder().Buy("AAPL", 10).Limit(182.5).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	guard := NewOrderGuard(client.Orders)
+	ctx := context.Background()
+	if _, _, err := guard.PlaceOrder(ctx, "signal-1", "1", order); err == nil {
+		t.Fatal("first attempt: got no error, want the 504")
+	}
+	placed, _, err := guard.PlaceOrder(ctx, "signal-1", "1", order)
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+
+	if fake.posts != 1 {
+		t.Errorf("orders placed = %d, want 1", fake.posts)
+	}
+	if placed.OrderID != 1000 {
+		t.Errorf("retry returned order %d, want the order placed by the first attempt, 1000", placed.OrderID)
+	}
+}
+
+func TestIsRejection(t *testing.T) {
+	for _, tt := range []struct {
+		err  error
+		want bool
+	}{
+		{&APIError{StatusCode: http.StatusBadRequest}, true},
+		{&APIError{StatusCode: http.StatusUnauthorized}, true},
+		{&APIError{StatusCode: http.StatusForbidden}, true},
+		{&APIError{StatusCode: http.StatusTooManyRequests}, true},
+		{&OrderRejectedError{APIError: &APIError{StatusCode: http.StatusBadRequest}}, true},
+		{&APIError{StatusCode: http.StatusInternalServerError}, false},
+		{&APIError{StatusCode: http.StatusBadGateway}, false},
+		{&APIError{StatusCode: http.StatusGatewayTimeout}, false},
+		{context.DeadlineExceeded, false},
+	} {
+		if got := isRejection(tt.err); got != tt.want {
+			t.Errorf("isRejection(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
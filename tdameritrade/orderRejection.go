@@ -0,0 +1,140 @@
+package tdameritrade
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// RejectReason is the cause of an order rejection, classified from the
+// messages of the API.
+type RejectReason string
+
+const (
+	RejectBuyingPower     RejectReason = "BUYING_POWER"
+	RejectMarketClosed    RejectReason = "MARKET_CLOSED"
+	RejectNotShortable    RejectReason = "NOT_SHORTABLE"
+	RejectInvalidPrice    RejectReason = "INVALID_PRICE"
+	RejectInvalidQuantity RejectReason = "INVALID_QUANTITY"
+	RejectInvalidSymbol   RejectReason = "INVALID_SYMBOL"
+	RejectNotPermitted    RejectReason = "NOT_PERMITTED"
+	RejectUnknown         RejectReason = "UNKNOWN"
+)
+
+func (r RejectReason) String() string { return string(r) }
+
+// rejectKeywords maps lowercase phrases of rejection messages to the reason
+// they indicate. The first reason with a match wins, so the phrases are
+// specific and the restrictions of the account come before the reasons
+// their messages mention in passing, like the symbol or the quantity.
+var rejectKeywords = []struct {
+	reason   RejectReason
+	keywords []string
+}{
+	{RejectNotPermitted, []string{"not permitted", "not authorized", "not approved", "not eligible", "option level", "options level", "trading level", "account is restricted", "closing only", "closing transactions only"}},
+	{RejectNotShortable, []string{"not shortable", "hard to borrow", "not available to borrow", "no shares available to short", "short sale restriction", "locate"}},
+	{RejectBuyingPower, []string{"buying power", "insufficient funds", "not enough funds", "insufficient cash", "not enough cash", "cash available"}},
+	{RejectMarketClosed, []string{"market is closed", "market closed", "outside of trading hours", "outside of market hours", "outside market hours", "not open for trading", "order entry is closed"}},
+	{RejectInvalidSymbol, []string{"invalid symbol", "unknown symbol", "symbol not found", "symbol is not valid", "symbol is invalid", "invalid cusip", "invalid instrument", "instrument not found"}},
+	{RejectInvalidQuantity, []string{"quantity", "lot size", "odd lot", "oversold", "overbought", "number of shares"}},
+	{RejectInvalidPrice, []string{"limit price", "stop price", "invalid price", "price must", "price is outside", "price increment", "tick size", "minimum increment"}},
+}
+
+// OrderRejectedError is returned when the API refuses an order, e.g. for a
+// lack of buying power or because the market is closed. Messages holds the
+// validation messages of the API and Reason their classification, so callers
+// can react to the cause:
+//
+//	if rejected, ok := err.(*OrderRejectedError); ok && rejected.Reason == RejectBuyingPower {
+//		...
+//	}
+//
+// It unwraps to the *APIError of the response, so errors.As finds either.
+type OrderRejectedError struct {
+	*APIError
+	Messages []string
+	Reason   RejectReason
+}
+
+func (e *OrderRejectedError) Error() string {
+	return "order rejected: " + strings.Join(e.Messages, "; ")
+}
+
+func (e *OrderRejectedError) Unwrap() error { return e.APIError }
+
+// orderError turns err into an OrderRejectedError if it is the API
+// rejecting an order, and returns it unchanged otherwise.
+func orderError(err error) error {
+	apiErr, ok := err.(*APIError)
+	if !ok || !apiErr.IsBadRequest() {
+		return err
+	}
+
+	messages := errorMessages(apiErr.Body)
+	if len(messages) == 0 {
+		messages = []string{http.StatusText(apiErr.StatusCode)}
+	}
+	return &OrderRejectedError{
+		APIError: apiErr,
+		Messages: messages,
+		Reason:   rejectReason(messages),
+	}
+}
+
+// errorMessages extracts the messages from the body of an error response,
+// which is either {"error": "..."}, {"errors": [...]} with strings or
+// objects holding a message, or plain text.
+func errorMessages(body string) []string {
+	var payload struct {
+		Error   string            `json:"error"`
+		Message string            `json:"message"`
+		Errors  []json.RawMessage `json:"errors"`
+	}
+	if err := json.Unmarshal([]byte(body), &payload); err != nil {
+		if body = strings.TrimSpace(body); body != "" {
+			return []string{body}
+		}
+		return nil
+	}
+
+	var messages []string
+	for _, m := range []string{payload.Error, payload.Message} {
+		if m != "" {
+			messages = append(messages, m)
+		}
+	}
+	for _, raw := range payload.Errors {
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			messages = append(messages, s)
+			continue
+		}
+		var obj struct {
+			Message string `json:"message"`
+			Detail  string `json:"detail"`
+			Title   string `json:"title"`
+		}
+		if json.Unmarshal(raw, &obj) == nil {
+			for _, m := range []string{obj.Message, obj.Detail, obj.Title} {
+				if m != "" {
+					messages = append(messages, m)
+					break
+				}
+			}
+		}
+	}
+	return messages
+}
+
+// rejectReason classifies messages.
+func rejectReason(messages []string) RejectReason {
+	text := strings.ToLower(strings.Join(messages, " "))
+	for _, r := range rejectKeywords {
+		for _, keyword := range r.keywords {
+			if strings.Contains(text, keyword) {
+				return r.reason
+			}
+		}
+	}
+	return RejectUnknown
+}
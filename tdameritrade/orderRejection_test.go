@@ -0,0 +1,49 @@
+package tdameritrade
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestRejectReason(t *testing.T) {
+	for _, tt := range []struct {
+		message string
+		want    RejectReason
+	}{
+		{"Your buying power will be below zero ($-1,234.56) if this order is accepted.", RejectBuyingPower},
+		{"Insufficient funds for this order.", RejectBuyingPower},
+		{"Order rejected: maintenance margin requirement for AAPL must be met with cash.", RejectUnknown},
+		{"Orders cannot be placed outside of market hours.", RejectMarketClosed},
+		{"This order is not valid for the extended hours session.", RejectUnknown},
+		{"Order quantity for symbol AAPL must be a whole number.", RejectInvalidQuantity},
+		{"This order may result in an oversold position in your account.", RejectInvalidQuantity},
+		{"Invalid symbol: XYZZY", RejectInvalidSymbol},
+		{"The limit price must be a multiple of the minimum tick of 0.05.", RejectInvalidPrice},
+		{"Stop price must be below the current bid for a sell stop order.", RejectInvalidPrice},
+		{"Limit orders are not permitted for this security.", RejectNotPermitted},
+		{"Your account is restricted to closing only transactions for symbol AAPL.", RejectNotPermitted},
+		{"Your option level does not allow naked calls on SPY limit orders.", RejectNotPermitted},
+		{"The security is not available to borrow.", RejectNotShortable},
+		{"A validation error occurred while processing the request.", RejectUnknown},
+	} {
+		if got := rejectReason([]string{tt.message}); got != tt.want {
+			t.Errorf("rejectReason(%q) = %s, want %s", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestOrderRejectedErrorUnwrap(t *testing.T) {
+	err := orderError(&APIError{
+		StatusCode: http.StatusBadRequest,
+		Body:       `{"error": "Your buying power will be below zero ($-1,234.56) if this order is accepted."}`,
+	})
+	var rejected *OrderRejectedError
+	if !errors.As(err, &rejected) || rejected.Reason != RejectBuyingPower {
+		t.Fatalf("err = %#v, want an OrderRejectedError for the buying power", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("errors.As(err, *APIError) = %v, want the 400 response", apiErr)
+	}
+}
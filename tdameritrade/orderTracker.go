@@ -0,0 +1,234 @@
+package tdameritrade
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// OrderState is a stage in the life of an order as followed by OrderTracker.
+// It condenses the many statuses of the API, and the filled quantity, into
+// the states a strategy acts on.
+type OrderState int
+
+const (
+	OrderStateNew             OrderState = iota // not seen yet
+	OrderStatePending                           // accepted, not working yet
+	OrderStateWorking                           // working, nothing filled
+	OrderStatePartiallyFilled                   // working, partly filled
+	OrderStateFilled
+	OrderStateCanceled
+	OrderStateRejected
+	OrderStateExpired
+	OrderStateReplaced
+)
+
+var orderStateNames = [...]string{
+	OrderStateNew:             "NEW",
+	OrderStatePending:         "PENDING",
+	OrderStateWorking:         "WORKING",
+	OrderStatePartiallyFilled: "PARTIALLY_FILLED",
+	OrderStateFilled:          "FILLED",
+	OrderStateCanceled:        "CANCELED",
+	OrderStateRejected:        "REJECTED",
+	OrderStateExpired:         "EXPIRED",
+	OrderStateReplaced:        "REPLACED",
+}
+
+func (s OrderState) String() string {
+	if s < 0 || int(s) >= len(orderStateNames) {
+		return fmt.Sprintf("OrderState(%d)", int(s))
+	}
+	return orderStateNames[s]
+}
+
+// Final reports whether an order in the state can no longer change.
+func (s OrderState) Final() bool {
+	return s >= OrderStateFilled
+}
+
+// orderTransitions lists the states each state can move to. Final states
+// cannot move at all.
+var orderTransitions = map[OrderState][]OrderState{
+	OrderStateNew: {OrderStatePending, OrderStateWorking, OrderStatePartiallyFilled, OrderStateFilled,
+		OrderStateCanceled, OrderStateRejected, OrderStateExpired, OrderStateReplaced},
+	OrderStatePending: {OrderStateWorking, OrderStatePartiallyFilled, OrderStateFilled,
+		OrderStateCanceled, OrderStateRejected, OrderStateExpired, OrderStateReplaced},
+	OrderStateWorking: {OrderStatePartiallyFilled, OrderStateFilled,
+		OrderStateCanceled, OrderStateRejected, OrderStateExpired, OrderStateReplaced},
+	OrderStatePartiallyFilled: {OrderStatePartiallyFilled, OrderStateFilled,
+		OrderStateCanceled, OrderStateExpired, OrderStateReplaced},
+}
+
+// orderState returns the state of order, or false if its status is unknown.
+func orderState(order *Order) (OrderState, bool) {
+	filled := order.FilledQuantity > 0
+	switch order.Status {
+	case OrderStatusAwaitingParentOrder, OrderStatusAwaitingCondition,
+		OrderStatusAwaitingManualReview, OrderStatusAccepted, OrderStatusAwaitingUROut,
+		OrderStatusPendingActivation, OrderStatusQueued:
+		return OrderStatePending, true
+	case OrderStatusWorking, OrderStatusPendingCancel, OrderStatusPendingReplace:
+		if filled {
+			return OrderStatePartiallyFilled, true
+		}
+		return OrderStateWorking, true
+	case OrderStatusFilled:
+		return OrderStateFilled, true
+	case OrderStatusCanceled:
+		return OrderStateCanceled, true
+	case OrderStatusRejected:
+		return OrderStateRejected, true
+	case OrderStatusExpired:
+		return OrderStateExpired, true
+	case OrderStatusReplaced:
+		return OrderStateReplaced, true
+	}
+	return 0, false
+}
+
+// OrderTransition is emitted by OrderTracker when an order changes state,
+// or fills some more while partially filled.
+type OrderTransition struct {
+	OrderID int64
+	From    OrderState
+	To      OrderState
+	Time    time.Time
+	Order   *Order  // the update causing the transition
+	Filled  float64 // quantity filled since the previous transition
+}
+
+// InvalidTransitionError is returned by OrderTracker.Update for an update
+// that cannot follow the order's current state, such as a filled order
+// working again or a filled quantity going down. The update is ignored.
+type InvalidTransitionError struct {
+	OrderID int64
+	From    OrderState
+	To      OrderState
+	Reason  string
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("order %d: invalid transition from %v to %v: %s", e.OrderID, e.From, e.To, e.Reason)
+}
+
+// OrderTracker keeps the state of orders from a stream of updates, from
+// polling GetOrder or GetOrdersByPath or from streaming, and reports every
+// change of state. Updates may come in repeatedly; only changes produce
+// transitions. An OrderTracker is safe for concurrent use.
+type OrderTracker struct {
+	// OnTransition, if set, is called with every transition, in order,
+	// before Update returns. Calls for concurrent updates are serialized
+	// in the order the updates were applied, so OnTransition must not call
+	// Update itself.
+	OnTransition func(OrderTransition)
+
+	mu      sync.Mutex
+	orders  map[int64]*trackedOrder
+	deliver sync.Mutex // held while transitions are passed to OnTransition
+}
+
+type trackedOrder struct {
+	state  OrderState
+	filled float64
+}
+
+// NewOrderTracker returns an OrderTracker without any orders.
+func NewOrderTracker() *OrderTracker {
+	return &OrderTracker{orders: make(map[int64]*trackedOrder)}
+}
+
+// State returns the current state of the order orderID, OrderStateNew if it
+// was never seen.
+func (t *OrderTracker) State(orderID int64) OrderState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if o := t.orders[orderID]; o != nil {
+		return o.state
+	}
+	return OrderStateNew
+}
+
+// Update ingests the latest version of order and of its child orders and
+// returns the transitions they cause. Invalid updates are skipped and the
+// first of their errors is returned along with the valid transitions.
+// Updates without an order id or with an unknown status are ignored.
+func (t *OrderTracker) Update(order *Order) ([]OrderTransition, error) {
+	t.mu.Lock()
+	var transitions []OrderTransition
+	var firstErr error
+	now := time.Now()
+	var visit func(*Order)
+	visit = func(o *Order) {
+		if tr, err := t.update(o, now); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+		} else if tr != nil {
+			transitions = append(transitions, *tr)
+		}
+		for _, child := range o.ChildOrderStrategies {
+			visit(child)
+		}
+	}
+	visit(order)
+	if t.OnTransition == nil || len(transitions) == 0 {
+		t.mu.Unlock()
+		return transitions, firstErr
+	}
+	// Taking deliver before letting the next update in keeps the calls in
+	// the order of the updates.
+	t.deliver.Lock()
+	t.mu.Unlock()
+	defer t.deliver.Unlock()
+	for _, tr := range transitions {
+		t.OnTransition(tr)
+	}
+	return transitions, firstErr
+}
+
+func (t *OrderTracker) update(order *Order, now time.Time) (*OrderTransition, error) {
+	to, ok := orderState(order)
+	if order.OrderID == 0 || !ok {
+		return nil, nil
+	}
+	cur := t.orders[order.OrderID]
+	if cur == nil {
+		cur = &trackedOrder{state: OrderStateNew}
+	}
+
+	invalid := func(reason string) error {
+		return &InvalidTransitionError{OrderID: order.OrderID, From: cur.state, To: to, Reason: reason}
+	}
+	if order.FilledQuantity < cur.filled {
+		return nil, invalid(fmt.Sprintf("filled quantity went down from %v to %v", cur.filled, order.FilledQuantity))
+	}
+	if to == cur.state && (to != OrderStatePartiallyFilled || order.FilledQuantity == cur.filled) {
+		return nil, nil
+	}
+	if !canTransition(cur.state, to) {
+		return nil, invalid("not allowed")
+	}
+
+	tr := &OrderTransition{
+		OrderID: order.OrderID,
+		From:    cur.state,
+		To:      to,
+		Time:    now,
+		Order:   order,
+		Filled:  order.FilledQuantity - cur.filled,
+	}
+	cur.state = to
+	cur.filled = order.FilledQuantity
+	t.orders[order.OrderID] = cur
+	return tr, nil
+}
+
+func canTransition(from, to OrderState) bool {
+	for _, s := range orderTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package tdameritrade
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestOrderTrackerConcurrentUpdates(t *testing.T) {
+	tracker := NewOrderTracker()
+	var delivered []float64
+	tracker.OnTransition = func(tr OrderTransition) {
+		delivered = append(delivered, tr.Order.FilledQuantity)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Updates arriving after a larger fill are rejected; the others
+			// must reach OnTransition in the order they were applied.
+			for filled := 1; filled <= 1000; filled++ {
+				tracker.Update(&Order{OrderID: 1, Status: OrderStatusWorking, Quantity: 1000, FilledQuantity: float64(filled)})
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(delivered) == 0 {
+		t.Fatal("no transitions delivered")
+	}
+	for i := 1; i < len(delivered); i++ {
+		if delivered[i] <= delivered[i-1] {
+			t.Fatalf("delivered filled quantities %v, want them increasing", delivered)
+		}
+	}
+}
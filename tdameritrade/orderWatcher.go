@@ -0,0 +1,56 @@
+package tdameritrade
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrOrderNotFilled is returned by WaitForFill when the order was canceled,
+// rejected, expired or replaced instead of filling.
+var ErrOrderNotFilled = errors.New("order ended without filling")
+
+const (
+	// minFillPollInterval and maxFillPollInterval bound the backoff of
+	// WaitForFill.
+	minFillPollInterval = 500 * time.Millisecond
+	maxFillPollInterval = 10 * time.Second
+)
+
+// WaitForFill polls the order orderID until it reaches a final status and
+// returns it. The order is returned with ErrOrderNotFilled if it ended in any
+// final status but FILLED. Polling starts every 500ms and backs off to every
+// 10s while the status stays the same. If onStatus is non-nil it is called
+// with the order every time its status changes, starting with the first
+// status seen. WaitForFill returns early with ctx's error when ctx is done.
+func (s *OrdersService) WaitForFill(ctx context.Context, accountID string, orderID int64, onStatus func(*Order)) (*Order, error) {
+	interval := minFillPollInterval
+	var status OrderStatus
+	for {
+		order, _, err := s.GetOrder(ctx, accountID, orderID)
+		if err != nil {
+			return nil, err
+		}
+
+		if order.Status != status {
+			status = order.Status
+			interval = minFillPollInterval
+			if onStatus != nil {
+				onStatus(order)
+			}
+		} else if interval *= 2; interval > maxFillPollInterval {
+			interval = maxFillPollInterval
+		}
+
+		if order.Status.terminal() {
+			if order.Status != OrderStatusFilled {
+				return order, ErrOrderNotFilled
+			}
+			return order, nil
+		}
+
+		if err := sleepCtx(ctx, interval); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package tdameritrade
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+
+	"github.com/google/go-querystring/query"
+)
+
+// OrdersService handles communication with the order related methods of
+// the TDAmeritrade API.
+//
+// TDAmeritrade API docs: https://developer.tdameritrade.com/account-access/apis
+type OrdersService struct {
+	client *Client
+
+	// Concurrency bounds the number of requests CancelAllOrders keeps in
+	// flight. Defaults to 4.
+	Concurrency int
+}
+
+const defaultOrdersConcurrency = 4
+
+// OrderLeg is one instrument traded by an order.
+type OrderLeg struct {
+	OrderLegType   string      `json:"orderLegType,omitempty"`
+	LegID          int64       `json:"legId,omitempty"`
+	Instrument     Instrument  `json:"instrument"`
+	Instruction    Instruction `json:"instruction"`
+	PositionEffect string      `json:"positionEffect,omitempty"`
+	Quantity       float64     `json:"quantity"`
+	QuantityType   string      `json:"quantityType,omitempty"`
+}
+
+// OrderLegCollection is the former name of OrderLeg.
+//
+// Deprecated: use OrderLeg.
+type OrderLegCollection = OrderLeg
+
+type CancelTime struct {
+	Date        string `json:"date,omitempty"`
+	ShortFormat bool   `json:"shortFormat,omitempty"`
+}
+
+type Order struct {
+	Session                  Session                  `json:"session,omitempty"`
+	Duration                 Duration                 `json:"duration,omitempty"`
+	OrderType                OrderType                `json:"orderType,omitempty"`
+	CancelTime               *CancelTime              `json:"cancelTime,omitempty"`
+	ComplexOrderStrategyType ComplexOrderStrategyType `json:"complexOrderStrategyType,omitempty"`
+	Quantity                 float64                  `json:"quantity,omitempty"`
+	FilledQuantity           float64                  `json:"filledQuantity,omitempty"`
+	RemainingQuantity        float64                  `json:"remainingQuantity,omitempty"`
+	RequestedDestination     string                   `json:"requestedDestination,omitempty"`
+	DestinationLinkName      string                   `json:"destinationLinkName,omitempty"`
+	ReleaseTime              string                   `json:"releaseTime,omitempty"`
+	StopPrice                float64                  `json:"stopPrice,omitempty"`
+	StopPriceLinkBasis       string                   `json:"stopPriceLinkBasis,omitempty"`
+	StopPriceLinkType        string                   `json:"stopPriceLinkType,omitempty"`
+	StopPriceOffset          float64                  `json:"stopPriceOffset,omitempty"`
+	StopType                 string                   `json:"stopType,omitempty"`
+	PriceLinkBasis           string                   `json:"priceLinkBasis,omitempty"`
+	PriceLinkType            string                   `json:"priceLinkType,omitempty"`
+	Price                    float64                  `json:"price,omitempty"`
+	TaxLotMethod             string                   `json:"taxLotMethod,omitempty"`
+	OrderLegCollection       []*OrderLeg              `json:"orderLegCollection,omitempty"`
+	ActivationPrice          float64                  `json:"activationPrice,omitempty"`
+	SpecialInstruction       string                   `json:"specialInstruction,omitempty"`
+	OrderStrategyType        OrderStrategyType        `json:"orderStrategyType"`
+	OrderID                  int64                    `json:"orderId,omitempty"`
+	Cancelable               bool                     `json:"cancelable,omitempty"`
+	Editable                 bool                     `json:"editable,omitempty"`
+	Status                   OrderStatus              `json:"status,omitempty"`
+	EnteredTime              string                   `json:"enteredTime,omitempty"`
+	CloseTime                string                   `json:"closeTime,omitempty"`
+	Tag                      string                   `json:"tag,omitempty"`
+	AccountID                int64                    `json:"accountId,omitempty"`
+	OrderActivityCollection  []*OrderActivity         `json:"orderActivityCollection,omitempty"`
+	ReplacingOrderCollection []*Order                 `json:"replacingOrderCollection,omitempty"`
+	ChildOrderStrategies     []*Order                 `json:"childOrderStrategies,omitempty"`
+	StatusDescription        string                   `json:"statusDescription,omitempty"`
+}
+
+type ExecutionLeg struct {
+	LegID             int64   `json:"legId"`
+	Quantity          float64 `json:"quantity"`
+	MismarkedQuantity float64 `json:"mismarkedQuantity"`
+	Price             float64 `json:"price"`
+	Time              string  `json:"time"`
+}
+
+// OrderActivity is an execution or other action on an order.
+type OrderActivity struct {
+	ActivityType           string          `json:"activityType"` //"'EXECUTION' or 'ORDER_ACTION'",
+	ActivityID             int64           `json:"activityId"`
+	ExecutionType          string          `json:"executionType"` //"'FILL'",
+	Quantity               float64         `json:"quantity"`
+	OrderRemainingQuantity float64         `json:"orderRemainingQuantity"`
+	ExecutionLegs          []*ExecutionLeg `json:"executionLegs"`
+}
+
+// Execution is the former name of OrderActivity.
+//
+// Deprecated: use OrderActivity.
+type Execution = OrderActivity
+
+// OrdersOptions filter the orders returned by GetOrdersByPath and
+// GetOrdersByQuery. Orders entered up to 60 days ago can be requested.
+type OrdersOptions struct {
+	// AccountID restricts GetOrdersByQuery to one account; it returns the
+	// orders of all linked accounts otherwise. GetOrdersByPath ignores it.
+	AccountID       string      `url:"accountId,omitempty"`
+	MaxResults      int         `url:"maxResults,omitempty"`
+	FromEnteredTime Date        `url:"fromEnteredTime,omitempty"`
+	ToEnteredTime   Date        `url:"toEnteredTime,omitempty"`
+	Status          OrderStatus `url:"status,omitempty"`
+}
+
+func (opts *OrdersOptions) validate() error {
+	if opts.Status != "" && !opts.Status.valid() {
+		return fmt.Errorf("invalid status %q", opts.Status)
+	}
+	if opts.MaxResults < 0 {
+		return fmt.Errorf("maxResults must not be negative")
+	}
+	if !opts.FromEnteredTime.IsZero() && !opts.ToEnteredTime.IsZero() && opts.ToEnteredTime.Before(opts.FromEnteredTime.Time) {
+		return fmt.Errorf("toEnteredTime is before fromEnteredTime")
+	}
+	return nil
+}
+
+// PlacedOrder identifies an order created by PlaceOrder or ReplaceOrder.
+type PlacedOrder struct {
+	OrderID   int64
+	AccountID string
+}
+
+// PlaceOrder places order in the account accountID and returns the id of the
+// new order. If the API refuses the order the error is an
+// OrderRejectedError.
+// TDAmeritrade API Docs: https://developer.tdameritrade.com/account-access/apis/post/accounts/%7BaccountId%7D/orders-0
+func (s *OrdersService) PlaceOrder(ctx context.Context, accountID string, order *Order) (*PlacedOrder, *Response, error) {
+	if order == nil {
+		return nil, nil, fmt.Errorf("order is nil")
+	}
+	u := fmt.Sprintf("accounts/%s/orders", accountID)
+	return s.submit(ctx, "POST", u, accountID, order)
+}
+
+// ReplaceOrder cancels the order orderID and places order in its place. It
+// returns the id of the new order.
+// TDAmeritrade API Docs: https://developer.tdameritrade.com/account-access/apis/put/accounts/%7BaccountId%7D/orders/%7BorderId%7D-0
+func (s *OrdersService) ReplaceOrder(ctx context.Context, accountID string, orderID int64, order *Order) (*PlacedOrder, *Response, error) {
+	if order == nil {
+		return nil, nil, fmt.Errorf("order is nil")
+	}
+	u := fmt.Sprintf("accounts/%s/orders/%d", accountID, orderID)
+	return s.submit(ctx, "PUT", u, accountID, order)
+}
+
+// submit sends order and reads the id of the created order from the
+// Location header of the response. A rejected order fails with an
+// OrderRejectedError.
+func (s *OrdersService) submit(ctx context.Context, method, u, accountID string, order *Order) (*PlacedOrder, *Response, error) {
+	req, err := s.client.NewRequest(method, u, order)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := s.client.Do(ctx, req, nil)
+	if err != nil {
+		return nil, resp, orderError(err)
+	}
+	orderID, err := resp.OrderID()
+	if err != nil {
+		return nil, resp, err
+	}
+	return &PlacedOrder{OrderID: orderID, AccountID: accountID}, resp, nil
+}
+
+// CancelOrder cancels the order orderID.
+// TDAmeritrade API Docs: https://developer.tdameritrade.com/account-access/apis/delete/accounts/%7BaccountId%7D/orders/%7BorderId%7D-0
+func (s *OrdersService) CancelOrder(ctx context.Context, accountID string, orderID int64) (*Response, error) {
+	u := fmt.Sprintf("accounts/%s/orders/%d", accountID, orderID)
+	req, err := s.client.NewRequest("DELETE", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.Do(ctx, req, nil)
+}
+
+// GetOrder returns the order orderID.
+// TDAmeritrade API Docs: https://developer.tdameritrade.com/account-access/apis/get/accounts/%7BaccountId%7D/orders/%7BorderId%7D-0
+func (s *OrdersService) GetOrder(ctx context.Context, accountID string, orderID int64) (*Order, *Response, error) {
+	return Get[Order](ctx, s.client, fmt.Sprintf("accounts/%s/orders/%d", accountID, orderID))
+}
+
+// GetOrderRaw is GetOrder returning the undecoded response body.
+func (s *OrdersService) GetOrderRaw(ctx context.Context, accountID string, orderID int64) (json.RawMessage, *Response, error) {
+	return s.client.getRaw(ctx, fmt.Sprintf("accounts/%s/orders/%d", accountID, orderID))
+}
+
+// GetOrdersByPath returns the orders of the account accountID matching opts,
+// which may be nil.
+// TDAmeritrade API Docs: https://developer.tdameritrade.com/account-access/apis/get/accounts/%7BaccountId%7D/orders-0
+func (s *OrdersService) GetOrdersByPath(ctx context.Context, accountID string, opts *OrdersOptions) ([]*Order, *Response, error) {
+	u := fmt.Sprintf("accounts/%s/orders", accountID)
+	if opts != nil {
+		o := *opts
+		o.AccountID = ""
+		opts = &o
+	}
+	return s.listOrders(ctx, u, opts)
+}
+
+// GetOrdersByQuery returns the orders matching opts, which may be nil, across
+// all linked accounts unless opts.AccountID is set.
+// TDAmeritrade API Docs: https://developer.tdameritrade.com/account-access/apis/get/orders-0
+func (s *OrdersService) GetOrdersByQuery(ctx context.Context, opts *OrdersOptions) ([]*Order, *Response, error) {
+	return s.listOrders(ctx, "orders", opts)
+}
+
+func (s *OrdersService) listOrders(ctx context.Context, u string, opts *OrdersOptions) ([]*Order, *Response, error) {
+	if opts != nil {
+		if err := opts.validate(); err != nil {
+			return nil, nil, err
+		}
+		q, err := query.Values(opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(q) > 0 {
+			u = fmt.Sprintf("%s?%s", u, q.Encode())
+		}
+	}
+
+	req, err := s.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var orders []*Order
+	resp, err := s.client.Do(ctx, req, &orders)
+	if err != nil {
+		return nil, resp, err
+	}
+	return orders, resp, nil
+}
+
+// OrderID returns the id of the order created by a request placing an order,
+// which the API only reports in the Location header.
+func (r *Response) OrderID() (int64, error) {
+	location := r.Header.Get("Location")
+	if location == "" {
+		return 0, fmt.Errorf("response has no Location header")
+	}
+	id, err := strconv.ParseInt(path.Base(location), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("no order id in Location %q", location)
+	}
+	return id, nil
+}
@@ -0,0 +1,689 @@
+package tdameritrade
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PaperTrader simulates the order endpoints of the API. Once a client has a
+// PaperTrader, set with Client.SetPaperTrader, every order request it makes
+// (placing, replacing, canceling and reading orders, including through
+// AccountsService) is answered by the PaperTrader and never reaches the API.
+// Accounts requested with their orders, through AccountOptions.Orders, come
+// from the API with their orderStrategies swapped for the paper orders.
+// Quotes, price history and all other requests still reach the API, so a
+// strategy can be tried on live data without changing its code.
+//
+// Orders are filled from live quotes whenever orders are read: buys at the
+// ask, sells at the bid. Market orders fill right away, limit orders once
+// the market reaches their price, stop orders once the last price crosses
+// their stop, and stop limit orders once both hold. Multi-leg orders fill
+// once the net price of their legs reaches their net debit or credit. The
+// children of a TRIGGER order start working when it fills, and the first
+// child of an OCO order to fill cancels the others. Trailing stop, market on
+// close and exercise orders keep working until canceled.
+type PaperTrader struct {
+	mu     sync.Mutex
+	nextID int64
+	orders map[string][]*Order // by account id, oldest first
+}
+
+// NewPaperTrader returns a PaperTrader without any orders.
+func NewPaperTrader() *PaperTrader {
+	return &PaperTrader{nextID: 1, orders: make(map[string][]*Order)}
+}
+
+// SetPaperTrader routes the client's order requests to p. Pass nil to send
+// them to the API again.
+func (c *Client) SetPaperTrader(p *PaperTrader) {
+	c.paperMu.Lock()
+	c.paper = p
+	c.paperMu.Unlock()
+}
+
+func (c *Client) paperTrader() *PaperTrader {
+	c.paperMu.Lock()
+	defer c.paperMu.Unlock()
+	return c.paper
+}
+
+// Orders returns a copy of the orders placed in the account accountID,
+// oldest first.
+func (p *PaperTrader) Orders(accountID string) []*Order {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	orders := make([]*Order, len(p.orders[accountID]))
+	for i, order := range p.orders[accountID] {
+		orders[i] = cloneOrder(order)
+	}
+	return orders
+}
+
+// SetOrderStatus sets the status of the order or child order orderID of the
+// account accountID, e.g. to FILLED to fill it at once or to REJECTED. A
+// filled order has all its quantity filled and starts its children, an
+// order ended otherwise cancels them.
+func (p *PaperTrader) SetOrderStatus(accountID string, orderID int64, status OrderStatus) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	order := p.find(accountID, orderID)
+	if order == nil {
+		return fmt.Errorf("no order %d in account %s", orderID, accountID)
+	}
+
+	now := time.Now()
+	switch {
+	case status == OrderStatusFilled:
+		order.Status = OrderStatusFilled
+		order.FilledQuantity = order.Quantity
+		order.RemainingQuantity = 0
+		order.CloseTime = now.Format(timestampLayout)
+		order.Cancelable = false
+		order.Editable = false
+		for _, child := range order.ChildOrderStrategies {
+			activateOrder(child)
+		}
+		// Without quotes nothing fills, but the OCO orders whose child
+		// this was cancel the other children.
+		matchOrders(p.orders[accountID], Quotes{}, now)
+	case status.terminal():
+		closeOrder(order, status, now)
+	default:
+		order.Status = status
+	}
+	return nil
+}
+
+// serve answers req if it is an order request, or a request of accounts
+// with their orders, reporting whether it did.
+func (p *PaperTrader) serve(ctx context.Context, c *Client, req *http.Request) (*http.Response, bool, error) {
+	parts, ok := apiPath(c, req)
+	if !ok {
+		return nil, false, nil
+	}
+	if len(parts) <= 2 && parts[0] == "accounts" && req.Method == "GET" {
+		if !selectsOrders(req) {
+			return nil, false, nil
+		}
+		var accountID string
+		if len(parts) == 2 {
+			accountID = parts[1]
+		}
+		resp, err := p.projectOrders(ctx, c, req, accountID)
+		return resp, true, err
+	}
+	return p.serveOrders(ctx, c, req, parts)
+}
+
+// Handler returns an http.Handler answering the order requests of the API
+// from p, for fake API servers such as the one of package tdameritradetest.
+// The handler is served at the BaseURL of c, which fetches the quotes the
+// orders fill at. Other requests are answered with 404 Not Found.
+func (p *PaperTrader) Handler(c *Client) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var resp *http.Response
+		var err error
+		handled := false
+		if parts, ok := apiPath(c, r); ok {
+			resp, handled, err = p.serveOrders(r.Context(), c, r, parts)
+		}
+		switch {
+		case err != nil:
+			resp = paperResponse(r, http.StatusInternalServerError, errorBody(err.Error()), nil)
+		case !handled:
+			resp = paperResponse(r, http.StatusNotFound, errorBody("Not found"), nil)
+		}
+		defer resp.Body.Close()
+		for k, v := range resp.Header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+	})
+}
+
+// apiPath returns the segments of the path of req below the BaseURL of c.
+func apiPath(c *Client, req *http.Request) ([]string, bool) {
+	if !strings.HasPrefix(req.URL.Path, c.BaseURL.Path) {
+		return nil, false
+	}
+	return strings.Split(strings.TrimPrefix(req.URL.Path, c.BaseURL.Path), "/"), true
+}
+
+// serveOrders answers req if it is an order request, reporting whether it
+// did. parts is the path of req, as returned by apiPath.
+func (p *PaperTrader) serveOrders(ctx context.Context, c *Client, req *http.Request, parts []string) (*http.Response, bool, error) {
+	var accountID string
+	var orderID int64
+	switch {
+	case len(parts) == 1 && parts[0] == "orders" && req.Method == "GET":
+		accountID = req.URL.Query().Get("accountId")
+	case len(parts) == 3 && parts[0] == "accounts" && parts[2] == "orders":
+		accountID = parts[1]
+	case len(parts) == 4 && parts[0] == "accounts" && parts[2] == "orders":
+		accountID = parts[1]
+		id, err := strconv.ParseInt(parts[3], 10, 64)
+		if err != nil {
+			return paperResponse(req, http.StatusBadRequest, errorBody("invalid order id"), nil), true, nil
+		}
+		orderID = id
+	default:
+		return nil, false, nil
+	}
+
+	if req.Method == "GET" {
+		if err := p.fill(ctx, c, accountID); err != nil {
+			return nil, true, err
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	switch {
+	case req.Method == "GET" && orderID == 0:
+		return paperResponse(req, http.StatusOK, p.list(accountID, req), nil), true, nil
+	case req.Method == "POST" && orderID == 0:
+		return p.place(req, c.BaseURL, accountID, now), true, nil
+	}
+
+	order := p.find(accountID, orderID)
+	if order == nil {
+		return paperResponse(req, http.StatusNotFound, errorBody("Order not found"), nil), true, nil
+	}
+	switch req.Method {
+	case "GET":
+		return paperResponse(req, http.StatusOK, order, nil), true, nil
+	case "DELETE":
+		if !openOrder(order) {
+			return paperResponse(req, http.StatusBadRequest, errorBody("Order is not cancelable"), nil), true, nil
+		}
+		closeOrder(order, OrderStatusCanceled, now)
+		return paperResponse(req, http.StatusOK, nil, nil), true, nil
+	case "PUT":
+		if !openOrder(order) {
+			return paperResponse(req, http.StatusBadRequest, errorBody("Order is not replaceable"), nil), true, nil
+		}
+		resp := p.place(req, c.BaseURL, accountID, now)
+		if resp.StatusCode == http.StatusCreated {
+			closeOrder(order, OrderStatusReplaced, now)
+		}
+		return resp, true, nil
+	}
+	return paperResponse(req, http.StatusMethodNotAllowed, errorBody("Method not allowed"), nil), true, nil
+}
+
+// selectsOrders reports whether the account request req selects the orders
+// of the accounts.
+func selectsOrders(req *http.Request) bool {
+	for _, field := range strings.Split(req.URL.Query().Get("fields"), ",") {
+		if field == "orders" {
+			return true
+		}
+	}
+	return false
+}
+
+// projectOrders sends the account request req to the API and answers with
+// its response, the orders of each account in it swapped for the paper
+// orders, newest first.
+func (p *PaperTrader) projectOrders(ctx context.Context, c *Client, req *http.Request, accountID string) (*http.Response, error) {
+	resp, err := c.client.Do(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.fill(ctx, c, accountID); err != nil {
+		return nil, err
+	}
+
+	// GET accounts answers with a list of accounts, GET accounts/{id} with
+	// one.
+	var accounts []map[string]json.RawMessage
+	single := false
+	if err := json.Unmarshal(body, &accounts); err != nil {
+		var account map[string]json.RawMessage
+		if err := json.Unmarshal(body, &account); err != nil {
+			return nil, fmt.Errorf("paper trading accounts: %v", err)
+		}
+		accounts, single = []map[string]json.RawMessage{account}, true
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, account := range accounts {
+		var securities map[string]json.RawMessage
+		if err := json.Unmarshal(account["securitiesAccount"], &securities); err != nil {
+			return nil, fmt.Errorf("paper trading accounts: %v", err)
+		}
+		var id string
+		json.Unmarshal(securities["accountId"], &id)
+		orders := []*Order{}
+		if id != "" {
+			orders = p.list(id, req)
+		}
+		if securities["orderStrategies"], err = json.Marshal(orders); err != nil {
+			return nil, err
+		}
+		if account["securitiesAccount"], err = json.Marshal(securities); err != nil {
+			return nil, err
+		}
+	}
+	if single {
+		return paperResponse(req, http.StatusOK, accounts[0], nil), nil
+	}
+	return paperResponse(req, http.StatusOK, accounts, nil), nil
+}
+
+// list returns the orders matching the status and maxResults parameters of
+// req, newest first.
+func (p *PaperTrader) list(accountID string, req *http.Request) []*Order {
+	var accounts []string
+	if accountID != "" {
+		accounts = []string{accountID}
+	} else {
+		for id := range p.orders {
+			accounts = append(accounts, id)
+		}
+	}
+
+	q := req.URL.Query()
+	status := OrderStatus(q.Get("status"))
+	max, _ := strconv.Atoi(q.Get("maxResults"))
+	orders := []*Order{}
+	for _, id := range accounts {
+		for _, order := range p.orders[id] {
+			if status == "" || order.Status == status {
+				orders = append(orders, order)
+			}
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].OrderID > orders[j].OrderID })
+	if max > 0 && len(orders) > max {
+		orders = orders[:max]
+	}
+	return orders
+}
+
+// place records the order in the body of req and answers with its location
+// relative to base.
+func (p *PaperTrader) place(req *http.Request, base *url.URL, accountID string, now time.Time) *http.Response {
+	order := new(Order)
+	if req.Body == nil {
+		return paperResponse(req, http.StatusBadRequest, errorBody("order is missing"), nil)
+	}
+	if err := json.NewDecoder(req.Body).Decode(order); err != nil {
+		return paperResponse(req, http.StatusBadRequest, errorBody(err.Error()), nil)
+	}
+	if err := checkPaperOrder(order); err != nil {
+		return paperResponse(req, http.StatusBadRequest, errorBody(err.Error()), nil)
+	}
+
+	account, _ := strconv.ParseInt(accountID, 10, 64)
+	p.enter(order, account, now)
+	activateOrder(order)
+	p.orders[accountID] = append(p.orders[accountID], order)
+
+	location := *base
+	location.Path = path.Join(base.Path, "accounts", accountID, "orders", strconv.FormatInt(order.OrderID, 10))
+	header := http.Header{}
+	header.Set("Location", location.String())
+	return paperResponse(req, http.StatusCreated, nil, header)
+}
+
+// checkPaperOrder rejects the orders the API would not take.
+func checkPaperOrder(order *Order) error {
+	if order.OrderStrategyType == OrderStrategyTypeOCO {
+		if len(order.ChildOrderStrategies) < 2 {
+			return fmt.Errorf("OCO order needs at least 2 child orders")
+		}
+	} else if len(order.OrderLegCollection) == 0 {
+		return fmt.Errorf("order has no orderLegCollection")
+	}
+	for _, leg := range order.OrderLegCollection {
+		if leg.Quantity <= 0 || leg.Instrument.Symbol() == "" {
+			return fmt.Errorf("order leg needs a symbol and a positive quantity")
+		}
+	}
+	for _, child := range order.ChildOrderStrategies {
+		if err := checkPaperOrder(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enter gives order and its children ids and makes them wait for their
+// parent.
+func (p *PaperTrader) enter(order *Order, accountID int64, now time.Time) {
+	order.OrderID = p.nextID
+	p.nextID++
+	order.AccountID = accountID
+	order.EnteredTime = now.Format(timestampLayout)
+	order.Cancelable = false
+	order.Editable = false
+	if order.OrderStrategyType != OrderStrategyTypeOCO {
+		order.Status = OrderStatusAwaitingParentOrder
+		if order.Quantity == 0 && len(order.OrderLegCollection) > 0 {
+			order.Quantity = order.OrderLegCollection[0].Quantity
+		}
+		order.FilledQuantity = 0
+		order.RemainingQuantity = order.Quantity
+	}
+	for i, leg := range order.OrderLegCollection {
+		leg.LegID = int64(i + 1)
+	}
+	for _, child := range order.ChildOrderStrategies {
+		p.enter(child, accountID, now)
+	}
+}
+
+// fill fills the working orders of the account accountID, or of all
+// accounts, whose prices the current quotes reach.
+func (p *PaperTrader) fill(ctx context.Context, c *Client, accountID string) error {
+	p.mu.Lock()
+	seen := make(map[string]bool)
+	var symbols []string
+	p.walk(accountID, func(order *Order) {
+		if order.Status != OrderStatusWorking {
+			return
+		}
+		for _, leg := range order.OrderLegCollection {
+			if symbol := leg.Instrument.Symbol(); !seen[symbol] {
+				seen[symbol] = true
+				symbols = append(symbols, symbol)
+			}
+		}
+	})
+	p.mu.Unlock()
+	if len(symbols) == 0 {
+		return nil
+	}
+
+	quotes, _, err := c.Quotes.GetQuotes(ctx, strings.Join(symbols, ","))
+	if err != nil {
+		return fmt.Errorf("paper trading quotes: %v", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	for id, orders := range p.orders {
+		if accountID == "" || id == accountID {
+			matchOrders(orders, *quotes, now)
+		}
+	}
+	return nil
+}
+
+// walk calls fn with every order and child order of the account accountID,
+// or of all accounts.
+func (p *PaperTrader) walk(accountID string, fn func(*Order)) {
+	var visit func([]*Order)
+	visit = func(orders []*Order) {
+		for _, order := range orders {
+			fn(order)
+			visit(order.ChildOrderStrategies)
+		}
+	}
+	for id, orders := range p.orders {
+		if accountID == "" || id == accountID {
+			visit(orders)
+		}
+	}
+}
+
+// find returns the order or child order orderID of the account accountID.
+func (p *PaperTrader) find(accountID string, orderID int64) *Order {
+	var found *Order
+	p.walk(accountID, func(order *Order) {
+		if order.OrderID == orderID {
+			found = order
+		}
+	})
+	return found
+}
+
+// matchOrders fills the working orders among orders, and their children,
+// whose prices quotes reach.
+func matchOrders(orders []*Order, quotes Quotes, now time.Time) {
+	for _, order := range orders {
+		if order.OrderStrategyType == OrderStrategyTypeOCO {
+			matchOrders(order.ChildOrderStrategies, quotes, now)
+			for _, child := range order.ChildOrderStrategies {
+				if child.Status != OrderStatusFilled {
+					continue
+				}
+				for _, other := range order.ChildOrderStrategies {
+					if other != child && openOrder(other) {
+						closeOrder(other, OrderStatusCanceled, now)
+					}
+				}
+				break
+			}
+			continue
+		}
+
+		if order.Status == OrderStatusWorking {
+			if prices, ok := fillPrices(order, quotes); ok {
+				fillOrder(order, prices, now)
+				for _, child := range order.ChildOrderStrategies {
+					activateOrder(child)
+				}
+			}
+		}
+		if order.Status == OrderStatusFilled {
+			matchOrders(order.ChildOrderStrategies, quotes, now)
+		}
+	}
+}
+
+// fillPrices returns the price each leg of order fills at, if quotes reach
+// the order's price.
+func fillPrices(order *Order, quotes Quotes) ([]float64, bool) {
+	prices := make([]float64, len(order.OrderLegCollection))
+	var net, last float64
+	for i, leg := range order.OrderLegCollection {
+		bid, ask, l, ok := quotePrices(quotes[leg.Instrument.Symbol()])
+		if !ok {
+			return nil, false
+		}
+		ratio := leg.Quantity / order.Quantity
+		if leg.Instruction.isBuy() {
+			prices[i] = ask
+			net += ask * ratio
+		} else {
+			prices[i] = bid
+			net -= bid * ratio
+		}
+		last = l
+	}
+	buy := order.OrderLegCollection[0].Instruction.isBuy()
+
+	// limit reports whether the single leg fills at price or better.
+	limit := func(price float64) bool {
+		if buy {
+			return prices[0] <= price
+		}
+		return prices[0] >= price
+	}
+	stopped := func() bool {
+		if buy {
+			return last >= order.StopPrice
+		}
+		return last <= order.StopPrice
+	}
+
+	switch order.OrderType {
+	case OrderTypeMarket:
+		return prices, true
+	case OrderTypeLimit:
+		return prices, limit(order.Price)
+	case OrderTypeStop:
+		return prices, stopped()
+	case OrderTypeStopLimit:
+		return prices, stopped() && limit(order.Price)
+	case OrderTypeNetDebit:
+		return prices, net <= order.Price
+	case OrderTypeNetCredit:
+		return prices, -net >= order.Price
+	case OrderTypeNetZero:
+		return prices, net <= 0
+	}
+	return nil, false
+}
+
+// quotePrices returns the bid, ask and last price of q, standing in the last
+// price for a missing bid or ask.
+func quotePrices(q *Quote) (bid, ask, last float64, ok bool) {
+	if q == nil {
+		return 0, 0, 0, false
+	}
+	switch d := q.Data.(type) {
+	case *EquityQuote:
+		bid, ask, last = d.BidPrice, d.AskPrice, d.LastPrice
+	case *OptionQuote:
+		bid, ask, last = d.BidPrice, d.AskPrice, d.LastPrice
+	case *FutureQuote:
+		bid, ask, last = d.BidPriceInDouble, d.AskPriceInDouble, d.LastPriceInDouble
+	case *ForexQuote:
+		bid, ask, last = d.BidPriceInDouble, d.AskPriceInDouble, d.LastPriceInDouble
+	default:
+		last, _ = q.price()
+	}
+	if last <= 0 {
+		last = (bid + ask) / 2
+	}
+	if bid <= 0 {
+		bid = last
+	}
+	if ask <= 0 {
+		ask = last
+	}
+	return bid, ask, last, last > 0
+}
+
+// fillOrder fills order completely, its legs at prices.
+func fillOrder(order *Order, prices []float64, now time.Time) {
+	legs := make([]*ExecutionLeg, len(order.OrderLegCollection))
+	for i, leg := range order.OrderLegCollection {
+		legs[i] = &ExecutionLeg{
+			LegID:    leg.LegID,
+			Quantity: leg.Quantity,
+			Price:    math.Round(prices[i]*10000) / 10000,
+			Time:     now.Format(timestampLayout),
+		}
+	}
+	order.OrderActivityCollection = append(order.OrderActivityCollection, &OrderActivity{
+		ActivityType:  "EXECUTION",
+		ExecutionType: "FILL",
+		Quantity:      order.RemainingQuantity,
+		ExecutionLegs: legs,
+	})
+	order.Status = OrderStatusFilled
+	order.FilledQuantity = order.Quantity
+	order.RemainingQuantity = 0
+	order.CloseTime = now.Format(timestampLayout)
+	order.Cancelable = false
+	order.Editable = false
+}
+
+// activateOrder makes order, or every child of an OCO order, start working.
+func activateOrder(order *Order) {
+	if order.OrderStrategyType == OrderStrategyTypeOCO {
+		for _, child := range order.ChildOrderStrategies {
+			activateOrder(child)
+		}
+		return
+	}
+	order.Status = OrderStatusWorking
+	order.Cancelable = true
+	order.Editable = true
+}
+
+// openOrder reports whether order, or any child of an OCO order, can still
+// fill.
+func openOrder(order *Order) bool {
+	if order.OrderStrategyType == OrderStrategyTypeOCO {
+		for _, child := range order.ChildOrderStrategies {
+			if openOrder(child) {
+				return true
+			}
+		}
+		return false
+	}
+	return !order.Status.terminal()
+}
+
+// closeOrder ends order and its open children with status.
+func closeOrder(order *Order, status OrderStatus, now time.Time) {
+	if order.OrderStrategyType != OrderStrategyTypeOCO && !order.Status.terminal() {
+		order.Status = status
+		order.CloseTime = now.Format(timestampLayout)
+		order.Cancelable = false
+		order.Editable = false
+	}
+	for _, child := range order.ChildOrderStrategies {
+		closeOrder(child, OrderStatusCanceled, now)
+	}
+}
+
+// cloneOrder returns a deep copy of order.
+func cloneOrder(order *Order) *Order {
+	b, err := json.Marshal(order)
+	if err != nil {
+		panic(fmt.Sprintf("tdameritrade: copying paper order: %v", err))
+	}
+	cp := new(Order)
+	if err := json.Unmarshal(b, cp); err != nil {
+		panic(fmt.Sprintf("tdameritrade: copying paper order: %v", err))
+	}
+	return cp
+}
+
+func errorBody(msg string) map[string]string {
+	return map[string]string{"error": msg}
+}
+
+// paperResponse returns the response to req with status code status and
+// body, if not nil, encoded as JSON.
+func paperResponse(req *http.Request, status int, body interface{}, header http.Header) *http.Response {
+	var b []byte
+	if body != nil {
+		b, _ = json.Marshal(body)
+	}
+	if header == nil {
+		header = http.Header{}
+	}
+	if body != nil {
+		header.Set("Content-Type", "application/json")
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(b)),
+		ContentLength: int64(len(b)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,82 @@
+package tdameritrade
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPaperTraderAccountOrders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/accounts/123":
+			w.Write([]byte(`{"securitiesAccount": {"type": "CASH", "accountId": "123",` +
+				`"positions": [{"longQuantity": 5, "instrument": {"assetType": "EQUITY", "symbol": "MSFT"}}],` +
+				`"orderStrategies": [{"orderId": 999, "status": "WORKING", "orderType": "LIMIT", "price": 1.5}]}}`))
+		case "/accounts":
+			w.Write([]byte(`[{"securitiesAccount": {"type": "CASH", "accountId": "123",` +
+				`"orderStrategies": [{"orderId": 999, "status": "WORKING"}]}},` +
+				`{"securitiesAccount": {"type": "MARGIN", "accountId": "456",` +
+				`"orderStrategies": [{"orderId": 998, "status": "WORKING"}]}}]`))
+		case "/marketdata/quotes":
+			w.Write([]byte(`{"AAPL": {"assetType": "EQUITY", "symbol": "AAPL", "bidPrice": 181.9, "askPrice": 182.1, "lastPrice": 182}}`))
+		default:
+			t.Errorf("request to the API: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	client, err := NewClient(srv.Client(), WithBaseURL(srv.URL+"/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetPaperTrader(NewPaperTrader())
+	ctx := context.Background()
+
+	market, _ := NewEquityOrder().Buy("AAPL", 10).Market().Build()
+	limit, _ := NewEquityOrder().Buy("AAPL", 10).Limit(100).Build()
+	for _, order := range []*Order{market, limit} {
+		if _, _, err := client.Orders.PlaceOrder(ctx, "123", order); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	account, _, err := client.Account.GetAccount(ctx, "123", &AccountOptions{Orders: true, Position: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders := account.SecuritiesAccount.OrderStrategies
+	if len(orders) != 2 || orders[0].Status != OrderStatusWorking || orders[1].Status != OrderStatusFilled {
+		t.Errorf("orders = %+v, want the working limit order and the filled market order", orders)
+	}
+	if positions := account.SecuritiesAccount.Positions; len(positions) != 1 || positions[0].LongQuantity != 5 {
+		t.Errorf("positions = %+v, want the live MSFT position", positions)
+	}
+
+	accounts, _, err := client.Account.GetAccounts(ctx, &AccountOptions{Orders: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*accounts) != 2 {
+		t.Fatalf("got %d accounts, want 2", len(*accounts))
+	}
+	for _, a := range *accounts {
+		want := 0
+		if a.SecuritiesAccount.AccountID == "123" {
+			want = 2
+		}
+		if got := a.SecuritiesAccount.OrderStrategies; len(got) != want {
+			t.Errorf("account %s orders = %+v, want %d paper orders", a.SecuritiesAccount.AccountID, got, want)
+		}
+	}
+
+	// Without the orders the account comes from the API untouched.
+	account, _, err = client.Account.GetAccount(ctx, "123", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if orders := account.SecuritiesAccount.OrderStrategies; len(orders) != 1 || orders[0].OrderID != 999 {
+		t.Errorf("orders = %+v, want the live order", orders)
+	}
+}
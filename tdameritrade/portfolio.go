@@ -0,0 +1,181 @@
+package tdameritrade
+
+import (
+	"context"
+	"sort"
+)
+
+// Portfolio is the positions of an account valued at current prices.
+type Portfolio struct {
+	Positions []PortfolioPosition
+
+	// Totals over all positions.
+	CostBasis    float64
+	MarketValue  float64
+	UnrealizedPL float64
+
+	// Net market value by asset type (EQUITY, OPTION, ...) and by sector.
+	// Positions without a sector are counted under "".
+	ByAssetType map[string]float64
+	BySector    map[string]float64
+
+	// Unquoted lists the symbols that could not be quoted. Their positions
+	// are valued at the market value reported with the account instead.
+	Unquoted []string
+}
+
+// PortfolioPosition is a position valued at its current price. Quantities and
+// amounts of short positions are negative, so a short position gains when
+// its market value rises towards zero.
+type PortfolioPosition struct {
+	Symbol     string
+	AssetType  string
+	Sector     string
+	Quantity   float64 // long minus short quantity
+	Multiplier float64 // shares per contract, 1 for anything but options
+	Price      float64 // current price per share or contract
+
+	CostBasis           float64 // average price * quantity * multiplier
+	MarketValue         float64 // price * quantity * multiplier
+	UnrealizedPL        float64 // MarketValue - CostBasis
+	UnrealizedPLPercent float64 // UnrealizedPL as a percentage of |CostBasis|
+}
+
+// Portfolio fetches the positions of accountID together with quotes for all
+// of them and values them with NewPortfolio. sector maps a symbol to its
+// sector for the sector exposure and may be nil; the API itself does not
+// report sectors.
+func (s *AccountsService) Portfolio(ctx context.Context, accountID string, sector func(symbol string) string) (*Portfolio, error) {
+	account, _, err := s.GetAccount(ctx, accountID, &AccountOptions{Position: true})
+	if err != nil {
+		return nil, err
+	}
+
+	var symbols []string
+	seen := make(map[string]bool)
+	for _, p := range account.Positions {
+		if symbol := p.Instrument.Symbol(); symbol != "" && !seen[symbol] {
+			seen[symbol] = true
+			symbols = append(symbols, symbol)
+		}
+	}
+
+	quotes := Quotes{}
+	if len(symbols) > 0 {
+		result, _, err := s.client.Quotes.GetQuotesResult(ctx, symbols)
+		if err != nil {
+			return nil, err
+		}
+		quotes = result.Quotes
+	}
+	return NewPortfolio(account.Positions, quotes, sector), nil
+}
+
+// NewPortfolio values positions at the prices in quotes, keyed by symbol.
+// The mark is used where the quote has one and the last price otherwise.
+// sector may be nil.
+func NewPortfolio(positions []*Position, quotes Quotes, sector func(symbol string) string) *Portfolio {
+	p := &Portfolio{
+		ByAssetType: make(map[string]float64),
+		BySector:    make(map[string]float64),
+	}
+	unquoted := make(map[string]bool)
+
+	for _, pos := range positions {
+		pp := PortfolioPosition{
+			Symbol:     pos.Instrument.Symbol(),
+			AssetType:  pos.Instrument.AssetType,
+			Quantity:   pos.LongQuantity - pos.ShortQuantity,
+			Multiplier: 1,
+		}
+		if option, ok := pos.Instrument.Data.(*OptionA); ok {
+			pp.Multiplier = option.OptionMultiplier
+			if pp.Multiplier == 0 {
+				pp.Multiplier = 100
+			}
+		}
+		if sector != nil {
+			pp.Sector = sector(pp.Symbol)
+		}
+
+		if price, ok := quotes[pp.Symbol].price(); ok {
+			pp.Price = price
+			pp.MarketValue = price * pp.Quantity * pp.Multiplier
+		} else {
+			unquoted[pp.Symbol] = true
+			pp.MarketValue = pos.MarketValue
+			if pp.Quantity != 0 {
+				pp.Price = pos.MarketValue / (pp.Quantity * pp.Multiplier)
+			}
+		}
+		pp.CostBasis = pos.AveragePrice * pp.Quantity * pp.Multiplier
+		pp.UnrealizedPL = pp.MarketValue - pp.CostBasis
+		if pp.CostBasis != 0 {
+			basis := pp.CostBasis
+			if basis < 0 {
+				basis = -basis
+			}
+			pp.UnrealizedPLPercent = pp.UnrealizedPL / basis * 100
+		}
+
+		p.Positions = append(p.Positions, pp)
+		p.CostBasis += pp.CostBasis
+		p.MarketValue += pp.MarketValue
+		p.UnrealizedPL += pp.UnrealizedPL
+		p.ByAssetType[pp.AssetType] += pp.MarketValue
+		p.BySector[pp.Sector] += pp.MarketValue
+	}
+
+	for symbol := range unquoted {
+		p.Unquoted = append(p.Unquoted, symbol)
+	}
+	sort.Strings(p.Unquoted)
+	return p
+}
+
+// price returns the current price of the quoted instrument: its mark if it
+// has one and its last price otherwise.
+func (q *Quote) price() (float64, bool) {
+	if q == nil {
+		return 0, false
+	}
+	var mark, last float64
+	switch d := q.Data.(type) {
+	case *EquityQuote:
+		mark, last = d.Mark, d.LastPrice
+	case *OptionQuote:
+		mark, last = d.Mark, d.LastPrice
+	case *IndexQuote:
+		last = d.LastPrice
+	case *MutualFundQuote:
+		last = d.NAV
+		if last == 0 {
+			last = d.ClosePrice
+		}
+	case *FutureQuote:
+		mark, last = d.Mark, d.LastPriceInDouble
+	case *ForexQuote:
+		mark, last = d.Mark, d.LastPriceInDouble
+	}
+	if mark > 0 {
+		return mark, true
+	}
+	return last, last > 0
+}
+
+// Symbol returns the symbol of the instrument, or "" if it has none.
+func (i *Instrument) Symbol() string {
+	switch d := i.Data.(type) {
+	case *Equity:
+		return d.Symbol
+	case *OptionA:
+		return d.Symbol
+	case *MutualFund:
+		return d.Symbol
+	case *CashEquivalent:
+		return d.Symbol
+	case *FixedIncome:
+		return d.Symbol
+	}
+	return ""
+}
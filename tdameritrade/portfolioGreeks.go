@@ -0,0 +1,170 @@
+package tdameritrade
+
+import (
+	"context"
+	"math"
+	"sort"
+)
+
+// BetaBenchmark is the symbol PortfolioGreeks beta-weights deltas against.
+const BetaBenchmark = "SPY"
+
+// NetGreeks are the greeks of one or more positions, scaled by quantity and
+// contract multiplier: Delta and Gamma in shares of the underlying, Theta in
+// dollars per day and Vega in dollars per volatility point. Shares of stock
+// count as a delta of one each.
+type NetGreeks struct {
+	Delta float64
+	Gamma float64
+	Theta float64
+	Vega  float64
+}
+
+func (g *NetGreeks) add(o NetGreeks) {
+	g.Delta += o.Delta
+	g.Gamma += o.Gamma
+	g.Theta += o.Theta
+	g.Vega += o.Vega
+}
+
+// PortfolioGreeks are the net greeks of the stock and option positions of an
+// account.
+type PortfolioGreeks struct {
+	ByUnderlying map[string]NetGreeks
+	Total        NetGreeks
+
+	// BetaWeightedDelta is the total delta expressed in shares of
+	// BetaBenchmark: each underlying's delta times its beta times its price,
+	// divided by the benchmark price.
+	BetaWeightedDelta float64
+
+	// Unpriced lists the symbols left out because their quote was missing
+	// or had no greeks, and those whose underlying could not be priced for
+	// beta weighting.
+	Unpriced []string
+}
+
+// PortfolioGreeks fetches the positions of accountID and quotes for their
+// options, underlyings and BetaBenchmark, and nets them with
+// NewPortfolioGreeks. betas maps underlyings to their beta against the
+// benchmark; underlyings without one are weighted with a beta of 1.
+func (s *AccountsService) PortfolioGreeks(ctx context.Context, accountID string, betas map[string]float64) (*PortfolioGreeks, error) {
+	account, _, err := s.GetAccount(ctx, accountID, &AccountOptions{Position: true})
+	if err != nil {
+		return nil, err
+	}
+
+	symbols := []string{BetaBenchmark}
+	seen := map[string]bool{BetaBenchmark: true}
+	add := func(symbol string) {
+		if symbol != "" && !seen[symbol] {
+			seen[symbol] = true
+			symbols = append(symbols, symbol)
+		}
+	}
+	for _, p := range account.Positions {
+		symbol := p.Instrument.Symbol()
+		add(symbol)
+		if option := parsePositionOption(p); option != nil {
+			add(option.Underlying)
+		}
+	}
+
+	result, _, err := s.client.Quotes.GetQuotesResult(ctx, symbols)
+	if err != nil {
+		return nil, err
+	}
+	return NewPortfolioGreeks(account.Positions, result.Quotes, betas), nil
+}
+
+// NewPortfolioGreeks nets the greeks of the equity and option positions using
+// the greeks and prices in quotes, keyed by symbol. Option positions are
+// grouped under the underlying parsed from their symbol. Beta weighting needs
+// quotes of the underlyings and of BetaBenchmark; betas may be nil.
+func NewPortfolioGreeks(positions []*Position, quotes Quotes, betas map[string]float64) *PortfolioGreeks {
+	pg := &PortfolioGreeks{ByUnderlying: make(map[string]NetGreeks)}
+	unpriced := make(map[string]bool)
+
+	for _, p := range positions {
+		quantity := p.LongQuantity - p.ShortQuantity
+		symbol := p.Instrument.Symbol()
+
+		var underlying string
+		var g NetGreeks
+		switch p.Instrument.AssetType {
+		case "EQUITY":
+			underlying = symbol
+			g.Delta = quantity
+		case "OPTION":
+			option := parsePositionOption(p)
+			var q *OptionQuote
+			if quote := quotes[symbol]; quote != nil {
+				q, _ = quote.Option()
+			}
+			if option == nil || q == nil || math.IsNaN(q.Delta) {
+				unpriced[symbol] = true
+				continue
+			}
+			multiplier := q.Multiplier
+			if multiplier == 0 {
+				multiplier = 100
+			}
+			scale := quantity * multiplier
+			underlying = option.Underlying
+			g = NetGreeks{
+				Delta: q.Delta * scale,
+				Gamma: finite(q.Gamma) * scale,
+				Theta: finite(q.Theta) * scale,
+				Vega:  finite(q.Vega) * scale,
+			}
+		default:
+			continue
+		}
+
+		net := pg.ByUnderlying[underlying]
+		net.add(g)
+		pg.ByUnderlying[underlying] = net
+		pg.Total.add(g)
+	}
+
+	benchmark, hasBenchmark := quotes[BetaBenchmark].price()
+	for underlying, g := range pg.ByUnderlying {
+		price, ok := quotes[underlying].price()
+		if !ok || !hasBenchmark {
+			unpriced[underlying] = true
+			continue
+		}
+		beta, ok := betas[underlying]
+		if !ok {
+			beta = 1
+		}
+		pg.BetaWeightedDelta += g.Delta * beta * price / benchmark
+	}
+
+	for symbol := range unpriced {
+		pg.Unpriced = append(pg.Unpriced, symbol)
+	}
+	sort.Strings(pg.Unpriced)
+	return pg
+}
+
+// parsePositionOption returns the parsed symbol of an option position, which
+// the API reports in its own format, or nil for other positions.
+func parsePositionOption(p *Position) *OptionSymbol {
+	if p.Instrument.AssetType != "OPTION" {
+		return nil
+	}
+	option, err := parseOptionOrOCC(p.Instrument.Symbol())
+	if err != nil {
+		return nil
+	}
+	return option
+}
+
+// finite returns f, or 0 if f is NaN.
+func finite(f float64) float64 {
+	if math.IsNaN(f) {
+		return 0
+	}
+	return f
+}
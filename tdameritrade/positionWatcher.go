@@ -0,0 +1,138 @@
+package tdameritrade
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// PositionEventType is the kind of change reported by a PositionEvent.
+type PositionEventType int
+
+const (
+	PositionOpened PositionEventType = iota
+	PositionClosed
+	PositionSizeChanged
+	PositionAveragePriceChanged
+)
+
+func (t PositionEventType) String() string {
+	switch t {
+	case PositionOpened:
+		return "opened"
+	case PositionClosed:
+		return "closed"
+	case PositionSizeChanged:
+		return "size changed"
+	case PositionAveragePriceChanged:
+		return "average price changed"
+	}
+	return "unknown"
+}
+
+// PositionEvent is a change of a position between two snapshots. Previous is
+// nil for opened positions and Current is nil for closed ones.
+type PositionEvent struct {
+	Type     PositionEventType
+	Time     time.Time
+	Symbol   string
+	Previous *Position
+	Current  *Position
+
+	// Err is set, and the other fields are zero, if fetching a snapshot
+	// failed.
+	Err error
+}
+
+// WatchPositions fetches the positions of accountID every interval, plus a
+// small random jitter, and delivers the changes since the previous snapshot
+// on the returned channel until ctx is done, at which point the channel is
+// closed. The first snapshot only sets the baseline and produces no events.
+// Intervals below 500ms are raised to 500ms. A failed request is delivered
+// as an event with Err set and the next snapshot is compared with the last
+// successful one.
+func (s *AccountsService) WatchPositions(ctx context.Context, accountID string, interval time.Duration) <-chan PositionEvent {
+	ch := make(chan PositionEvent)
+	go func() {
+		defer close(ch)
+		var prev []*Position
+		first := true
+		poll(ctx, interval, func() bool {
+			account, _, err := s.GetAccount(ctx, accountID, &AccountOptions{Position: true})
+			if ctx.Err() != nil {
+				return false
+			}
+
+			var events []PositionEvent
+			switch {
+			case err != nil:
+				events = []PositionEvent{{Time: time.Now(), Err: err}}
+			case first:
+				prev, first = account.Positions, false
+			default:
+				events = DiffPositions(prev, account.Positions)
+				now := time.Now()
+				for i := range events {
+					events[i].Time = now
+				}
+				prev = account.Positions
+			}
+
+			for _, e := range events {
+				select {
+				case ch <- e:
+				case <-ctx.Done():
+					return false
+				}
+			}
+			return true
+		})
+	}()
+	return ch
+}
+
+// DiffPositions returns the changes from the positions prev to cur, matched
+// by symbol and ordered by symbol. A position whose size and average price
+// both changed produces two events.
+func DiffPositions(prev, cur []*Position) []PositionEvent {
+	before := positionsBySymbol(prev)
+	after := positionsBySymbol(cur)
+
+	symbols := make([]string, 0, len(before)+len(after))
+	for symbol := range before {
+		symbols = append(symbols, symbol)
+	}
+	for symbol := range after {
+		if _, ok := before[symbol]; !ok {
+			symbols = append(symbols, symbol)
+		}
+	}
+	sort.Strings(symbols)
+
+	var events []PositionEvent
+	for _, symbol := range symbols {
+		p, c := before[symbol], after[symbol]
+		switch {
+		case p == nil:
+			events = append(events, PositionEvent{Type: PositionOpened, Symbol: symbol, Current: c})
+		case c == nil:
+			events = append(events, PositionEvent{Type: PositionClosed, Symbol: symbol, Previous: p})
+		default:
+			if p.LongQuantity != c.LongQuantity || p.ShortQuantity != c.ShortQuantity {
+				events = append(events, PositionEvent{Type: PositionSizeChanged, Symbol: symbol, Previous: p, Current: c})
+			}
+			if p.AveragePrice != c.AveragePrice {
+				events = append(events, PositionEvent{Type: PositionAveragePriceChanged, Symbol: symbol, Previous: p, Current: c})
+			}
+		}
+	}
+	return events
+}
+
+func positionsBySymbol(positions []*Position) map[string]*Position {
+	m := make(map[string]*Position, len(positions))
+	for _, p := range positions {
+		m[p.Instrument.Symbol()] = p
+	}
+	return m
+}
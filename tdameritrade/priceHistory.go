@@ -2,19 +2,46 @@ package tdameritrade
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"github.com/google/go-querystring/query"
+	"sort"
 	"time"
+
+	"github.com/google/go-querystring/query"
 )
 
 var (
 	validPeriodTypes    = []string{"day", "month", "year", "ytd"}
 	validFrequencyTypes = []string{"minute", "daily", "weekly", "monthly"}
+
+	// validPeriods lists the periods accepted for each periodType.
+	validPeriods = map[string][]int{
+		"day":   {1, 2, 3, 4, 5, 10},
+		"month": {1, 2, 3, 6},
+		"year":  {1, 2, 3, 5, 10, 15, 20},
+		"ytd":   {1},
+	}
+
+	// validPeriodFrequencyTypes lists the frequencyTypes accepted for each
+	// periodType; the first one is the API default.
+	validPeriodFrequencyTypes = map[string][]string{
+		"day":   {"minute"},
+		"month": {"weekly", "daily"},
+		"year":  {"monthly", "daily", "weekly"},
+		"ytd":   {"weekly", "daily"},
+	}
+
+	// validFrequencies lists the frequencies accepted for each frequencyType.
+	validFrequencies = map[string][]int{
+		"minute":  {1, 5, 10, 15, 30},
+		"daily":   {1},
+		"weekly":  {1},
+		"monthly": {1},
+	}
 )
 
 const (
-	defaultPeriodType    = "day"
-	defaultFrequencyType = "minute"
+	defaultPeriodType = "day"
 )
 
 // PriceHistoryService handles communication with the marketdata related methods of
@@ -23,8 +50,33 @@ const (
 // TDAmeritrade API docs: https://developer.tdameritrade.com/price-history/apis
 type PriceHistoryService struct {
 	client *Client
+
+	// RequestInterval is the minimum time between two requests made by
+	// DownloadHistory and BulkPriceHistory. Defaults to 500ms, which stays
+	// within the 120 requests per minute allowed by the API. It is ignored
+	// if the client has a Limiter, which paces them instead.
+	RequestInterval time.Duration
+
+	// Concurrency bounds the number of requests BulkPriceHistory keeps in
+	// flight. Defaults to 4.
+	Concurrency int
+
+	// MaxRetries is the number of times BulkPriceHistory retries a symbol
+	// the API rejected with 429 Too Many Requests. Defaults to 3. It is
+	// ignored if the client has a RetryPolicy, which retries them instead.
+	MaxRetries int
 }
 
+const (
+	defaultHistoryRequestInterval = 500 * time.Millisecond
+	defaultHistoryConcurrency     = 4
+	defaultHistoryMaxRetries      = 3
+
+	// minuteHistoryWindow is the longest range of minute bars requested at
+	// once; longer requests are silently truncated by the API.
+	minuteHistoryWindow = 10 * 24 * time.Hour
+)
+
 // PriceHistoryOptions is parsed and translated to query options in the https request
 type PriceHistoryOptions struct {
 	PeriodType            string    `url:"periodType,omitempty"`
@@ -39,31 +91,86 @@ type PriceHistoryOptions struct {
 }
 
 type PriceHistory struct {
-	Candles []struct {
-		Close    float64 `json:"close"`
-		Datetime int     `json:"datetime"`
-		High     float64 `json:"high"`
-		Low      float64 `json:"low"`
-		Open     float64 `json:"open"`
-		Volume   float64 `json:"volume"`
-	} `json:"candles"`
-	Empty  bool   `json:"empty"`
-	Symbol string `json:"symbol"`
-}
-
-// PriceHistory get the price history for a symbol
+	Candles []Candle `json:"candles"`
+	Empty   bool     `json:"empty"`
+	Symbol  string   `json:"symbol"`
+}
+
+// Candle is a single OHLCV bar. Datetime is the start of the bar in
+// America/New_York time; the API sends it as epoch milliseconds.
+type Candle struct {
+	Datetime time.Time
+	Open     float64
+	High     float64
+	Low      float64
+	Close    float64
+	Volume   float64
+}
+
+type candleJSON struct {
+	Close    float64 `json:"close"`
+	Datetime int64   `json:"datetime"`
+	High     float64 `json:"high"`
+	Low      float64 `json:"low"`
+	Open     float64 `json:"open"`
+	Volume   float64 `json:"volume"`
+}
+
+func (c *Candle) UnmarshalJSON(b []byte) error {
+	var raw candleJSON
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	c.Datetime = fromMillis(raw.Datetime)
+	c.Open = raw.Open
+	c.High = raw.High
+	c.Low = raw.Low
+	c.Close = raw.Close
+	c.Volume = raw.Volume
+	return nil
+}
+
+func (c Candle) MarshalJSON() ([]byte, error) {
+	return json.Marshal(candleJSON{
+		Close:    c.Close,
+		Datetime: toMillis(c.Datetime),
+		High:     c.High,
+		Low:      c.Low,
+		Open:     c.Open,
+		Volume:   c.Volume,
+	})
+}
+
+// Range returns the high-low range of the bar.
+func (c Candle) Range() float64 {
+	return c.High - c.Low
+}
+
+// TypicalPrice returns (high + low + close) / 3.
+func (c Candle) TypicalPrice() float64 {
+	return (c.High + c.Low + c.Close) / 3
+}
+
+// IsGapUp reports whether the bar opened entirely above prev, i.e. its low
+// is above prev's high.
+func (c Candle) IsGapUp(prev Candle) bool {
+	return c.Low > prev.High
+}
+
+// IsGapDown reports whether the bar traded entirely below prev, i.e. its
+// high is below prev's low.
+func (c Candle) IsGapDown(prev Candle) bool {
+	return c.High < prev.Low
+}
+
+// PriceHistory get the price history for a symbol. opts are checked against
+// the periodType/period/frequencyType/frequency combinations the API accepts
+// before the request is sent, since it answers invalid ones with no candles.
 // TDAmeritrade API Docs: https://developer.tdameritrade.com/price-history/apis/get/marketdata/%7Bsymbol%7D/pricehistory
 func (s *PriceHistoryService) PriceHistory(ctx context.Context, symbol string, opts *PriceHistoryOptions) (*PriceHistory, *Response, error) {
-	u := fmt.Sprintf("marketdata/%s/pricehistory", symbol)
-	if opts != nil {
-		if err := opts.validate(); err != nil {
-			return nil, nil, err
-		}
-		q, err := query.Values(opts)
-		if err != nil {
-			return nil, nil, err
-		}
-		u = fmt.Sprintf("%s?%s", u, q.Encode())
+	u, err := priceHistoryURL(symbol, opts)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	req, err := s.client.NewRequest("GET", u, nil)
@@ -82,6 +189,107 @@ func (s *PriceHistoryService) PriceHistory(ctx context.Context, symbol string, o
 	return priceHistory, resp, nil
 }
 
+// PriceHistoryRaw is PriceHistory returning the undecoded response body.
+func (s *PriceHistoryService) PriceHistoryRaw(ctx context.Context, symbol string, opts *PriceHistoryOptions) (json.RawMessage, *Response, error) {
+	u, err := priceHistoryURL(symbol, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.client.getRaw(ctx, u)
+}
+
+func priceHistoryURL(symbol string, opts *PriceHistoryOptions) (string, error) {
+	u := fmt.Sprintf("marketdata/%s/pricehistory", symbol)
+	if opts != nil {
+		if err := opts.validate(); err != nil {
+			return "", err
+		}
+		q, err := query.Values(opts)
+		if err != nil {
+			return "", err
+		}
+		u = fmt.Sprintf("%s?%s", u, q.Encode())
+	}
+	return u, nil
+}
+
+// DownloadHistory returns the bars of symbol between from and to, with a
+// bar size of frequency minutes (1, 5, 10, 15 or 30). The range is split into
+// windows the API serves in full, which are requested one after another at
+// most once per RequestInterval, or as the client's Limiter allows if it has
+// one, then stitched into one series ordered by
+// time without duplicates. Windows without any data, such as weekends, are
+// skipped.
+func (s *PriceHistoryService) DownloadHistory(ctx context.Context, symbol string, from, to time.Time, frequency int) ([]Candle, error) {
+	if !containsInt(frequency, validFrequencies["minute"]) {
+		return nil, fmt.Errorf("invalid frequency %d, must have the value of one of the following %v", frequency, validFrequencies["minute"])
+	}
+	if !to.After(from) {
+		return nil, fmt.Errorf("to must be after from")
+	}
+	interval := s.RequestInterval
+	if interval <= 0 {
+		interval = defaultHistoryRequestInterval
+	}
+	limiter := s.client.helperLimiter(interval)
+
+	byTime := make(map[int64]Candle)
+	for start := from; start.Before(to); start = start.Add(minuteHistoryWindow) {
+		end := start.Add(minuteHistoryWindow - time.Millisecond)
+		if end.After(to) {
+			end = to
+		}
+
+		if err := waitLimiter(ctx, limiter); err != nil {
+			return nil, err
+		}
+
+		history, _, err := s.PriceHistory(ctx, symbol, &PriceHistoryOptions{
+			PeriodType:    "day",
+			FrequencyType: "minute",
+			Frequency:     frequency,
+			StartDate:     start,
+			EndDate:       end,
+		})
+		if err != nil {
+			if history != nil && history.Empty {
+				continue
+			}
+			return nil, fmt.Errorf("fetching %s to %s: %v", start.Format(time.RFC3339), end.Format(time.RFC3339), err)
+		}
+		for _, c := range history.Candles {
+			if c.Datetime.Before(from) || c.Datetime.After(to) {
+				continue
+			}
+			byTime[toMillis(c.Datetime)] = c
+		}
+	}
+
+	candles := make([]Candle, 0, len(byTime))
+	for _, c := range byTime {
+		candles = append(candles, c)
+	}
+	sort.Slice(candles, func(i, j int) bool {
+		return candles[i].Datetime.Before(candles[j].Datetime)
+	})
+	return candles, nil
+}
+
+// sleepCtx waits for d or until ctx is done, whichever comes first.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (opts *PriceHistoryOptions) validate() error {
 	if opts.PeriodType != "" {
 		if !contains(opts.PeriodType, validPeriodTypes) {
@@ -91,22 +299,42 @@ func (opts *PriceHistoryOptions) validate() error {
 		opts.PeriodType = defaultPeriodType
 	}
 
+	if opts.Period != 0 && !containsInt(opts.Period, validPeriods[opts.PeriodType]) {
+		return fmt.Errorf("invalid period %d for periodType %s, must have the value of one of the following %v", opts.Period, opts.PeriodType, validPeriods[opts.PeriodType])
+	}
+
+	frequencyTypes := validPeriodFrequencyTypes[opts.PeriodType]
 	if opts.FrequencyType != "" {
 		if !contains(opts.FrequencyType, validFrequencyTypes) {
 			return fmt.Errorf("invalid frequencyType, must have the value of one of the following %v", validFrequencyTypes)
 		}
+		if !contains(opts.FrequencyType, frequencyTypes) {
+			return fmt.Errorf("invalid frequencyType %s for periodType %s, must have the value of one of the following %v", opts.FrequencyType, opts.PeriodType, frequencyTypes)
+		}
 	} else {
-		opts.PeriodType = defaultFrequencyType
+		opts.FrequencyType = frequencyTypes[0]
+	}
+
+	if opts.Frequency != 0 && !containsInt(opts.Frequency, validFrequencies[opts.FrequencyType]) {
+		return fmt.Errorf("invalid frequency %d for frequencyType %s, must have the value of one of the following %v", opts.Frequency, opts.FrequencyType, validFrequencies[opts.FrequencyType])
 	}
 
 	if !opts.EndDate.IsZero() && opts.EndDateUnix == nil {
-		end := opts.EndDate.Unix() * 1000
+		end := toMillis(opts.EndDate)
 		opts.EndDateUnix = &end
 	}
 	if !opts.StartDate.IsZero() && opts.StartDateUnix == nil {
-		start := opts.StartDate.Unix() * 1000
+		start := toMillis(opts.StartDate)
 		opts.StartDateUnix = &start
 	}
+	if opts.StartDateUnix != nil && opts.EndDateUnix != nil {
+		if opts.Period != 0 {
+			return fmt.Errorf("period cannot be combined with both startDate and endDate")
+		}
+		if *opts.EndDateUnix < *opts.StartDateUnix {
+			return fmt.Errorf("endDate is before startDate")
+		}
+	}
 
 	return nil
 }
@@ -119,3 +347,12 @@ func contains(s string, lst []string) bool {
 	}
 	return false
 }
+
+func containsInt(n int, lst []int) bool {
+	for _, e := range lst {
+		if e == n {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,239 @@
+// Package prommetrics collects the metrics of tdameritrade clients and
+// serves them in the Prometheus text format, without depending on the
+// Prometheus client library:
+//
+//	m := prommetrics.New("")
+//	client, err := tdameritrade.NewClient(httpClient, tdameritrade.WithMetrics(m))
+//	http.Handle("/metrics", m)
+//
+// It exports
+//
+//	tdameritrade_requests_total{method, endpoint, status}
+//	tdameritrade_request_duration_seconds{method, endpoint}
+//	tdameritrade_rate_limit_wait_seconds
+//	tdameritrade_streamer_messages_total{service}
+//
+// where status is 0 for requests that got no response.
+package prommetrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/glacialspring/go-tdameritrade/tdameritrade"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the histogram buckets,
+// those of the Prometheus client library.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Collector is a tdameritrade.Metrics keeping the measurements of the
+// clients using it, which may be several, and an http.Handler serving them.
+// It is safe for concurrent use.
+type Collector struct {
+	namespace string
+	buckets   []float64
+
+	mu       sync.Mutex
+	requests map[requestKey]uint64
+	latency  map[endpointKey]*histogram
+	waits    *histogram
+	messages map[string]uint64
+}
+
+var _ tdameritrade.Metrics = (*Collector)(nil)
+
+type endpointKey struct {
+	method, endpoint string
+}
+
+type requestKey struct {
+	endpointKey
+	status int
+}
+
+type histogram struct {
+	counts []uint64 // by bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// New returns a collector whose metric names start with namespace, or
+// tdameritrade if namespace is empty.
+func New(namespace string) *Collector {
+	if namespace == "" {
+		namespace = "tdameritrade"
+	}
+	c := &Collector{
+		namespace: namespace,
+		buckets:   DefaultBuckets,
+		requests:  make(map[requestKey]uint64),
+		latency:   make(map[endpointKey]*histogram),
+		messages:  make(map[string]uint64),
+	}
+	c.waits = c.newHistogram()
+	return c
+}
+
+func (c *Collector) newHistogram() *histogram {
+	return &histogram{counts: make([]uint64, len(c.buckets))}
+}
+
+func (c *Collector) observe(h *histogram, d time.Duration) {
+	secs := d.Seconds()
+	if i := sort.SearchFloat64s(c.buckets, secs); i < len(c.buckets) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += secs
+}
+
+// ObserveRequest counts a request and records its latency.
+func (c *Collector) ObserveRequest(method, endpoint string, status int, d time.Duration) {
+	key := endpointKey{method: method, endpoint: endpoint}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests[requestKey{endpointKey: key, status: status}]++
+	h, ok := c.latency[key]
+	if !ok {
+		h = c.newHistogram()
+		c.latency[key] = h
+	}
+	c.observe(h, d)
+}
+
+// ObserveRateLimitWait records a wait for the rate limiter.
+func (c *Collector) ObserveRateLimitWait(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.observe(c.waits, d)
+}
+
+// IncStreamerMessages counts a streamer message.
+func (c *Collector) IncStreamerMessages(service string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages[service]++
+}
+
+// ServeHTTP serves the metrics to a Prometheus scrape.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.WriteTo(w)
+}
+
+// WriteTo writes the metrics to w in the Prometheus text format.
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	c.mu.Lock()
+	c.write(cw)
+	c.mu.Unlock()
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+func (c *Collector) write(w *countingWriter) {
+	name := c.namespace + "_requests_total"
+	w.printf("# HELP %s Requests sent to the API, by endpoint and status.\n# TYPE %s counter\n", name, name)
+	requests := make([]requestKey, 0, len(c.requests))
+	for k := range c.requests {
+		requests = append(requests, k)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		a, b := requests[i], requests[j]
+		if a.endpointKey != b.endpointKey {
+			return lessEndpoint(a.endpointKey, b.endpointKey)
+		}
+		return a.status < b.status
+	})
+	for _, k := range requests {
+		w.printf("%s{method=%s,endpoint=%s,status=\"%d\"} %d\n", name, quote(k.method), quote(k.endpoint), k.status, c.requests[k])
+	}
+
+	name = c.namespace + "_request_duration_seconds"
+	w.printf("# HELP %s Latency of the requests to the API.\n# TYPE %s histogram\n", name, name)
+	endpoints := make([]endpointKey, 0, len(c.latency))
+	for k := range c.latency {
+		endpoints = append(endpoints, k)
+	}
+	sort.Slice(endpoints, func(i, j int) bool { return lessEndpoint(endpoints[i], endpoints[j]) })
+	for _, k := range endpoints {
+		c.writeHistogram(w, name, fmt.Sprintf("method=%s,endpoint=%s", quote(k.method), quote(k.endpoint)), c.latency[k])
+	}
+
+	name = c.namespace + "_rate_limit_wait_seconds"
+	w.printf("# HELP %s Time requests waited for the rate limiter.\n# TYPE %s histogram\n", name, name)
+	c.writeHistogram(w, name, "", c.waits)
+
+	name = c.namespace + "_streamer_messages_total"
+	w.printf("# HELP %s Messages received from the streamer, by service.\n# TYPE %s counter\n", name, name)
+	services := make([]string, 0, len(c.messages))
+	for s := range c.messages {
+		services = append(services, s)
+	}
+	sort.Strings(services)
+	for _, s := range services {
+		w.printf("%s{service=%s} %d\n", name, quote(s), c.messages[s])
+	}
+}
+
+func (c *Collector) writeHistogram(w *countingWriter, name, labels string, h *histogram) {
+	sep := ""
+	if labels != "" {
+		sep = ","
+	}
+	var cumulative uint64
+	for i, le := range c.buckets {
+		cumulative += h.counts[i]
+		w.printf("%s_bucket{%s%sle=\"%s\"} %d\n", name, labels, sep, formatFloat(le), cumulative)
+	}
+	w.printf("%s_bucket{%s%sle=\"+Inf\"} %d\n", name, labels, sep, h.count)
+	if labels != "" {
+		labels = "{" + labels + "}"
+	}
+	w.printf("%s_sum%s %s\n", name, labels, formatFloat(h.sum))
+	w.printf("%s_count%s %d\n", name, labels, h.count)
+}
+
+func lessEndpoint(a, b endpointKey) bool {
+	if a.endpoint != b.endpoint {
+		return a.endpoint < b.endpoint
+	}
+	return a.method < b.method
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// labelEscaper escapes label values as the text format requires.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func quote(s string) string {
+	return `"` + labelEscaper.Replace(s) + `"`
+}
+
+// countingWriter counts the bytes written and keeps the first error.
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (w *countingWriter) printf(format string, args ...interface{}) {
+	if w.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(w.w, format, args...)
+	w.n += int64(n)
+	w.err = err
+}
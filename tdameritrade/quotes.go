@@ -2,9 +2,18 @@ package tdameritrade
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand"
+	"net/url"
+	"strings"
+	"time"
 )
 
+// ErrSymbolNotFound is reported for symbols the API returned no data for.
+var ErrSymbolNotFound = errors.New("symbol not found")
+
 // QuotesService handles communication with the marketdata related methods of
 // the TDAmeritrade API.
 //
@@ -15,13 +24,32 @@ type QuotesService struct {
 
 type Quotes map[string]*Quote
 
+type _Quote Quote
+
+// Quote is the quote of a single symbol. The fields shared by every asset
+// class are decoded directly, the rest ends up in Data, whose type depends
+// on AssetMainType:
+//
+//	EQUITY      *EquityQuote (also used for ETFs)
+//	OPTION      *OptionQuote
+//	INDEX       *IndexQuote
+//	MUTUAL_FUND *MutualFundQuote
+//	FUTURE      *FutureQuote
+//	FOREX       *ForexQuote
+//
+// Quotes of other asset classes keep their undecoded json.RawMessage.
 type Quote struct {
-	AssetType                          string  `json:"assetType"`
-	AssetMainType                      string  `json:"assetMainType"`
-	Cusip                              string  `json:"cusip"`
-	AssetSubType                       string  `json:"assetSubType"`
-	Symbol                             string  `json:"symbol"`
-	Description                        string  `json:"description"`
+	AssetType     string      `json:"assetType"`
+	AssetMainType string      `json:"assetMainType"`
+	AssetSubType  string      `json:"assetSubType"`
+	Cusip         string      `json:"cusip"`
+	Symbol        string      `json:"symbol"`
+	Description   string      `json:"description"`
+	Delayed       bool        `json:"delayed"`
+	Data          interface{} `json:"-"`
+}
+
+type EquityQuote struct {
 	BidPrice                           float64 `json:"bidPrice"`
 	BidSize                            float64 `json:"bidSize"`
 	BidID                              string  `json:"bidId"`
@@ -63,16 +91,305 @@ type Quote struct {
 	MarkChangeInDouble                 float64 `json:"markChangeInDouble"`
 	MarkPercentChangeInDouble          float64 `json:"markPercentChangeInDouble"`
 	RegularMarketPercentChangeInDouble float64 `json:"regularMarketPercentChangeInDouble"`
-	Delayed                            bool    `json:"delayed"`
 }
 
+type OptionQuote struct {
+	BidPrice               float64 `json:"bidPrice"`
+	BidSize                float64 `json:"bidSize"`
+	AskPrice               float64 `json:"askPrice"`
+	AskSize                float64 `json:"askSize"`
+	LastPrice              float64 `json:"lastPrice"`
+	LastSize               float64 `json:"lastSize"`
+	OpenPrice              float64 `json:"openPrice"`
+	HighPrice              float64 `json:"highPrice"`
+	LowPrice               float64 `json:"lowPrice"`
+	ClosePrice             float64 `json:"closePrice"`
+	NetChange              float64 `json:"netChange"`
+	TotalVolume            float64 `json:"totalVolume"`
+	QuoteTimeInLong        int64   `json:"quoteTimeInLong"`
+	TradeTimeInLong        int64   `json:"tradeTimeInLong"`
+	Mark                   float64 `json:"mark"`
+	OpenInterest           float64 `json:"openInterest"`
+	Volatility             float64 `json:"volatility"`
+	MoneyIntrinsicValue    float64 `json:"moneyIntrinsicValue"`
+	Multiplier             float64 `json:"multiplier"`
+	StrikePrice            float64 `json:"strikePrice"`
+	ContractType           string  `json:"contractType"`
+	Underlying             string  `json:"underlying"`
+	TimeValue              float64 `json:"timeValue"`
+	Deliverables           string  `json:"deliverables"`
+	Delta                  float64 `json:"delta"`
+	Gamma                  float64 `json:"gamma"`
+	Theta                  float64 `json:"theta"`
+	Vega                   float64 `json:"vega"`
+	Rho                    float64 `json:"rho"`
+	SecurityStatus         string  `json:"securityStatus"`
+	TheoreticalOptionValue float64 `json:"theoreticalOptionValue"`
+	UnderlyingPrice        float64 `json:"underlyingPrice"`
+	UvExpirationType       string  `json:"uvExpirationType"`
+	Exchange               string  `json:"exchange"`
+	ExchangeName           string  `json:"exchangeName"`
+	SettlementType         string  `json:"settlementType"`
+	ExpirationDay          int     `json:"expirationDay"`
+	ExpirationMonth        int     `json:"expirationMonth"`
+	ExpirationYear         int     `json:"expirationYear"`
+	DaysToExpiration       int     `json:"daysToExpiration"`
+}
+
+type IndexQuote struct {
+	LastPrice       float64 `json:"lastPrice"`
+	OpenPrice       float64 `json:"openPrice"`
+	HighPrice       float64 `json:"highPrice"`
+	LowPrice        float64 `json:"lowPrice"`
+	ClosePrice      float64 `json:"closePrice"`
+	NetChange       float64 `json:"netChange"`
+	TotalVolume     float64 `json:"totalVolume"`
+	TradeTimeInLong int64   `json:"tradeTimeInLong"`
+	Exchange        string  `json:"exchange"`
+	ExchangeName    string  `json:"exchangeName"`
+	Digits          int     `json:"digits"`
+	Five2WkHigh     float64 `json:"52WkHigh"`
+	Five2WkLow      float64 `json:"52WkLow"`
+	SecurityStatus  string  `json:"securityStatus"`
+}
+
+type MutualFundQuote struct {
+	ClosePrice      float64 `json:"closePrice"`
+	NetChange       float64 `json:"netChange"`
+	TotalVolume     float64 `json:"totalVolume"`
+	TradeTimeInLong int64   `json:"tradeTimeInLong"`
+	Exchange        string  `json:"exchange"`
+	ExchangeName    string  `json:"exchangeName"`
+	Digits          int     `json:"digits"`
+	Five2WkHigh     float64 `json:"52WkHigh"`
+	Five2WkLow      float64 `json:"52WkLow"`
+	NAV             float64 `json:"nAV"`
+	PeRatio         float64 `json:"peRatio"`
+	DivAmount       float64 `json:"divAmount"`
+	DivYield        float64 `json:"divYield"`
+	DivDate         string  `json:"divDate"`
+	SecurityStatus  string  `json:"securityStatus"`
+}
+
+// FutureQuote is the quote of a futures contract such as /ES or /CLZ24.
+type FutureQuote struct {
+	BidPriceInDouble      float64 `json:"bidPriceInDouble"`
+	AskPriceInDouble      float64 `json:"askPriceInDouble"`
+	LastPriceInDouble     float64 `json:"lastPriceInDouble"`
+	BidSizeInLong         int64   `json:"bidSizeInLong"`
+	AskSizeInLong         int64   `json:"askSizeInLong"`
+	LastSizeInLong        int64   `json:"lastSizeInLong"`
+	BidID                 string  `json:"bidId"`
+	AskID                 string  `json:"askId"`
+	LastID                string  `json:"lastId"`
+	HighPriceInDouble     float64 `json:"highPriceInDouble"`
+	LowPriceInDouble      float64 `json:"lowPriceInDouble"`
+	ClosePriceInDouble    float64 `json:"closePriceInDouble"`
+	OpenPriceInDouble     float64 `json:"openPriceInDouble"`
+	ChangeInDouble        float64 `json:"changeInDouble"`
+	FuturePercentChange   float64 `json:"futurePercentChange"`
+	TotalVolume           float64 `json:"totalVolume"`
+	QuoteTimeInLong       int64   `json:"quoteTimeInLong"`
+	TradeTimeInLong       int64   `json:"tradeTimeInLong"`
+	Exchange              string  `json:"exchange"`
+	ExchangeName          string  `json:"exchangeName"`
+	SecurityStatus        string  `json:"securityStatus"`
+	OpenInterest          float64 `json:"openInterest"`
+	Mark                  float64 `json:"mark"`
+	Tick                  float64 `json:"tick"`
+	TickAmount            float64 `json:"tickAmount"`
+	Product               string  `json:"product"`
+	FuturePriceFormat     string  `json:"futurePriceFormat"`
+	FutureTradingHours    string  `json:"futureTradingHours"`
+	FutureIsTradable      bool    `json:"futureIsTradable"`
+	FutureMultiplier      float64 `json:"futureMultiplier"`
+	FutureIsActive        bool    `json:"futureIsActive"`
+	FutureSettlementPrice float64 `json:"futureSettlementPrice"`
+	FutureActiveSymbol    string  `json:"futureActiveSymbol"`
+	FutureExpirationDate  int64   `json:"futureExpirationDate"`
+}
+
+// ForexQuote is the quote of a currency pair such as EUR/USD.
+type ForexQuote struct {
+	BidPriceInDouble    float64 `json:"bidPriceInDouble"`
+	AskPriceInDouble    float64 `json:"askPriceInDouble"`
+	LastPriceInDouble   float64 `json:"lastPriceInDouble"`
+	BidSizeInLong       int64   `json:"bidSizeInLong"`
+	AskSizeInLong       int64   `json:"askSizeInLong"`
+	LastSizeInLong      int64   `json:"lastSizeInLong"`
+	HighPriceInDouble   float64 `json:"highPriceInDouble"`
+	LowPriceInDouble    float64 `json:"lowPriceInDouble"`
+	ClosePriceInDouble  float64 `json:"closePriceInDouble"`
+	OpenPriceInDouble   float64 `json:"openPriceInDouble"`
+	ChangeInDouble      float64 `json:"changeInDouble"`
+	PercentChange       float64 `json:"percentChange"`
+	TotalVolume         float64 `json:"totalVolume"`
+	QuoteTimeInLong     int64   `json:"quoteTimeInLong"`
+	TradeTimeInLong     int64   `json:"tradeTimeInLong"`
+	Exchange            string  `json:"exchange"`
+	ExchangeName        string  `json:"exchangeName"`
+	Digits              int     `json:"digits"`
+	SecurityStatus      string  `json:"securityStatus"`
+	Tick                float64 `json:"tick"`
+	TickAmount          float64 `json:"tickAmount"`
+	Product             string  `json:"product"`
+	TradingHours        string  `json:"tradingHours"`
+	IsTradable          bool    `json:"isTradable"`
+	MarketMaker         string  `json:"marketMaker"`
+	Five2WkHighInDouble float64 `json:"52WkHighInDouble"`
+	Five2WkLowInDouble  float64 `json:"52WkLowInDouble"`
+	Mark                float64 `json:"mark"`
+}
+
+// FuturesSymbol returns the quote symbol of a futures root or contract,
+// e.g. FuturesSymbol("ES") is "/ES".
+func FuturesSymbol(root string) string {
+	return "/" + strings.TrimPrefix(strings.ToUpper(root), "/")
+}
+
+// ForexSymbol returns the quote symbol of a currency pair, e.g.
+// ForexSymbol("eur", "usd") is "EUR/USD".
+func ForexSymbol(base, quote string) string {
+	return strings.ToUpper(base) + "/" + strings.ToUpper(quote)
+}
+
+// IsFuturesSymbol reports whether symbol is a futures symbol such as /ES.
+func IsFuturesSymbol(symbol string) bool {
+	return strings.HasPrefix(symbol, "/")
+}
+
+// IsForexSymbol reports whether symbol is a currency pair such as EUR/USD.
+func IsForexSymbol(symbol string) bool {
+	i := strings.Index(symbol, "/")
+	return i > 0 && i < len(symbol)-1
+}
+
+func (q *Quote) UnmarshalJSON(bs []byte) error {
+	quote := _Quote{}
+	if err := json.Unmarshal(bs, &quote); err != nil {
+		return err
+	}
+
+	mainType := quote.AssetMainType
+	if mainType == "" {
+		mainType = quote.AssetType
+	}
+	switch mainType {
+	case "EQUITY", "ETF":
+		quote.Data = &EquityQuote{}
+	case "OPTION":
+		quote.Data = &OptionQuote{}
+	case "INDEX":
+		quote.Data = &IndexQuote{}
+	case "MUTUAL_FUND":
+		quote.Data = &MutualFundQuote{}
+	case "FUTURE":
+		quote.Data = &FutureQuote{}
+	case "FOREX":
+		quote.Data = &ForexQuote{}
+	default:
+		quote.Data = json.RawMessage(append([]byte(nil), bs...))
+		*q = Quote(quote)
+		return nil
+	}
+	err := json.Unmarshal(bs, quote.Data)
+	*q = Quote(quote)
+
+	return err
+}
+
+func (o *OptionQuote) UnmarshalJSON(b []byte) error {
+	type alias OptionQuote
+	raw := struct {
+		*alias
+		Volatility             naNFloat `json:"volatility"`
+		Delta                  naNFloat `json:"delta"`
+		Gamma                  naNFloat `json:"gamma"`
+		Theta                  naNFloat `json:"theta"`
+		Vega                   naNFloat `json:"vega"`
+		Rho                    naNFloat `json:"rho"`
+		TheoreticalOptionValue naNFloat `json:"theoreticalOptionValue"`
+	}{alias: (*alias)(o)}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	o.Volatility = float64(raw.Volatility)
+	o.Delta = float64(raw.Delta)
+	o.Gamma = float64(raw.Gamma)
+	o.Theta = float64(raw.Theta)
+	o.Vega = float64(raw.Vega)
+	o.Rho = float64(raw.Rho)
+	o.TheoreticalOptionValue = float64(raw.TheoreticalOptionValue)
+	return nil
+}
+
+func (q *Quote) MarshalJSON() ([]byte, error) {
+	header, err := json.Marshal((*_Quote)(q))
+	if err != nil {
+		return nil, err
+	}
+	if q.Data == nil {
+		return header, nil
+	}
+	data, err := json.Marshal(q.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Data holds the asset class specific fields, the header the shared ones.
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(header, &fields); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+// Equity returns the equity fields of the quote, if it is one.
+func (q *Quote) Equity() (*EquityQuote, bool) {
+	d, ok := q.Data.(*EquityQuote)
+	return d, ok
+}
+
+// Option returns the option fields of the quote, if it is one.
+func (q *Quote) Option() (*OptionQuote, bool) {
+	d, ok := q.Data.(*OptionQuote)
+	return d, ok
+}
+
+// Index returns the index fields of the quote, if it is one.
+func (q *Quote) Index() (*IndexQuote, bool) {
+	d, ok := q.Data.(*IndexQuote)
+	return d, ok
+}
+
+// MutualFund returns the mutual fund fields of the quote, if it is one.
+func (q *Quote) MutualFund() (*MutualFundQuote, bool) {
+	d, ok := q.Data.(*MutualFundQuote)
+	return d, ok
+}
+
+// Future returns the futures fields of the quote, if it is one.
+func (q *Quote) Future() (*FutureQuote, bool) {
+	d, ok := q.Data.(*FutureQuote)
+	return d, ok
+}
 
+// Forex returns the forex fields of the quote, if it is one.
+func (q *Quote) Forex() (*ForexQuote, bool) {
+	d, ok := q.Data.(*ForexQuote)
+	return d, ok
+}
+
+// GetQuotes fetches quotes for a comma separated list of symbols, which may
+// mix equities, options, futures (/ES) and currency pairs (EUR/USD).
 func (s *QuotesService) GetQuotes(ctx context.Context, symbols string) (*Quotes, *Response, error) {
-	u := fmt.Sprintf("marketdata/quotes")
-	if symbols == "" {
-		return nil, nil, fmt.Errorf("no symbols present")
+	u, err := quotesURL(symbols)
+	if err != nil {
+		return nil, nil, err
 	}
-	u = fmt.Sprintf("%s?symbol=%s", u, symbols)
 
 	req, err := s.client.NewRequest("GET", u, nil)
 
@@ -90,3 +407,149 @@ func (s *QuotesService) GetQuotes(ctx context.Context, symbols string) (*Quotes,
 	return quotes, resp, nil
 }
 
+// GetQuotesRaw is GetQuotes returning the undecoded response body.
+func (s *QuotesService) GetQuotesRaw(ctx context.Context, symbols string) (json.RawMessage, *Response, error) {
+	u, err := quotesURL(symbols)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.client.getRaw(ctx, u)
+}
+
+func quotesURL(symbols string) (string, error) {
+	if symbols == "" {
+		return "", fmt.Errorf("no symbols present")
+	}
+	return fmt.Sprintf("marketdata/quotes?%s", url.Values{"symbol": {symbols}}.Encode()), nil
+}
+
+// QuotesResult is the outcome of a batch quote request. The API silently
+// leaves out symbols it does not know, so every requested symbol ends up
+// either in Quotes or in Missing.
+type QuotesResult struct {
+	Quotes Quotes
+
+	// Missing lists the requested symbols without a usable quote, in request
+	// order, and Errors holds the reason for each of them: ErrSymbolNotFound
+	// if the API omitted the symbol, or the decoding error of its quote.
+	Missing []string
+	Errors  SymbolErrors
+}
+
+// Err returns Errors, or nil if every symbol was quoted.
+func (r *QuotesResult) Err() error {
+	if len(r.Errors) == 0 {
+		return nil
+	}
+	return r.Errors
+}
+
+// GetQuotesResult fetches quotes for symbols and reports which of them could
+// not be quoted. Unlike GetQuotes, a quote that fails to decode only fails
+// its own symbol.
+func (s *QuotesService) GetQuotesResult(ctx context.Context, symbols []string) (*QuotesResult, *Response, error) {
+	if len(symbols) == 0 {
+		return nil, nil, fmt.Errorf("no symbols present")
+	}
+	q := url.Values{"symbol": {strings.Join(symbols, ",")}}
+	u := fmt.Sprintf("marketdata/quotes?%s", q.Encode())
+
+	req, err := s.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	raw := make(map[string]json.RawMessage)
+	resp, err := s.client.Do(ctx, req, &raw)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	result := &QuotesResult{
+		Quotes: make(Quotes, len(raw)),
+		Errors: make(SymbolErrors),
+	}
+	for _, symbol := range symbols {
+		data, ok := raw[symbol]
+		if !ok {
+			data, ok = raw[strings.ToUpper(symbol)]
+		}
+		if !ok {
+			result.Missing = append(result.Missing, symbol)
+			result.Errors[symbol] = ErrSymbolNotFound
+			continue
+		}
+		quote := new(Quote)
+		if err := json.Unmarshal(data, quote); err != nil {
+			result.Missing = append(result.Missing, symbol)
+			result.Errors[symbol] = err
+			continue
+		}
+		result.Quotes[symbol] = quote
+	}
+	return result, resp, nil
+}
+
+const (
+	// minPollInterval keeps a single poller within the API limit of 120
+	// requests per minute.
+	minPollInterval = 500 * time.Millisecond
+	// pollJitter is the largest fraction of the interval added to each wait
+	// so that several pollers don't fire in lockstep.
+	pollJitter = 0.1
+)
+
+// poll calls fn, and again every interval plus a random jitter, until fn
+// returns false or ctx is done. Intervals below minPollInterval are raised
+// to it.
+func poll(ctx context.Context, interval time.Duration, fn func() bool) {
+	if interval < minPollInterval {
+		interval = minPollInterval
+	}
+	for fn() {
+		wait := interval + time.Duration(rand.Int63n(int64(float64(interval)*pollJitter)+1))
+		if sleepCtx(ctx, wait) != nil {
+			return
+		}
+	}
+}
+
+// QuoteSnapshot is one round of quotes delivered by Poll.
+type QuoteSnapshot struct {
+	Time    time.Time
+	Quotes  Quotes
+	Missing []string
+	Err     error // set if the whole request failed
+}
+
+// Poll fetches quotes for symbols every interval, plus a small random
+// jitter, and delivers them on the returned channel until ctx is done, at
+// which point the channel is closed. Intervals below 500ms are raised to
+// 500ms. A failed request is delivered as a snapshot with Err set and
+// polling continues. A slow reader delays the next request rather than
+// piling up snapshots.
+func (s *QuotesService) Poll(ctx context.Context, symbols []string, interval time.Duration) <-chan QuoteSnapshot {
+	ch := make(chan QuoteSnapshot)
+	go func() {
+		defer close(ch)
+		poll(ctx, interval, func() bool {
+			result, _, err := s.GetQuotesResult(ctx, symbols)
+			if ctx.Err() != nil {
+				return false
+			}
+			snap := QuoteSnapshot{Time: time.Now(), Err: err}
+			if result != nil {
+				snap.Quotes = result.Quotes
+				snap.Missing = result.Missing
+			}
+
+			select {
+			case ch <- snap:
+			case <-ctx.Done():
+				return false
+			}
+			return true
+		})
+	}()
+	return ch
+}
@@ -0,0 +1,121 @@
+package tdameritrade
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultRequestsPerMinute is the rate the API allows an app.
+const defaultRequestsPerMinute = 120
+
+// RateLimiter spaces out the requests of the clients sharing it, so that
+// together they stay within a number of requests per minute. It is a
+// Limiter; set it on a client with WithLimiter or SetRateLimiter.
+type RateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// NewRateLimiter returns a limiter allowing requestsPerMinute requests a
+// minute, or 120 if requestsPerMinute is not positive.
+func NewRateLimiter(requestsPerMinute int) *RateLimiter {
+	if requestsPerMinute <= 0 {
+		requestsPerMinute = defaultRequestsPerMinute
+	}
+	return &RateLimiter{interval: time.Minute / time.Duration(requestsPerMinute)}
+}
+
+// Wait blocks until the caller may send a request, or ctx is done. The
+// slot is taken only once the wait is over, so a caller that gives up
+// leaves it to the others.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		l.mu.Lock()
+		now := time.Now()
+		if !now.Before(l.next) {
+			l.next = now.Add(l.interval)
+			l.mu.Unlock()
+			return nil
+		}
+		d := l.next.Sub(now)
+		l.mu.Unlock()
+
+		if err := sleepCtx(ctx, d); err != nil {
+			return err
+		}
+	}
+}
+
+// Limiter paces the requests of a client. Wait blocks until the next
+// request may be sent, or returns an error, such as ctx's, to fail it.
+// RateLimiter is the one shipped; wrap golang.org/x/time/rate or a
+// distributed limiter to share a budget between processes.
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+// WithRateLimit makes the client send at most requestsPerMinute requests a
+// minute, or 120, the rate the API allows, if requestsPerMinute is not
+// positive. Goroutines sharing the client then queue up instead of
+// tripping 429 Too Many Requests.
+func WithRateLimit(requestsPerMinute int) ClientOption {
+	return WithLimiter(NewRateLimiter(requestsPerMinute))
+}
+
+// WithLimiter makes the client wait for l before every request.
+func WithLimiter(l Limiter) ClientOption {
+	return func(c *Client) error {
+		c.SetRateLimiter(l)
+		return nil
+	}
+}
+
+// SetRateLimiter makes the client wait for l before every request. Pass nil
+// to stop limiting.
+func (c *Client) SetRateLimiter(l Limiter) {
+	if rl, ok := l.(*RateLimiter); ok && rl == nil {
+		l = nil
+	}
+	c.limiterMu.Lock()
+	c.limiter = l
+	c.limiterMu.Unlock()
+}
+
+func (c *Client) rateLimiter() Limiter {
+	c.limiterMu.Lock()
+	defer c.limiterMu.Unlock()
+	return c.limiter
+}
+
+// helperLimiter returns the Limiter spacing out the requests of the helpers
+// sending many requests, such as BulkPriceHistory, interval apart. A client
+// with a Limiter already paces every request, so they get none then.
+func (c *Client) helperLimiter(interval time.Duration) Limiter {
+	if c.rateLimiter() != nil {
+		return nil
+	}
+	return &RateLimiter{interval: interval}
+}
+
+// helperRetries returns the number of times the helpers retry a request
+// rejected with 429 Too Many Requests: none if the client's RetryPolicy
+// retries it already, maxRetries otherwise.
+func (c *Client) helperRetries(maxRetries int) int {
+	if c.retry != nil && c.retry.MaxAttempts > 1 {
+		return 0
+	}
+	return maxRetries
+}
+
+// waitLimiter waits for l, if not nil, or only checks ctx otherwise.
+func waitLimiter(ctx context.Context, l Limiter) error {
+	if l == nil {
+		return ctx.Err()
+	}
+	return l.Wait(ctx)
+}
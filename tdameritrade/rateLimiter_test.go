@@ -0,0 +1,137 @@
+package tdameritrade
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingLimiter lets every request through at once, counting them.
+type countingLimiter struct{ waits int32 }
+
+func (l *countingLimiter) Wait(ctx context.Context) error {
+	atomic.AddInt32(&l.waits, 1)
+	return ctx.Err()
+}
+
+// marketDataServer answers fundamentals, option chains and price history
+// requests for any symbol.
+func marketDataServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		symbol := r.URL.Query().Get("symbol")
+		switch r.URL.Path {
+		case "/instruments":
+			w.Write([]byte(`{"` + symbol + `": {"symbol": "` + symbol + `", "fundamental": {"symbol": "` + symbol + `", "peRatio": 20}}}`))
+		case "/marketdata/chains":
+			w.Write([]byte(`{"symbol": "` + symbol + `", "status": "SUCCESS"}`))
+		default:
+			w.Write([]byte(`{"symbol": "` + symbol + `", "empty": false, "candles": [{"open": 1, "high": 2, "low": 0.5, "close": 1.5, "volume": 100, "datetime": 1612137600000}]}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestHelpersUseClientLimiter(t *testing.T) {
+	symbols := []string{"AAPL", "MSFT", "SPY", "QQQ"}
+	for _, tt := range []struct {
+		name string
+		run  func(context.Context, *Client) error
+	}{
+		{"BulkFundamentals", func(ctx context.Context, c *Client) error {
+			_, err := c.Instrument.BulkFundamentals(ctx, symbols)
+			return err
+		}},
+		{"BulkPriceHistory", func(ctx context.Context, c *Client) error {
+			_, err := c.PriceHistory.BulkPriceHistory(ctx, symbols, nil, nil)
+			return err
+		}},
+		{"OptionChains", func(ctx context.Context, c *Client) error {
+			_, err := c.OptionChain.OptionChains(ctx, symbols, nil)
+			return err
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := marketDataServer(t)
+			limiter := new(countingLimiter)
+			client, err := NewClient(srv.Client(), WithBaseURL(srv.URL+"/"), WithLimiter(limiter))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Paced by their own intervals the helpers would take hours.
+			client.Instrument.RequestInterval = time.Hour
+			client.PriceHistory.RequestInterval = time.Hour
+			client.OptionChain.RequestInterval = time.Hour
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := tt.run(ctx, client); err != nil {
+				t.Fatal(err)
+			}
+			if got := atomic.LoadInt32(&limiter.waits); got != int32(len(symbols)) {
+				t.Errorf("limiter waits = %d, want %d", got, len(symbols))
+			}
+		})
+	}
+}
+
+func TestHelpersDeferToRetryPolicy(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		policy   bool
+		requests int32
+	}{
+		{"helper retries", false, 3},
+		{"retry policy", true, 2},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				w.WriteHeader(http.StatusTooManyRequests)
+			}))
+			defer srv.Close()
+			opts := []ClientOption{WithBaseURL(srv.URL + "/")}
+			if tt.policy {
+				opts = append(opts, WithRetry(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}))
+			}
+			client, err := NewClient(srv.Client(), opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			client.Instrument.RequestInterval = time.Millisecond
+			client.Instrument.MaxRetries = 2
+
+			if _, err := client.Instrument.BulkFundamentals(context.Background(), []string{"AAPL"}); err == nil {
+				t.Fatal("got no error, want the 429")
+			}
+			if got := atomic.LoadInt32(&requests); got != tt.requests {
+				t.Errorf("requests = %d, want %d", got, tt.requests)
+			}
+		})
+	}
+}
+
+func TestRateLimiterCancelledWait(t *testing.T) {
+	l := &RateLimiter{interval: 100 * time.Millisecond}
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// A wait given up before its turn leaves the slot to the next caller.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("err = %v, want %v", err, context.DeadlineExceeded)
+	}
+	start := time.Now()
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if waited := time.Since(start); waited > 150*time.Millisecond {
+		t.Errorf("waited %v after a cancelled wait, want at most one interval", waited)
+	}
+}
@@ -0,0 +1,271 @@
+package tdameritrade
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// LotMethod chooses which open lots a closing trade is matched against.
+type LotMethod int
+
+const (
+	LotFIFO     LotMethod = iota // oldest lot first
+	LotLIFO                      // newest lot first
+	LotSpecific                  // lots picked by RealizedPLOptions.SelectLots
+)
+
+// Lot is an open position built by one opening transaction, or what is left
+// of it. Quantity is positive for long lots and negative for short ones, and
+// Cash is the net cash of opening the remaining quantity, fees included:
+// negative for lots that were paid for.
+type Lot struct {
+	Symbol        string
+	Underlying    string
+	Quantity      float64
+	Cash          float64
+	Opened        time.Time
+	TransactionID int64
+}
+
+// RealizedTrade is a lot, or part of it, closed by a later transaction.
+type RealizedTrade struct {
+	Symbol     string
+	Underlying string
+	Quantity   float64 // closed quantity, always positive
+	Long       bool
+	Opened     time.Time
+	Closed     time.Time
+	Proceeds   float64 // cash received, opening or closing
+	Cost       float64 // cash paid, opening or closing
+	Gain       float64 // Proceeds - Cost
+
+	// CloseType is TRADE, or EXPIRATION, ASSIGNMENT or EXERCISE for options
+	// removed from the account.
+	CloseType          string
+	OpenTransactionID  int64
+	CloseTransactionID int64
+}
+
+// RealizedPLOptions configure RealizedPL.
+type RealizedPLOptions struct {
+	Method LotMethod
+
+	// SelectLots returns, for LotSpecific, the lots closing is matched
+	// against first, in order. Remaining quantity is matched FIFO.
+	SelectLots func(closing *Transaction, lots []*Lot) []*Lot
+
+	// Period returns the period a gain realized at t is reported in.
+	// Defaults to the month, as "2006-01".
+	Period func(t time.Time) string
+}
+
+// RealizedPLReport is the outcome of RealizedPL.
+type RealizedPLReport struct {
+	Trades       []*RealizedTrade
+	Total        float64
+	BySymbol     map[string]float64
+	ByUnderlying map[string]float64
+	ByPeriod     map[string]float64
+
+	// Open holds the lots left open at the end, by symbol.
+	Open map[string][]*Lot
+}
+
+// RealizedPL matches the closing trades among transactions to the lots they
+// close and reports the realized gains. Cash amounts are the net amounts of
+// the transactions, so fees are included. Options removed from the account
+// by expiration, assignment or exercise are closed at zero; the stock trade
+// of an assignment or exercise is matched like any other trade. Transactions
+// may come in any order and those other than trades and option removals are
+// ignored. opts may be nil.
+func RealizedPL(transactions []*Transaction, opts *RealizedPLOptions) (*RealizedPLReport, error) {
+	var o RealizedPLOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Method == LotSpecific && o.SelectLots == nil {
+		return nil, fmt.Errorf("LotSpecific needs SelectLots")
+	}
+	if o.Period == nil {
+		o.Period = func(t time.Time) string { return t.Format("2006-01") }
+	}
+
+	sorted := append([]*Transaction(nil), transactions...)
+	sortTransactions(sorted)
+
+	report := &RealizedPLReport{
+		BySymbol:     make(map[string]float64),
+		ByUnderlying: make(map[string]float64),
+		ByPeriod:     make(map[string]float64),
+		Open:         make(map[string][]*Lot),
+	}
+	for _, t := range sorted {
+		item := t.TransactionItem
+		if item == nil || item.Instrument == nil || item.Amount <= 0 {
+			continue
+		}
+		tm, err := t.Time()
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", t.TransactionID, err)
+		}
+
+		symbol := item.Instrument.Symbol
+		lots := report.Open[symbol]
+		var quantity, cash float64
+		closeType := "TRADE"
+		if removal := optionRemoval(t); removal != "" {
+			// Close whatever is open, at no cash.
+			closeType = removal
+			if len(lots) == 0 {
+				continue
+			}
+			quantity = -math.Copysign(item.Amount, lots[0].Quantity)
+		} else {
+			switch strings.ToUpper(item.Instruction) {
+			case "BUY":
+				quantity = item.Amount
+			case "SELL":
+				quantity = -item.Amount
+			default:
+				continue
+			}
+			cash = t.NetAmount
+			if cash == 0 {
+				cash = item.Cost
+			}
+		}
+
+		remaining := math.Abs(quantity)
+		if len(lots) > 0 && (lots[0].Quantity > 0) != (quantity > 0) {
+			for _, lot := range orderLots(t, lots, &o) {
+				if remaining <= quantityEpsilon {
+					break
+				}
+				n := math.Min(remaining, math.Abs(lot.Quantity))
+				openCash := lot.Cash * n / math.Abs(lot.Quantity)
+				closeCash := cash * n / math.Abs(quantity)
+				trade := &RealizedTrade{
+					Symbol:             symbol,
+					Underlying:         lot.Underlying,
+					Quantity:           n,
+					Long:               lot.Quantity > 0,
+					Opened:             lot.Opened,
+					Closed:             tm,
+					CloseType:          closeType,
+					OpenTransactionID:  lot.TransactionID,
+					CloseTransactionID: t.TransactionID,
+				}
+				for _, c := range []float64{openCash, closeCash} {
+					if c > 0 {
+						trade.Proceeds += c
+					} else {
+						trade.Cost -= c
+					}
+				}
+				trade.Gain = trade.Proceeds - trade.Cost
+				report.add(trade, o.Period(tm))
+
+				lot.Cash -= openCash
+				lot.Quantity -= math.Copysign(n, lot.Quantity)
+				remaining -= n
+			}
+			lots = removeClosedLots(lots)
+		}
+		if remaining > quantityEpsilon && closeType == "TRADE" {
+			underlying := item.Instrument.UnderlyingSymbol
+			if underlying == "" {
+				underlying = symbol
+			}
+			lots = append(lots, &Lot{
+				Symbol:        symbol,
+				Underlying:    underlying,
+				Quantity:      math.Copysign(remaining, quantity),
+				Cash:          cash * remaining / math.Abs(quantity),
+				Opened:        tm,
+				TransactionID: t.TransactionID,
+			})
+		}
+		if len(lots) > 0 {
+			report.Open[symbol] = lots
+		} else {
+			delete(report.Open, symbol)
+		}
+	}
+	return report, nil
+}
+
+// quantityEpsilon absorbs rounding in matched quantities.
+const quantityEpsilon = 1e-9
+
+func (r *RealizedPLReport) add(trade *RealizedTrade, period string) {
+	r.Trades = append(r.Trades, trade)
+	r.Total += trade.Gain
+	r.BySymbol[trade.Symbol] += trade.Gain
+	r.ByUnderlying[trade.Underlying] += trade.Gain
+	r.ByPeriod[period] += trade.Gain
+}
+
+// optionRemoval returns EXPIRATION, ASSIGNMENT or EXERCISE if t removes an
+// option from the account for that reason, and "" otherwise.
+func optionRemoval(t *Transaction) string {
+	if t.Type != "RECEIVE_AND_DELIVER" || t.TransactionItem.Instrument.AssetType != "OPTION" {
+		return ""
+	}
+	description := strings.ToUpper(t.Description)
+	for _, reason := range []string{"EXPIRATION", "ASSIGNMENT", "EXERCISE"} {
+		if strings.Contains(description, reason) {
+			return reason
+		}
+	}
+	return ""
+}
+
+// orderLots returns lots in the order closing is matched against them.
+func orderLots(closing *Transaction, lots []*Lot, opts *RealizedPLOptions) []*Lot {
+	switch opts.Method {
+	case LotLIFO:
+		ordered := make([]*Lot, len(lots))
+		for i, lot := range lots {
+			ordered[len(lots)-1-i] = lot
+		}
+		return ordered
+	case LotSpecific:
+		picked := opts.SelectLots(closing, append([]*Lot(nil), lots...))
+		seen := make(map[*Lot]bool, len(picked))
+		var ordered []*Lot
+		for _, lot := range picked {
+			if !seen[lot] && containsLot(lots, lot) {
+				seen[lot] = true
+				ordered = append(ordered, lot)
+			}
+		}
+		for _, lot := range lots {
+			if !seen[lot] {
+				ordered = append(ordered, lot)
+			}
+		}
+		return ordered
+	}
+	return lots
+}
+
+func containsLot(lots []*Lot, lot *Lot) bool {
+	for _, l := range lots {
+		if l == lot {
+			return true
+		}
+	}
+	return false
+}
+
+func removeClosedLots(lots []*Lot) []*Lot {
+	open := lots[:0]
+	for _, lot := range lots {
+		if math.Abs(lot.Quantity) > quantityEpsilon {
+			open = append(open, lot)
+		}
+	}
+	return open
+}
@@ -0,0 +1,155 @@
+package tdameritrade
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRetryBaseDelay = 500 * time.Millisecond
+	defaultRetryMaxDelay  = 30 * time.Second
+)
+
+// RetryPolicy makes a client retry requests the API answers with 429 Too
+// Many Requests or a 5xx status. Between attempts it waits the Retry-After
+// delay when the API sends one, and otherwise an exponential backoff with
+// jitter. Set it with WithRetry.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts of a request, the first one
+	// included. Values below 2 disable retries.
+	MaxAttempts int
+
+	// BaseDelay is the backoff before the first retry, doubling with each
+	// further one. Defaults to 500ms.
+	BaseDelay time.Duration
+
+	// MaxDelay caps the wait between attempts, Retry-After included.
+	// Defaults to 30s.
+	MaxDelay time.Duration
+
+	// MaxElapsed, if positive, is the time budget of a request: no retry is
+	// made that would start after it is spent.
+	MaxElapsed time.Duration
+
+	// RetryNonIdempotent also retries POST and PATCH requests, and the PUT
+	// requests replacing orders and saved orders. These place orders, and a
+	// 5xx answer doesn't tell whether the order went through, so a retry
+	// may place it twice.
+	RetryNonIdempotent bool
+}
+
+// WithRetry makes the client retry failed requests as p says.
+func WithRetry(p RetryPolicy) ClientOption {
+	return func(c *Client) error {
+		c.retry = &p
+		return nil
+	}
+}
+
+// retryable tells whether req may be sent again after resp.
+func (p *RetryPolicy) retryable(req *http.Request, resp *http.Response) bool {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+		return false
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return false
+	}
+	if !idempotent(req) {
+		return p.RetryNonIdempotent
+	}
+	return true
+}
+
+// idempotent reports whether sending req twice has the effect of sending it
+// once. Replacing an order places a new one, although it is a PUT.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodPost, http.MethodPatch:
+		return false
+	case http.MethodPut:
+		return !strings.Contains(req.URL.Path, "/orders/") && !strings.Contains(req.URL.Path, "/savedorders/")
+	}
+	return true
+}
+
+// delay returns the wait before the retry following the attempt-th attempt,
+// answered with resp.
+func (p *RetryPolicy) delay(attempt int, resp *http.Response) time.Duration {
+	base, max := p.BaseDelay, p.MaxDelay
+	if base <= 0 {
+		base = defaultRetryBaseDelay
+	}
+	if max <= 0 {
+		max = defaultRetryMaxDelay
+	}
+
+	d, ok := retryAfter(resp)
+	if !ok {
+		d = base << uint(attempt-1)
+		if d <= 0 || d > max {
+			d = max
+		}
+		// Wait between half and all of the backoff, so that clients failing
+		// together don't retry together.
+		d = d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+	}
+	if d > max {
+		d = max
+	}
+	return d
+}
+
+// retryAfter parses the Retry-After header of resp, given in seconds or as
+// an HTTP date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// sendRetrying sends req like sendAuthorized, retrying it as the client's
+// RetryPolicy says.
+func (c *Client) sendRetrying(ctx context.Context, req *http.Request) (*http.Response, error) {
+	p := c.retry
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		resp, err := c.sendAuthorized(ctx, req)
+		if err != nil || p == nil || attempt >= p.MaxAttempts || !p.retryable(req, resp) {
+			return resp, err
+		}
+		wait := p.delay(attempt, resp)
+		if p.MaxElapsed > 0 && time.Since(start)+wait > p.MaxElapsed {
+			return resp, nil
+		}
+		c.logf("tdameritrade: %s: %s, retrying in %v (attempt %d of %d)", describe(req), resp.Status, wait, attempt+1, p.MaxAttempts)
+		resp.Body.Close()
+		if err := sleepCtx(ctx, wait); err != nil {
+			return nil, err
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.WithContext(ctx)
+			req.Body = body
+		}
+	}
+}
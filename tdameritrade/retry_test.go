@@ -0,0 +1,68 @@
+package tdameritrade
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryOrderSubmission(t *testing.T) {
+	order, err := NewEquityOrder().Buy("AAPL", 10).Limit(182.5).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name     string
+		send     func(*Client) error
+		nonIdem  bool
+		attempts int32
+	}{
+		{"place order", func(c *Client) error {
+			_, _, err := c.Orders.PlaceOrder(context.Background(), "1", order)
+			return err
+		}, false, 1},
+		{"replace order", func(c *Client) error {
+			_, _, err := c.Orders.ReplaceOrder(context.Background(), "1", 42, order)
+			return err
+		}, false, 1},
+		{"replace saved order", func(c *Client) error {
+			_, err := c.SavedOrders.ReplaceSavedOrder(context.Background(), "1", 42, order)
+			return err
+		}, false, 1},
+		{"replace order opted in", func(c *Client) error {
+			_, _, err := c.Orders.ReplaceOrder(context.Background(), "1", 42, order)
+			return err
+		}, true, 3},
+		{"get order", func(c *Client) error {
+			_, _, err := c.Orders.GetOrder(context.Background(), "1", 42)
+			return err
+		}, false, 3},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&attempts, 1)
+				w.WriteHeader(http.StatusBadGateway)
+			}))
+			defer srv.Close()
+			client, err := NewClient(srv.Client(), WithBaseURL(srv.URL+"/"), WithRetry(RetryPolicy{
+				MaxAttempts:        3,
+				BaseDelay:          time.Millisecond,
+				RetryNonIdempotent: tt.nonIdem,
+			}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tt.send(client); err == nil {
+				t.Fatal("got no error, want the 502")
+			}
+			if got := atomic.LoadInt32(&attempts); got != tt.attempts {
+				t.Errorf("attempts = %d, want %d", got, tt.attempts)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package tdameritrade
+
+import (
+	"context"
+	"fmt"
+)
+
+// SavedOrdersService handles communication with the saved order related
+// methods of the TDAmeritrade API. Saved orders are not sent to the market;
+// they wait in thinkorswim for a manual review before being placed.
+//
+// TDAmeritrade API docs: https://developer.tdameritrade.com/account-access/apis
+type SavedOrdersService struct {
+	client *Client
+}
+
+// SavedOrder is an order saved for later review.
+type SavedOrder struct {
+	Order
+	SavedOrderID int64  `json:"savedOrderId,omitempty"`
+	SavedTime    string `json:"savedTime,omitempty"`
+}
+
+// CreateSavedOrder saves order in the account accountID and returns the id of
+// the saved order.
+// TDAmeritrade API Docs: https://developer.tdameritrade.com/account-access/apis/post/accounts/%7BaccountId%7D/savedorders-0
+func (s *SavedOrdersService) CreateSavedOrder(ctx context.Context, accountID string, order *Order) (int64, *Response, error) {
+	if order == nil {
+		return 0, nil, fmt.Errorf("order is nil")
+	}
+	u := fmt.Sprintf("accounts/%s/savedorders", accountID)
+	req, err := s.client.NewRequest("POST", u, order)
+	if err != nil {
+		return 0, nil, err
+	}
+	resp, err := s.client.Do(ctx, req, nil)
+	if err != nil {
+		return 0, resp, orderError(err)
+	}
+	savedOrderID, err := resp.OrderID()
+	if err != nil {
+		return 0, resp, err
+	}
+	return savedOrderID, resp, nil
+}
+
+// GetSavedOrder returns the saved order savedOrderID.
+// TDAmeritrade API Docs: https://developer.tdameritrade.com/account-access/apis/get/accounts/%7BaccountId%7D/savedorders/%7BsavedOrderId%7D-0
+func (s *SavedOrdersService) GetSavedOrder(ctx context.Context, accountID string, savedOrderID int64) (*SavedOrder, *Response, error) {
+	u := fmt.Sprintf("accounts/%s/savedorders/%d", accountID, savedOrderID)
+	req, err := s.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	order := new(SavedOrder)
+	resp, err := s.client.Do(ctx, req, order)
+	if err != nil {
+		return nil, resp, err
+	}
+	return order, resp, nil
+}
+
+// GetSavedOrders returns the saved orders of the account accountID.
+// TDAmeritrade API Docs: https://developer.tdameritrade.com/account-access/apis/get/accounts/%7BaccountId%7D/savedorders-0
+func (s *SavedOrdersService) GetSavedOrders(ctx context.Context, accountID string) ([]*SavedOrder, *Response, error) {
+	u := fmt.Sprintf("accounts/%s/savedorders", accountID)
+	req, err := s.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var orders []*SavedOrder
+	resp, err := s.client.Do(ctx, req, &orders)
+	if err != nil {
+		return nil, resp, err
+	}
+	return orders, resp, nil
+}
+
+// ReplaceSavedOrder replaces the saved order savedOrderID with order.
+// TDAmeritrade API Docs: https://developer.tdameritrade.com/account-access/apis/put/accounts/%7BaccountId%7D/savedorders/%7BsavedOrderId%7D-0
+func (s *SavedOrdersService) ReplaceSavedOrder(ctx context.Context, accountID string, savedOrderID int64, order *Order) (*Response, error) {
+	if order == nil {
+		return nil, fmt.Errorf("order is nil")
+	}
+	u := fmt.Sprintf("accounts/%s/savedorders/%d", accountID, savedOrderID)
+	req, err := s.client.NewRequest("PUT", u, order)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(ctx, req, nil)
+	return resp, orderError(err)
+}
+
+// DeleteSavedOrder deletes the saved order savedOrderID.
+// TDAmeritrade API Docs: https://developer.tdameritrade.com/account-access/apis/delete/accounts/%7BaccountId%7D/savedorders/%7BsavedOrderId%7D-0
+func (s *SavedOrdersService) DeleteSavedOrder(ctx context.Context, accountID string, savedOrderID int64) (*Response, error) {
+	u := fmt.Sprintf("accounts/%s/savedorders/%d", accountID, savedOrderID)
+	req, err := s.client.NewRequest("DELETE", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.Do(ctx, req, nil)
+}
@@ -0,0 +1,152 @@
+package tdameritrade
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ScreenOptions select the instruments Screen returns. Bounds left at zero
+// don't filter.
+type ScreenOptions struct {
+	// Pattern is the regular expression the whole symbol must match, e.g.
+	// "XL[A-Z]" or "AA.*". It is required.
+	Pattern string
+
+	// AssetTypes and Exchanges, if set, list the asset types (EQUITY,
+	// ETF, ...) and exchanges (NYSE, NASDAQ, ...) to keep.
+	AssetTypes []string
+	Exchanges  []string
+
+	MinMarketCap     float64 // millions of dollars
+	MaxMarketCap     float64 // millions of dollars
+	MinDividendYield float64 // percent
+	MinPERatio       float64
+	MaxPERatio       float64
+	MinVolume        float64 // 10 day average, shares
+
+	// Filter, if set, is applied last to instruments that passed every
+	// other criterion, with their Fundamental set.
+	Filter func(*InstrumentInfo) bool
+}
+
+func (opts *ScreenOptions) needsFundamentals() bool {
+	return opts.MinMarketCap != 0 || opts.MaxMarketCap != 0 || opts.MinDividendYield != 0 ||
+		opts.MinPERatio != 0 || opts.MaxPERatio != 0 || opts.MinVolume != 0 || opts.Filter != nil
+}
+
+func (opts *ScreenOptions) matchFundamental(f *Fundamental) bool {
+	switch {
+	case opts.MinMarketCap != 0 && f.MarketCap < opts.MinMarketCap,
+		opts.MaxMarketCap != 0 && f.MarketCap > opts.MaxMarketCap,
+		opts.MinDividendYield != 0 && f.DividendYield < opts.MinDividendYield,
+		opts.MinPERatio != 0 && f.PeRatio < opts.MinPERatio,
+		opts.MaxPERatio != 0 && f.PeRatio > opts.MaxPERatio,
+		opts.MinVolume != 0 && f.Vol10DayAvg < opts.MinVolume:
+		return false
+	}
+	return true
+}
+
+// SearchInstrumentsRegex returns the instruments whose symbol matches the
+// regular expression pattern, keyed by symbol.
+// TDAmeritrade API Docs: https://developer.tdameritrade.com/instruments/apis/get/instruments
+func (s *InstrumentService) SearchInstrumentsRegex(ctx context.Context, pattern string) (Instruments, *Response, error) {
+	if pattern == "" {
+		return nil, nil, fmt.Errorf("no pattern present")
+	}
+	q := url.Values{"symbol": {pattern}, "projection": {"symbol-regex"}}
+	u := fmt.Sprintf("instruments?%s", q.Encode())
+
+	req, err := s.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	instruments := make(Instruments)
+	resp, err := s.client.Do(ctx, req, &instruments)
+	if err != nil {
+		return nil, resp, err
+	}
+	return instruments, resp, nil
+}
+
+// Screen searches the instruments whose symbol matches opts.Pattern and
+// keeps those that pass the other criteria of opts, ordered by symbol. The
+// search is checked again locally, as the API also returns instruments
+// whose symbol only starts with a match. Fundamentals are fetched, only for
+// the instruments left after the asset type and exchange filters and only
+// when a criterion needs them, with BulkFundamentals, so its rate limit and
+// retries apply.
+//
+// Instruments whose fundamentals could not be fetched are left out and
+// reported in the returned SymbolErrors; the instruments that passed are
+// still returned.
+func (s *InstrumentService) Screen(ctx context.Context, opts *ScreenOptions) ([]*InstrumentInfo, error) {
+	if opts == nil || opts.Pattern == "" {
+		return nil, fmt.Errorf("no pattern present")
+	}
+	re, err := regexp.Compile("^(?:" + opts.Pattern + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %v", err)
+	}
+
+	found, _, err := s.SearchInstrumentsRegex(ctx, opts.Pattern)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []*InstrumentInfo
+	for symbol, info := range found {
+		if info == nil {
+			continue
+		}
+		if info.Symbol == "" {
+			info.Symbol = symbol
+		}
+		if !re.MatchString(info.Symbol) ||
+			len(opts.AssetTypes) > 0 && !containsFold(opts.AssetTypes, info.Type) ||
+			len(opts.Exchanges) > 0 && !containsFold(opts.Exchanges, info.Exchange) {
+			continue
+		}
+		candidates = append(candidates, info)
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Symbol < candidates[j].Symbol })
+	if !opts.needsFundamentals() || len(candidates) == 0 {
+		return candidates, nil
+	}
+
+	symbols := make([]string, len(candidates))
+	for i, info := range candidates {
+		symbols[i] = info.Symbol
+	}
+	fundamentals, err := s.BulkFundamentals(ctx, symbols)
+	if _, ok := err.(SymbolErrors); err != nil && !ok {
+		return nil, err
+	}
+
+	var matches []*InstrumentInfo
+	for _, info := range candidates {
+		f, ok := fundamentals[info.Symbol]
+		if !ok || !opts.matchFundamental(f) {
+			continue
+		}
+		info.Fundamental = f
+		if opts.Filter != nil && !opts.Filter(info) {
+			continue
+		}
+		matches = append(matches, info)
+	}
+	return matches, err
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,206 @@
+package tdameritrade
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// spreadRatios lists the leg quantity ratios, smallest first, of the
+// complexOrderStrategyTypes whose shape Build checks.
+var spreadRatios = map[ComplexOrderStrategyType][]float64{
+	ComplexOrderStrategyTypeVertical:   {1, 1},
+	ComplexOrderStrategyTypeCalendar:   {1, 1},
+	ComplexOrderStrategyTypeDiagonal:   {1, 1},
+	ComplexOrderStrategyTypeStraddle:   {1, 1},
+	ComplexOrderStrategyTypeStrangle:   {1, 1},
+	ComplexOrderStrategyTypeButterfly:  {1, 1, 2},
+	ComplexOrderStrategyTypeCondor:     {1, 1, 1, 1},
+	ComplexOrderStrategyTypeIronCondor: {1, 1, 1, 1},
+}
+
+// NewSpreadOrder starts a multi-leg option order of the complexOrderStrategyType
+// strategy, such as ComplexOrderStrategyTypeButterfly. Add the legs with AddLeg and price
+// the order with NetDebit or NetCredit; it is a market order by default.
+func NewSpreadOrder(strategy ComplexOrderStrategyType) *OrderBuilder {
+	b := newOrderBuilder()
+	b.order.ComplexOrderStrategyType = strategy
+	return b
+}
+
+// NewVerticalOrder opens a vertical spread, buying long and selling short,
+// which must be options of the same type and expiration.
+func NewVerticalOrder(long, short string, contracts float64) *OrderBuilder {
+	return NewSpreadOrder(ComplexOrderStrategyTypeVertical).
+		AddLeg(InstructionBuyToOpen, long, contracts).
+		AddLeg(InstructionSellToOpen, short, contracts)
+}
+
+// NewIronCondorOrder opens an iron condor: a short put vertical of longPut
+// and shortPut and a short call vertical of shortCall and longCall, all of
+// the same expiration.
+func NewIronCondorOrder(longPut, shortPut, shortCall, longCall string, contracts float64) *OrderBuilder {
+	return NewSpreadOrder(ComplexOrderStrategyTypeIronCondor).
+		AddLeg(InstructionBuyToOpen, longPut, contracts).
+		AddLeg(InstructionSellToOpen, shortPut, contracts).
+		AddLeg(InstructionSellToOpen, shortCall, contracts).
+		AddLeg(InstructionBuyToOpen, longCall, contracts)
+}
+
+// NewCalendarOrder opens a calendar spread, selling near and buying far,
+// which must be options of the same type and strike.
+func NewCalendarOrder(near, far string, contracts float64) *OrderBuilder {
+	return NewSpreadOrder(ComplexOrderStrategyTypeCalendar).
+		AddLeg(InstructionSellToOpen, near, contracts).
+		AddLeg(InstructionBuyToOpen, far, contracts)
+}
+
+// NewStraddleOrder opens a long straddle, buying call and put, which must
+// have the same strike and expiration. Use Flip for a short straddle.
+func NewStraddleOrder(call, put string, contracts float64) *OrderBuilder {
+	return NewSpreadOrder(ComplexOrderStrategyTypeStraddle).
+		AddLeg(InstructionBuyToOpen, call, contracts).
+		AddLeg(InstructionBuyToOpen, put, contracts)
+}
+
+// AddLeg adds a leg trading contracts of the option symbol, TD Ameritrade or
+// OCC format, with instruction, such as InstructionBuyToOpen.
+func (b *OrderBuilder) AddLeg(instruction Instruction, symbol string, contracts float64) *OrderBuilder {
+	b.order.OrderLegCollection = append(b.order.OrderLegCollection, &OrderLeg{
+		Instruction: instruction,
+		Quantity:    contracts,
+		Instrument:  optionInstrument(symbol),
+	})
+	return b
+}
+
+// NetDebit makes the order a limit order paying at most price.
+func (b *OrderBuilder) NetDebit(price float64) *OrderBuilder {
+	b.setType(OrderTypeNetDebit)
+	b.order.Price = price
+	return b
+}
+
+// NetCredit makes the order a limit order receiving at least price.
+func (b *OrderBuilder) NetCredit(price float64) *OrderBuilder {
+	b.setType(OrderTypeNetCredit)
+	b.order.Price = price
+	return b
+}
+
+// flippedInstructions maps each instruction to the one trading the other way.
+var flippedInstructions = map[Instruction]Instruction{
+	InstructionBuy:         InstructionSell,
+	InstructionSell:        InstructionBuy,
+	InstructionBuyToOpen:   InstructionSellToOpen,
+	InstructionSellToOpen:  InstructionBuyToOpen,
+	InstructionBuyToClose:  InstructionSellToClose,
+	InstructionSellToClose: InstructionBuyToClose,
+}
+
+// Flip turns every buy into a sell and every sell into a buy, e.g. to open
+// a short straddle or to close a position opened by the order.
+func (b *OrderBuilder) Flip() *OrderBuilder {
+	for i, leg := range b.order.OrderLegCollection {
+		l := *leg
+		if flipped, ok := flippedInstructions[l.Instruction]; ok {
+			l.Instruction = flipped
+		}
+		b.order.OrderLegCollection[i] = &l
+	}
+	return b
+}
+
+// ToClose turns the opening instructions into closing ones. Combined with
+// Flip it builds the order closing a spread.
+func (b *OrderBuilder) ToClose() *OrderBuilder {
+	for i, leg := range b.order.OrderLegCollection {
+		l := *leg
+		switch l.Instruction {
+		case InstructionBuyToOpen:
+			l.Instruction = InstructionBuyToClose
+		case InstructionSellToOpen:
+			l.Instruction = InstructionSellToClose
+		}
+		b.order.OrderLegCollection[i] = &l
+	}
+	return b
+}
+
+type spreadLeg struct {
+	*OptionSymbol
+	buy      bool
+	quantity float64
+}
+
+// validateSpread checks that the legs of a multi-leg order fit its
+// complexOrderStrategyType.
+func validateSpread(order *Order) error {
+	strategy := order.ComplexOrderStrategyType
+	legs := make([]spreadLeg, len(order.OrderLegCollection))
+	for i, leg := range order.OrderLegCollection {
+		if leg.Instrument.AssetType != "OPTION" {
+			return fmt.Errorf("%s order leg %s is not an option", strategy, leg.Instrument.Symbol())
+		}
+		s, err := parseOptionOrOCC(leg.Instrument.Symbol())
+		if err != nil {
+			return err
+		}
+		if i > 0 && s.Underlying != legs[0].Underlying {
+			return fmt.Errorf("%s order mixes underlyings %s and %s", strategy, legs[0].Underlying, s.Underlying)
+		}
+		legs[i] = spreadLeg{s, leg.Instruction.isBuy(), leg.Quantity}
+	}
+
+	ratios, ok := spreadRatios[strategy]
+	if !ok {
+		return nil
+	}
+	if len(legs) != len(ratios) {
+		return fmt.Errorf("%s order needs %d legs, has %d", strategy, len(ratios), len(legs))
+	}
+	min := math.Inf(1)
+	for _, l := range legs {
+		min = math.Min(min, l.quantity)
+	}
+	got := make([]float64, len(legs))
+	for i, l := range legs {
+		got[i] = l.quantity / min
+	}
+	sort.Float64s(got)
+	for i := range got {
+		if math.Abs(got[i]-ratios[i]) > 1e-9 {
+			return fmt.Errorf("invalid leg ratio %v for %s order, must be %v", got, strategy, ratios)
+		}
+	}
+
+	sameExp := func(a, b spreadLeg) bool { return a.Expiration.Equal(b.Expiration) }
+	switch a, b := legs[0], legs[len(legs)-1]; strategy {
+	case ComplexOrderStrategyTypeVertical:
+		if !sameExp(a, b) || a.PutCall != b.PutCall || a.Strike == b.Strike || a.buy == b.buy {
+			return fmt.Errorf("VERTICAL order must buy and sell options of the same type and expiration with different strikes")
+		}
+	case ComplexOrderStrategyTypeCalendar:
+		if sameExp(a, b) || a.PutCall != b.PutCall || a.Strike != b.Strike || a.buy == b.buy {
+			return fmt.Errorf("CALENDAR order must buy and sell options of the same type and strike with different expirations")
+		}
+	case ComplexOrderStrategyTypeStraddle:
+		if !sameExp(a, b) || a.PutCall == b.PutCall || a.Strike != b.Strike || a.buy != b.buy {
+			return fmt.Errorf("STRADDLE order must buy or sell a call and a put of the same strike and expiration")
+		}
+	case ComplexOrderStrategyTypeIronCondor:
+		calls := 0
+		for _, l := range legs {
+			if !sameExp(l, a) {
+				return fmt.Errorf("IRON_CONDOR order legs must have the same expiration")
+			}
+			if l.PutCall == ContractTypeCall {
+				calls++
+			}
+		}
+		if calls != 2 {
+			return fmt.Errorf("IRON_CONDOR order must have two puts and two calls")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package streamer
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy says what becomes of a message arriving at a full buffer.
+type OverflowPolicy int
+
+const (
+	// Block waits for room in the buffer, holding up the stream until the
+	// consumer catches up.
+	Block OverflowPolicy = iota
+
+	// DropOldest drops the oldest message of the buffer to make room.
+	DropOldest
+
+	// CoalesceLatest keeps only the latest update of every symbol in the
+	// buffer. Quote and option quote updates, which carry the fields that
+	// changed, are merged with the one they replace so that no change is
+	// lost. If the buffer is full of updates of other symbols, the oldest
+	// is dropped. Only handlers of a Bus may coalesce.
+	CoalesceLatest
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case Block:
+		return "block"
+	case DropOldest:
+		return "drop oldest"
+	case CoalesceLatest:
+		return "coalesce latest"
+	}
+	return "unknown"
+}
+
+// WithOverflow sets what becomes of messages arriving while the Messages
+// channel is full: Block, the default, or DropOldest, which keeps the
+// stream flowing at the cost of the messages Dropped counts. The size of
+// the channel is set by WithBufferSize.
+func WithOverflow(policy OverflowPolicy) Option {
+	return func(s *Streamer) error {
+		switch policy {
+		case Block, DropOldest:
+		default:
+			return fmt.Errorf("streamer: overflow policy %v is not supported for the Messages channel", policy)
+		}
+		s.overflow = policy
+		return nil
+	}
+}
+
+// Dropped returns the number of messages dropped from the full Messages
+// channel.
+func (s *Streamer) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// deliver sends m on the Messages channel as the overflow policy says.
+func (s *Streamer) deliver(m *Message) {
+	if s.overflow == DropOldest {
+		for {
+			select {
+			case s.messages <- m:
+				return
+			case <-s.closing:
+				return
+			default:
+			}
+			select {
+			case <-s.messages:
+				atomic.AddUint64(&s.dropped, 1)
+			default:
+			}
+		}
+	}
+	select {
+	case s.messages <- m:
+	case <-s.closing:
+	}
+}
+
+// HandlerOption configures a handler of a Bus.
+type HandlerOption func(*handler)
+
+// Buffered makes a handler run on a goroutine of its own, fed by a buffer
+// of size events handled as policy says, so that a slow handler doesn't
+// hold up the others. Handlers are otherwise called by the goroutine
+// dispatching the messages.
+func Buffered(size int, policy OverflowPolicy) HandlerOption {
+	if size < 1 {
+		size = 1
+	}
+	return func(h *handler) {
+		h.queue = &queue{
+			size:   size,
+			policy: policy,
+			notify: make(chan struct{}, 1),
+			room:   make(chan struct{}, 1),
+			done:   make(chan struct{}),
+		}
+	}
+}
+
+// queue is the buffer of a buffered handler.
+type queue struct {
+	size    int
+	policy  OverflowPolicy
+	notify  chan struct{} // signaled when an event is pushed
+	room    chan struct{} // signaled when an event is popped
+	done    chan struct{} // closed when the handler is removed
+	dropped *uint64       // the count of the bus
+
+	mu     sync.Mutex
+	events []event
+}
+
+// merger is implemented by the updates carrying only the fields that
+// changed, which coalescing merges rather than replaces.
+type merger interface {
+	merge(next interface{}) interface{}
+}
+
+// push adds e to q.
+func (q *queue) push(e event) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.policy == CoalesceLatest {
+		for i, pending := range q.events {
+			if pending.symbol != e.symbol {
+				continue
+			}
+			if m, ok := pending.v.(merger); ok {
+				e.v = m.merge(e.v)
+			}
+			q.events[i] = e
+			return
+		}
+	}
+	for len(q.events) >= q.size {
+		if q.policy != Block {
+			q.events = q.events[1:]
+			atomic.AddUint64(q.dropped, 1)
+			break
+		}
+		q.mu.Unlock()
+		select {
+		case <-q.room:
+		case <-q.done:
+			q.mu.Lock()
+			return
+		}
+		q.mu.Lock()
+	}
+	q.events = append(q.events, e)
+	signal(q.notify)
+}
+
+// run calls fn with the events of q until the handler is removed.
+func (q *queue) run(fn func(interface{})) {
+	for {
+		select {
+		case <-q.notify:
+		case <-q.done:
+			return
+		}
+		for {
+			q.mu.Lock()
+			if len(q.events) == 0 {
+				q.mu.Unlock()
+				break
+			}
+			e := q.events[0]
+			q.events = q.events[1:]
+			q.mu.Unlock()
+			signal(q.room)
+
+			select {
+			case <-q.done:
+				return
+			default:
+			}
+			fn(e.v)
+		}
+	}
+}
+
+// signal wakes the goroutine waiting on ch, if any, without blocking.
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+func (q *Quote) merge(next interface{}) interface{} {
+	n := next.(*Quote)
+	m := *q
+	mergeFields(&m, n, fieldInts(n.Fields))
+	m.Fields = unionFields(q.Fields, n.Fields)
+	return &m
+}
+
+func (o *OptionQuote) merge(next interface{}) interface{} {
+	n := next.(*OptionQuote)
+	m := *o
+	mergeFields(&m, n, fieldInts(n.Fields))
+	m.Fields = unionFields(o.Fields, n.Fields)
+	return &m
+}
+
+func fieldInts[F ~int](fields []F) []int {
+	nums := make([]int, len(fields))
+	for i, f := range fields {
+		nums[i] = int(f)
+	}
+	return nums
+}
+
+func unionFields[F ~int](a, b []F) []F {
+	seen := make(map[F]bool, len(a)+len(b))
+	var fields []F
+	for _, f := range append(append([]F(nil), a...), b...) {
+		if !seen[f] {
+			seen[f] = true
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
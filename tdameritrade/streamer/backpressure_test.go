@@ -0,0 +1,117 @@
+package streamer
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestOverflowDropOldest(t *testing.T) {
+	ts := newTestServer(t)
+	s := ts.dial(t, WithBufferSize(2), WithOverflow(DropOldest))
+
+	for i := 1; i <= 5; i++ {
+		ts.send(t, fmt.Sprintf(`{"data": [{"service": "QUOTE", "timestamp": 1600000000000, "content": [{"key": "AAPL", "3": %d}]}]}`, i))
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Dropped() < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := s.Dropped(); got != 3 {
+		t.Fatalf("Dropped = %d, want 3", got)
+	}
+	for _, want := range []string{"4", "5"} {
+		m := nextMessage(t, s)
+		if got := string(m.Content[0]["3"]); got != want {
+			t.Errorf("last price = %s, want %s", got, want)
+		}
+	}
+}
+
+func TestOverflowPolicies(t *testing.T) {
+	ts := newTestServer(t)
+	if _, err := New(ts.principals(), WithOverflow(CoalesceLatest)); err == nil {
+		t.Error("New accepted coalescing the Messages channel")
+	}
+	if _, err := New(ts.principals(), WithBufferSize(-1)); err == nil {
+		t.Error("New accepted a negative buffer size")
+	}
+}
+
+// gatedHandler returns a quote handler sending the quotes it gets on a
+// channel, which it blocks on until it is read.
+func gatedHandler() (func(*Quote), chan *Quote) {
+	got := make(chan *Quote)
+	return func(q *Quote) { got <- q }, got
+}
+
+func nextQuote(t *testing.T, got chan *Quote) *Quote {
+	t.Helper()
+	select {
+	case q := <-got:
+		return q
+	case <-time.After(5 * time.Second):
+		t.Fatal("no quote handled")
+		return nil
+	}
+}
+
+func TestBufferedCoalesceLatest(t *testing.T) {
+	bus := NewBus()
+	fn, got := gatedHandler()
+	defer bus.OnQuote("", fn, Buffered(4, CoalesceLatest))()
+
+	// The handler holds the first quote while the others are buffered.
+	bus.Dispatch(quoteMessage(t, `[{"key": "AAPL", "3": 116}]`))
+	for i := 0; i < 5000 && !bufferedQuotes(bus, 0); i++ {
+		time.Sleep(time.Millisecond)
+	}
+	bus.Dispatch(quoteMessage(t, `[{"key": "AAPL", "1": 116.1}, {"key": "MSFT", "3": 205}]`))
+	bus.Dispatch(quoteMessage(t, `[{"key": "AAPL", "2": 116.2}]`))
+
+	if q := nextQuote(t, got); q.Symbol != "AAPL" || q.LastPrice != 116 {
+		t.Errorf("first quote = %+v", q)
+	}
+	q := nextQuote(t, got)
+	if q.Symbol != "AAPL" || q.BidPrice != 116.1 || q.AskPrice != 116.2 || !q.Has(QuoteBidPrice) || !q.Has(QuoteAskPrice) || q.Has(QuoteLastPrice) {
+		t.Errorf("coalesced quote = %+v, want the bid and ask merged", q)
+	}
+	if q := nextQuote(t, got); q.Symbol != "MSFT" {
+		t.Errorf("third quote = %+v, want MSFT", q)
+	}
+	if n := bus.Dropped(); n != 0 {
+		t.Errorf("Dropped = %d, want 0", n)
+	}
+}
+
+func TestBufferedDropOldest(t *testing.T) {
+	bus := NewBus()
+	fn, got := gatedHandler()
+	defer bus.OnQuote("", fn, Buffered(1, DropOldest))()
+
+	bus.Dispatch(quoteMessage(t, `[{"key": "AAPL", "3": 1}]`))
+	for i := 0; i < 5000 && !bufferedQuotes(bus, 0); i++ {
+		time.Sleep(time.Millisecond)
+	}
+	bus.Dispatch(quoteMessage(t, `[{"key": "AAPL", "3": 2}, {"key": "AAPL", "3": 3}]`))
+
+	if n := bus.Dropped(); n != 1 {
+		t.Errorf("Dropped = %d, want 1", n)
+	}
+	for _, want := range []float64{1, 3} {
+		if q := nextQuote(t, got); q.LastPrice != want {
+			t.Errorf("last price = %v, want %v", q.LastPrice, want)
+		}
+	}
+}
+
+// bufferedQuotes reports whether the buffered quote handler of bus holds n
+// quotes.
+func bufferedQuotes(bus *Bus, n int) bool {
+	bus.mu.RLock()
+	q := bus.handlers[ServiceQuote][0].queue
+	bus.mu.RUnlock()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.events) == n
+}
@@ -0,0 +1,210 @@
+package streamer
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+)
+
+// The book services stream the level two books of NASDAQ equities, of
+// equities listed on the other exchanges and of options.
+const (
+	ServiceNasdaqBook  = "NASDAQ_BOOK"
+	ServiceListedBook  = "LISTED_BOOK"
+	ServiceOptionsBook = "OPTIONS_BOOK"
+)
+
+// bookFields are the fields of the book services: the symbol, the time, the
+// bids and the asks.
+const bookFields = "0,1,2,3"
+
+// Book is a level two book update of a symbol. An update replaces the
+// sides it carries, as HasBids and HasAsks tell; a BookKeeper applies
+// updates to keep the current book.
+type Book struct {
+	Service string
+	Symbol  string      `field:"key"`
+	Time    time.Time   `field:"1"`
+	Bids    []BookLevel `field:"2"` // best, highest, first
+	Asks    []BookLevel `field:"3"` // best, lowest, first
+
+	HasBids bool
+	HasAsks bool
+}
+
+// BookLevel is a price level of a side of a book.
+type BookLevel struct {
+	Price      float64     `field:"0"`
+	Size       float64     `field:"1"` // the total size at the price
+	NumEntries int         `field:"2"`
+	Entries    []BookEntry `field:"3"`
+}
+
+// BookEntry is the size an exchange or market maker shows at a price
+// level.
+type BookEntry struct {
+	ID   string  `field:"0"` // the exchange or market maker, such as NSDQ
+	Size float64 `field:"1"`
+
+	// Time is the time of the entry, in milliseconds since midnight
+	// eastern time.
+	Time int64 `field:"2"`
+}
+
+func (l *BookLevel) UnmarshalJSON(b []byte) error {
+	var c Content
+	if err := json.Unmarshal(b, &c); err != nil {
+		return err
+	}
+	return decodeContent(c, l)
+}
+
+func (e *BookEntry) UnmarshalJSON(b []byte) error {
+	var c Content
+	if err := json.Unmarshal(b, &c); err != nil {
+		return err
+	}
+	return decodeContent(c, e)
+}
+
+// BestBid returns the highest bid of b, if it has one.
+func (b *Book) BestBid() (BookLevel, bool) {
+	if len(b.Bids) == 0 {
+		return BookLevel{}, false
+	}
+	return b.Bids[0], true
+}
+
+// BestAsk returns the lowest ask of b, if it has one.
+func (b *Book) BestAsk() (BookLevel, bool) {
+	if len(b.Asks) == 0 {
+		return BookLevel{}, false
+	}
+	return b.Asks[0], true
+}
+
+// SubscribeNasdaqBook subscribes to the books of the NASDAQ equities
+// symbols, replacing the symbols subscribed before.
+func (s *Streamer) SubscribeNasdaqBook(ctx context.Context, symbols []string) error {
+	return s.subscribe(ctx, ServiceNasdaqBook, symbols, bookFields)
+}
+
+// SubscribeListedBook subscribes to the books of the equities symbols
+// listed on NYSE and the other exchanges, replacing the symbols subscribed
+// before.
+func (s *Streamer) SubscribeListedBook(ctx context.Context, symbols []string) error {
+	return s.subscribe(ctx, ServiceListedBook, symbols, bookFields)
+}
+
+// SubscribeOptionsBook subscribes to the books of the options symbols,
+// given in TD Ameritrade or OCC format, replacing the options subscribed
+// before.
+func (s *Streamer) SubscribeOptionsBook(ctx context.Context, symbols []string) error {
+	keys, err := optionKeys(symbols)
+	if err != nil {
+		return err
+	}
+	return s.subscribe(ctx, ServiceOptionsBook, keys, bookFields)
+}
+
+// ParseBooks returns the book updates of m, a message of a book service.
+func ParseBooks(m *Message) ([]*Book, error) {
+	if err := checkService(m, ServiceNasdaqBook, ServiceListedBook, ServiceOptionsBook); err != nil {
+		return nil, err
+	}
+	books := make([]*Book, 0, len(m.Content))
+	for _, c := range m.Content {
+		b := &Book{Service: m.Service}
+		if err := decodeContent(c, b); err != nil {
+			return nil, err
+		}
+		_, b.HasBids = c["2"]
+		_, b.HasAsks = c["3"]
+		books = append(books, b)
+	}
+	return books, nil
+}
+
+// BookKeeper keeps the current book of every symbol from the book updates
+// it is given. Its methods may be called concurrently.
+type BookKeeper struct {
+	mu    sync.RWMutex
+	books map[string]*Book
+}
+
+// NewBookKeeper returns a BookKeeper holding no book.
+func NewBookKeeper() *BookKeeper {
+	return &BookKeeper{books: make(map[string]*Book)}
+}
+
+// Update applies the book updates of m, and ignores messages of other
+// services than the book ones.
+func (k *BookKeeper) Update(m *Message) error {
+	if m.Type != Data && m.Type != Snapshot {
+		return nil
+	}
+	switch m.Service {
+	case ServiceNasdaqBook, ServiceListedBook, ServiceOptionsBook:
+	default:
+		return nil
+	}
+	books, err := ParseBooks(m)
+	if err != nil {
+		return err
+	}
+	for _, b := range books {
+		k.Apply(b)
+	}
+	return nil
+}
+
+// Apply applies the book update u, replacing the sides it carries.
+func (k *BookKeeper) Apply(u *Book) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	b, ok := k.books[u.Symbol]
+	if !ok {
+		b = &Book{Service: u.Service, Symbol: u.Symbol, HasBids: true, HasAsks: true}
+		k.books[u.Symbol] = b
+	}
+	if u.Time.After(b.Time) {
+		b.Time = u.Time
+	}
+	if u.HasBids {
+		b.Bids = append([]BookLevel(nil), u.Bids...)
+		sort.SliceStable(b.Bids, func(i, j int) bool { return b.Bids[i].Price > b.Bids[j].Price })
+	}
+	if u.HasAsks {
+		b.Asks = append([]BookLevel(nil), u.Asks...)
+		sort.SliceStable(b.Asks, func(i, j int) bool { return b.Asks[i].Price < b.Asks[j].Price })
+	}
+}
+
+// Book returns a copy of the current book of symbol, if an update of it
+// was applied.
+func (k *BookKeeper) Book(symbol string) (*Book, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	b, ok := k.books[symbol]
+	if !ok {
+		return nil, false
+	}
+	c := *b
+	c.Bids = append([]BookLevel(nil), b.Bids...)
+	c.Asks = append([]BookLevel(nil), b.Asks...)
+	return &c, true
+}
+
+// Symbols returns the symbols the keeper holds a book of, sorted.
+func (k *BookKeeper) Symbols() []string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	symbols := make([]string, 0, len(k.books))
+	for symbol := range k.books {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
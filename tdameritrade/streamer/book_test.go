@@ -0,0 +1,92 @@
+package streamer
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSubscribeBooks(t *testing.T) {
+	ts := newTestServer(t)
+	s := ts.dial(t)
+	ts.nextRequest(t) // login
+
+	ctx := context.Background()
+	if err := s.SubscribeNasdaqBook(ctx, []string{"MSFT"}); err != nil {
+		t.Fatal(err)
+	}
+	if req := ts.nextRequest(t); req.Service != ServiceNasdaqBook || req.Parameters["keys"] != "MSFT" || req.Parameters["fields"] != bookFields {
+		t.Errorf("request = %+v, want NASDAQ_BOOK SUBS of MSFT", req)
+	}
+	if err := s.SubscribeOptionsBook(ctx, []string{"AAPL  240119C00150000"}); err != nil {
+		t.Fatal(err)
+	}
+	if req := ts.nextRequest(t); req.Service != ServiceOptionsBook || req.Parameters["keys"] != "AAPL_011924C150" {
+		t.Errorf("request = %+v, want OPTIONS_BOOK SUBS of AAPL_011924C150", req)
+	}
+}
+
+// bookMessage returns a NASDAQ_BOOK message of MSFT with the sides given,
+// as JSON arrays of levels, if not "".
+func bookMessage(bids, asks string) *Message {
+	c := Content{"key": []byte(`"MSFT"`), "1": []byte(`1600000000000`)}
+	if bids != "" {
+		c["2"] = []byte(bids)
+	}
+	if asks != "" {
+		c["3"] = []byte(asks)
+	}
+	return &Message{Type: Data, Service: ServiceNasdaqBook, Content: []Content{c}}
+}
+
+func TestParseBooks(t *testing.T) {
+	books, err := ParseBooks(bookMessage(
+		`[{"0": 205.1, "1": 500, "2": 2, "3": [{"0": "NSDQ", "1": 300, "2": 34200000}, {"0": "ARCX", "1": 200, "2": 34200100}]}]`,
+		`[]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := books[0]
+	if b.Symbol != "MSFT" || !b.HasBids || !b.HasAsks || len(b.Bids) != 1 || len(b.Asks) != 0 {
+		t.Fatalf("book = %+v, want one bid level and no asks", b)
+	}
+	level := b.Bids[0]
+	if level.Price != 205.1 || level.Size != 500 || level.NumEntries != 2 || len(level.Entries) != 2 ||
+		level.Entries[1] != (BookEntry{ID: "ARCX", Size: 200, Time: 34200100}) {
+		t.Errorf("bid level = %+v", level)
+	}
+}
+
+func TestBookKeeper(t *testing.T) {
+	k := NewBookKeeper()
+	must := func(m *Message) {
+		t.Helper()
+		if err := k.Update(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(bookMessage(`[{"0": 205.0, "1": 100}, {"0": 205.1, "1": 500}]`, `[{"0": 205.3, "1": 200}, {"0": 205.2, "1": 400}]`))
+	// An update of the bids only leaves the asks alone.
+	must(bookMessage(`[{"0": 205.15, "1": 50}]`, ""))
+	// Messages of other services are ignored.
+	must(&Message{Type: Data, Service: ServiceQuote, Content: []Content{{"key": []byte(`"MSFT"`)}}})
+
+	b, ok := k.Book("MSFT")
+	if !ok {
+		t.Fatal("no book of MSFT")
+	}
+	if bid, ok := b.BestBid(); !ok || bid.Price != 205.15 || len(b.Bids) != 1 {
+		t.Errorf("bids = %+v, want the replacing level only", b.Bids)
+	}
+	if ask, ok := b.BestAsk(); !ok || ask.Price != 205.2 || len(b.Asks) != 2 {
+		t.Errorf("asks = %+v, want both levels, lowest first", b.Asks)
+	}
+	if symbols := k.Symbols(); len(symbols) != 1 || symbols[0] != "MSFT" {
+		t.Errorf("symbols = %v, want MSFT", symbols)
+	}
+
+	// The copy returned is the caller's.
+	b.Asks[0].Price = 1
+	if b, _ := k.Book("MSFT"); b.Asks[0].Price != 205.2 {
+		t.Error("changing a returned book changed the keeper's")
+	}
+}
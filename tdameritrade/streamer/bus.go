@@ -0,0 +1,249 @@
+package streamer
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Bus dispatches the messages of a streamer to the handlers registered for
+// their service and symbol, so that many consumers can share a connection:
+//
+//	bus := streamer.NewBus()
+//	bus.OnQuote("AAPL", func(q *streamer.Quote) { ... })
+//	bus.OnBar("", func(b *streamer.Bar) { ... }) // the bars of every symbol
+//	go bus.Run(ctx, s.Messages())
+//
+// Messages are only decoded for the services having handlers. Handlers are
+// called one at a time, in the order the messages came, from the goroutine
+// dispatching them, unless they are Buffered. Its methods may be called
+// concurrently.
+type Bus struct {
+	mu       sync.RWMutex
+	nextID   int
+	handlers map[string][]*handler // by service
+	messages []*handler            // OnMessage handlers
+	errors   []func(*Message, error)
+	dropped  uint64
+}
+
+// handler is a handler of the events of a service, for a symbol or for
+// every symbol if symbol is "".
+type handler struct {
+	id     int
+	symbol string
+	fn     func(interface{})
+	queue  *queue // the buffer of a Buffered handler
+}
+
+// call hands e to h.
+func (h *handler) call(e event) {
+	if h.queue != nil {
+		h.queue.push(e)
+		return
+	}
+	h.fn(e.v)
+}
+
+// event is a decoded entry of a message.
+type event struct {
+	symbol string
+	v      interface{}
+}
+
+// NewBus returns a Bus with no handler.
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[string][]*handler)}
+}
+
+// decoders decode the messages of the services the bus has typed handlers
+// for.
+var decoders = map[string]func(*Message) ([]event, error){
+	ServiceQuote:        decoderOf(ParseQuotes, func(q *Quote) string { return q.Symbol }),
+	ServiceOption:       decoderOf(ParseOptionQuotes, func(o *OptionQuote) string { return o.Symbol }),
+	ServiceChartEquity:  decoderOf(ParseBars, func(b *Bar) string { return b.Symbol }),
+	ServiceChartFutures: decoderOf(ParseBars, func(b *Bar) string { return b.Symbol }),
+	ServiceNasdaqBook:   decoderOf(ParseBooks, func(b *Book) string { return b.Symbol }),
+	ServiceListedBook:   decoderOf(ParseBooks, func(b *Book) string { return b.Symbol }),
+	ServiceOptionsBook:  decoderOf(ParseBooks, func(b *Book) string { return b.Symbol }),
+	ServiceNewsHeadline: decoderOf(ParseHeadlines, func(h *Headline) string { return h.Symbol }),
+}
+
+func decoderOf[T any](parse func(*Message) ([]*T, error), symbol func(*T) string) func(*Message) ([]event, error) {
+	return func(m *Message) ([]event, error) {
+		vs, err := parse(m)
+		if err != nil {
+			return nil, err
+		}
+		events := make([]event, len(vs))
+		for i, v := range vs {
+			events[i] = event{symbol: symbol(v), v: v}
+		}
+		return events, nil
+	}
+}
+
+// newHandler returns a handler calling fn, configured by opts.
+func (b *Bus) newHandler(symbol string, fn func(interface{}), opts []HandlerOption) *handler {
+	b.nextID++
+	h := &handler{id: b.nextID, symbol: symbol, fn: fn}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.queue != nil {
+		h.queue.dropped = &b.dropped
+		go h.queue.run(fn)
+	}
+	return h
+}
+
+// stop stops the goroutine of h, if it is buffered.
+func (h *handler) stop() {
+	if h.queue != nil {
+		close(h.queue.done)
+	}
+}
+
+// on registers fn for the events of services for symbol, and returns a
+// function removing it.
+func on[T any](b *Bus, symbol string, fn func(T), opts []HandlerOption, services ...string) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	h := b.newHandler(symbol, func(v interface{}) { fn(v.(T)) }, opts)
+	for _, service := range services {
+		b.handlers[service] = append(b.handlers[service], h)
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			for _, service := range services {
+				b.handlers[service] = without(b.handlers[service], h.id)
+			}
+			h.stop()
+		})
+	}
+}
+
+func without(hs []*handler, id int) []*handler {
+	for i, h := range hs {
+		if h.id == id {
+			return append(hs[:i:i], hs[i+1:]...)
+		}
+	}
+	return hs
+}
+
+// OnQuote registers fn for the level one quotes of symbol, or of every
+// symbol if symbol is "", configured by opts. It returns a function
+// removing fn.
+func (b *Bus) OnQuote(symbol string, fn func(*Quote), opts ...HandlerOption) func() {
+	return on(b, symbol, fn, opts, ServiceQuote)
+}
+
+// OnOptionQuote registers fn for the level one quotes of the option
+// symbol, in TD Ameritrade format, or of every option if symbol is "".
+func (b *Bus) OnOptionQuote(symbol string, fn func(*OptionQuote), opts ...HandlerOption) func() {
+	return on(b, symbol, fn, opts, ServiceOption)
+}
+
+// OnBar registers fn for the minute bars of symbol, an equity or a future,
+// or of every symbol if symbol is "".
+func (b *Bus) OnBar(symbol string, fn func(*Bar), opts ...HandlerOption) func() {
+	return on(b, symbol, fn, opts, ServiceChartEquity, ServiceChartFutures)
+}
+
+// OnBook registers fn for the book updates of symbol, from any of the book
+// services, or of every symbol if symbol is "".
+func (b *Bus) OnBook(symbol string, fn func(*Book), opts ...HandlerOption) func() {
+	return on(b, symbol, fn, opts, ServiceNasdaqBook, ServiceListedBook, ServiceOptionsBook)
+}
+
+// OnHeadline registers fn for the news headlines of symbol, or of every
+// symbol if symbol is "".
+func (b *Bus) OnHeadline(symbol string, fn func(*Headline), opts ...HandlerOption) func() {
+	return on(b, symbol, fn, opts, ServiceNewsHeadline)
+}
+
+// OnMessage registers fn for every message, undecoded, such as notices and
+// the messages of services without typed handlers. Buffered messages
+// coalesce by service. It returns a function removing fn.
+func (b *Bus) OnMessage(fn func(*Message), opts ...HandlerOption) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	h := b.newHandler("", func(v interface{}) { fn(v.(*Message)) }, opts)
+	b.messages = append(b.messages, h)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			b.messages = without(b.messages, h.id)
+			h.stop()
+		})
+	}
+}
+
+// OnError registers fn for the messages the bus fails to decode, which are
+// otherwise dropped.
+func (b *Bus) OnError(fn func(m *Message, err error)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.errors = append(b.errors, fn)
+}
+
+// Dispatch calls the handlers of m, and returns the error decoding it, if
+// any.
+func (b *Bus) Dispatch(m *Message) error {
+	b.mu.RLock()
+	messages := b.messages
+	hs := b.handlers[m.Service]
+	errs := b.errors
+	b.mu.RUnlock()
+
+	for _, h := range messages {
+		h.call(event{symbol: m.Service, v: m})
+	}
+	decode := decoders[m.Service]
+	if len(hs) == 0 || decode == nil || (m.Type != Data && m.Type != Snapshot) {
+		return nil
+	}
+	events, err := decode(m)
+	if err != nil {
+		for _, fn := range errs {
+			fn(m, err)
+		}
+		return err
+	}
+	for _, e := range events {
+		for _, h := range hs {
+			if h.symbol == "" || h.symbol == e.symbol {
+				h.call(e)
+			}
+		}
+	}
+	return nil
+}
+
+// Dropped returns the number of events dropped from the full buffers of
+// Buffered handlers.
+func (b *Bus) Dropped() uint64 {
+	return atomic.LoadUint64(&b.dropped)
+}
+
+// Run dispatches the messages of msgs, such as the Messages of a Streamer,
+// until msgs is closed or ctx is done.
+func (b *Bus) Run(ctx context.Context, msgs <-chan *Message) error {
+	for {
+		select {
+		case m, ok := <-msgs:
+			if !ok {
+				return nil
+			}
+			b.Dispatch(m)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package streamer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// quoteMessage returns a QUOTE data message of the JSON entries.
+func quoteMessage(t *testing.T, entries string) *Message {
+	t.Helper()
+	var content []Content
+	if err := json.Unmarshal([]byte(entries), &content); err != nil {
+		t.Fatal(err)
+	}
+	return &Message{Type: Data, Service: ServiceQuote, Content: content}
+}
+
+func TestBusDispatch(t *testing.T) {
+	bus := NewBus()
+	var aapl, all []string
+	bus.OnQuote("AAPL", func(q *Quote) { aapl = append(aapl, fmt.Sprint(q.Symbol, " ", q.LastPrice)) })
+	remove := bus.OnQuote("", func(q *Quote) { all = append(all, q.Symbol) })
+	var services []string
+	bus.OnMessage(func(m *Message) { services = append(services, m.Service) })
+	bars := 0
+	bus.OnBar("", func(*Bar) { bars++ })
+
+	if err := bus.Dispatch(quoteMessage(t, `[{"key": "AAPL", "3": 116.5}, {"key": "MSFT", "3": 205}]`)); err != nil {
+		t.Fatal(err)
+	}
+	remove()
+	remove() // a second call does nothing
+	if err := bus.Dispatch(quoteMessage(t, `[{"key": "AAPL", "3": 117}]`)); err != nil {
+		t.Fatal(err)
+	}
+	bus.Dispatch(&Message{Type: Notify, Heartbeat: true})
+
+	if got := fmt.Sprint(aapl); got != "[AAPL 116.5 AAPL 117]" {
+		t.Errorf("AAPL handler got %s", got)
+	}
+	if got := fmt.Sprint(all); got != "[AAPL MSFT]" {
+		t.Errorf("handler of every symbol got %s, want the quotes before its removal", got)
+	}
+	if got := fmt.Sprint(services); got != "[QUOTE QUOTE ]" {
+		t.Errorf("message handler got %s", got)
+	}
+	if bars != 0 {
+		t.Errorf("bar handler called %d times", bars)
+	}
+}
+
+func TestBusDecodeError(t *testing.T) {
+	bus := NewBus()
+	bus.OnQuote("", func(q *Quote) { t.Errorf("quote %+v delivered", q) })
+	var failed *Message
+	bus.OnError(func(m *Message, err error) { failed = m })
+
+	m := quoteMessage(t, `[{"key": "AAPL", "3": "high"}]`)
+	if err := bus.Dispatch(m); err == nil {
+		t.Error("Dispatch of a bad quote: err = nil")
+	}
+	if failed != m {
+		t.Errorf("error handler got %v, want the bad message", failed)
+	}
+}
+
+func TestBusRun(t *testing.T) {
+	bus := NewBus()
+	var mu sync.Mutex
+	n := 0
+	bus.OnQuote("", func(*Quote) {
+		mu.Lock()
+		defer mu.Unlock()
+		n++
+	})
+
+	msgs := make(chan *Message, 2)
+	msgs <- quoteMessage(t, `[{"key": "AAPL", "3": 116.5}]`)
+	msgs <- quoteMessage(t, `[{"key": "MSFT", "3": 205}]`)
+	close(msgs)
+	if err := bus.Run(context.Background(), msgs); err != nil {
+		t.Errorf("Run: %v", err)
+	}
+	mu.Lock()
+	if n != 2 {
+		t.Errorf("handler called %d times, want 2", n)
+	}
+	mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := bus.Run(ctx, make(chan *Message)); err != context.DeadlineExceeded {
+		t.Errorf("Run until ctx is done: err = %v", err)
+	}
+}
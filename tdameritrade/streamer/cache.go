@@ -0,0 +1,191 @@
+package streamer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/glacialspring/go-tdameritrade/tdameritrade"
+)
+
+// QuoteCache keeps the latest level one quote of every symbol, merging the
+// streamed updates, which only carry the fields that changed, into it:
+//
+//	cache := streamer.NewQuoteCache(client.Quotes, 5*time.Second)
+//	bus.OnQuote("", cache.Update)
+//	...
+//	q, err := cache.Latest(ctx, "AAPL")
+//
+// Symbols the stream hasn't quoted for longer than the max age are quoted
+// with the REST API. Its methods may be called concurrently.
+type QuoteCache struct {
+	rest   *tdameritrade.QuotesService
+	maxAge time.Duration
+
+	mu     sync.RWMutex
+	quotes map[string]*cachedQuote // by symbol
+}
+
+// cachedQuote is a quote of the cache. Its quote is never modified, but
+// replaced by the merge of the next update.
+type cachedQuote struct {
+	quote   *Quote
+	updated time.Time // when it was last quoted, by the stream or the REST API
+}
+
+// NewQuoteCache returns an empty QuoteCache quoting with rest the symbols
+// missing from it, or not quoted within maxAge. Quiet symbols, which the
+// stream sends no updates of, are thus quoted with the REST API again every
+// maxAge. If rest is nil, the cached quotes are returned however old. REST
+// quotes are merged with the streamed updates of their symbol if any come.
+func NewQuoteCache(rest *tdameritrade.QuotesService, maxAge time.Duration) *QuoteCache {
+	return &QuoteCache{
+		rest:   rest,
+		maxAge: maxAge,
+		quotes: make(map[string]*cachedQuote),
+	}
+}
+
+// Update merges the streamed update q into the quote of its symbol.
+func (c *QuoteCache) Update(q *Quote) {
+	symbol := strings.ToUpper(q.Symbol)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	cached, ok := c.quotes[symbol]
+	if !ok {
+		c.quotes[symbol] = &cachedQuote{quote: q.copy(), updated: now}
+		return
+	}
+	c.quotes[symbol] = &cachedQuote{quote: cached.quote.merge(q).(*Quote), updated: now}
+}
+
+// Latest returns the latest quote of symbol, whose Fields are those quoted
+// so far. A symbol missing from the cache, or last quoted longer than the
+// max age ago, is quoted with the REST API; an error is returned if it
+// can't be, such as tdameritrade.ErrSymbolNotFound.
+func (c *QuoteCache) Latest(ctx context.Context, symbol string) (*Quote, error) {
+	q, fresh := c.latest(symbol)
+	if fresh || (q != nil && c.rest == nil) {
+		return q, nil
+	}
+	if c.rest == nil {
+		return nil, fmt.Errorf("streamer: no quote of %s", symbol)
+	}
+
+	start := time.Now()
+	result, _, err := c.rest.GetQuotesResult(ctx, []string{symbol})
+	if err != nil {
+		return nil, err
+	}
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+	q, err = restQuote(result.Quotes[symbol])
+	if err != nil {
+		return nil, err
+	}
+
+	key := strings.ToUpper(symbol)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cached, ok := c.quotes[key]; ok && cached.updated.After(start) {
+		// The stream quoted it meanwhile.
+		return cached.quote.copy(), nil
+	}
+	c.quotes[key] = &cachedQuote{quote: q, updated: time.Now()}
+	return q.copy(), nil
+}
+
+// latest returns the cached quote of symbol, or nil if there is none, and
+// whether it was quoted within the max age.
+func (c *QuoteCache) latest(symbol string) (*Quote, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	cached, ok := c.quotes[strings.ToUpper(symbol)]
+	if !ok {
+		return nil, false
+	}
+	return cached.quote.copy(), time.Since(cached.updated) <= c.maxAge
+}
+
+// Symbols returns the symbols of the cache.
+func (c *QuoteCache) Symbols() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	symbols := make([]string, 0, len(c.quotes))
+	for symbol := range c.quotes {
+		symbols = append(symbols, symbol)
+	}
+	return symbols
+}
+
+// Forget removes the quote of symbol, such as after unsubscribing from it.
+func (c *QuoteCache) Forget(symbol string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.quotes, strings.ToUpper(symbol))
+}
+
+func (q *Quote) copy() *Quote {
+	cp := *q
+	cp.Fields = append([]QuoteField(nil), q.Fields...)
+	return &cp
+}
+
+// restQuote returns the level one quote of rq, a quote of the REST API of
+// an equity, an index or a mutual fund.
+func restQuote(rq *tdameritrade.Quote) (*Quote, error) {
+	q := &Quote{Symbol: rq.Symbol, Delayed: rq.Delayed, Description: rq.Description}
+	fields := []QuoteField{QuoteDescription}
+	switch d := rq.Data.(type) {
+	case *tdameritrade.EquityQuote:
+		q.BidPrice, q.AskPrice, q.LastPrice = d.BidPrice, d.AskPrice, d.LastPrice
+		q.BidSize, q.AskSize, q.LastSize = d.BidSize, d.AskSize, d.LastSize
+		q.BidID, q.AskID, q.LastID, q.BidTick = d.BidID, d.AskID, d.LastID, d.BidTick
+		q.OpenPrice, q.HighPrice, q.LowPrice, q.ClosePrice = d.OpenPrice, d.HighPrice, d.LowPrice, d.ClosePrice
+		q.NetChange, q.TotalVolume, q.Mark = d.NetChange, d.TotalVolume, d.Mark
+		q.QuoteTime, q.TradeTime = msTime(d.QuoteTimeInLong), msTime(d.TradeTimeInLong)
+		q.Exchange, q.ExchangeName, q.Digits = d.Exchange, d.ExchangeName, d.Digits
+		q.Marginable, q.Shortable, q.Volatility = d.Marginable, d.Shortable, d.Volatility
+		q.Five2WkHigh, q.Five2WkLow, q.NAV, q.PERatio = d.Five2WkHigh, d.Five2WkLow, d.NAV, d.PeRatio
+		q.DivAmount, q.DivYield, q.DivDate = d.DivAmount, d.DivYield, d.DivDate
+		q.SecurityStatus = d.SecurityStatus
+		q.RegularMarketLastPrice, q.RegularMarketLastSize = d.RegularMarketLastPrice, float64(d.RegularMarketLastSize)
+		q.RegularMarketNetChange, q.RegularMarketTradeTime = d.RegularMarketNetChange, msTime(d.RegularMarketTradeTimeInLong)
+		fields = append(fields,
+			QuoteBidPrice, QuoteAskPrice, QuoteLastPrice, QuoteBidSize, QuoteAskSize, QuoteLastSize,
+			QuoteBidID, QuoteAskID, QuoteLastID, QuoteBidTick,
+			QuoteOpenPrice, QuoteHighPrice, QuoteLowPrice, QuoteClosePrice,
+			QuoteNetChange, QuoteTotalVolume, QuoteMark, QuoteTime, QuoteTradeTime,
+			QuoteExchange, QuoteExchangeName, QuoteDigits, QuoteMarginable, QuoteShortable, QuoteVolatility,
+			Quote52WkHigh, Quote52WkLow, QuoteNAV, QuotePERatio, QuoteDivAmount, QuoteDivYield, QuoteDivDate,
+			QuoteSecurityStatus, QuoteRegularMarketLastPrice, QuoteRegularMarketLastSize,
+			QuoteRegularMarketNetChange, QuoteRegularMarketTradeTime)
+	case *tdameritrade.IndexQuote:
+		q.LastPrice, q.OpenPrice, q.HighPrice, q.LowPrice, q.ClosePrice = d.LastPrice, d.OpenPrice, d.HighPrice, d.LowPrice, d.ClosePrice
+		q.NetChange, q.TotalVolume, q.TradeTime = d.NetChange, d.TotalVolume, msTime(d.TradeTimeInLong)
+		q.Exchange, q.ExchangeName, q.Digits = d.Exchange, d.ExchangeName, d.Digits
+		q.Five2WkHigh, q.Five2WkLow, q.SecurityStatus = d.Five2WkHigh, d.Five2WkLow, d.SecurityStatus
+		fields = append(fields,
+			QuoteLastPrice, QuoteOpenPrice, QuoteHighPrice, QuoteLowPrice, QuoteClosePrice,
+			QuoteNetChange, QuoteTotalVolume, QuoteTradeTime, QuoteExchange, QuoteExchangeName, QuoteDigits,
+			Quote52WkHigh, Quote52WkLow, QuoteSecurityStatus)
+	case *tdameritrade.MutualFundQuote:
+		q.ClosePrice, q.NetChange, q.TotalVolume, q.TradeTime = d.ClosePrice, d.NetChange, d.TotalVolume, msTime(d.TradeTimeInLong)
+		q.Exchange, q.ExchangeName, q.Digits = d.Exchange, d.ExchangeName, d.Digits
+		q.Five2WkHigh, q.Five2WkLow, q.NAV, q.PERatio = d.Five2WkHigh, d.Five2WkLow, d.NAV, d.PeRatio
+		q.DivAmount, q.DivYield, q.DivDate, q.SecurityStatus = d.DivAmount, d.DivYield, d.DivDate, d.SecurityStatus
+		fields = append(fields,
+			QuoteClosePrice, QuoteNetChange, QuoteTotalVolume, QuoteTradeTime,
+			QuoteExchange, QuoteExchangeName, QuoteDigits, Quote52WkHigh, Quote52WkLow,
+			QuoteNAV, QuotePERatio, QuoteDivAmount, QuoteDivYield, QuoteDivDate, QuoteSecurityStatus)
+	default:
+		return nil, fmt.Errorf("streamer: %s is a %s, which has no level one quote", rq.Symbol, rq.AssetMainType)
+	}
+	q.Fields = fields
+	return q, nil
+}
@@ -0,0 +1,81 @@
+package streamer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/glacialspring/go-tdameritrade/tdameritrade"
+)
+
+// quotesServer returns a REST client quoting every symbol at lastPrice 182,
+// and the count of the quotes it made.
+func quotesServer(t *testing.T) (*tdameritrade.Client, *int32) {
+	var n int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&n, 1)
+		symbol := r.URL.Query().Get("symbol")
+		fmt.Fprintf(w, `{%q: {"assetType": "EQUITY", "symbol": %q, "bidPrice": 181.9, "askPrice": 182.1, "lastPrice": 182}}`, symbol, symbol)
+	}))
+	t.Cleanup(srv.Close)
+	c, err := tdameritrade.NewClient(srv.Client(), tdameritrade.WithBaseURL(srv.URL+"/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, &n
+}
+
+func TestQuoteCache(t *testing.T) {
+	client, fetches := quotesServer(t)
+	cache := NewQuoteCache(client.Quotes, 100*time.Millisecond)
+	ctx := context.Background()
+
+	latest := func(symbol string, wantLast float64, wantFetches int32) *Quote {
+		t.Helper()
+		q, err := cache.Latest(ctx, symbol)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if q.LastPrice != wantLast {
+			t.Errorf("%s last price = %v, want %v", symbol, q.LastPrice, wantLast)
+		}
+		if got := atomic.LoadInt32(fetches); got != wantFetches {
+			t.Errorf("%d REST quotes, want %d", got, wantFetches)
+		}
+		return q
+	}
+
+	cache.Update(&Quote{Symbol: "AAPL", LastPrice: 116, Fields: []QuoteField{QuoteLastPrice}})
+	latest("aapl", 116, 0)
+	latest("MSFT", 182, 1)
+
+	// A streamed quote older than the max age is quoted again.
+	time.Sleep(150 * time.Millisecond)
+	latest("AAPL", 182, 2)
+	latest("AAPL", 182, 2)
+
+	cache.Update(&Quote{Symbol: "AAPL", BidPrice: 181.5, Fields: []QuoteField{QuoteBidPrice}})
+	if q := latest("AAPL", 182, 2); q.BidPrice != 181.5 || q.AskPrice != 182.1 {
+		t.Errorf("quote = %+v, want the update merged into the REST quote", q)
+	}
+
+	cache.Forget("AAPL")
+	latest("AAPL", 182, 3)
+}
+
+func TestQuoteCacheWithoutREST(t *testing.T) {
+	cache := NewQuoteCache(nil, time.Millisecond)
+	cache.Update(&Quote{Symbol: "AAPL", LastPrice: 116, Fields: []QuoteField{QuoteLastPrice}})
+	time.Sleep(5 * time.Millisecond)
+
+	if q, err := cache.Latest(context.Background(), "AAPL"); err != nil || q.LastPrice != 116 {
+		t.Errorf("Latest = %+v, %v, want the old streamed quote", q, err)
+	}
+	if _, err := cache.Latest(context.Background(), "MSFT"); err == nil {
+		t.Error("Latest of a symbol never quoted: err = nil")
+	}
+}
@@ -0,0 +1,94 @@
+package streamer
+
+import (
+	"context"
+	"time"
+
+	"github.com/glacialspring/go-tdameritrade/tdameritrade"
+)
+
+// The chart services stream a minute bar of every symbol subscribed to as
+// each minute ends.
+const (
+	ServiceChartEquity  = "CHART_EQUITY"
+	ServiceChartFutures = "CHART_FUTURES"
+)
+
+// Bar is a minute bar of a chart service. Its Candle starts at the minute
+// it covers, like the candles of the price history.
+type Bar struct {
+	Symbol string
+	tdameritrade.Candle
+
+	// Sequence numbers the bars of CHART_EQUITY, and is 0 for futures.
+	Sequence int64
+}
+
+// equityBar is a bar of CHART_EQUITY, whose fields differ from those of
+// CHART_FUTURES.
+type equityBar struct {
+	Symbol   string    `field:"key"`
+	Open     float64   `field:"1"`
+	High     float64   `field:"2"`
+	Low      float64   `field:"3"`
+	Close    float64   `field:"4"`
+	Volume   float64   `field:"5"`
+	Sequence int64     `field:"6"`
+	Time     time.Time `field:"7"`
+}
+
+type futuresBar struct {
+	Symbol string    `field:"key"`
+	Time   time.Time `field:"1"`
+	Open   float64   `field:"2"`
+	High   float64   `field:"3"`
+	Low    float64   `field:"4"`
+	Close  float64   `field:"5"`
+	Volume float64   `field:"6"`
+}
+
+// SubscribeChartEquity subscribes to the minute bars of the equities
+// symbols, replacing the symbols subscribed before.
+func (s *Streamer) SubscribeChartEquity(ctx context.Context, symbols []string) error {
+	return s.subscribe(ctx, ServiceChartEquity, symbols, fieldList(taggedFields(equityBar{})))
+}
+
+// SubscribeChartFutures subscribes to the minute bars of the futures
+// symbols, such as /ES, replacing the symbols subscribed before.
+func (s *Streamer) SubscribeChartFutures(ctx context.Context, symbols []string) error {
+	return s.subscribe(ctx, ServiceChartFutures, symbols, fieldList(taggedFields(futuresBar{})))
+}
+
+// ParseBars returns the bars of m, a message of the CHART_EQUITY or
+// CHART_FUTURES service.
+func ParseBars(m *Message) ([]*Bar, error) {
+	if err := checkService(m, ServiceChartEquity, ServiceChartFutures); err != nil {
+		return nil, err
+	}
+	bars := make([]*Bar, 0, len(m.Content))
+	for _, c := range m.Content {
+		var bar *Bar
+		if m.Service == ServiceChartFutures {
+			var b futuresBar
+			if err := decodeContent(c, &b); err != nil {
+				return nil, err
+			}
+			bar = &Bar{
+				Symbol: b.Symbol,
+				Candle: tdameritrade.Candle{Datetime: b.Time, Open: b.Open, High: b.High, Low: b.Low, Close: b.Close, Volume: b.Volume},
+			}
+		} else {
+			var b equityBar
+			if err := decodeContent(c, &b); err != nil {
+				return nil, err
+			}
+			bar = &Bar{
+				Symbol:   b.Symbol,
+				Candle:   tdameritrade.Candle{Datetime: b.Time, Open: b.Open, High: b.High, Low: b.Low, Close: b.Close, Volume: b.Volume},
+				Sequence: b.Sequence,
+			}
+		}
+		bars = append(bars, bar)
+	}
+	return bars, nil
+}
@@ -0,0 +1,68 @@
+package streamer
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSubscribeCharts(t *testing.T) {
+	ts := newTestServer(t)
+	s := ts.dial(t)
+	ts.nextRequest(t) // login
+
+	ctx := context.Background()
+	if err := s.SubscribeChartEquity(ctx, []string{"SPY"}); err != nil {
+		t.Fatal(err)
+	}
+	if req := ts.nextRequest(t); req.Service != ServiceChartEquity || req.Parameters["fields"] != "0,1,2,3,4,5,6,7" {
+		t.Errorf("request = %+v, want CHART_EQUITY SUBS of every field", req)
+	}
+	if err := s.SubscribeChartFutures(ctx, []string{"/ES"}); err != nil {
+		t.Fatal(err)
+	}
+	if req := ts.nextRequest(t); req.Service != ServiceChartFutures || req.Parameters["keys"] != "/ES" || req.Parameters["fields"] != "0,1,2,3,4,5,6" {
+		t.Errorf("request = %+v, want CHART_FUTURES SUBS of /ES", req)
+	}
+}
+
+func TestParseBars(t *testing.T) {
+	minute := time.Unix(1600000020, 0)
+	for _, tt := range []struct {
+		name     string
+		m        *Message
+		sequence int64
+	}{
+		{
+			name: "equity",
+			m: &Message{Service: ServiceChartEquity, Content: []Content{{
+				"key": []byte(`"SPY"`), "1": []byte(`335`), "2": []byte(`336`), "3": []byte(`334.5`),
+				"4": []byte(`335.5`), "5": []byte(`12000`), "6": []byte(`42`), "7": []byte(`1600000020000`),
+			}}},
+			sequence: 42,
+		},
+		{
+			name: "futures",
+			m: &Message{Service: ServiceChartFutures, Content: []Content{{
+				"key": []byte(`"SPY"`), "1": []byte(`1600000020000`), "2": []byte(`335`), "3": []byte(`336`),
+				"4": []byte(`334.5`), "5": []byte(`335.5`), "6": []byte(`12000`),
+			}}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			bars, err := ParseBars(tt.m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := bars[0]
+			if b.Symbol != "SPY" || !b.Datetime.Equal(minute) || b.Open != 335 || b.High != 336 || b.Low != 334.5 ||
+				b.Close != 335.5 || b.Volume != 12000 || b.Sequence != tt.sequence {
+				t.Errorf("bar = %+v", b)
+			}
+		})
+	}
+
+	if _, err := ParseBars(&Message{Service: ServiceQuote}); err == nil {
+		t.Error("parsing a QUOTE message as bars succeeded, want an error")
+	}
+}
@@ -0,0 +1,118 @@
+package streamer
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The typed messages of the services are structs whose fields carry a
+// field tag naming the content key they decode, such as "1" or "key".
+// Fields of type time.Time decode epoch milliseconds.
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// fieldPlans caches the tagged fields of the struct types, by type.
+var fieldPlans sync.Map
+
+type fieldPlan struct {
+	key   string
+	index int
+}
+
+func plan(t reflect.Type) []fieldPlan {
+	if p, ok := fieldPlans.Load(t); ok {
+		return p.([]fieldPlan)
+	}
+	var p []fieldPlan
+	for i := 0; i < t.NumField(); i++ {
+		if key, ok := t.Field(i).Tag.Lookup("field"); ok {
+			p = append(p, fieldPlan{key: key, index: i})
+		}
+	}
+	fieldPlans.Store(t, p)
+	return p
+}
+
+// decodeContent sets the tagged fields of the struct v points to from the
+// keys of c, leaving those c lacks alone.
+func decodeContent(c Content, v interface{}) error {
+	rv := reflect.ValueOf(v).Elem()
+	for _, p := range plan(rv.Type()) {
+		raw, ok := c[p.key]
+		if !ok {
+			continue
+		}
+		f := rv.Field(p.index)
+		if f.Type() == timeType {
+			var ms float64
+			if err := json.Unmarshal(raw, &ms); err != nil {
+				return fmt.Errorf("streamer: decoding field %s of %s: %v", p.key, c.Key(), err)
+			}
+			f.Set(reflect.ValueOf(msTime(int64(ms))))
+			continue
+		}
+		if err := json.Unmarshal(raw, f.Addr().Interface()); err != nil {
+			return fmt.Errorf("streamer: decoding field %s of %s: %v", p.key, c.Key(), err)
+		}
+	}
+	return nil
+}
+
+// mergeFields copies the fields tagged with the numbers nums from the
+// struct src points to into the one dst points to, of the same type.
+func mergeFields(dst, src interface{}, nums []int) {
+	want := make(map[string]bool, len(nums))
+	for _, n := range nums {
+		want[strconv.Itoa(n)] = true
+	}
+	d, sv := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem()
+	for _, p := range plan(d.Type()) {
+		if want[p.key] {
+			d.Field(p.index).Set(sv.Field(p.index))
+		}
+	}
+}
+
+// fieldNumbers returns the numeric keys of c, sorted.
+func fieldNumbers(c Content) []int {
+	var nums []int
+	for k := range c {
+		if n, err := strconv.Atoi(k); err == nil {
+			nums = append(nums, n)
+		}
+	}
+	sort.Ints(nums)
+	return nums
+}
+
+// taggedFields returns the numeric field tags of the struct type of v.
+func taggedFields(v interface{}) []int {
+	var nums []int
+	for _, p := range plan(reflect.TypeOf(v)) {
+		if n, err := strconv.Atoi(p.key); err == nil {
+			nums = append(nums, n)
+		}
+	}
+	sort.Ints(nums)
+	return nums
+}
+
+// fieldList returns the fields parameter of a subscription to fields, which
+// always include 0, the symbol.
+func fieldList(fields []int) string {
+	seen := map[int]bool{0: true}
+	list := []string{"0"}
+	for _, f := range fields {
+		if !seen[f] {
+			seen[f] = true
+			list = append(list, strconv.Itoa(f))
+		}
+	}
+	return strings.Join(list, ",")
+}
@@ -0,0 +1,39 @@
+package streamer
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// ErrStalled is the error of a connection on which nothing arrived within
+// the stall timeout.
+var ErrStalled = errors.New("streamer: connection stalled")
+
+// WithStallTimeout makes the streamer drop its connection when no frame,
+// data or heartbeat, arrives within d, which would otherwise go unnoticed
+// as the connection stays open. The streamer sends heartbeats every 10s or
+// so while no data flows, so d should be well above that; 30s is a good
+// value. A Stall message is then delivered, and the connection is made
+// again if the streamer reconnects, or else the stream ends with
+// ErrStalled.
+func WithStallTimeout(d time.Duration) Option {
+	return func(s *Streamer) error {
+		if d < 0 {
+			return fmt.Errorf("streamer: negative stall timeout %v", d)
+		}
+		s.stallTimeout = d
+		return nil
+	}
+}
+
+// LastMessage returns when the last frame, data or heartbeat, arrived from
+// the streamer, or the zero time if none did.
+func (s *Streamer) LastMessage() time.Time {
+	ns := atomic.LoadInt64(&s.lastFrame)
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
@@ -0,0 +1,43 @@
+package streamer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStallTimeout(t *testing.T) {
+	ts := newTestServer(t)
+	s := ts.dial(t, WithStallTimeout(200*time.Millisecond))
+	ts.nextRequest(t) // login
+
+	// Heartbeats keep the connection alive past the timeout.
+	for i := 0; i < 4; i++ {
+		ts.send(t, `{"notify": [{"heartbeat": "1600000000000"}]}`)
+		if m := nextMessage(t, s); !m.Heartbeat {
+			t.Fatalf("message = %+v, want a heartbeat", m)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	last := s.LastMessage()
+	if last.IsZero() || time.Since(last) > time.Second {
+		t.Errorf("LastMessage = %v, want the last heartbeat", last)
+	}
+
+	m := nextMessage(t, s)
+	if m.Type != Stall || !m.Time.Equal(last) {
+		t.Errorf("message = %+v, want a stall since %v", m, last)
+	}
+	if _, ok := <-s.Messages(); ok {
+		t.Error("message received after the stall")
+	}
+	if err := s.Err(); err != ErrStalled {
+		t.Errorf("Err = %v, want %v", err, ErrStalled)
+	}
+}
+
+func TestStallTimeoutNegative(t *testing.T) {
+	ts := newTestServer(t)
+	if _, err := New(ts.principals(), WithStallTimeout(-time.Second)); err == nil {
+		t.Error("New accepted a negative stall timeout")
+	}
+}
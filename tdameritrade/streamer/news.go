@@ -0,0 +1,73 @@
+package streamer
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// ServiceNewsHeadline streams the news headlines of symbols.
+const ServiceNewsHeadline = "NEWS_HEADLINE"
+
+// Headline is a news headline of a symbol.
+type Headline struct {
+	Symbol       string    `field:"key"`
+	ErrorCode    int       `field:"1"` // 0 unless the headline is an error
+	StoryTime    time.Time `field:"2"`
+	HeadlineID   string    `field:"3"`
+	Status       string    `field:"4"`
+	Headline     string    `field:"5"`
+	StoryID      string    `field:"6"`
+	KeywordCount int       `field:"7"`
+	Keywords     Keywords  `field:"8"`
+	IsHot        bool      `field:"9"`
+	Source       string    `field:"10"` // the story source, such as DJ
+}
+
+// Keywords are the keywords of a story, mostly the symbols it is about.
+type Keywords []string
+
+// UnmarshalJSON decodes keywords sent as an array, or as a string of
+// comma separated keywords.
+func (k *Keywords) UnmarshalJSON(b []byte) error {
+	var list []string
+	if err := json.Unmarshal(b, &list); err == nil {
+		*k = list
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	*k = nil
+	for _, kw := range strings.Split(s, ",") {
+		if kw = strings.TrimSpace(kw); kw != "" {
+			*k = append(*k, kw)
+		}
+	}
+	return nil
+}
+
+// SubscribeNewsHeadlines subscribes to the news headlines of symbols,
+// replacing the symbols subscribed before.
+func (s *Streamer) SubscribeNewsHeadlines(ctx context.Context, symbols []string) error {
+	return s.subscribe(ctx, ServiceNewsHeadline, symbols, fieldList(taggedFields(Headline{})))
+}
+
+// ParseHeadlines returns the headlines of m, a message of the NEWS_HEADLINE
+// service.
+func ParseHeadlines(m *Message) ([]*Headline, error) {
+	if err := checkService(m, ServiceNewsHeadline); err != nil {
+		return nil, err
+	}
+	headlines := make([]*Headline, 0, len(m.Content))
+	for _, c := range m.Content {
+		h := new(Headline)
+		if err := decodeContent(c, h); err != nil {
+			return nil, err
+		}
+		headlines = append(headlines, h)
+	}
+	return headlines, nil
+}
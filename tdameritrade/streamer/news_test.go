@@ -0,0 +1,47 @@
+package streamer
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSubscribeNewsHeadlines(t *testing.T) {
+	ts := newTestServer(t)
+	s := ts.dial(t)
+	ts.nextRequest(t) // login
+
+	if err := s.SubscribeNewsHeadlines(context.Background(), []string{"AAPL"}); err != nil {
+		t.Fatal(err)
+	}
+	if req := ts.nextRequest(t); req.Service != ServiceNewsHeadline || req.Parameters["fields"] != "0,1,2,3,4,5,6,7,8,9,10" {
+		t.Errorf("request = %+v, want NEWS_HEADLINE SUBS of every field", req)
+	}
+}
+
+func TestParseHeadlines(t *testing.T) {
+	m := &Message{Type: Snapshot, Service: ServiceNewsHeadline, Content: []Content{
+		{
+			"key": []byte(`"AAPL"`), "1": []byte(`0`), "2": []byte(`1600000000000`), "3": []byte(`"H1"`),
+			"5": []byte(`"Apple unveils new products"`), "8": []byte(`"AAPL, MSFT,"`), "9": []byte(`true`), "10": []byte(`"DJ"`),
+		},
+		{"key": []byte(`"AAPL"`), "5": []byte(`"Second story"`), "8": []byte(`["AAPL"]`)},
+	}}
+	headlines, err := ParseHeadlines(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(headlines) != 2 {
+		t.Fatalf("got %d headlines, want 2", len(headlines))
+	}
+	h := headlines[0]
+	if h.Symbol != "AAPL" || h.HeadlineID != "H1" || !h.StoryTime.Equal(time.Unix(1600000000, 0)) || !h.IsHot || h.Source != "DJ" {
+		t.Errorf("headline = %+v", h)
+	}
+	if len(h.Keywords) != 2 || h.Keywords[0] != "AAPL" || h.Keywords[1] != "MSFT" {
+		t.Errorf("keywords = %q, want AAPL and MSFT", h.Keywords)
+	}
+	if kw := headlines[1].Keywords; len(kw) != 1 || kw[0] != "AAPL" {
+		t.Errorf("keywords = %q, want AAPL", kw)
+	}
+}
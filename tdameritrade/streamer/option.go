@@ -0,0 +1,171 @@
+package streamer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/glacialspring/go-tdameritrade/tdameritrade"
+)
+
+// ServiceOption streams level one quotes of options.
+const ServiceOption = "OPTION"
+
+// OptionField is a field of the level one option quotes, which
+// subscriptions may select.
+type OptionField int
+
+const (
+	OptionSymbol           OptionField = 0
+	OptionDescription      OptionField = 1
+	OptionBidPrice         OptionField = 2
+	OptionAskPrice         OptionField = 3
+	OptionLastPrice        OptionField = 4
+	OptionHighPrice        OptionField = 5
+	OptionLowPrice         OptionField = 6
+	OptionClosePrice       OptionField = 7
+	OptionTotalVolume      OptionField = 8
+	OptionOpenInterest     OptionField = 9
+	OptionVolatility       OptionField = 10
+	OptionIntrinsicValue   OptionField = 13
+	OptionExpirationYear   OptionField = 16
+	OptionMultiplier       OptionField = 17
+	OptionDigits           OptionField = 18
+	OptionOpenPrice        OptionField = 19
+	OptionBidSize          OptionField = 20
+	OptionAskSize          OptionField = 21
+	OptionLastSize         OptionField = 22
+	OptionNetChange        OptionField = 23
+	OptionStrikePrice      OptionField = 24
+	OptionContractType     OptionField = 25
+	OptionUnderlying       OptionField = 26
+	OptionExpirationMonth  OptionField = 27
+	OptionDeliverables     OptionField = 28
+	OptionTimeValue        OptionField = 29
+	OptionExpirationDay    OptionField = 30
+	OptionDaysToExpiration OptionField = 31
+	OptionDelta            OptionField = 32
+	OptionGamma            OptionField = 33
+	OptionTheta            OptionField = 34
+	OptionVega             OptionField = 35
+	OptionRho              OptionField = 36
+	OptionSecurityStatus   OptionField = 37
+	OptionTheoreticalValue OptionField = 38
+	OptionUnderlyingPrice  OptionField = 39
+	OptionUVExpirationType OptionField = 40
+	OptionMark             OptionField = 41
+)
+
+// OptionQuote is a level one option quote update, greeks included. As with
+// Quote, only the fields listed in Fields changed; the others are zero.
+type OptionQuote struct {
+	Symbol           string  `field:"key"`
+	Delayed          bool    `field:"delayed"`
+	Description      string  `field:"1"`
+	BidPrice         float64 `field:"2"`
+	AskPrice         float64 `field:"3"`
+	LastPrice        float64 `field:"4"`
+	HighPrice        float64 `field:"5"`
+	LowPrice         float64 `field:"6"`
+	ClosePrice       float64 `field:"7"`
+	TotalVolume      float64 `field:"8"`
+	OpenInterest     float64 `field:"9"`
+	Volatility       float64 `field:"10"`
+	IntrinsicValue   float64 `field:"13"`
+	ExpirationYear   int     `field:"16"`
+	Multiplier       float64 `field:"17"`
+	Digits           int     `field:"18"`
+	OpenPrice        float64 `field:"19"`
+	BidSize          float64 `field:"20"`
+	AskSize          float64 `field:"21"`
+	LastSize         float64 `field:"22"`
+	NetChange        float64 `field:"23"`
+	StrikePrice      float64 `field:"24"`
+	ContractType     string  `field:"25"` // C or P
+	Underlying       string  `field:"26"`
+	ExpirationMonth  int     `field:"27"`
+	Deliverables     string  `field:"28"`
+	TimeValue        float64 `field:"29"`
+	ExpirationDay    int     `field:"30"`
+	DaysToExpiration int     `field:"31"`
+	Delta            float64 `field:"32"`
+	Gamma            float64 `field:"33"`
+	Theta            float64 `field:"34"`
+	Vega             float64 `field:"35"`
+	Rho              float64 `field:"36"`
+	SecurityStatus   string  `field:"37"`
+	TheoreticalValue float64 `field:"38"`
+	UnderlyingPrice  float64 `field:"39"`
+	UVExpirationType string  `field:"40"`
+	Mark             float64 `field:"41"`
+
+	Fields []OptionField // the fields of the update, by number
+}
+
+// Has reports whether the update carries the field f.
+func (o *OptionQuote) Has(f OptionField) bool {
+	for _, g := range o.Fields {
+		if g == f {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseSymbol parses the symbol of the option, to match it with the
+// contracts of an option chain.
+func (o *OptionQuote) ParseSymbol() (*tdameritrade.OptionSymbol, error) {
+	return tdameritrade.ParseOptionSymbol(o.Symbol)
+}
+
+// SubscribeOptions subscribes to the level one quotes of the options
+// symbols, replacing the options subscribed before. Symbols may be given in
+// TD Ameritrade format, as the Symbol of the contracts of an option chain,
+// or in OCC format; updates always carry the TD Ameritrade format. Only
+// fields are sent if any are given, otherwise every field of OptionQuote is.
+func (s *Streamer) SubscribeOptions(ctx context.Context, symbols []string, fields ...OptionField) error {
+	keys, err := optionKeys(symbols)
+	if err != nil {
+		return err
+	}
+	nums := fieldInts(fields)
+	if len(nums) == 0 {
+		nums = taggedFields(OptionQuote{})
+	}
+	return s.subscribe(ctx, ServiceOption, keys, fieldList(nums))
+}
+
+// optionKeys returns symbols, option symbols in TD Ameritrade or OCC
+// format, in TD Ameritrade format.
+func optionKeys(symbols []string) ([]string, error) {
+	keys := make([]string, len(symbols))
+	for i, symbol := range symbols {
+		sym, err := tdameritrade.ParseOptionSymbol(symbol)
+		if err != nil {
+			if sym, err = tdameritrade.ParseOCCSymbol(symbol); err != nil {
+				return nil, fmt.Errorf("streamer: invalid option symbol %q", symbol)
+			}
+		}
+		keys[i] = sym.String()
+	}
+	return keys, nil
+}
+
+// ParseOptionQuotes returns the option quotes of m, a message of the OPTION
+// service.
+func ParseOptionQuotes(m *Message) ([]*OptionQuote, error) {
+	if err := checkService(m, ServiceOption); err != nil {
+		return nil, err
+	}
+	options := make([]*OptionQuote, 0, len(m.Content))
+	for _, c := range m.Content {
+		o := new(OptionQuote)
+		if err := decodeContent(c, o); err != nil {
+			return nil, err
+		}
+		for _, n := range fieldNumbers(c) {
+			o.Fields = append(o.Fields, OptionField(n))
+		}
+		options = append(options, o)
+	}
+	return options, nil
+}
@@ -0,0 +1,54 @@
+package streamer
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSubscribeOptions(t *testing.T) {
+	ts := newTestServer(t)
+	s := ts.dial(t)
+	ts.nextRequest(t) // login
+
+	ctx := context.Background()
+	err := s.SubscribeOptions(ctx, []string{"AAPL_011924C150", "SPY   240119P00412500"}, OptionBidPrice, OptionAskPrice, OptionDelta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := ts.nextRequest(t)
+	if req.Service != ServiceOption || req.Parameters["keys"] != "AAPL_011924C150,SPY_011924P412.5" || req.Parameters["fields"] != "0,2,3,32" {
+		t.Errorf("request = %+v, want OPTION SUBS of both options in TD Ameritrade format", req)
+	}
+
+	if err := s.SubscribeOptions(ctx, []string{"AAPL"}); err == nil {
+		t.Error("subscribing to an equity as an option succeeded, want an error")
+	}
+}
+
+func TestParseOptionQuotes(t *testing.T) {
+	m := &Message{Type: Data, Service: ServiceOption, Content: []Content{{
+		"key": []byte(`"AAPL_011924C150"`),
+		"2":   []byte(`4.1`),
+		"3":   []byte(`4.25`),
+		"25":  []byte(`"C"`),
+		"32":  []byte(`0.52`),
+	}}}
+	options, err := ParseOptionQuotes(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := options[0]
+	if o.Symbol != "AAPL_011924C150" || o.BidPrice != 4.1 || o.AskPrice != 4.25 || o.ContractType != "C" || o.Delta != 0.52 {
+		t.Errorf("option quote = %+v", o)
+	}
+	if !o.Has(OptionDelta) || o.Has(OptionGamma) || len(o.Fields) != 4 {
+		t.Errorf("fields = %v, want bid, ask, contract type and delta", o.Fields)
+	}
+	sym, err := o.ParseSymbol()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sym.Underlying != "AAPL" || sym.Strike != 150 {
+		t.Errorf("symbol = %+v, want the AAPL 150 call", sym)
+	}
+}
@@ -0,0 +1,221 @@
+package streamer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Services and commands of the admin protocol.
+const (
+	ServiceAdmin = "ADMIN"
+
+	CommandLogin  = "LOGIN"
+	CommandLogout = "LOGOUT"
+)
+
+// MessageType is the kind of a Message.
+type MessageType int
+
+const (
+	// Response answers a request, such as a login or a subscription.
+	Response MessageType = iota
+	// Data carries updates of a subscription.
+	Data
+	// Snapshot carries data sent once, such as a list of headlines.
+	Snapshot
+	// Notify is a heartbeat or a notice of the streamer, such as the
+	// end of the stream.
+	Notify
+	// Stall is not sent by the streamer but delivered when nothing came
+	// from it within the stall timeout, Time being when the last frame
+	// arrived. The connection is closed, and made again if the streamer
+	// reconnects.
+	Stall
+)
+
+func (t MessageType) String() string {
+	switch t {
+	case Response:
+		return "response"
+	case Data:
+		return "data"
+	case Snapshot:
+		return "snapshot"
+	case Notify:
+		return "notify"
+	case Stall:
+		return "stall"
+	}
+	return "unknown"
+}
+
+// Message is a message received from the streamer.
+type Message struct {
+	Type    MessageType
+	Service string
+	Command string
+	Time    time.Time
+
+	// RequestID is the id of the request a response answers.
+	RequestID string
+
+	// Code and Text are the result of a response, 0 meaning success, or
+	// the code and text of a notice.
+	Code int
+	Text string
+
+	// Heartbeat is set on the heartbeats the streamer sends while no data
+	// flows, Time being their time.
+	Heartbeat bool
+
+	// Content holds the entries of a data or snapshot message, one per
+	// symbol.
+	Content []Content
+}
+
+// Content is an entry of a data or snapshot message: the fields of a
+// symbol, keyed by field number, along with "key", the symbol.
+type Content map[string]json.RawMessage
+
+// Key returns the symbol of c.
+func (c Content) Key() string {
+	var key string
+	json.Unmarshal(c["key"], &key)
+	return key
+}
+
+// ResponseError is returned when the streamer refuses a request.
+type ResponseError struct {
+	Service string
+	Command string
+	Code    int
+	Message string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("streamer: %s %s: %d %s", e.Service, e.Command, e.Code, e.Message)
+}
+
+// request is a request sent to the streamer.
+type request struct {
+	Service    string            `json:"service"`
+	Command    string            `json:"command"`
+	RequestID  string            `json:"requestid"`
+	Account    string            `json:"account"`
+	Source     string            `json:"source"`
+	Parameters map[string]string `json:"parameters"`
+}
+
+// frame is a frame received from the streamer, which holds messages of a
+// single type.
+type frame struct {
+	Response []wireMessage `json:"response"`
+	Data     []wireMessage `json:"data"`
+	Snapshot []wireMessage `json:"snapshot"`
+	Notify   []wireMessage `json:"notify"`
+}
+
+type wireMessage struct {
+	Service   string          `json:"service"`
+	Command   string          `json:"command"`
+	RequestID flexString      `json:"requestid"`
+	Timestamp int64           `json:"timestamp"`
+	Heartbeat flexString      `json:"heartbeat"`
+	Content   json.RawMessage `json:"content"`
+}
+
+// flexString is a string the streamer sends either quoted or as a number.
+type flexString string
+
+func (s *flexString) UnmarshalJSON(b []byte) error {
+	var str string
+	if err := json.Unmarshal(b, &str); err == nil {
+		*s = flexString(str)
+		return nil
+	}
+	*s = flexString(b)
+	return nil
+}
+
+// parseFrame returns the messages of the frame b.
+func parseFrame(b []byte) ([]*Message, error) {
+	var f frame
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("streamer: decoding frame: %v", err)
+	}
+
+	var msgs []*Message
+	add := func(t MessageType, wms []wireMessage) error {
+		for _, wm := range wms {
+			m, err := wm.message(t)
+			if err != nil {
+				return err
+			}
+			msgs = append(msgs, m)
+		}
+		return nil
+	}
+	if err := add(Response, f.Response); err != nil {
+		return nil, err
+	}
+	if err := add(Data, f.Data); err != nil {
+		return nil, err
+	}
+	if err := add(Snapshot, f.Snapshot); err != nil {
+		return nil, err
+	}
+	if err := add(Notify, f.Notify); err != nil {
+		return nil, err
+	}
+	return msgs, nil
+}
+
+func (wm wireMessage) message(t MessageType) (*Message, error) {
+	m := &Message{
+		Type:      t,
+		Service:   wm.Service,
+		Command:   wm.Command,
+		RequestID: string(wm.RequestID),
+		Time:      msTime(wm.Timestamp),
+	}
+
+	if wm.Heartbeat != "" {
+		ms, err := strconv.ParseInt(string(wm.Heartbeat), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("streamer: bad heartbeat %q", wm.Heartbeat)
+		}
+		m.Heartbeat, m.Time = true, msTime(ms)
+		return m, nil
+	}
+	if len(wm.Content) == 0 {
+		return m, nil
+	}
+
+	switch t {
+	case Response, Notify:
+		var result struct {
+			Code int    `json:"code"`
+			Msg  string `json:"msg"`
+		}
+		if err := json.Unmarshal(wm.Content, &result); err != nil {
+			return nil, fmt.Errorf("streamer: decoding %s %s: %v", wm.Service, t, err)
+		}
+		m.Code, m.Text = result.Code, result.Msg
+	default:
+		if err := json.Unmarshal(wm.Content, &m.Content); err != nil {
+			return nil, fmt.Errorf("streamer: decoding %s %s: %v", wm.Service, t, err)
+		}
+	}
+	return m, nil
+}
+
+// msTime returns the time of ms, in epoch milliseconds, or the zero time if
+// ms is 0.
+func msTime(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
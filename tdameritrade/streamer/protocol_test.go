@@ -0,0 +1,83 @@
+package streamer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseFrame(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		frame string
+		want  Message
+	}{
+		{
+			name:  "heartbeat",
+			frame: `{"notify": [{"heartbeat": "1600000000000"}]}`,
+			want:  Message{Type: Notify, Heartbeat: true, Time: time.Unix(1600000000, 0)},
+		},
+		{
+			name:  "notice",
+			frame: `{"notify": [{"service": "ADMIN", "timestamp": 1600000000000, "content": {"code": 30, "msg": "Stop streaming due to empty subscription"}}]}`,
+			want:  Message{Type: Notify, Service: "ADMIN", Time: time.Unix(1600000000, 0), Code: 30, Text: "Stop streaming due to empty subscription"},
+		},
+		{
+			name:  "response with a numeric request id",
+			frame: `{"response": [{"service": "ADMIN", "requestid": 1, "command": "LOGIN", "timestamp": 1600000000000, "content": {"code": 0, "msg": "29-3"}}]}`,
+			want:  Message{Type: Response, Service: "ADMIN", Command: "LOGIN", RequestID: "1", Time: time.Unix(1600000000, 0), Text: "29-3"},
+		},
+		{
+			name:  "refused request",
+			frame: `{"response": [{"service": "QUOTE", "requestid": "2", "command": "SUBS", "timestamp": 1600000000000, "content": {"code": 11, "msg": "Bad key"}}]}`,
+			want:  Message{Type: Response, Service: "QUOTE", Command: "SUBS", RequestID: "2", Time: time.Unix(1600000000, 0), Code: 11, Text: "Bad key"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			msgs, err := parseFrame([]byte(tt.frame))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(msgs) != 1 {
+				t.Fatalf("got %d messages, want 1", len(msgs))
+			}
+			m := msgs[0]
+			if m.Type != tt.want.Type || m.Service != tt.want.Service || m.Command != tt.want.Command ||
+				m.RequestID != tt.want.RequestID || !m.Time.Equal(tt.want.Time) || m.Code != tt.want.Code ||
+				m.Text != tt.want.Text || m.Heartbeat != tt.want.Heartbeat {
+				t.Errorf("message = %+v, want %+v", m, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFrameData(t *testing.T) {
+	msgs, err := parseFrame([]byte(`{"data": [
+		{"service": "QUOTE", "command": "SUBS", "timestamp": 1600000000000, "content": [{"key": "AAPL", "1": 115.5}, {"key": "MSFT", "2": 205.25}]},
+		{"service": "CHART_EQUITY", "command": "SUBS", "timestamp": 1600000001000, "content": [{"key": "SPY", "4": 335.1}]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 2 {
+		t.Fatalf("got %d messages, want 2", len(msgs))
+	}
+	if m := msgs[0]; m.Type != Data || m.Service != "QUOTE" || len(m.Content) != 2 || m.Content[1].Key() != "MSFT" {
+		t.Errorf("first message = %+v, want QUOTE data of AAPL and MSFT", m)
+	}
+	if m := msgs[1]; m.Service != "CHART_EQUITY" || !m.Time.Equal(time.Unix(1600000001, 0)) {
+		t.Errorf("second message = %+v, want CHART_EQUITY data", m)
+	}
+}
+
+func TestParseFrameErrors(t *testing.T) {
+	for _, frame := range []string{
+		`not json`,
+		`{"notify": [{"heartbeat": "soon"}]}`,
+		`{"response": [{"service": "ADMIN", "content": "bad"}]}`,
+		`{"data": [{"service": "QUOTE", "content": {"key": "AAPL"}}]}`,
+	} {
+		if _, err := parseFrame([]byte(frame)); err == nil {
+			t.Errorf("parseFrame(%s) succeeded, want an error", frame)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package streamer
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// CommandQOS sets the quality of service of the ADMIN service.
+const CommandQOS = "QOS"
+
+// QOSLevel is a quality of service of the streamer: how often it sends
+// updates.
+type QOSLevel int
+
+const (
+	QOSExpress  QOSLevel = iota // every 500ms
+	QOSRealTime                 // every 750ms
+	QOSFast                     // every second, the default
+	QOSModerate                 // every 1.5s
+	QOSSlow                     // every 3s
+	QOSDelayed                  // every 5s
+)
+
+var qosIntervals = [...]time.Duration{
+	QOSExpress:  500 * time.Millisecond,
+	QOSRealTime: 750 * time.Millisecond,
+	QOSFast:     time.Second,
+	QOSModerate: 1500 * time.Millisecond,
+	QOSSlow:     3 * time.Second,
+	QOSDelayed:  5 * time.Second,
+}
+
+func (l QOSLevel) String() string {
+	switch l {
+	case QOSExpress:
+		return "express"
+	case QOSRealTime:
+		return "real-time"
+	case QOSFast:
+		return "fast"
+	case QOSModerate:
+		return "moderate"
+	case QOSSlow:
+		return "slow"
+	case QOSDelayed:
+		return "delayed"
+	}
+	return "unknown"
+}
+
+// Interval returns the time between updates at l.
+func (l QOSLevel) Interval() time.Duration {
+	if !l.valid() {
+		return 0
+	}
+	return qosIntervals[l]
+}
+
+func (l QOSLevel) valid() bool {
+	return l >= QOSExpress && l <= QOSDelayed
+}
+
+// WithQOS makes the streamer log in at the quality of service level rather
+// than QOSFast.
+func WithQOS(level QOSLevel) Option {
+	return func(s *Streamer) error {
+		if !level.valid() {
+			return fmt.Errorf("streamer: invalid QOS level %d", level)
+		}
+		s.qos = level
+		return nil
+	}
+}
+
+// SetQOS changes the quality of service of the streamer to level. The level
+// is kept for later logins, so it holds across reconnects; if the streamer
+// is not connected, it only takes effect at the next login.
+func (s *Streamer) SetQOS(ctx context.Context, level QOSLevel) error {
+	if !level.valid() {
+		return fmt.Errorf("streamer: invalid QOS level %d", level)
+	}
+
+	s.mu.Lock()
+	prev := s.qos
+	s.qos = level
+	connected := s.conn != nil
+	s.mu.Unlock()
+	if !connected {
+		return nil
+	}
+
+	_, err := s.Request(ctx, ServiceAdmin, CommandQOS, map[string]string{"qoslevel": strconv.Itoa(int(level))})
+	if _, ok := err.(*ResponseError); ok {
+		s.mu.Lock()
+		if s.qos == level {
+			s.qos = prev
+		}
+		s.mu.Unlock()
+	}
+	return err
+}
+
+// QOS returns the quality of service level of the streamer.
+func (s *Streamer) QOS() QOSLevel {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.qos
+}
@@ -0,0 +1,71 @@
+package streamer
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestQOS(t *testing.T) {
+	ts := newTestServer(t)
+	ts.answer = func(req request) []string {
+		if req.Command == CommandQOS && req.Parameters["qoslevel"] == "5" {
+			return []string{response(req, 1, "QOS level not allowed")}
+		}
+		return []string{response(req, 0, "ok")}
+	}
+	s := ts.dial(t, WithQOS(QOSSlow))
+	if req := ts.nextRequest(t); req.Parameters["qoslevel"] != "4" {
+		t.Errorf("login qoslevel = %q, want 4", req.Parameters["qoslevel"])
+	}
+
+	ctx := context.Background()
+	if err := s.SetQOS(ctx, QOSExpress); err != nil {
+		t.Fatal(err)
+	}
+	if req := ts.nextRequest(t); req.Service != ServiceAdmin || req.Command != CommandQOS || req.Parameters["qoslevel"] != "0" {
+		t.Errorf("request = %+v, want ADMIN QOS at level 0", req)
+	}
+	if got := s.QOS(); got != QOSExpress {
+		t.Errorf("QOS = %v, want %v", got, QOSExpress)
+	}
+
+	// A refused level is not kept.
+	if _, ok := s.SetQOS(ctx, QOSDelayed).(*ResponseError); !ok {
+		t.Error("SetQOS(QOSDelayed) succeeded, want it refused")
+	}
+	if got := s.QOS(); got != QOSExpress {
+		t.Errorf("QOS after a refused change = %v, want %v", got, QOSExpress)
+	}
+	if err := s.SetQOS(ctx, QOSLevel(9)); err == nil {
+		t.Error("SetQOS(9) succeeded, want an error")
+	}
+}
+
+func TestQOSBeforeDial(t *testing.T) {
+	ts := newTestServer(t)
+	s, err := New(ts.principals())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if err := s.SetQOS(context.Background(), QOSModerate); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Dial(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if req := ts.nextRequest(t); req.Parameters["qoslevel"] != "3" {
+		t.Errorf("login qoslevel = %q, want 3", req.Parameters["qoslevel"])
+	}
+}
+
+func TestQOSInterval(t *testing.T) {
+	if got := QOSModerate.Interval(); got != 1500*time.Millisecond {
+		t.Errorf("QOSModerate.Interval() = %v, want 1.5s", got)
+	}
+	if got := QOSLevel(-1).Interval(); got != 0 {
+		t.Errorf("QOSLevel(-1).Interval() = %v, want 0", got)
+	}
+}
@@ -0,0 +1,149 @@
+package streamer
+
+import (
+	"context"
+	"time"
+)
+
+// ServiceQuote streams level one quotes of equities and ETFs.
+const ServiceQuote = "QUOTE"
+
+// QuoteField is a field of the level one quotes, which subscriptions may
+// select.
+type QuoteField int
+
+const (
+	QuoteSymbol                 QuoteField = 0
+	QuoteBidPrice               QuoteField = 1
+	QuoteAskPrice               QuoteField = 2
+	QuoteLastPrice              QuoteField = 3
+	QuoteBidSize                QuoteField = 4
+	QuoteAskSize                QuoteField = 5
+	QuoteAskID                  QuoteField = 6
+	QuoteBidID                  QuoteField = 7
+	QuoteTotalVolume            QuoteField = 8
+	QuoteLastSize               QuoteField = 9
+	QuoteHighPrice              QuoteField = 12
+	QuoteLowPrice               QuoteField = 13
+	QuoteBidTick                QuoteField = 14
+	QuoteClosePrice             QuoteField = 15
+	QuoteExchange               QuoteField = 16
+	QuoteMarginable             QuoteField = 17
+	QuoteShortable              QuoteField = 18
+	QuoteVolatility             QuoteField = 24
+	QuoteDescription            QuoteField = 25
+	QuoteLastID                 QuoteField = 26
+	QuoteDigits                 QuoteField = 27
+	QuoteOpenPrice              QuoteField = 28
+	QuoteNetChange              QuoteField = 29
+	Quote52WkHigh               QuoteField = 30
+	Quote52WkLow                QuoteField = 31
+	QuotePERatio                QuoteField = 32
+	QuoteDivAmount              QuoteField = 33
+	QuoteDivYield               QuoteField = 34
+	QuoteNAV                    QuoteField = 37
+	QuoteFundPrice              QuoteField = 38
+	QuoteExchangeName           QuoteField = 39
+	QuoteDivDate                QuoteField = 40
+	QuoteRegularMarketQuote     QuoteField = 41
+	QuoteRegularMarketTrade     QuoteField = 42
+	QuoteRegularMarketLastPrice QuoteField = 43
+	QuoteRegularMarketLastSize  QuoteField = 44
+	QuoteRegularMarketNetChange QuoteField = 47
+	QuoteSecurityStatus         QuoteField = 48
+	QuoteMark                   QuoteField = 49
+	QuoteTime                   QuoteField = 50
+	QuoteTradeTime              QuoteField = 51
+	QuoteRegularMarketTradeTime QuoteField = 52
+)
+
+// Quote is a level one quote update. The streamer only sends the fields
+// that changed since the previous update of the symbol, which Fields
+// lists; the others are zero.
+type Quote struct {
+	Symbol                 string    `field:"key"`
+	Delayed                bool      `field:"delayed"`
+	BidPrice               float64   `field:"1"`
+	AskPrice               float64   `field:"2"`
+	LastPrice              float64   `field:"3"`
+	BidSize                float64   `field:"4"`
+	AskSize                float64   `field:"5"`
+	AskID                  string    `field:"6"`
+	BidID                  string    `field:"7"`
+	TotalVolume            float64   `field:"8"`
+	LastSize               float64   `field:"9"`
+	HighPrice              float64   `field:"12"`
+	LowPrice               float64   `field:"13"`
+	BidTick                string    `field:"14"`
+	ClosePrice             float64   `field:"15"`
+	Exchange               string    `field:"16"`
+	Marginable             bool      `field:"17"`
+	Shortable              bool      `field:"18"`
+	Volatility             float64   `field:"24"`
+	Description            string    `field:"25"`
+	LastID                 string    `field:"26"`
+	Digits                 int       `field:"27"`
+	OpenPrice              float64   `field:"28"`
+	NetChange              float64   `field:"29"`
+	Five2WkHigh            float64   `field:"30"`
+	Five2WkLow             float64   `field:"31"`
+	PERatio                float64   `field:"32"`
+	DivAmount              float64   `field:"33"`
+	DivYield               float64   `field:"34"`
+	NAV                    float64   `field:"37"`
+	FundPrice              float64   `field:"38"`
+	ExchangeName           string    `field:"39"`
+	DivDate                string    `field:"40"`
+	RegularMarketQuote     bool      `field:"41"`
+	RegularMarketTrade     bool      `field:"42"`
+	RegularMarketLastPrice float64   `field:"43"`
+	RegularMarketLastSize  float64   `field:"44"`
+	RegularMarketNetChange float64   `field:"47"`
+	SecurityStatus         string    `field:"48"`
+	Mark                   float64   `field:"49"`
+	QuoteTime              time.Time `field:"50"`
+	TradeTime              time.Time `field:"51"`
+	RegularMarketTradeTime time.Time `field:"52"`
+
+	Fields []QuoteField // the fields of the update, by number
+}
+
+// Has reports whether the update carries the field f.
+func (q *Quote) Has(f QuoteField) bool {
+	for _, g := range q.Fields {
+		if g == f {
+			return true
+		}
+	}
+	return false
+}
+
+// SubscribeQuotes subscribes to the level one quotes of symbols, replacing
+// the symbols subscribed before. Only fields are sent if any are given,
+// which saves bandwidth; otherwise every field of Quote is.
+func (s *Streamer) SubscribeQuotes(ctx context.Context, symbols []string, fields ...QuoteField) error {
+	nums := fieldInts(fields)
+	if len(nums) == 0 {
+		nums = taggedFields(Quote{})
+	}
+	return s.subscribe(ctx, ServiceQuote, symbols, fieldList(nums))
+}
+
+// ParseQuotes returns the quotes of m, a message of the QUOTE service.
+func ParseQuotes(m *Message) ([]*Quote, error) {
+	if err := checkService(m, ServiceQuote); err != nil {
+		return nil, err
+	}
+	quotes := make([]*Quote, 0, len(m.Content))
+	for _, c := range m.Content {
+		q := new(Quote)
+		if err := decodeContent(c, q); err != nil {
+			return nil, err
+		}
+		for _, n := range fieldNumbers(c) {
+			q.Fields = append(q.Fields, QuoteField(n))
+		}
+		quotes = append(quotes, q)
+	}
+	return quotes, nil
+}
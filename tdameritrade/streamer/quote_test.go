@@ -0,0 +1,70 @@
+package streamer
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSubscribeQuotes(t *testing.T) {
+	ts := newTestServer(t)
+	s := ts.dial(t)
+	ts.nextRequest(t) // login
+
+	ctx := context.Background()
+	if err := s.SubscribeQuotes(ctx, []string{"AAPL", "MSFT"}, QuoteBidPrice, QuoteAskPrice, QuoteLastPrice); err != nil {
+		t.Fatal(err)
+	}
+	req := ts.nextRequest(t)
+	if req.Service != ServiceQuote || req.Command != CommandSubs || req.Parameters["keys"] != "AAPL,MSFT" || req.Parameters["fields"] != "0,1,2,3" {
+		t.Errorf("request = %+v, want QUOTE SUBS of AAPL,MSFT with fields 0,1,2,3", req)
+	}
+
+	if err := s.SubscribeQuotes(ctx, []string{"SPY"}); err != nil {
+		t.Fatal(err)
+	}
+	req = ts.nextRequest(t)
+	if fields := req.Parameters["fields"]; !strings.HasPrefix(fields, "0,1,2,3,4,") || !strings.HasSuffix(fields, ",50,51,52") {
+		t.Errorf("fields = %s, want every field of Quote", fields)
+	}
+	if got := s.Subscribed(ServiceQuote); len(got) != 1 || got[0] != "SPY" {
+		t.Errorf("subscribed = %v, want SPY only", got)
+	}
+	if err := s.SubscribeQuotes(ctx, nil); err == nil {
+		t.Error("subscribing to no symbols succeeded, want an error")
+	}
+}
+
+func TestParseQuotes(t *testing.T) {
+	ts := newTestServer(t)
+	s := ts.dial(t)
+
+	ts.send(t, `{"data": [{"service": "QUOTE", "command": "SUBS", "timestamp": 1600000000000, "content": [
+		{"key": "AAPL", "delayed": false, "1": 115.5, "2": 115.6, "4": 300, "50": 1600000000000, "17": true},
+		{"key": "MSFT", "3": 205.25}
+	]}]}`)
+	m := nextMessage(t, s)
+	quotes, err := ParseQuotes(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quotes) != 2 {
+		t.Fatalf("got %d quotes, want 2", len(quotes))
+	}
+	q := quotes[0]
+	if q.Symbol != "AAPL" || q.BidPrice != 115.5 || q.AskPrice != 115.6 || q.BidSize != 300 || !q.Marginable ||
+		!q.QuoteTime.Equal(time.Unix(1600000000, 0)) {
+		t.Errorf("quote = %+v, want the AAPL update", q)
+	}
+	if want := []QuoteField{QuoteBidPrice, QuoteAskPrice, QuoteBidSize, QuoteMarginable, QuoteTime}; len(q.Fields) != len(want) || !q.Has(QuoteTime) || q.Has(QuoteLastPrice) {
+		t.Errorf("fields = %v, want %v", q.Fields, want)
+	}
+	if q := quotes[1]; q.Symbol != "MSFT" || q.LastPrice != 205.25 || len(q.Fields) != 1 {
+		t.Errorf("quote = %+v, want the MSFT last price", q)
+	}
+
+	if _, err := ParseQuotes(&Message{Service: ServiceOption}); err == nil {
+		t.Error("parsing an OPTION message as quotes succeeded, want an error")
+	}
+}
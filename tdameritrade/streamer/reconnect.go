@@ -0,0 +1,241 @@
+package streamer
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/glacialspring/go-tdameritrade/tdameritrade"
+)
+
+const (
+	defaultReconnectBaseDelay = time.Second
+	defaultReconnectMaxDelay  = time.Minute
+)
+
+// State is the state of the connection of a Streamer.
+type State int
+
+const (
+	Disconnected State = iota // not dialed yet, or Dial failed
+	Connecting                // dialing and logging in
+	Connected                 // logged in
+	Reconnecting              // the connection was lost, and is being made again
+	Closed                    // closed, or lost for good
+)
+
+func (s State) String() string {
+	switch s {
+	case Disconnected:
+		return "disconnected"
+	case Connecting:
+		return "connecting"
+	case Connected:
+		return "connected"
+	case Reconnecting:
+		return "reconnecting"
+	case Closed:
+		return "closed"
+	}
+	return "unknown"
+}
+
+// ReconnectPolicy makes a streamer reconnect when its connection is lost,
+// waiting an exponential backoff with jitter between attempts. Once
+// reconnected, it logs in again, at the QOS level last set, and makes its
+// subscriptions again. Set it with WithReconnect.
+type ReconnectPolicy struct {
+	// MaxAttempts is the number of attempts to reconnect after a loss, or 0
+	// to try forever.
+	MaxAttempts int
+
+	// BaseDelay is the wait before the first attempt, doubling with each
+	// further one. Defaults to 1s.
+	BaseDelay time.Duration
+
+	// MaxDelay caps the wait between attempts. Defaults to 1m.
+	MaxDelay time.Duration
+}
+
+// WithReconnect makes the streamer reconnect as p says when its connection
+// is lost, rather than end the stream.
+func WithReconnect(p ReconnectPolicy) Option {
+	return func(s *Streamer) error {
+		s.reconnect = &p
+		return nil
+	}
+}
+
+// WithStateHandler makes the streamer call fn whenever the state of its
+// connection changes, with the error that caused the change, if any: the
+// failure of Dial, the loss of the connection, or the failure ending the
+// stream. fn is called from the goroutine changing the state, so it must
+// not block.
+func WithStateHandler(fn func(state State, err error)) Option {
+	return func(s *Streamer) error {
+		s.onState = fn
+		return nil
+	}
+}
+
+// WithRefresh makes the streamer get new user principals with fn before
+// every reconnect attempt, in case the token it logged in with expired.
+// Connect sets one getting them with its client.
+func WithRefresh(fn func(ctx context.Context) (*tdameritrade.UserPrincipals, error)) Option {
+	return func(s *Streamer) error {
+		s.refresh = fn
+		return nil
+	}
+}
+
+// State returns the state of the connection.
+func (s *Streamer) State() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+func (s *Streamer) setState(state State, err error) {
+	s.mu.Lock()
+	changed := s.state != state
+	s.state = state
+	s.mu.Unlock()
+	if changed && s.onState != nil {
+		s.onState(state, err)
+	}
+}
+
+// run keeps the stream of the connection c going, reconnecting as the
+// ReconnectPolicy says when it is lost, and ends it when it can't.
+func (s *Streamer) run(c *conn) {
+	var err error
+	for {
+		<-c.done
+		s.mu.Lock()
+		s.conn = nil
+		s.mu.Unlock()
+		if s.isClosing() {
+			err = nil
+			break
+		}
+		err = c.err
+		if s.reconnect == nil {
+			break
+		}
+		s.setState(Reconnecting, err)
+		if c, err = s.reconnectLoop(); c == nil {
+			break
+		}
+		s.setState(Connected, nil)
+	}
+
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+	s.setState(Closed, err)
+	close(s.messages)
+}
+
+func (s *Streamer) isClosing() bool {
+	select {
+	case <-s.closing:
+		return true
+	default:
+		return false
+	}
+}
+
+// reconnectLoop reconnects, logs in and subscribes again, and returns the
+// new connection, or the error of the last attempt once the policy gives
+// up. It returns nil and a nil error if the streamer is closed meanwhile.
+func (s *Streamer) reconnectLoop() (*conn, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-s.closing:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	p := s.reconnect
+	var err error
+	for attempt := 1; p.MaxAttempts == 0 || attempt <= p.MaxAttempts; attempt++ {
+		if sleepCtx(ctx, p.delay(attempt)) != nil {
+			return nil, nil
+		}
+		var c *conn
+		if c, err = s.reconnectOnce(ctx); err == nil {
+			return c, nil
+		}
+		if s.isClosing() {
+			return nil, nil
+		}
+	}
+	return nil, err
+}
+
+// reconnectOnce makes an attempt to reconnect, publishing the connection
+// once it is logged in and subscribed again.
+func (s *Streamer) reconnectOnce(ctx context.Context) (*conn, error) {
+	if s.refresh != nil {
+		principals, err := s.refresh(ctx)
+		if err != nil {
+			return nil, err
+		}
+		creds, err := credentialsOf(principals, s.accountID)
+		if err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		s.creds = creds
+		s.mu.Unlock()
+	}
+
+	c, err := s.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range s.subscriptions() {
+		if _, err := s.request(ctx, c, sub.service, CommandSubs, sub.parameters()); err != nil {
+			c.close()
+			return nil, err
+		}
+	}
+	// Requests only go out on the connection once its subscriptions are
+	// back, so that none is made on a half restored stream.
+	if !s.publish(c) {
+		c.close()
+	}
+	return c, nil
+}
+
+// delay returns the wait before the attempt-th attempt.
+func (p *ReconnectPolicy) delay(attempt int) time.Duration {
+	base, max := p.BaseDelay, p.MaxDelay
+	if base <= 0 {
+		base = defaultReconnectBaseDelay
+	}
+	if max <= 0 {
+		max = defaultReconnectMaxDelay
+	}
+	d := base << uint(attempt-1)
+	if d <= 0 || d > max {
+		d = max
+	}
+	// Wait between half and all of the backoff, so that streamers
+	// disconnected together don't reconnect together.
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
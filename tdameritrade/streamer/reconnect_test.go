@@ -0,0 +1,184 @@
+package streamer
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/glacialspring/go-tdameritrade/tdameritrade"
+)
+
+// stateRecorder records the states a streamer goes through.
+type stateRecorder struct {
+	ch chan State
+}
+
+func newStateRecorder() *stateRecorder {
+	return &stateRecorder{ch: make(chan State, 100)}
+}
+
+func (r *stateRecorder) handle(state State, err error) {
+	r.ch <- state
+}
+
+// waitFor waits for the streamer to reach state.
+func (r *stateRecorder) waitFor(t *testing.T, state State) {
+	t.Helper()
+	for {
+		select {
+		case s := <-r.ch:
+			if s == state {
+				return
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("state %v not reached", state)
+		}
+	}
+}
+
+var fastReconnect = ReconnectPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 20 * time.Millisecond}
+
+func TestReconnectResubscribes(t *testing.T) {
+	ts := newTestServer(t)
+	states := newStateRecorder()
+	s := ts.dial(t, WithReconnect(fastReconnect), WithStateHandler(states.handle), WithQOS(QOSSlow))
+	ts.nextRequest(t) // login
+
+	ctx := context.Background()
+	if err := s.SubscribeQuotes(ctx, []string{"AAPL"}, QuoteLastPrice); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SubscribeChartEquity(ctx, []string{"SPY"}); err != nil {
+		t.Fatal(err)
+	}
+	ts.nextRequest(t)
+	ts.nextRequest(t)
+
+	ts.drop()
+	states.waitFor(t, Reconnecting)
+	if req := ts.nextRequest(t); req.Command != CommandLogin || req.Parameters["qoslevel"] != "4" {
+		t.Errorf("request = %+v, want a login at QOS level 4", req)
+	}
+	if req := ts.nextRequest(t); req.Service != ServiceQuote || req.Command != CommandSubs || req.Parameters["keys"] != "AAPL" || req.Parameters["fields"] != "0,3" {
+		t.Errorf("request = %+v, want the QUOTE subscription again", req)
+	}
+	if req := ts.nextRequest(t); req.Service != ServiceChartEquity || req.Parameters["keys"] != "SPY" {
+		t.Errorf("request = %+v, want the CHART_EQUITY subscription again", req)
+	}
+	states.waitFor(t, Connected)
+
+	ts.send(t, `{"data": [{"service": "QUOTE", "timestamp": 1600000000000, "content": [{"key": "AAPL", "3": 116}]}]}`)
+	if m := nextMessage(t, s); m.Service != ServiceQuote {
+		t.Errorf("message = %+v, want the quote on the new connection", m)
+	}
+}
+
+func TestRequestWaitsForResubscription(t *testing.T) {
+	ts := newTestServer(t)
+	release := make(chan struct{})
+	var connections int32
+	ts.answer = func(req request) []string {
+		if req.Command == CommandLogin {
+			atomic.AddInt32(&connections, 1)
+		}
+		if req.Command == CommandSubs && atomic.LoadInt32(&connections) > 1 {
+			<-release
+		}
+		return []string{response(req, 0, "ok")}
+	}
+	states := newStateRecorder()
+	s := ts.dial(t, WithReconnect(fastReconnect), WithStateHandler(states.handle))
+	ts.nextRequest(t) // login
+	ctx := context.Background()
+	if err := s.SubscribeQuotes(ctx, []string{"AAPL"}); err != nil {
+		t.Fatal(err)
+	}
+	ts.nextRequest(t)
+
+	ts.drop()
+	states.waitFor(t, Reconnecting)
+	ts.nextRequest(t) // login
+	ts.nextRequest(t) // the held subscription
+	if _, err := s.Request(ctx, ServiceAdmin, CommandQOS, map[string]string{"qoslevel": "0"}); err != ErrNotConnected {
+		t.Errorf("request while subscribing again: err = %v, want %v", err, ErrNotConnected)
+	}
+	if got := s.State(); got != Reconnecting {
+		t.Errorf("state while subscribing again = %v, want %v", got, Reconnecting)
+	}
+
+	close(release)
+	states.waitFor(t, Connected)
+	if _, err := s.Request(ctx, ServiceAdmin, CommandQOS, map[string]string{"qoslevel": "0"}); err != nil {
+		t.Errorf("request once reconnected: %v", err)
+	}
+}
+
+func TestReconnectGivesUp(t *testing.T) {
+	ts := newTestServer(t)
+	var logins int32
+	ts.answer = func(req request) []string {
+		if req.Command == CommandLogin && atomic.AddInt32(&logins, 1) > 1 {
+			return []string{response(req, 3, "Login denied")}
+		}
+		return []string{response(req, 0, "ok")}
+	}
+	policy := fastReconnect
+	policy.MaxAttempts = 2
+	s := ts.dial(t, WithReconnect(policy))
+
+	ts.drop()
+	for range s.Messages() {
+	}
+	if re, ok := s.Err().(*ResponseError); !ok || re.Code != 3 {
+		t.Errorf("Err = %v, want the refused login", s.Err())
+	}
+	if got := atomic.LoadInt32(&logins); got != 3 {
+		t.Errorf("%d logins, want the first and 2 attempts", got)
+	}
+	if got := s.State(); got != Closed {
+		t.Errorf("state = %v, want %v", got, Closed)
+	}
+}
+
+func TestReconnectRefreshesPrincipals(t *testing.T) {
+	ts := newTestServer(t)
+	var mu sync.Mutex
+	refreshed := 0
+	refresh := func(ctx context.Context) (*tdameritrade.UserPrincipals, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		refreshed++
+		p := ts.principals()
+		p.StreamerInfo.Token = "renewed"
+		return p, nil
+	}
+	ts.dial(t, WithReconnect(fastReconnect), WithRefresh(refresh))
+	ts.nextRequest(t) // login
+
+	ts.drop()
+	if req := ts.nextRequest(t); req.Command != CommandLogin || req.Parameters["token"] != "renewed" {
+		t.Errorf("request = %+v, want a login with the renewed token", req)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if refreshed != 1 {
+		t.Errorf("principals refreshed %d times, want once", refreshed)
+	}
+}
+
+func TestStreamEndsWithoutReconnect(t *testing.T) {
+	ts := newTestServer(t)
+	s := ts.dial(t)
+
+	ts.drop()
+	for range s.Messages() {
+	}
+	if s.Err() == nil {
+		t.Error("Err = nil, want the loss of the connection")
+	}
+	if _, err := s.Request(context.Background(), ServiceAdmin, CommandQOS, nil); err != ErrNotConnected {
+		t.Errorf("request after the stream ended: err = %v, want %v", err, ErrNotConnected)
+	}
+}
@@ -0,0 +1,133 @@
+package streamer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// A recording is a file of the frames received from the streamer, one JSON
+// object per line holding the time the frame arrived and the frame:
+//
+//	{"time":"2020-09-14T09:30:00.123456789-04:00","frame":{"data":[...]}}
+
+// record is a line of a recording.
+type record struct {
+	Time  time.Time       `json:"time"`
+	Frame json.RawMessage `json:"frame"`
+}
+
+// Recorder writes the frames a streamer receives to a recording, to be
+// played back with Replay. Set it with WithRecorder. Its methods may be
+// called concurrently.
+type Recorder struct {
+	mu  sync.Mutex
+	w   io.Writer
+	err error
+}
+
+// NewRecorder returns a Recorder writing to w, which the caller closes once
+// the streamer is closed.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: w}
+}
+
+// WithRecorder makes the streamer write every frame it receives to r,
+// across reconnects.
+func WithRecorder(r *Recorder) Option {
+	return func(s *Streamer) error {
+		s.recorder = r
+		return nil
+	}
+}
+
+// Record writes frame, received at t, to the recording. Once a write fails,
+// the recording stops and Record returns the error.
+func (r *Recorder) Record(t time.Time, frame []byte) error {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, frame); err != nil {
+		return fmt.Errorf("streamer: recording frame: %v", err)
+	}
+	line, err := json.Marshal(record{Time: t, Frame: compact.Bytes()})
+	if err != nil {
+		return fmt.Errorf("streamer: recording frame: %v", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	if _, err := r.w.Write(append(line, '\n')); err != nil {
+		r.err = fmt.Errorf("streamer: recording frame: %v", err)
+	}
+	return r.err
+}
+
+// Err returns the error that stopped the recording, if any.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Replay plays back the recording r, calling fn with the messages of its
+// frames, such as the Dispatch of a Bus:
+//
+//	f, err := os.Open("session.jsonl")
+//	...
+//	err = streamer.Replay(ctx, f, 10, func(m *streamer.Message) { bus.Dispatch(m) })
+//
+// Frames are played speed times faster than they were recorded: 1 replays
+// them in real time, and 0 as fast as fn takes them. Replay returns once
+// the recording is played back, or when ctx is done.
+func Replay(ctx context.Context, r io.Reader, speed float64, fn func(*Message)) error {
+	if speed < 0 {
+		return fmt.Errorf("streamer: negative replay speed %v", speed)
+	}
+
+	br := bufio.NewReader(r)
+	var first time.Time
+	start := time.Now()
+	for n := 1; ; n++ {
+		line, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("streamer: reading recording: %v", err)
+		}
+		if len(bytes.TrimSpace(line)) > 0 {
+			var rec record
+			if err := json.Unmarshal(line, &rec); err != nil {
+				return fmt.Errorf("streamer: line %d of recording: %v", n, err)
+			}
+			msgs, err := parseFrame(rec.Frame)
+			if err != nil {
+				return fmt.Errorf("streamer: line %d of recording: %v", n, err)
+			}
+
+			if first.IsZero() {
+				first = rec.Time
+			}
+			if speed > 0 {
+				// Wait relative to the start, so that the time fn takes
+				// doesn't add up.
+				at := start.Add(time.Duration(float64(rec.Time.Sub(first)) / speed))
+				if err := sleepCtx(ctx, time.Until(at)); err != nil {
+					return err
+				}
+			} else if err := ctx.Err(); err != nil {
+				return err
+			}
+			for _, m := range msgs {
+				fn(m)
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
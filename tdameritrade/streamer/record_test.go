@@ -0,0 +1,106 @@
+package streamer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordReplay(t *testing.T) {
+	ts := newTestServer(t)
+	var recording bytes.Buffer
+	s := ts.dial(t, WithRecorder(NewRecorder(&recording)))
+	ts.nextRequest(t) // login
+
+	ts.send(t, `{"data": [{"service": "QUOTE", "timestamp": 1600000000000, "content": [{"key": "AAPL", "3": 116}]}]}`)
+	ts.send(t, `{"notify": [{"heartbeat": "1600000001000"}]}`)
+	nextMessage(t, s)
+	nextMessage(t, s)
+	s.Close()
+	for range s.Messages() {
+	}
+
+	var replayed []*Message
+	if err := Replay(context.Background(), &recording, 0, func(m *Message) { replayed = append(replayed, m) }); err != nil {
+		t.Fatal(err)
+	}
+	// The login response, the quote and the heartbeat, and maybe the
+	// logout response.
+	if len(replayed) < 3 {
+		t.Fatalf("replayed %d messages, want at least 3", len(replayed))
+	}
+	if m := replayed[0]; m.Type != Response || m.Command != CommandLogin {
+		t.Errorf("first message = %+v, want the login response", m)
+	}
+	if m := replayed[1]; m.Type != Data || m.Service != ServiceQuote || m.Content[0].Key() != "AAPL" {
+		t.Errorf("second message = %+v, want the quote", m)
+	}
+	if m := replayed[2]; !m.Heartbeat || !m.Time.Equal(time.Unix(1600000001, 0)) {
+		t.Errorf("third message = %+v, want the heartbeat", m)
+	}
+}
+
+const testRecording = `{"time":"2020-09-14T09:30:00-04:00","frame":{"notify":[{"heartbeat":"1600090200000"}]}}
+{"time":"2020-09-14T09:30:00.2-04:00","frame":{"notify":[{"heartbeat":"1600090200200"}]}}
+`
+
+func TestReplaySpeed(t *testing.T) {
+	for _, tt := range []struct {
+		speed    float64
+		min, max time.Duration
+	}{
+		{0, 0, 50 * time.Millisecond},
+		{2, 100 * time.Millisecond, time.Second},
+	} {
+		start := time.Now()
+		n := 0
+		if err := Replay(context.Background(), strings.NewReader(testRecording), tt.speed, func(*Message) { n++ }); err != nil {
+			t.Fatal(err)
+		}
+		if took := time.Since(start); took < tt.min || took > tt.max {
+			t.Errorf("replay at speed %v took %v, want %v to %v", tt.speed, took, tt.min, tt.max)
+		}
+		if n != 2 {
+			t.Errorf("replay at speed %v: %d messages, want 2", tt.speed, n)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Replay(ctx, strings.NewReader(testRecording), 1, func(*Message) {}); err != context.Canceled {
+		t.Errorf("replay with ctx done: err = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestReplayErrors(t *testing.T) {
+	noop := func(*Message) {}
+	if err := Replay(context.Background(), strings.NewReader(testRecording), -1, noop); err == nil {
+		t.Error("replay at a negative speed: err = nil")
+	}
+	bad := testRecording + "not json\n"
+	if err := Replay(context.Background(), strings.NewReader(bad), 0, noop); err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("replay of a bad line: err = %v, want an error at line 3", err)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestRecorderError(t *testing.T) {
+	r := NewRecorder(failingWriter{})
+	if err := r.Record(time.Now(), []byte(`{"notify": []}`)); err == nil {
+		t.Fatal("Record to a failing writer: err = nil")
+	}
+	if err := r.Err(); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("Err = %v, want the write error", err)
+	}
+	if err := NewRecorder(&bytes.Buffer{}).Record(time.Now(), []byte(`{`)); err == nil {
+		t.Error("Record of a bad frame: err = nil")
+	}
+}
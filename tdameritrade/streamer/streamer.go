@@ -0,0 +1,529 @@
+// Package streamer is a client of the TDAmeritrade streaming API, which
+// pushes quotes, charts, books and news over a WebSocket.
+//
+//	s, err := streamer.Connect(ctx, client)
+//	if err != nil {
+//		return err
+//	}
+//	defer s.Close()
+//	for msg := range s.Messages() {
+//		...
+//	}
+//
+// TDAmeritrade API Docs: https://developer.tdameritrade.com/content/streaming-data
+package streamer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/glacialspring/go-tdameritrade/tdameritrade"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	defaultBufferSize = 1024
+
+	// logoutTimeout bounds the wait for the answer to the logout of Close.
+	logoutTimeout = 5 * time.Second
+)
+
+// ErrNotConnected is returned by requests made while the streamer is not
+// connected, including while it reconnects, until it has logged in and made
+// its subscriptions again.
+var ErrNotConnected = errors.New("streamer: not connected")
+
+// ErrClosed is returned by requests cut short by Close.
+var ErrClosed = errors.New("streamer: closed")
+
+// Option configures a Streamer.
+type Option func(*Streamer) error
+
+// WithDialer makes the streamer dial its connections with d, e.g. to set a
+// proxy or TLS config. Defaults to websocket.DefaultDialer.
+func WithDialer(d *websocket.Dialer) Option {
+	return func(s *Streamer) error {
+		s.dialer = d
+		return nil
+	}
+}
+
+// WithMetrics makes the streamer count the data messages it receives with
+// m, as a client made WithMetrics does.
+func WithMetrics(m tdameritrade.Metrics) Option {
+	return func(s *Streamer) error {
+		s.metrics = m
+		return nil
+	}
+}
+
+// WithAccount makes the streamer log in with the account accountID of the
+// user principals rather than the primary account.
+func WithAccount(accountID string) Option {
+	return func(s *Streamer) error {
+		s.accountID = accountID
+		return nil
+	}
+}
+
+// WithBufferSize sets the capacity of the Messages channel. Defaults to
+// 1024.
+func WithBufferSize(n int) Option {
+	return func(s *Streamer) error {
+		if n < 0 {
+			return fmt.Errorf("streamer: negative buffer size %d", n)
+		}
+		s.bufferSize = n
+		return nil
+	}
+}
+
+// Streamer is a connection to the streamer, logged in with the credentials
+// of the user principals. Its methods may be called concurrently.
+type Streamer struct {
+	dialer     *websocket.Dialer
+	metrics    tdameritrade.Metrics
+	accountID  string
+	bufferSize int
+	overflow   OverflowPolicy
+	reconnect  *ReconnectPolicy
+	onState    func(State, error)
+	refresh    func(context.Context) (*tdameritrade.UserPrincipals, error)
+	recorder   *Recorder
+
+	stallTimeout time.Duration
+	lastFrame    int64 // when the last frame arrived, in unix nanoseconds
+	dropped      uint64
+
+	messages  chan *Message
+	closing   chan struct{} // closed by Close
+	closeOnce sync.Once
+
+	mu      sync.Mutex
+	creds   *credentials
+	conn    *conn
+	dialed  bool
+	nextID  int
+	pending map[string]chan *Message // response waiters by request id
+	qos     QOSLevel
+	subs    []*subscription // in the order they were made
+	state   State
+	err     error
+}
+
+// conn is a connection to the streamer.
+type conn struct {
+	ws      *websocket.Conn
+	writeMu sync.Mutex
+	done    chan struct{} // closed when the read loop ends
+	err     error         // why the read loop ended, set before done is closed
+}
+
+// credentials are what the streamer logs in with, from the user
+// principals.
+type credentials struct {
+	url       string
+	info      *tdameritrade.StreamerInfo
+	account   *tdameritrade.UserAccount
+	tokenTime time.Time
+}
+
+// New returns a Streamer logging in with principals, which must have been
+// fetched with the streamer connection info. Dial connects it.
+func New(principals *tdameritrade.UserPrincipals, opts ...Option) (*Streamer, error) {
+	s := &Streamer{
+		dialer:     websocket.DefaultDialer,
+		bufferSize: defaultBufferSize,
+		qos:        QOSFast,
+		closing:    make(chan struct{}),
+		pending:    make(map[string]chan *Message),
+	}
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	if s.creds, err = credentialsOf(principals, s.accountID); err != nil {
+		return nil, err
+	}
+	s.messages = make(chan *Message, s.bufferSize)
+	return s, nil
+}
+
+// credentialsOf returns the credentials of principals for the account
+// accountID, or the primary account if accountID is "".
+func credentialsOf(principals *tdameritrade.UserPrincipals, accountID string) (*credentials, error) {
+	info := principals.StreamerInfo
+	if info == nil {
+		return nil, errors.New("streamer: the user principals have no streamer info")
+	}
+
+	var account *tdameritrade.UserAccount
+	id := accountID
+	if id == "" {
+		id = principals.PrimaryAccountID
+	}
+	for _, a := range principals.Accounts {
+		if a.AccountID == id {
+			account = a
+			break
+		}
+	}
+	if account == nil && accountID == "" && len(principals.Accounts) > 0 {
+		account = principals.Accounts[0]
+	}
+	if account == nil {
+		if accountID != "" {
+			return nil, fmt.Errorf("streamer: no account %s in the user principals", accountID)
+		}
+		return nil, errors.New("streamer: the user principals have no account")
+	}
+
+	tokenTime, err := info.TokenTime()
+	if err != nil {
+		return nil, fmt.Errorf("streamer: bad token timestamp: %v", err)
+	}
+	return &credentials{
+		url:       socketURL(info.StreamerSocketURL),
+		info:      info,
+		account:   account,
+		tokenTime: tokenTime,
+	}, nil
+}
+
+// Connect gets the user principals with client, with the streamer
+// connection info and subscription keys, and returns a Streamer logged in
+// with them. Unless WithRefresh says otherwise, the streamer gets them
+// again with client before reconnecting, in case its token expired.
+func Connect(ctx context.Context, client *tdameritrade.Client, opts ...Option) (*Streamer, error) {
+	getPrincipals := func(ctx context.Context) (*tdameritrade.UserPrincipals, error) {
+		principals, _, err := client.User.GetUserPrincipals(ctx, &tdameritrade.UserPrincipalsOptions{
+			StreamerSubscriptionKeys: true,
+			StreamerConnectionInfo:   true,
+		})
+		return principals, err
+	}
+	principals, err := getPrincipals(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s, err := New(principals, append([]Option{WithRefresh(getPrincipals)}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Dial(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// socketURL returns the URL of the streamer at addr, the host the user
+// principals report, or a URL of its own if overridden.
+func socketURL(addr string) string {
+	if strings.Contains(addr, "://") {
+		return addr
+	}
+	return "wss://" + addr + "/ws"
+}
+
+// Dial connects to the streamer and logs in. A refused login returns a
+// *ResponseError, and a Close meanwhile ErrClosed. Dial may be called again
+// if it fails, but not once it succeeded.
+func (s *Streamer) Dial(ctx context.Context) error {
+	s.mu.Lock()
+	dialed := s.dialed
+	s.mu.Unlock()
+	if dialed {
+		return errors.New("streamer: already dialed")
+	}
+	select {
+	case <-s.closing:
+		return ErrClosed
+	default:
+	}
+
+	// Close gives up on the login.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-s.closing:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	s.setState(Connecting, nil)
+	c, err := s.connect(ctx)
+	if err != nil {
+		if s.isClosing() {
+			err = ErrClosed
+		}
+		s.setState(Disconnected, err)
+		return err
+	}
+	if !s.publish(c) {
+		// Close ran while logging in.
+		c.close()
+		return ErrClosed
+	}
+	s.setState(Connected, nil)
+	go s.run(c)
+	return nil
+}
+
+// connect dials a connection and logs in on it. The connection is not used
+// by Request until it is published.
+func (s *Streamer) connect(ctx context.Context) (*conn, error) {
+	s.mu.Lock()
+	creds := s.creds
+	s.mu.Unlock()
+	ws, _, err := s.dialer.DialContext(ctx, creds.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("streamer: dialing %s: %v", creds.url, err)
+	}
+	c := &conn{ws: ws, done: make(chan struct{})}
+	go s.readLoop(c)
+
+	if _, err := s.request(ctx, c, ServiceAdmin, CommandLogin, s.loginParameters(creds)); err != nil {
+		ws.Close()
+		<-c.done
+		return nil, err
+	}
+	return c, nil
+}
+
+// publish makes c, logged in, the connection of the streamer, unless Close
+// ran, in which case it returns false and the caller closes c. A connection
+// published before Close is closed by it.
+func (s *Streamer) publish(c *conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.isClosing() {
+		return false
+	}
+	s.conn = c
+	s.dialed = true
+	return true
+}
+
+// loginParameters returns the parameters of the login request.
+func (s *Streamer) loginParameters(creds *credentials) map[string]string {
+	a, info := creds.account, creds.info
+	s.mu.Lock()
+	qos := s.qos
+	s.mu.Unlock()
+	credential := url.Values{
+		"userid":      {a.AccountID},
+		"token":       {info.Token},
+		"company":     {a.Company},
+		"segment":     {a.Segment},
+		"cddomain":    {a.AccountCdDomainID},
+		"usergroup":   {info.UserGroup},
+		"accesslevel": {info.AccessLevel},
+		"authorized":  {"Y"},
+		"timestamp":   {strconv.FormatInt(creds.tokenTime.UnixMilli(), 10)},
+		"appid":       {info.AppID},
+		"acl":         {info.ACL},
+	}
+	return map[string]string{
+		"credential": credential.Encode(),
+		"token":      info.Token,
+		"version":    "1.0",
+		"qoslevel":   strconv.Itoa(int(qos)),
+	}
+}
+
+// readLoop reads the messages of c until it fails.
+func (s *Streamer) readLoop(c *conn) {
+	defer close(c.done)
+	for {
+		if s.stallTimeout > 0 {
+			c.ws.SetReadDeadline(time.Now().Add(s.stallTimeout))
+		}
+		_, b, err := c.ws.ReadMessage()
+		if err != nil {
+			c.err = err
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				c.err = ErrStalled
+				s.dispatch(&Message{Type: Stall, Time: s.LastMessage()})
+			}
+			c.ws.Close()
+			return
+		}
+		now := time.Now()
+		atomic.StoreInt64(&s.lastFrame, now.UnixNano())
+		if s.recorder != nil {
+			s.recorder.Record(now, b)
+		}
+		msgs, err := parseFrame(b)
+		if err != nil {
+			c.err = err
+			c.ws.Close()
+			return
+		}
+		for _, m := range msgs {
+			s.dispatch(m)
+		}
+	}
+}
+
+// dispatch hands m to the request it answers, or else delivers it on the
+// Messages channel.
+func (s *Streamer) dispatch(m *Message) {
+	if m.Type == Response {
+		s.mu.Lock()
+		reply, ok := s.pending[m.RequestID]
+		delete(s.pending, m.RequestID)
+		s.mu.Unlock()
+		if ok {
+			reply <- m
+			return
+		}
+	}
+	if s.metrics != nil && (m.Type == Data || m.Type == Snapshot) {
+		s.metrics.IncStreamerMessages(m.Service)
+	}
+	s.deliver(m)
+}
+
+// Messages returns the channel of the messages of the streamer: data,
+// snapshots, notices and heartbeats, and the responses no request waits
+// for. It is closed when the stream ends, after which Err tells why, and
+// stays open if Dial never succeeds. A full channel holds up the stream,
+// unless WithOverflow says otherwise, so keep it drained.
+func (s *Streamer) Messages() <-chan *Message {
+	return s.messages
+}
+
+// Err returns the error that ended the stream once Messages is closed, or
+// nil if Close ended it.
+func (s *Streamer) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Request sends command to service with params and waits for the response,
+// for the commands this package doesn't wrap. A refused request returns a
+// *ResponseError along with the response.
+func (s *Streamer) Request(ctx context.Context, service, command string, params map[string]string) (*Message, error) {
+	s.mu.Lock()
+	c := s.conn
+	s.mu.Unlock()
+	if c == nil {
+		return nil, ErrNotConnected
+	}
+	return s.request(ctx, c, service, command, params)
+}
+
+// request sends command to service with params on c and waits for the
+// response.
+func (s *Streamer) request(ctx context.Context, c *conn, service, command string, params map[string]string) (*Message, error) {
+	if params == nil {
+		params = map[string]string{}
+	}
+
+	s.mu.Lock()
+	creds := s.creds
+	s.nextID++
+	id := strconv.Itoa(s.nextID)
+	reply := make(chan *Message, 1)
+	s.pending[id] = reply
+	s.mu.Unlock()
+
+	req := request{
+		Service:    service,
+		Command:    command,
+		RequestID:  id,
+		Account:    creds.account.AccountID,
+		Source:     creds.info.AppID,
+		Parameters: params,
+	}
+	if err := c.write(ctx, req); err != nil {
+		s.forget(id)
+		return nil, fmt.Errorf("streamer: sending %s %s: %v", service, command, err)
+	}
+
+	select {
+	case m := <-reply:
+		if m.Code != 0 {
+			return m, &ResponseError{Service: service, Command: command, Code: m.Code, Message: m.Text}
+		}
+		return m, nil
+	case <-c.done:
+		s.forget(id)
+		if c.err == nil {
+			return nil, ErrClosed
+		}
+		select {
+		case <-s.closing:
+			return nil, ErrClosed
+		default:
+		}
+		return nil, fmt.Errorf("streamer: connection lost: %v", c.err)
+	case <-ctx.Done():
+		s.forget(id)
+		return nil, ctx.Err()
+	}
+}
+
+// forget stops waiting for the response to the request id.
+func (s *Streamer) forget(id string) {
+	s.mu.Lock()
+	delete(s.pending, id)
+	s.mu.Unlock()
+}
+
+// write sends req on c.
+func (c *conn) write(ctx context.Context, req request) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	deadline, _ := ctx.Deadline()
+	c.ws.SetWriteDeadline(deadline)
+	return c.ws.WriteJSON(struct {
+		Requests []request `json:"requests"`
+	}{[]request{req}})
+}
+
+// close closes c, telling the streamer first.
+func (c *conn) close() {
+	c.writeMu.Lock()
+	c.ws.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	c.writeMu.Unlock()
+	c.ws.Close()
+	<-c.done
+}
+
+// Close logs out and closes the connection, ending the stream. Messages not
+// yet received are dropped.
+func (s *Streamer) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.closing)
+
+		s.mu.Lock()
+		c := s.conn
+		s.mu.Unlock()
+		if c == nil {
+			// Not connected, or Dial or a reconnect is logging in and
+			// will see closing.
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), logoutTimeout)
+		s.Request(ctx, ServiceAdmin, CommandLogout, nil)
+		cancel()
+		c.close()
+	})
+	return nil
+}
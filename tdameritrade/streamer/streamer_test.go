@@ -0,0 +1,337 @@
+package streamer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glacialspring/go-tdameritrade/tdameritrade"
+	"github.com/gorilla/websocket"
+)
+
+// testServer is a WebSocket server speaking the streamer protocol. It
+// answers every request with success, unless answer says otherwise, and
+// lets tests push frames and drop connections.
+type testServer struct {
+	*httptest.Server
+
+	// answer, if set, returns the frames sent in reply to req, instead of
+	// a success response. It is called from the goroutine reading the
+	// connection, so it may block to hold back the reply.
+	answer func(req request) []string
+
+	requests chan request
+
+	mu    sync.Mutex
+	conns []*serverConn
+}
+
+// serverConn is a connection of the test server.
+type serverConn struct {
+	mu sync.Mutex
+	ws *websocket.Conn
+}
+
+func (c *serverConn) send(frame string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ws.WriteMessage(websocket.TextMessage, []byte(frame))
+}
+
+func newTestServer(t *testing.T) *testServer {
+	ts := &testServer{requests: make(chan request, 100)}
+	upgrader := websocket.Upgrader{}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		c := &serverConn{ws: ws}
+		ts.mu.Lock()
+		ts.conns = append(ts.conns, c)
+		ts.mu.Unlock()
+		defer ws.Close()
+
+		for {
+			var msg struct {
+				Requests []request `json:"requests"`
+			}
+			if err := ws.ReadJSON(&msg); err != nil {
+				return
+			}
+			for _, req := range msg.Requests {
+				ts.requests <- req
+				frames := []string{response(req, 0, "ok")}
+				if ts.answer != nil {
+					frames = ts.answer(req)
+				}
+				for _, f := range frames {
+					if c.send(f) != nil {
+						return
+					}
+				}
+			}
+		}
+	}))
+	t.Cleanup(func() {
+		ts.mu.Lock()
+		for _, c := range ts.conns {
+			c.ws.Close()
+		}
+		ts.mu.Unlock()
+		ts.Close()
+	})
+	return ts
+}
+
+// principals returns user principals pointing the streamer at ts.
+func (ts *testServer) principals() *tdameritrade.UserPrincipals {
+	return &tdameritrade.UserPrincipals{
+		PrimaryAccountID: "123",
+		StreamerInfo: &tdameritrade.StreamerInfo{
+			StreamerSocketURL: "ws" + strings.TrimPrefix(ts.URL, "http"),
+			Token:             "token",
+			TokenTimestamp:    "2020-06-15T14:07:02+0000",
+			UserGroup:         "ACCT",
+			AccessLevel:       "ACCT",
+			ACL:               "ACL",
+			AppID:             "APP",
+		},
+		Accounts: []*tdameritrade.UserAccount{{
+			AccountID:         "123",
+			AccountCdDomainID: "A000000012345678",
+			Company:           "AMER",
+			Segment:           "AMER",
+		}},
+	}
+}
+
+// dial returns a streamer logged in to ts, closed at the end of the test.
+func (ts *testServer) dial(t *testing.T, opts ...Option) *Streamer {
+	t.Helper()
+	s, err := New(ts.principals(), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.Dial(ctx); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// nextRequest returns the next request ts received.
+func (ts *testServer) nextRequest(t *testing.T) request {
+	t.Helper()
+	select {
+	case req := <-ts.requests:
+		return req
+	case <-time.After(5 * time.Second):
+		t.Fatal("no request received")
+		return request{}
+	}
+}
+
+// send sends frame on the latest connection of ts.
+func (ts *testServer) send(t *testing.T, frame string) {
+	t.Helper()
+	ts.mu.Lock()
+	c := ts.conns[len(ts.conns)-1]
+	ts.mu.Unlock()
+	if err := c.send(frame); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// drop closes the latest connection of ts without a close message.
+func (ts *testServer) drop() {
+	ts.mu.Lock()
+	c := ts.conns[len(ts.conns)-1]
+	ts.mu.Unlock()
+	c.ws.UnderlyingConn().Close()
+}
+
+// response returns a frame answering req with code and msg.
+func response(req request, code int, msg string) string {
+	return fmt.Sprintf(`{"response": [{"service": %q, "requestid": %q, "command": %q, "timestamp": 1600000000000, "content": {"code": %d, "msg": %q}}]}`,
+		req.Service, req.RequestID, req.Command, code, msg)
+}
+
+// nextMessage returns the next message of s.
+func nextMessage(t *testing.T, s *Streamer) *Message {
+	t.Helper()
+	select {
+	case m, ok := <-s.Messages():
+		if !ok {
+			t.Fatalf("messages closed: %v", s.Err())
+		}
+		return m
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+		return nil
+	}
+}
+
+func TestDialLogin(t *testing.T) {
+	ts := newTestServer(t)
+	s := ts.dial(t)
+
+	req := ts.nextRequest(t)
+	if req.Service != ServiceAdmin || req.Command != CommandLogin || req.Account != "123" || req.Source != "APP" {
+		t.Errorf("login request = %+v, want ADMIN LOGIN of account 123 from APP", req)
+	}
+	if req.Parameters["token"] != "token" || req.Parameters["qoslevel"] != "2" || req.Parameters["version"] != "1.0" {
+		t.Errorf("login parameters = %v, want the token at QOS level 2", req.Parameters)
+	}
+	credential, err := url.ParseQuery(req.Parameters["credential"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{
+		"userid":    "123",
+		"token":     "token",
+		"company":   "AMER",
+		"cddomain":  "A000000012345678",
+		"timestamp": "1592230022000",
+		"appid":     "APP",
+	} {
+		if got := credential.Get(key); got != want {
+			t.Errorf("credential %s = %q, want %q", key, got, want)
+		}
+	}
+	if got := s.State(); got != Connected {
+		t.Errorf("state = %v, want %v", got, Connected)
+	}
+}
+
+func TestDialRefused(t *testing.T) {
+	ts := newTestServer(t)
+	ts.answer = func(req request) []string {
+		return []string{response(req, 3, "Login denied")}
+	}
+	s, err := New(ts.principals())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	err = s.Dial(context.Background())
+	if re, ok := err.(*ResponseError); !ok || re.Code != 3 || re.Command != CommandLogin {
+		t.Fatalf("err = %v, want a refused login", err)
+	}
+	if got := s.State(); got != Disconnected {
+		t.Errorf("state = %v, want %v", got, Disconnected)
+	}
+	if _, err := s.Request(context.Background(), ServiceAdmin, CommandQOS, nil); err != ErrNotConnected {
+		t.Errorf("request after a refused login: err = %v, want %v", err, ErrNotConnected)
+	}
+}
+
+func TestRequestMatchesResponses(t *testing.T) {
+	ts := newTestServer(t)
+	var mu sync.Mutex
+	var held []request
+	ts.answer = func(req request) []string {
+		if req.Service != "TEST" {
+			return []string{response(req, 0, "ok")}
+		}
+		// Answer the test requests in pairs, the later one first.
+		mu.Lock()
+		defer mu.Unlock()
+		held = append(held, req)
+		if len(held) < 2 {
+			return nil
+		}
+		frames := []string{response(held[1], 0, held[1].Parameters["n"]), response(held[0], 0, held[0].Parameters["n"])}
+		held = nil
+		return frames
+	}
+	s := ts.dial(t)
+	ts.nextRequest(t) // login
+
+	var wg sync.WaitGroup
+	for _, n := range []string{"first", "second"} {
+		wg.Add(1)
+		go func(n string) {
+			defer wg.Done()
+			m, err := s.Request(context.Background(), "TEST", "ECHO", map[string]string{"n": n})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if m.Text != n {
+				t.Errorf("request %s got the response %q", n, m.Text)
+			}
+		}(n)
+	}
+	wg.Wait()
+
+	// A response no request waits for is delivered.
+	ts.send(t, response(request{Service: "TEST", Command: "ECHO", RequestID: "999"}, 0, "late"))
+	if m := nextMessage(t, s); m.Type != Response || m.RequestID != "999" || m.Text != "late" {
+		t.Errorf("message = %+v, want the unmatched response", m)
+	}
+}
+
+func TestCloseDuringDial(t *testing.T) {
+	ts := newTestServer(t)
+	release := make(chan struct{})
+	defer close(release)
+	ts.answer = func(req request) []string {
+		if req.Command == CommandLogin {
+			<-release
+		}
+		return []string{response(req, 0, "ok")}
+	}
+	s, err := New(ts.principals())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dialed := make(chan error, 1)
+	go func() { dialed <- s.Dial(context.Background()) }()
+	ts.nextRequest(t) // the login, never answered
+	s.Close()
+
+	select {
+	case err := <-dialed:
+		if err != ErrClosed {
+			t.Errorf("Dial: err = %v, want %v", err, ErrClosed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Dial did not return after Close")
+	}
+	if got := s.State(); got == Connected {
+		t.Errorf("state = %v after Close", got)
+	}
+}
+
+func TestCloseLogsOut(t *testing.T) {
+	ts := newTestServer(t)
+	s := ts.dial(t)
+	ts.nextRequest(t) // login
+
+	s.Close()
+	if req := ts.nextRequest(t); req.Service != ServiceAdmin || req.Command != CommandLogout {
+		t.Errorf("request = %+v, want ADMIN LOGOUT", req)
+	}
+	select {
+	case _, ok := <-s.Messages():
+		if ok {
+			t.Error("message received after Close")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("messages not closed")
+	}
+	if err := s.Err(); err != nil {
+		t.Errorf("Err = %v, want nil after Close", err)
+	}
+}
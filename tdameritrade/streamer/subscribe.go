@@ -0,0 +1,209 @@
+package streamer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The commands managing the subscriptions to a service.
+const (
+	// CommandSubs subscribes to a service, replacing the symbols
+	// subscribed before.
+	CommandSubs = "SUBS"
+	// CommandAdd adds symbols to a subscription.
+	CommandAdd = "ADD"
+	// CommandUnsubs removes symbols from a subscription.
+	CommandUnsubs = "UNSUBS"
+)
+
+// subscription is a subscription to a service, made again after
+// reconnecting.
+type subscription struct {
+	service string
+	keys    []string
+	fields  string
+}
+
+func (sub *subscription) parameters() map[string]string {
+	return map[string]string{
+		"keys":   strings.Join(sub.keys, ","),
+		"fields": sub.fields,
+	}
+}
+
+// subscribe subscribes to the fields of service for keys, the symbols.
+func (s *Streamer) subscribe(ctx context.Context, service string, keys []string, fields string) error {
+	if len(keys) == 0 {
+		return errors.New("streamer: no symbols to subscribe to")
+	}
+	sub := &subscription{service: service, keys: keys, fields: fields}
+	if _, err := s.Request(ctx, service, CommandSubs, sub.parameters()); err != nil {
+		return err
+	}
+	s.setSubscription(sub)
+	return nil
+}
+
+// setSubscription records sub, replacing the subscription to its service.
+func (s *Streamer) setSubscription(sub *subscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, old := range s.subs {
+		if old.service == sub.service {
+			s.subs[i] = sub
+			return
+		}
+	}
+	s.subs = append(s.subs, sub)
+}
+
+// subscription returns the subscription to service, or nil if there is
+// none.
+func (s *Streamer) subscription(service string) *subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sub := range s.subs {
+		if sub.service == service {
+			return sub
+		}
+	}
+	return nil
+}
+
+// Add adds symbols to the subscription to service, such as QUOTE, with the
+// fields it was made with. Updates of the symbols subscribed before keep
+// flowing, unlike with a new subscription. Options may be given in TD
+// Ameritrade or OCC format.
+func (s *Streamer) Add(ctx context.Context, service string, symbols []string) error {
+	keys, err := serviceKeys(service, symbols)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return errors.New("streamer: no symbols to add")
+	}
+	sub := s.subscription(service)
+	if sub == nil {
+		return fmt.Errorf("streamer: not subscribed to %s", service)
+	}
+	params := map[string]string{
+		"keys":   strings.Join(keys, ","),
+		"fields": sub.fields,
+	}
+	if _, err := s.Request(ctx, service, CommandAdd, params); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, old := range s.subs {
+		if old.service == service {
+			s.subs[i] = &subscription{service: service, keys: union(old.keys, keys), fields: old.fields}
+		}
+	}
+	return nil
+}
+
+// Unsubscribe removes symbols from the subscription to service, or ends it
+// if no symbols are given.
+func (s *Streamer) Unsubscribe(ctx context.Context, service string, symbols ...string) error {
+	keys, err := serviceKeys(service, symbols)
+	if err != nil {
+		return err
+	}
+	sub := s.subscription(service)
+	if sub == nil {
+		return fmt.Errorf("streamer: not subscribed to %s", service)
+	}
+	if len(keys) == 0 {
+		keys = sub.keys
+	}
+	if _, err := s.Request(ctx, service, CommandUnsubs, map[string]string{"keys": strings.Join(keys, ",")}); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, old := range s.subs {
+		if old.service != service {
+			continue
+		}
+		left := difference(old.keys, keys)
+		if len(left) == 0 {
+			s.subs = append(s.subs[:i:i], s.subs[i+1:]...)
+		} else {
+			s.subs[i] = &subscription{service: service, keys: left, fields: old.fields}
+		}
+		break
+	}
+	return nil
+}
+
+// Subscribed returns the symbols subscribed to of service.
+func (s *Streamer) Subscribed(service string) []string {
+	sub := s.subscription(service)
+	if sub == nil {
+		return nil
+	}
+	return append([]string(nil), sub.keys...)
+}
+
+// serviceKeys returns the keys of symbols for service, converting options
+// to TD Ameritrade format.
+func serviceKeys(service string, symbols []string) ([]string, error) {
+	switch service {
+	case ServiceOption, ServiceOptionsBook:
+		return optionKeys(symbols)
+	}
+	return symbols, nil
+}
+
+// union returns a with the keys of b it lacks appended.
+func union(a, b []string) []string {
+	seen := make(map[string]bool, len(a))
+	keys := append([]string(nil), a...)
+	for _, k := range a {
+		seen[k] = true
+	}
+	for _, k := range b {
+		if !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// difference returns the keys of a not in b.
+func difference(a, b []string) []string {
+	drop := make(map[string]bool, len(b))
+	for _, k := range b {
+		drop[k] = true
+	}
+	var keys []string
+	for _, k := range a {
+		if !drop[k] {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// subscriptions returns the subscriptions of the streamer.
+func (s *Streamer) subscriptions() []*subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*subscription(nil), s.subs...)
+}
+
+// checkService returns an error if m is not a message of one of services.
+func checkService(m *Message, services ...string) error {
+	for _, service := range services {
+		if m.Service == service {
+			return nil
+		}
+	}
+	return errors.New("streamer: " + m.Service + " message is not of " + strings.Join(services, " or "))
+}
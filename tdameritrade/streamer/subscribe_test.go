@@ -0,0 +1,90 @@
+package streamer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestAddAndUnsubscribe(t *testing.T) {
+	ts := newTestServer(t)
+	s := ts.dial(t)
+	ts.nextRequest(t) // login
+	ctx := context.Background()
+
+	if err := s.Add(ctx, ServiceQuote, []string{"MSFT"}); err == nil || !strings.Contains(err.Error(), "not subscribed") {
+		t.Errorf("Add before subscribing: err = %v", err)
+	}
+	if err := s.SubscribeQuotes(ctx, []string{"AAPL", "SPY"}, QuoteBidPrice, QuoteAskPrice); err != nil {
+		t.Fatal(err)
+	}
+	ts.nextRequest(t)
+
+	if err := s.Add(ctx, ServiceQuote, []string{"MSFT", "SPY"}); err != nil {
+		t.Fatal(err)
+	}
+	if req := ts.nextRequest(t); req.Command != CommandAdd || req.Parameters["keys"] != "MSFT,SPY" || req.Parameters["fields"] != "0,1,2" {
+		t.Errorf("request = %+v, want ADD of MSFT and SPY with the subscribed fields", req)
+	}
+	if got := fmt.Sprint(s.Subscribed(ServiceQuote)); got != "[AAPL SPY MSFT]" {
+		t.Errorf("subscribed after Add = %s", got)
+	}
+
+	if err := s.Unsubscribe(ctx, ServiceQuote, "AAPL"); err != nil {
+		t.Fatal(err)
+	}
+	if req := ts.nextRequest(t); req.Command != CommandUnsubs || req.Parameters["keys"] != "AAPL" {
+		t.Errorf("request = %+v, want UNSUBS of AAPL", req)
+	}
+	if got := fmt.Sprint(s.Subscribed(ServiceQuote)); got != "[SPY MSFT]" {
+		t.Errorf("subscribed after Unsubscribe = %s", got)
+	}
+
+	if err := s.Unsubscribe(ctx, ServiceQuote); err != nil {
+		t.Fatal(err)
+	}
+	if req := ts.nextRequest(t); req.Parameters["keys"] != "SPY,MSFT" {
+		t.Errorf("request = %+v, want UNSUBS of all the symbols", req)
+	}
+	if got := s.Subscribed(ServiceQuote); got != nil {
+		t.Errorf("subscribed after ending the subscription = %v", got)
+	}
+	if err := s.Unsubscribe(ctx, ServiceQuote, "SPY"); err == nil {
+		t.Error("Unsubscribe after ending the subscription: err = nil")
+	}
+}
+
+func TestAddOptions(t *testing.T) {
+	ts := newTestServer(t)
+	s := ts.dial(t)
+	ts.nextRequest(t) // login
+	ctx := context.Background()
+
+	if err := s.SubscribeOptions(ctx, []string{"AAPL_011924C150"}, OptionBidPrice); err != nil {
+		t.Fatal(err)
+	}
+	ts.nextRequest(t)
+	if err := s.Add(ctx, ServiceOption, []string{"SPY   240119P00412500"}); err != nil {
+		t.Fatal(err)
+	}
+	if req := ts.nextRequest(t); req.Parameters["keys"] != "SPY_011924P412.5" {
+		t.Errorf("request = %+v, want the option in TD Ameritrade format", req)
+	}
+	if err := s.Add(ctx, ServiceOption, nil); err == nil {
+		t.Error("Add of no symbols: err = nil")
+	}
+}
+
+func TestUnionDifference(t *testing.T) {
+	a, b := []string{"A", "B", "C"}, []string{"C", "D", "A"}
+	if got := fmt.Sprint(union(a, b)); got != "[A B C D]" {
+		t.Errorf("union = %s, want [A B C D]", got)
+	}
+	if got := fmt.Sprint(difference(a, b)); got != "[B]" {
+		t.Errorf("difference = %s, want [B]", got)
+	}
+	if got := difference(a, a); got != nil {
+		t.Errorf("difference of a set with itself = %v, want nil", got)
+	}
+}
@@ -0,0 +1,88 @@
+package tdameritrade
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+type symbolCache struct {
+	mu      sync.Mutex
+	entries map[string]symbolCacheEntry
+}
+
+// symbolCacheEntry is a validated symbol; info is nil for unknown symbols.
+type symbolCacheEntry struct {
+	info    *InstrumentInfo
+	expires time.Time
+}
+
+func (c *symbolCache) get(symbol string) (*InstrumentInfo, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[symbol]
+	if !ok || time.Now().After(e.expires) {
+		return nil, false
+	}
+	return e.info, true
+}
+
+func (c *symbolCache) put(symbol string, info *InstrumentInfo, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if c.entries == nil {
+		c.entries = make(map[string]symbolCacheEntry)
+	}
+	for k, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[symbol] = symbolCacheEntry{info: info, expires: now.Add(ttl)}
+}
+
+// ValidateSymbol confirms that symbol names a known instrument and returns
+// it, with its asset type and exchange, or ErrSymbolNotFound. Symbols are
+// upper-cased, and both known and unknown symbols are remembered for the
+// service's SymbolCacheTTL, so order entry code can check user input
+// without repeated requests. Option symbols are not known to the
+// instruments endpoint; check them with ParseOptionSymbol and the option
+// chain instead. The returned instrument is shared between callers and
+// must not be modified.
+func (s *InstrumentService) ValidateSymbol(ctx context.Context, symbol string) (*InstrumentInfo, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if symbol == "" {
+		return nil, fmt.Errorf("no symbol present")
+	}
+	if info, ok := s.symbols.get(symbol); ok {
+		if info == nil {
+			return nil, ErrSymbolNotFound
+		}
+		return info, nil
+	}
+
+	q := url.Values{"symbol": {symbol}, "projection": {"symbol-search"}}
+	req, err := s.client.NewRequest("GET", fmt.Sprintf("instruments?%s", q.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	instruments := make(Instruments)
+	if _, err := s.client.Do(ctx, req, &instruments); err != nil {
+		return nil, err
+	}
+
+	ttl := s.SymbolCacheTTL
+	if ttl <= 0 {
+		ttl = defaultSymbolCacheTTL
+	}
+	info := instruments[symbol]
+	s.symbols.put(symbol, info, ttl)
+	if info == nil {
+		return nil, ErrSymbolNotFound
+	}
+	return info, nil
+}
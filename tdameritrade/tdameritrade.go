@@ -9,8 +9,15 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/http/httputil"
 	"net/url"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
 )
 
 const (
@@ -25,28 +32,101 @@ type Client struct {
 	// set to any endpoint. This allows for more manageable testing.
 	BaseURL *url.URL
 
+	// MarketDataURL, if set, is the base URL of marketdata/ requests, for
+	// deployments serving market data apart. See Endpoints.
+	MarketDataURL *url.URL
+
+	// UserAgent is sent with every request.
+	UserAgent string
+
+	// StreamerURL, if set, replaces StreamerInfo.StreamerSocketURL in the
+	// user principals.
+	StreamerURL string
+
 	// services used for talking to different parts of the tdameritrade api
-	PriceHistory *PriceHistoryService
-	Account      *AccountsService
-	MarketHours  *MarketHoursService
-	Quotes       *QuotesService
-	OptionChain  *OptionChainService
-	Instrument   *InstrumentService
-	Chains       *ChainsService
-	Mover        *MoverService
+	PriceHistory       *PriceHistoryService
+	Account            *AccountsService
+	MarketHours        *MarketHoursService
+	Quotes             *QuotesService
+	OptionChain        *OptionChainService
+	Instrument         *InstrumentService
+	Chains             *ChainsService
+	Mover              *MoverService
+	User               *UserService
+	Orders             *OrdersService
+	SavedOrders        *SavedOrdersService
+	TransactionHistory *TransactionHistoryService
+	Watchlist          *WatchlistService
+
+	debugMu sync.Mutex
+	debug   io.Writer
+
+	paperMu sync.Mutex
+	paper   *PaperTrader
+
+	limiterMu sync.Mutex
+	limiter   Limiter
+
+	retry *RetryPolicy
+
+	middlewareMu sync.Mutex
+	middleware   []Middleware
+
+	metrics Metrics
+	logger  Logger
+	timeout time.Duration
+	strict  bool
+
+	correlationIDs bool
 }
 
+// Response wraps the http.Response of an API call with the metadata of its
+// headers, to let callers throttle themselves. It is returned for error
+// responses too.
 type Response struct {
 	*http.Response
 
-	// TODO add additional items if needed
+	// RateLimit is the request quota the response reports, if any.
+	RateLimit RateLimitInfo
+
+	// RequestID identifies the request in the logs of the API, for support
+	// requests. Empty if the response has none.
+	RequestID string
+
+	// CorrelationID is the correlation id sent with the request, if any.
+	// See WithCorrelationIDs.
+	CorrelationID string
+
+	// ServerTime is the time of the API server, from the Date header, or
+	// zero if missing.
+	ServerTime time.Time
+}
+
+// RateLimitInfo is the request quota reported in the X-RateLimit headers.
+// The fields the response lacks are -1, or zero for Reset.
+type RateLimitInfo struct {
+	Limit     int       // requests allowed in the window
+	Remaining int       // requests left in the window
+	Reset     time.Time // when the window resets
 }
 
+// requestIDHeaders are the headers that may hold the id of a request.
+var requestIDHeaders = []string{"Schwab-Client-Correlid", "X-Request-Id", "X-Correlation-Id"}
+
 // NewClient returns a new TD-Ameritrade API client. If a nil httpClient is
 // provided, a new http.Client will be used. To use API methods which require
 // authentication, provide an http.Client that will perform the authentication
-// for you (such as that provided by the golang.org/x/oauth2 library).
-func NewClient(httpClient *http.Client) (*Client, error) {
+// for you (such as that provided by the golang.org/x/oauth2 library). With
+// the one of TokenSource.Client, a request rejected with 401 Unauthorized is
+// replayed once with a refreshed access token. opts are applied in order:
+//
+//	client, err := tdameritrade.NewClient(ts.Client(),
+//		tdameritrade.WithEndpoints(tdameritrade.SchwabEndpoints),
+//		tdameritrade.WithRateLimit(120),
+//		tdameritrade.WithRetry(tdameritrade.RetryPolicy{MaxAttempts: 3}),
+//		tdameritrade.WithLogger(log.New(os.Stderr, "", log.LstdFlags)),
+//	)
+func NewClient(httpClient *http.Client, opts ...ClientOption) (*Client, error) {
 	if httpClient == nil {
 		httpClient = &http.Client{}
 	}
@@ -55,7 +135,7 @@ func NewClient(httpClient *http.Client) (*Client, error) {
 		return nil, err
 	}
 
-	c := &Client{client: httpClient, BaseURL: b}
+	c := &Client{client: httpClient, BaseURL: b, UserAgent: defaultUserAgent}
 	c.PriceHistory = &PriceHistoryService{client: c}
 	c.Account = &AccountsService{client: c}
 	c.MarketHours = &MarketHoursService{client: c}
@@ -64,7 +144,17 @@ func NewClient(httpClient *http.Client) (*Client, error) {
 	c.Instrument = &InstrumentService{client: c}
 	c.Chains = &ChainsService{client: c}
 	c.Mover = &MoverService{client: c}
+	c.User = &UserService{client: c}
+	c.Orders = &OrdersService{client: c}
+	c.SavedOrders = &SavedOrdersService{client: c}
+	c.TransactionHistory = &TransactionHistoryService{client: c}
+	c.Watchlist = &WatchlistService{client: c}
 
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
 	return c, nil
 }
 
@@ -77,39 +167,85 @@ func (c *Client) UpdateBaseURL(baseURL string) error {
 	return nil
 }
 
+// SetDebugWriter makes the client dump every raw request and response,
+// headers and bodies, to w. Pass nil to turn dumping off again. The dumps
+// can contain account data, so only enable this while debugging.
+func (c *Client) SetDebugWriter(w io.Writer) {
+	c.debugMu.Lock()
+	c.debug = w
+	c.debugMu.Unlock()
+}
+
+func (c *Client) dumpRequest(req *http.Request) {
+	c.debugMu.Lock()
+	defer c.debugMu.Unlock()
+	if c.debug == nil {
+		return
+	}
+	dump, err := httputil.DumpRequestOut(req, true)
+	if err != nil {
+		fmt.Fprintf(c.debug, "dumping request: %v\n", err)
+		return
+	}
+	fmt.Fprintf(c.debug, "%s\n\n", dump)
+}
+
+func (c *Client) dumpResponse(resp *http.Response) {
+	c.debugMu.Lock()
+	defer c.debugMu.Unlock()
+	if c.debug == nil {
+		return
+	}
+	dump, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		fmt.Fprintf(c.debug, "dumping response: %v\n", err)
+		return
+	}
+	fmt.Fprintf(c.debug, "%s\n\n", dump)
+}
+
 func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*Response, error) {
 	if ctx == nil {
 		return nil, errors.New("context must be non-nil")
 	}
+	if c.timeout > 0 {
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+		}
+	}
 
 	req = req.WithContext(ctx)
-
-	resp, err := c.client.Do(req)
+	correlationID, err := c.correlationID(ctx)
 	if err != nil {
-		// If we got an error, and the context has been canceled,
-		// the context's error is probably more useful.
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		default:
-			return nil, err
-		}
+		return nil, err
 	}
-
-	defer resp.Body.Close()
-
-	if err := checkResponse(resp); err != nil {
+	if correlationID != "" {
+		req.Header.Set(CorrelationIDHeader, correlationID)
+	}
+	resp, err := c.sendRetrying(ctx, req)
+	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
 	response := newResponse(resp)
+	response.CorrelationID = correlationID
+	if err := checkResponse(resp); err != nil {
+		return response, err
+	}
 
 	// write to v for that good shit
 	if v != nil {
 		if w, ok := v.(io.Writer); ok {
-			_, _ = io.Copy(w, resp.Body)
+			_, err = io.Copy(w, resp.Body)
 		} else {
-			decErr := json.NewDecoder(resp.Body).Decode(v)
+			dec := json.NewDecoder(resp.Body)
+			if c.strict {
+				dec.DisallowUnknownFields()
+			}
+			decErr := dec.Decode(v)
 			if decErr == io.EOF {
 				decErr = nil // ignore EOF errors caused by empty response body
 			}
@@ -122,19 +258,202 @@ func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*Res
 	return response, err
 }
 
+// DoRaw sends req like Do and returns the response body undecoded, for
+// reading fields the types of this package don't cover yet.
+func (c *Client) DoRaw(ctx context.Context, req *http.Request) (json.RawMessage, *Response, error) {
+	var buf bytes.Buffer
+	resp, err := c.Do(ctx, req, &buf)
+	if err != nil {
+		return nil, resp, err
+	}
+	return json.RawMessage(buf.Bytes()), resp, nil
+}
+
+// getRaw GETs urlStr and returns the undecoded response body.
+func (c *Client) getRaw(ctx context.Context, urlStr string) (json.RawMessage, *Response, error) {
+	req, err := c.NewRequest("GET", urlStr, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.DoRaw(ctx, req)
+}
+
+// send sends req through the middleware, once the rate limiter allows.
+func (c *Client) send(ctx context.Context, req *http.Request) (*http.Response, error) {
+	if l := c.rateLimiter(); l != nil {
+		start := time.Now()
+		err := l.Wait(ctx)
+		if c.metrics != nil {
+			c.metrics.ObserveRateLimitWait(time.Since(start))
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	start := time.Now()
+	resp, err := c.chain()(req)
+	if c.metrics != nil {
+		status := 0
+		if err == nil {
+			status = resp.StatusCode
+		}
+		c.metrics.ObserveRequest(req.Method, c.endpoint(req.URL), status, time.Since(start))
+	}
+	if ue, ok := err.(*url.Error); ok {
+		if refreshErr, ok := ue.Err.(*TokenRefreshError); ok {
+			return nil, refreshErr
+		}
+	}
+	if err != nil {
+		// If we got an error, and the context has been canceled,
+		// the context's error is probably more useful.
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// transport sends req, or lets the paper trader answer it, and dumps the
+// exchange if debugging is on. It is the end of the middleware chain.
+func (c *Client) transport(req *http.Request) (*http.Response, error) {
+	c.dumpRequest(req)
+
+	var resp *http.Response
+	var err error
+	handled := false
+	if p := c.paperTrader(); p != nil {
+		resp, handled, err = p.serve(req.Context(), c, req)
+	}
+	if !handled {
+		resp, err = c.client.Do(req)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.dumpResponse(resp)
+	return resp, nil
+}
+
+// sendAuthorized sends req, replaying it once with a refreshed access token
+// if it is rejected with 401 Unauthorized. See reauthorize.
+func (c *Client) sendAuthorized(ctx context.Context, req *http.Request) (*http.Response, error) {
+	resp, err := c.send(ctx, req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	retry, err := c.reauthorize(ctx, req, resp)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if retry != nil {
+		resp.Body.Close()
+		resp = retry
+	}
+	return resp, nil
+}
+
+// reauthorize handles a 401 Unauthorized resp to req. If the client
+// authorizes its requests with a TokenSource, through TokenSource.Client, it
+// refreshes the rejected access token and replays req once, returning the
+// new response, or a *TokenRefreshError if the refresh fails. Otherwise, or
+// if the body of req cannot be replayed, it returns nil and no error.
+func (c *Client) reauthorize(ctx context.Context, req *http.Request, resp *http.Response) (*http.Response, error) {
+	t, ok := c.client.Transport.(*oauth2.Transport)
+	if !ok {
+		return nil, nil
+	}
+	ts, ok := t.Source.(*TokenSource)
+	if !ok || resp.Request == nil || (req.Body != nil && req.GetBody == nil) {
+		return nil, nil
+	}
+
+	c.logf("tdameritrade: %s: 401 Unauthorized, refreshing the access token", describe(req))
+	ts.invalidate(strings.TrimPrefix(resp.Request.Header.Get("Authorization"), "Bearer "))
+	if _, err := ts.Token(); err != nil {
+		c.logf("tdameritrade: refreshing the access token: %v", err)
+		return nil, err
+	}
+
+	replay := req.WithContext(ctx)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		replay.Body = body
+	}
+	return c.send(ctx, replay)
+}
+
 func checkResponse(r *http.Response) error {
 	if c := r.StatusCode; 200 <= c && c <= 299 {
 		return nil
 	}
-	errMsg, _ := ioutil.ReadAll(r.Body)
-	return errors.New(string(errMsg))
+	body, _ := ioutil.ReadAll(r.Body)
+	return newAPIError(r, body)
+}
+
+// SymbolErrors is returned by the methods that fetch several symbols at once
+// when some of them fail. It maps each failed symbol to its error.
+type SymbolErrors map[string]error
+
+func (e SymbolErrors) Error() string {
+	symbols := make([]string, 0, len(e))
+	for symbol := range e {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	msgs := make([]string, len(symbols))
+	for i, symbol := range symbols {
+		msgs[i] = fmt.Sprintf("%s: %v", symbol, e[symbol])
+	}
+	return fmt.Sprintf("%d symbol(s) failed: %s", len(e), strings.Join(msgs, "; "))
 }
 
 func newResponse(r *http.Response) *Response {
 	response := &Response{Response: r}
+	response.RateLimit = parseRateLimit(r.Header)
+	for _, h := range requestIDHeaders {
+		if id := r.Header.Get(h); id != "" {
+			response.RequestID = id
+			break
+		}
+	}
+	if t, err := http.ParseTime(r.Header.Get("Date")); err == nil {
+		response.ServerTime = t
+	}
 	return response
 }
 
+// parseRateLimit reads the X-RateLimit headers of h. Reset is given either
+// in seconds from now or as a Unix time.
+func parseRateLimit(h http.Header) RateLimitInfo {
+	rl := RateLimitInfo{Limit: -1, Remaining: -1}
+	if n, err := strconv.Atoi(h.Get("X-RateLimit-Limit")); err == nil {
+		rl.Limit = n
+	}
+	if n, err := strconv.Atoi(h.Get("X-RateLimit-Remaining")); err == nil {
+		rl.Remaining = n
+	}
+	if n, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil && n >= 0 {
+		// Unix times are past a billion; shorter values are a delay.
+		if n > 1e9 {
+			rl.Reset = time.Unix(n, 0)
+		} else {
+			rl.Reset = time.Now().Add(time.Duration(n) * time.Second)
+		}
+	}
+	return rl
+}
+
 // NewRequest creates an API request. A relative URL can be provided in urlStr,
 // in which case it is resolved relative to the BaseURL of the Client.
 // Relative URLs should always be specified without a preceding slash. If
@@ -145,7 +464,11 @@ func (c *Client) NewRequest(method, urlStr string, body interface{}) (*http.Requ
 		return nil, fmt.Errorf("BaseURL must have a trailing slash, but %q does not", c.BaseURL)
 	}
 
-	u, err := c.BaseURL.Parse(urlStr)
+	base := c.BaseURL
+	if c.MarketDataURL != nil && strings.HasPrefix(urlStr, "marketdata/") {
+		base, urlStr = c.MarketDataURL, strings.TrimPrefix(urlStr, "marketdata/")
+	}
+	u, err := base.Parse(urlStr)
 	if err != nil {
 		return nil, err
 	}
@@ -169,6 +492,9 @@ func (c *Client) NewRequest(method, urlStr string, body interface{}) (*http.Requ
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
 
 	return req, nil
 }
@@ -0,0 +1,347 @@
+// Package tdameritradetest provides a fake TD Ameritrade API for testing
+// code built on the tdameritrade package without credentials or network
+// access:
+//
+//	srv := tdameritradetest.NewServer()
+//	defer srv.Close()
+//	srv.SetQuote("AAPL", &tdameritrade.Quote{
+//		Symbol:        "AAPL",
+//		AssetMainType: "EQUITY",
+//		Data:          &tdameritrade.EquityQuote{BidPrice: 189.9, AskPrice: 190, LastPrice: 190},
+//	})
+//	client := srv.Client()
+//	runBot(client)
+//	for _, o := range srv.ReceivedOrders() {
+//		...
+//	}
+//
+// The server answers quotes, option chains and accounts from the fixtures
+// it is given and serves any other endpoint from responses registered with
+// Handle. Orders are kept by a tdameritrade.PaperTrader, so they get ids and
+// statuses like they do from the API, and working orders fill once the
+// quotes set with SetQuote reach their prices.
+package tdameritradetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/glacialspring/go-tdameritrade/tdameritrade"
+)
+
+// ReceivedOrder is an order request the server received.
+type ReceivedOrder struct {
+	AccountID string
+	Method    string // POST to place, PUT to replace
+	OrderID   int64  // the id the order was given, or 0 if rejected
+	Replaced  int64  // the id of the replaced order, for PUT
+	Order     *tdameritrade.Order
+	Body      []byte // the request body as sent
+	Time      time.Time
+}
+
+// Server is a fake API served by an httptest.Server. Its fixtures may be
+// changed while it runs. It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	paper  *tdameritrade.PaperTrader
+	orders http.Handler // the order endpoints, served by paper
+
+	mu       sync.Mutex
+	quotes   map[string]json.RawMessage
+	chains   map[string]json.RawMessage
+	accounts map[string]json.RawMessage
+	received []ReceivedOrder
+	reject   *cannedResponse
+	routes   map[string]cannedResponse // by method and path
+}
+
+type cannedResponse struct {
+	status int
+	body   json.RawMessage
+}
+
+// NewServer starts a server without fixtures. Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		paper:    tdameritrade.NewPaperTrader(),
+		quotes:   make(map[string]json.RawMessage),
+		chains:   make(map[string]json.RawMessage),
+		accounts: make(map[string]json.RawMessage),
+		routes:   make(map[string]cannedResponse),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	// The paper trader fetches the quotes orders fill at from the server.
+	s.orders = s.paper.Handler(s.Client())
+	return s
+}
+
+// Client returns a client talking to the server, configured by opts.
+func (s *Server) Client(opts ...tdameritrade.ClientOption) *tdameritrade.Client {
+	opts = append([]tdameritrade.ClientOption{tdameritrade.WithBaseURL(s.URL + "/")}, opts...)
+	c, err := tdameritrade.NewClient(s.Server.Client(), opts...)
+	if err != nil {
+		panic("tdameritradetest: " + err.Error())
+	}
+	return c
+}
+
+// fixture returns the JSON of v: v itself if it is a string, []byte or
+// json.RawMessage, or v marshaled otherwise.
+func fixture(v interface{}) (json.RawMessage, error) {
+	switch v := v.(type) {
+	case string:
+		return checkJSON([]byte(v))
+	case []byte:
+		return checkJSON(v)
+	case json.RawMessage:
+		return checkJSON(v)
+	}
+	return json.Marshal(v)
+}
+
+func checkJSON(b []byte) (json.RawMessage, error) {
+	if !json.Valid(b) {
+		return nil, fmt.Errorf("tdameritradetest: fixture is not valid JSON")
+	}
+	return json.RawMessage(b), nil
+}
+
+// SetQuote sets the quote of symbol, a *tdameritrade.Quote or its JSON.
+// Symbols without a quote are left out of quote responses, like the API
+// does for unknown symbols.
+func (s *Server) SetQuote(symbol string, quote interface{}) error {
+	b, err := fixture(quote)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.quotes[symbol] = b
+	s.mu.Unlock()
+	return nil
+}
+
+// SetChain sets the option chain of symbol, the JSON the API serves for
+// marketdata/chains. The options of chain requests are ignored. Symbols
+// without a chain get a chain with status FAILED.
+func (s *Server) SetChain(symbol string, chain interface{}) error {
+	b, err := fixture(chain)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.chains[symbol] = b
+	s.mu.Unlock()
+	return nil
+}
+
+// SetAccount sets the account accountID, a *tdameritrade.Account or its
+// JSON. The fields option of account requests is ignored.
+func (s *Server) SetAccount(accountID string, account interface{}) error {
+	b, err := fixture(account)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.accounts[accountID] = b
+	s.mu.Unlock()
+	return nil
+}
+
+// Handle makes the server answer method requests to path, such as
+// /marketdata/$SPX.X/movers, with status and body, any value marshaled to
+// JSON, or JSON itself if a string or []byte. Handled paths take precedence
+// over the built-in endpoints.
+func (s *Server) Handle(method, path string, status int, body interface{}) error {
+	var b json.RawMessage
+	if body != nil {
+		var err error
+		if b, err = fixture(body); err != nil {
+			return err
+		}
+	}
+	s.mu.Lock()
+	s.routes[method+" "+path] = cannedResponse{status: status, body: b}
+	s.mu.Unlock()
+	return nil
+}
+
+// RejectNextOrder makes the server refuse the next order placed or
+// replaced with status, such as 400, and the error message.
+func (s *Server) RejectNextOrder(status int, message string) {
+	body, _ := json.Marshal(map[string]string{"error": message})
+	s.mu.Lock()
+	s.reject = &cannedResponse{status: status, body: body}
+	s.mu.Unlock()
+}
+
+// ReceivedOrders returns the order requests received so far, oldest first.
+func (s *Server) ReceivedOrders() []ReceivedOrder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	received := make([]ReceivedOrder, len(s.received))
+	copy(received, s.received)
+	return received
+}
+
+// Orders returns the orders of the account accountID, oldest first.
+func (s *Server) Orders(accountID string) []*tdameritrade.Order {
+	return s.paper.Orders(accountID)
+}
+
+// SetOrderStatus changes the status of an order or child order, e.g. to
+// FILLED to simulate a fill, as tdameritrade.PaperTrader.SetOrderStatus
+// does.
+func (s *Server) SetOrderStatus(accountID string, orderID int64, status tdameritrade.OrderStatus) error {
+	if err := s.paper.SetOrderStatus(accountID, orderID, status); err != nil {
+		return fmt.Errorf("tdameritradetest: %v", err)
+	}
+	return nil
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	if canned, ok := s.routes[r.Method+" "+r.URL.Path]; ok {
+		s.mu.Unlock()
+		writeRaw(w, canned.status, canned.body)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if r.URL.Path == "/orders" && r.Method != "GET" {
+		s.mu.Unlock()
+		writeError(w, http.StatusMethodNotAllowed, "Orders are placed under an account")
+		return
+	}
+	if r.URL.Path == "/orders" || len(parts) >= 3 && len(parts) <= 4 && parts[0] == "accounts" && parts[2] == "orders" {
+		// The paper trader fetches quotes from the server while it answers.
+		s.mu.Unlock()
+		s.serveOrders(w, r, parts)
+		return
+	}
+	defer s.mu.Unlock()
+
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/marketdata/quotes":
+		s.serveQuotes(w, strings.Split(r.URL.Query().Get("symbol"), ","))
+	case r.Method == "GET" && len(parts) == 3 && parts[0] == "marketdata" && parts[2] == "quotes":
+		s.serveQuotes(w, []string{parts[1]})
+	case r.Method == "GET" && r.URL.Path == "/marketdata/chains":
+		symbol := r.URL.Query().Get("symbol")
+		if chain, ok := s.chains[symbol]; ok {
+			writeRaw(w, http.StatusOK, chain)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"symbol": symbol, "status": "FAILED"})
+	case r.Method == "GET" && r.URL.Path == "/accounts":
+		ids := make([]string, 0, len(s.accounts))
+		for id := range s.accounts {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		accounts := make([]json.RawMessage, len(ids))
+		for i, id := range ids {
+			accounts[i] = s.accounts[id]
+		}
+		writeJSON(w, http.StatusOK, accounts)
+	case r.Method == "GET" && len(parts) == 2 && parts[0] == "accounts":
+		account, ok := s.accounts[parts[1]]
+		if !ok {
+			writeError(w, http.StatusNotFound, "Account not found")
+			return
+		}
+		writeRaw(w, http.StatusOK, account)
+	default:
+		writeError(w, http.StatusNotFound, "No fixture for "+r.Method+" "+r.URL.Path)
+	}
+}
+
+func (s *Server) serveQuotes(w http.ResponseWriter, symbols []string) {
+	quotes := make(map[string]json.RawMessage)
+	for _, symbol := range symbols {
+		if q, ok := s.quotes[symbol]; ok {
+			quotes[symbol] = q
+		}
+	}
+	writeJSON(w, http.StatusOK, quotes)
+}
+
+// serveOrders answers the order request r, whose path is parts, recording
+// the orders placed and replaced.
+func (s *Server) serveOrders(w http.ResponseWriter, r *http.Request, parts []string) {
+	if r.Method != "POST" && r.Method != "PUT" {
+		s.orders.ServeHTTP(w, r)
+		return
+	}
+
+	body, _ := ioutil.ReadAll(r.Body)
+	received := ReceivedOrder{AccountID: parts[1], Method: r.Method, Body: body, Time: time.Now()}
+	if r.Method == "PUT" && len(parts) == 4 {
+		received.Replaced, _ = strconv.ParseInt(parts[3], 10, 64)
+	}
+	order := new(tdameritrade.Order)
+	if json.Unmarshal(body, order) == nil {
+		received.Order = order
+	}
+
+	s.mu.Lock()
+	reject := s.reject
+	if received.Order != nil {
+		s.reject = nil
+	} else {
+		reject = nil
+	}
+	s.mu.Unlock()
+
+	if reject != nil {
+		writeRaw(w, reject.status, reject.body)
+	} else {
+		rec := httptest.NewRecorder()
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		s.orders.ServeHTTP(rec, r)
+		if rec.Code == http.StatusCreated {
+			received.OrderID, _ = strconv.ParseInt(path.Base(rec.Header().Get("Location")), 10, 64)
+		}
+		for k, v := range rec.Header() {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(rec.Code)
+		w.Write(rec.Body.Bytes())
+	}
+
+	s.mu.Lock()
+	s.received = append(s.received, received)
+	s.mu.Unlock()
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeRaw(w, status, b)
+}
+
+func writeRaw(w http.ResponseWriter, status int, body []byte) {
+	if body != nil {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,147 @@
+package tdameritradetest
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/glacialspring/go-tdameritrade/tdameritrade"
+)
+
+func TestServerOrders(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	order, err := tdameritrade.NewEquityOrder().Buy("AAPL", 10).Limit(180).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	placed, _, err := client.Orders.PlaceOrder(ctx, "123", order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if placed.OrderID == 0 || placed.AccountID != "123" {
+		t.Fatalf("placed order %+v, want an id in account 123", placed)
+	}
+
+	got, _, err := client.Orders.GetOrder(ctx, "123", placed.OrderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != tdameritrade.OrderStatusWorking || got.Quantity != 10 || got.EnteredTime == "" {
+		t.Errorf("order = %+v, want a working order of 10 with its entered time", got)
+	}
+
+	// The order fills once the ask reaches its limit.
+	srv.SetQuote("AAPL", &tdameritrade.Quote{
+		Symbol:        "AAPL",
+		AssetMainType: "EQUITY",
+		Data:          &tdameritrade.EquityQuote{BidPrice: 179.9, AskPrice: 180, LastPrice: 180},
+	})
+	got, _, err = client.Orders.GetOrder(ctx, "123", placed.OrderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != tdameritrade.OrderStatusFilled || got.FilledQuantity != 10 {
+		t.Errorf("order = %+v, want it filled", got)
+	}
+
+	received := srv.ReceivedOrders()
+	if len(received) != 1 || received[0].OrderID != placed.OrderID || received[0].Method != "POST" {
+		t.Errorf("received orders = %+v, want the placed order", received)
+	}
+}
+
+func TestServerConditionalOrders(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	order, err := tdameritrade.NewBracketOrder(tdameritrade.NewEquityOrder().Buy("AAPL", 10).Limit(180), 200, 170)
+	if err != nil {
+		t.Fatal(err)
+	}
+	placed, _, err := client.Orders.PlaceOrder(ctx, "123", order)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, _, err := client.Orders.GetOrder(ctx, "123", placed.OrderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oco := got.ChildOrderStrategies[0]
+	target, stop := oco.ChildOrderStrategies[0], oco.ChildOrderStrategies[1]
+	ids := map[int64]bool{got.OrderID: true, oco.OrderID: true, target.OrderID: true, stop.OrderID: true}
+	if len(ids) != 4 || ids[0] {
+		t.Fatalf("order ids %d, %d, %d, %d, want distinct ids", got.OrderID, oco.OrderID, target.OrderID, stop.OrderID)
+	}
+	if target.Status != tdameritrade.OrderStatusAwaitingParentOrder {
+		t.Errorf("target status = %s, want it awaiting the entry", target.Status)
+	}
+
+	// Child orders can be read and driven by their own ids.
+	if err := srv.SetOrderStatus("123", got.OrderID, tdameritrade.OrderStatusFilled); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.SetOrderStatus("123", target.OrderID, tdameritrade.OrderStatusFilled); err != nil {
+		t.Fatal(err)
+	}
+	stopped, _, err := client.Orders.GetOrder(ctx, "123", stop.OrderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stopped.Status != tdameritrade.OrderStatusCanceled {
+		t.Errorf("stop status = %s, want it canceled by the filled target", stopped.Status)
+	}
+}
+
+func TestServerRejectNextOrder(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.Client()
+
+	srv.RejectNextOrder(400, "Your buying power will be below zero ($-1,234.56) if this order is accepted.")
+	order, err := tdameritrade.NewEquityOrder().Buy("AAPL", 10).Market().Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = client.Orders.PlaceOrder(context.Background(), "123", order)
+	rejected, ok := err.(*tdameritrade.OrderRejectedError)
+	if !ok || rejected.Reason != tdameritrade.RejectBuyingPower {
+		t.Fatalf("err = %v, want a rejection for the buying power", err)
+	}
+	if orders := srv.Orders("123"); len(orders) != 0 {
+		t.Errorf("orders = %+v, want none", orders)
+	}
+	if received := srv.ReceivedOrders(); len(received) != 1 || received[0].OrderID != 0 || received[0].Order == nil {
+		t.Errorf("received orders = %+v, want the rejected order", received)
+	}
+}
+
+func TestServerOrdersWithoutAccount(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	for _, method := range []string{"POST", "PUT"} {
+		req, err := http.NewRequest(method, srv.URL+"/orders", strings.NewReader(`{"orderType":"MARKET"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := srv.Server.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Errorf("%s /orders: status = %d, want %d", method, resp.StatusCode, http.StatusMethodNotAllowed)
+		}
+	}
+	if received := srv.ReceivedOrders(); len(received) != 0 {
+		t.Errorf("received orders = %+v, want none", received)
+	}
+}
@@ -0,0 +1,135 @@
+package tdameritrade
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// hangingServer answers after delay, or never if delay is zero, unless the
+// request is abandoned first. It counts the requests it gets.
+func hangingServer(t *testing.T, delay time.Duration) (*httptest.Server, *int32) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		var answer <-chan time.Time
+		if delay > 0 {
+			answer = time.After(delay)
+		}
+		select {
+		case <-answer:
+			w.Write([]byte(`{}`))
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestTimeout(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		timeout  time.Duration
+		deadline time.Duration
+		delay    time.Duration
+		err      error
+	}{
+		{"no deadline", 50 * time.Millisecond, 0, 0, context.DeadlineExceeded},
+		{"longer deadline", 50 * time.Millisecond, 5 * time.Second, 200 * time.Millisecond, nil},
+		{"shorter deadline", 5 * time.Second, 50 * time.Millisecond, 0, context.DeadlineExceeded},
+		{"no timeout", 0, 0, 200 * time.Millisecond, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := hangingServer(t, tt.delay)
+			client, err := NewClient(srv.Client(), WithBaseURL(srv.URL+"/"), WithTimeout(tt.timeout))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.Background()
+			if tt.deadline > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.deadline)
+				defer cancel()
+			}
+
+			start := time.Now()
+			_, _, err = client.Orders.GetOrder(ctx, "1", 42)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				limit := tt.timeout
+				if tt.deadline > 0 {
+					limit = tt.deadline
+				}
+				if took := time.Since(start); took > limit+time.Second {
+					t.Errorf("call took %v, want it cut off after %v", took, limit)
+				}
+			}
+		})
+	}
+}
+
+func TestCancel(t *testing.T) {
+	srv, requests := hangingServer(t, 0)
+	client, err := NewClient(srv.Client(), WithBaseURL(srv.URL+"/"), WithTimeout(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for atomic.LoadInt32(requests) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+
+	start := time.Now()
+	if _, _, err := client.Orders.GetOrder(ctx, "1", 42); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want %v", err, context.Canceled)
+	}
+	if took := time.Since(start); took > 5*time.Second {
+		t.Errorf("call took %v after the cancel", took)
+	}
+}
+
+func TestCancelDuringBackoff(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	client, err := NewClient(srv.Client(), WithBaseURL(srv.URL+"/"), WithRetry(RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Minute,
+		MaxDelay:    time.Minute,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for atomic.LoadInt32(&requests) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	if _, _, err := client.Orders.GetOrder(ctx, "1", 42); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want %v", err, context.Canceled)
+	}
+	if took := time.Since(start); took > 5*time.Second {
+		t.Errorf("call took %v, want the backoff cut short by the cancel", took)
+	}
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+}
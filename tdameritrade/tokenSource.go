@@ -0,0 +1,227 @@
+package tdameritrade
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	// defaultRefreshEarly is how long before its expiry an access token is
+	// refreshed, so that a request never goes out with a token that expires
+	// on the way.
+	defaultRefreshEarly = time.Minute
+	// defaultRenewWithin is how long before the refresh token expires a new
+	// one is requested with the access token.
+	defaultRenewWithin = 7 * 24 * time.Hour
+)
+
+// TokenSource is an oauth2.TokenSource that refreshes the 30 minute access
+// token with the refresh token shortly before it expires. Concurrent calls
+// to Token while a refresh is under way wait for that refresh instead of
+// starting their own. Its Client method builds the http.Client for
+// NewClient:
+//
+//	ts := tdameritrade.NewTokenSource(ctx, clientID, token)
+//	c, err := tdameritrade.NewClient(ts.Client())
+type TokenSource struct {
+	ctx          context.Context
+	endpoints    Endpoints
+	clientID     string
+	clientSecret string
+
+	// RefreshEarly is how long before its expiry the access token is
+	// refreshed. Defaults to one minute.
+	RefreshEarly time.Duration
+
+	// RefreshExpiry is when the refresh token expires. Within RenewWithin
+	// of it, or if it is unknown, a new refresh token is requested along
+	// with the access token. NewTokenSource sets it from the token, if the
+	// token tells, and it is kept up to date as refresh tokens are renewed.
+	RefreshExpiry time.Time
+
+	// RenewWithin defaults to 7 days.
+	RenewWithin time.Duration
+
+	// OnRefresh, if set, is called with every new token, e.g. to store the
+	// renewed refresh token.
+	OnRefresh func(*oauth2.Token)
+
+	mu      sync.Mutex
+	token   *oauth2.Token
+	pending *tokenRefresh
+}
+
+// tokenRefresh is a refresh in flight; done is closed when it finishes.
+type tokenRefresh struct {
+	done  chan struct{}
+	token *oauth2.Token
+	err   error
+}
+
+// NewTokenSource returns a TokenSource of the app with the consumer key
+// clientID, starting from token, which must have a refresh token and may
+// have no access token. ctx is used for every refresh request; like with
+// oauth2, an http.Client in ctx under oauth2.HTTPClient makes them.
+func NewTokenSource(ctx context.Context, clientID string, token *oauth2.Token) *TokenSource {
+	return TDAmeritradeEndpoints.NewTokenSource(ctx, clientID, "", token)
+}
+
+// NewTokenSource is NewTokenSource for an app of the deployment e with the
+// secret clientSecret, if it has one, such as a Schwab app:
+//
+//	ts := tdameritrade.SchwabEndpoints.NewTokenSource(ctx, clientID, clientSecret, token)
+//	c, err := tdameritrade.NewClient(ts.Client(), tdameritrade.WithEndpoints(tdameritrade.SchwabEndpoints))
+func (e Endpoints) NewTokenSource(ctx context.Context, clientID, clientSecret string, token *oauth2.Token) *TokenSource {
+	return &TokenSource{
+		ctx:           ctx,
+		endpoints:     e,
+		clientID:      clientID,
+		clientSecret:  clientSecret,
+		token:         token,
+		RefreshExpiry: RefreshTokenExpiry(token),
+	}
+}
+
+// RefreshTokenExpiry returns when the refresh token of token expires, from
+// the refresh_token_expires_in extra of the tokens returned by ExchangeCode
+// and RefreshToken, or the zero time if token doesn't tell. Store it along
+// with the token, as the extra is lost when the token is marshaled.
+func RefreshTokenExpiry(token *oauth2.Token) time.Time {
+	if token == nil {
+		return time.Time{}
+	}
+	secs := extraSeconds(token, "refresh_token_expires_in")
+	if secs <= 0 {
+		return time.Time{}
+	}
+	// The lifetimes count from when the token was issued, which the expiry
+	// of the access token tells.
+	issued := time.Now()
+	if expiresIn := extraSeconds(token, "expires_in"); expiresIn > 0 && !token.Expiry.IsZero() {
+		issued = token.Expiry.Add(-expiresIn)
+	}
+	return issued.Add(secs)
+}
+
+// extraSeconds returns the extra key of token, a number of seconds, which
+// oauth2 decodes as a float64 or a string.
+func extraSeconds(token *oauth2.Token, key string) time.Duration {
+	var secs float64
+	switch v := token.Extra(key).(type) {
+	case float64:
+		secs = v
+	case int64:
+		secs = float64(v)
+	case int:
+		secs = float64(v)
+	case json.Number:
+		secs, _ = v.Float64()
+	case string:
+		secs, _ = strconv.ParseFloat(v, 64)
+	}
+	return time.Duration(secs * float64(time.Second))
+}
+
+// TokenRefreshError is returned by Client methods when the access token
+// expired or was rejected and refreshing it failed, typically because the refresh
+// token expired or was revoked and the user has to authorize the app again.
+type TokenRefreshError struct {
+	Err error
+}
+
+func (e *TokenRefreshError) Error() string {
+	return fmt.Sprintf("refreshing access token: %v", e.Err)
+}
+
+// Client returns an http.Client that authorizes requests with the tokens of
+// ts. Unlike one from oauth2.NewClient, it lets a Client refresh a token the
+// API rejects before its expiry and replay the request.
+func (ts *TokenSource) Client() *http.Client {
+	var base http.RoundTripper
+	if hc, ok := ts.ctx.Value(oauth2.HTTPClient).(*http.Client); ok {
+		base = hc.Transport
+	}
+	return &http.Client{Transport: &oauth2.Transport{Source: ts, Base: base}}
+}
+
+// invalidate makes the next call to Token refresh the access token, unless
+// accessToken was already replaced.
+func (ts *TokenSource) invalidate(accessToken string) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.token != nil && ts.token.AccessToken == accessToken {
+		t := *ts.token
+		t.AccessToken = ""
+		ts.token = &t
+	}
+}
+
+// Token returns the current access token, refreshing it first if it expires
+// within RefreshEarly. A failed refresh returns a *TokenRefreshError.
+func (ts *TokenSource) Token() (*oauth2.Token, error) {
+	ts.mu.Lock()
+	early := ts.RefreshEarly
+	if early <= 0 {
+		early = defaultRefreshEarly
+	}
+	if t := ts.token; t != nil && t.AccessToken != "" && (t.Expiry.IsZero() || time.Now().Add(early).Before(t.Expiry)) {
+		ts.mu.Unlock()
+		return t, nil
+	}
+
+	r := ts.pending
+	if r == nil {
+		r = &tokenRefresh{done: make(chan struct{})}
+		ts.pending = r
+		go ts.refresh(r)
+	}
+	ts.mu.Unlock()
+
+	<-r.done
+	return r.token, r.err
+}
+
+// refresh runs r and publishes its outcome.
+func (ts *TokenSource) refresh(r *tokenRefresh) {
+	defer close(r.done)
+
+	ts.mu.Lock()
+	var refreshToken string
+	if ts.token != nil {
+		refreshToken = ts.token.RefreshToken
+	}
+	renewWithin := ts.RenewWithin
+	if renewWithin <= 0 {
+		renewWithin = defaultRenewWithin
+	}
+	renew := ts.RefreshExpiry.IsZero() || time.Now().Add(renewWithin).After(ts.RefreshExpiry)
+	ts.mu.Unlock()
+
+	token, err := ts.endpoints.RefreshToken(ts.ctx, ts.clientID, ts.clientSecret, refreshToken, renew)
+
+	ts.mu.Lock()
+	ts.pending = nil
+	if err == nil {
+		ts.token = token
+		if expiry := RefreshTokenExpiry(token); !expiry.IsZero() && renew {
+			ts.RefreshExpiry = expiry
+		}
+	}
+	onRefresh := ts.OnRefresh
+	ts.mu.Unlock()
+
+	r.token = token
+	if err != nil {
+		r.err = &TokenRefreshError{Err: err}
+	}
+	if err == nil && onRefresh != nil {
+		onRefresh(token)
+	}
+}
@@ -0,0 +1,142 @@
+package tdameritrade
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// tokenEndpoint answers token requests like the API, and records their
+// forms.
+type tokenEndpoint struct {
+	mu       sync.Mutex
+	forms    []url.Values
+	requests []*http.Request
+}
+
+func (e *tokenEndpoint) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, _ := ioutil.ReadAll(req.Body)
+	form, _ := url.ParseQuery(string(body))
+	e.mu.Lock()
+	e.forms = append(e.forms, form)
+	e.requests = append(e.requests, req)
+	e.mu.Unlock()
+
+	resp := `{"access_token":"access","token_type":"Bearer","expires_in":1800,"scope":"PlaceTrades"}`
+	if form.Get("grant_type") == "authorization_code" || form.Get("access_type") == "offline" {
+		resp = `{"access_token":"access","refresh_token":"renewed","token_type":"Bearer","expires_in":1800,` +
+			`"refresh_token_expires_in":7776000,"scope":"PlaceTrades"}`
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       ioutil.NopCloser(strings.NewReader(resp)),
+		Request:    req,
+	}, nil
+}
+
+func (e *tokenEndpoint) lastForm() url.Values {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.forms[len(e.forms)-1]
+}
+
+func TestExchangeCodeRefreshExpiry(t *testing.T) {
+	endpoint := new(tokenEndpoint)
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: endpoint})
+
+	token, err := ExchangeCode(ctx, "APP", "https://127.0.0.1/callback", "code")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := time.Now().Add(90 * 24 * time.Hour)
+	if got := RefreshTokenExpiry(token); got.Before(want.Add(-time.Minute)) || got.After(want.Add(time.Minute)) {
+		t.Errorf("RefreshTokenExpiry = %v, want about %v", got, want)
+	}
+	if got := NewTokenSource(ctx, "APP", token).RefreshExpiry; !got.Equal(RefreshTokenExpiry(token)) {
+		t.Errorf("NewTokenSource RefreshExpiry = %v, want %v", got, RefreshTokenExpiry(token))
+	}
+}
+
+func TestTokenSourceRenewsRefreshToken(t *testing.T) {
+	for _, tt := range []struct {
+		name          string
+		refreshExpiry time.Time
+		renew         bool
+	}{
+		{"unknown expiry", time.Time{}, true},
+		{"expiring soon", time.Now().Add(24 * time.Hour), true},
+		{"far from expiry", time.Now().Add(60 * 24 * time.Hour), false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := new(tokenEndpoint)
+			ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: endpoint})
+			ts := NewTokenSource(ctx, "APP", &oauth2.Token{RefreshToken: "refresh"})
+			ts.RefreshExpiry = tt.refreshExpiry
+
+			token, err := ts.Token()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := endpoint.lastForm().Get("access_type") == "offline"; got != tt.renew {
+				t.Errorf("renewed = %v, want %v", got, tt.renew)
+			}
+			if tt.renew {
+				if token.RefreshToken != "renewed" {
+					t.Errorf("refresh token = %q, want the renewed one", token.RefreshToken)
+				}
+				if until := time.Until(ts.RefreshExpiry); until < 89*24*time.Hour {
+					t.Errorf("RefreshExpiry in %v, want about 90 days", until)
+				}
+			} else if token.RefreshToken != "refresh" {
+				t.Errorf("refresh token = %q, want the old one", token.RefreshToken)
+			}
+		})
+	}
+}
+
+func TestSchwabTokenSource(t *testing.T) {
+	endpoint := new(tokenEndpoint)
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: endpoint})
+	ts := SchwabEndpoints.NewTokenSource(ctx, "APP", "SECRET", &oauth2.Token{RefreshToken: "refresh"})
+	ts.RefreshExpiry = time.Now().Add(60 * 24 * time.Hour)
+
+	if _, err := ts.Token(); err != nil {
+		t.Fatal(err)
+	}
+	req := endpoint.requests[0]
+	if got := req.URL.String(); got != SchwabEndpoints.TokenURL {
+		t.Errorf("token URL = %s, want %s", got, SchwabEndpoints.TokenURL)
+	}
+	if id, secret, ok := req.BasicAuth(); !ok || id != "APP" || secret != "SECRET" {
+		t.Errorf("basic auth = %q, %q, %v, want APP, SECRET", id, secret, ok)
+	}
+	if form := endpoint.lastForm(); form.Get("refresh_token") != "refresh" || form.Get("client_id") != "" {
+		t.Errorf("form = %v, want the refresh token and no client id", form)
+	}
+}
+
+func TestTDAmeritradeRefreshToken(t *testing.T) {
+	endpoint := new(tokenEndpoint)
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: endpoint})
+	if _, err := RefreshToken(ctx, "APP", "refresh", false); err != nil {
+		t.Fatal(err)
+	}
+	req := endpoint.requests[0]
+	if got := req.URL.String(); got != TokenURL {
+		t.Errorf("token URL = %s, want %s", got, TokenURL)
+	}
+	if _, _, ok := req.BasicAuth(); ok {
+		t.Error("request has basic auth, want none")
+	}
+	if got := endpoint.lastForm().Get("client_id"); got != "APP@AMER.OAUTHAP" {
+		t.Errorf("client_id = %q, want APP@AMER.OAUTHAP", got)
+	}
+}
@@ -0,0 +1,146 @@
+package tdameritrade
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// ErrNoToken is returned by TokenStore.Load when no token was saved yet.
+var ErrNoToken = errors.New("no token stored")
+
+// StoredToken is what a TokenStore keeps: the token and, if known, when its
+// refresh token expires.
+type StoredToken struct {
+	Token         *oauth2.Token `json:"token"`
+	RefreshExpiry time.Time     `json:"refreshExpiry,omitempty"`
+}
+
+// TokenStore persists the token of an app between runs. Implement it to
+// keep tokens in the OS keyring or a secrets manager; EncryptedFileStore
+// keeps them in a file. To save every refreshed token:
+//
+//	ts := tdameritrade.NewTokenSource(ctx, clientID, stored.Token)
+//	ts.RefreshExpiry = stored.RefreshExpiry
+//	ts.OnRefresh = func(t *oauth2.Token) {
+//		store.Save(&tdameritrade.StoredToken{Token: t, RefreshExpiry: ts.RefreshExpiry})
+//	}
+type TokenStore interface {
+	Load() (*StoredToken, error)
+	Save(*StoredToken) error
+}
+
+// tokenFileVersion starts every token file, identifying its format.
+const tokenFileVersion = 1
+
+// tokenFileAD is the additional data authenticated with every token file, so
+// that files encrypted with the same key for another purpose are rejected.
+var tokenFileAD = []byte("go-tdameritrade token")
+
+// EncryptedFileStore is a TokenStore keeping the token in a file encrypted
+// with AES-GCM, readable only by the owner. The key never touches the disk;
+// get it from the user, the OS keyring or the environment.
+type EncryptedFileStore struct {
+	path string
+	aead cipher.AEAD
+}
+
+// NewEncryptedFileStore returns a store keeping the token in the file at
+// path, encrypted with key, which must be 16, 24 or 32 bytes long to select
+// AES-128, AES-192 or AES-256. NewTokenKey generates one.
+func NewEncryptedFileStore(path string, key []byte) (*EncryptedFileStore, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedFileStore{path: path, aead: aead}, nil
+}
+
+// NewTokenKey returns a random 32 byte key for NewEncryptedFileStore.
+func NewTokenKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Load decrypts the stored token. It returns ErrNoToken if the file does
+// not exist and an error if it was not encrypted with the store's key or
+// was tampered with.
+func (s *EncryptedFileStore) Load() (*StoredToken, error) {
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, ErrNoToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	n := s.aead.NonceSize()
+	if len(data) < 1+n || data[0] != tokenFileVersion {
+		return nil, fmt.Errorf("%s: not a token file", s.path)
+	}
+	plain, err := s.aead.Open(nil, data[1:1+n], data[1+n:], tokenFileAD)
+	if err != nil {
+		return nil, fmt.Errorf("%s: cannot decrypt token: wrong key or corrupted file", s.path)
+	}
+	stored := new(StoredToken)
+	if err := json.Unmarshal(plain, stored); err != nil {
+		return nil, fmt.Errorf("%s: %v", s.path, err)
+	}
+	return stored, nil
+}
+
+// Save encrypts token and replaces the stored one. The file is written
+// next to the old one and renamed over it, so a crash never leaves a
+// partial file behind.
+func (s *EncryptedFileStore) Save(token *StoredToken) error {
+	if token == nil || token.Token == nil {
+		return fmt.Errorf("token is nil")
+	}
+	plain, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	data := append([]byte{tokenFileVersion}, nonce...)
+	data = s.aead.Seal(data, nonce, plain, tokenFileAD)
+
+	f, err := ioutil.TempFile(filepath.Dir(s.path), ".token-*")
+	if err != nil {
+		return err
+	}
+	// TempFile creates the file readable by the owner only.
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), s.path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
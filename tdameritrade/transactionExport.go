@@ -0,0 +1,166 @@
+package tdameritrade
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// TransactionField is a value of a Transaction that can be exported.
+type TransactionField int
+
+const (
+	// TransactionFieldDate is the Eastern calendar date of the
+	// transaction, yyyy-MM-dd.
+	TransactionFieldDate TransactionField = iota
+	// TransactionFieldSettlementDate is the settlement date, yyyy-MM-dd.
+	TransactionFieldSettlementDate
+	TransactionFieldID
+	TransactionFieldSymbol
+	TransactionFieldUnderlying
+	TransactionFieldAssetType
+	// TransactionFieldType is the transaction type, such as TRADE or
+	// DIVIDEND_OR_INTEREST.
+	TransactionFieldType
+	// TransactionFieldAction is the instruction of a trade, BUY or SELL,
+	// and empty for other transactions.
+	TransactionFieldAction
+	TransactionFieldDescription
+	// TransactionFieldQuantity is the number of shares or contracts.
+	TransactionFieldQuantity
+	TransactionFieldPrice
+	// TransactionFieldFees is the sum of all fees and commissions.
+	TransactionFieldFees
+	TransactionFieldCommission
+	// TransactionFieldNetAmount is the cash effect of the transaction, fees
+	// included: negative for money leaving the account.
+	TransactionFieldNetAmount
+)
+
+// TransactionColumn is an exported column: Field written under the header
+// Name.
+type TransactionColumn struct {
+	Name  string
+	Field TransactionField
+}
+
+// DefaultTransactionLayout is the column layout used when none is given. It
+// imports into common tax software and spreadsheets.
+var DefaultTransactionLayout = []TransactionColumn{
+	{"date", TransactionFieldDate},
+	{"symbol", TransactionFieldSymbol},
+	{"type", TransactionFieldType},
+	{"action", TransactionFieldAction},
+	{"quantity", TransactionFieldQuantity},
+	{"price", TransactionFieldPrice},
+	{"fees", TransactionFieldFees},
+	{"net_amount", TransactionFieldNetAmount},
+}
+
+func (f TransactionField) text(t *Transaction) (string, error) {
+	item := t.TransactionItem
+	if item == nil {
+		item = &TransactionItem{}
+	}
+	instrument := item.Instrument
+	if instrument == nil {
+		instrument = &TransactionInstrument{}
+	}
+	number := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+
+	switch f {
+	case TransactionFieldDate:
+		tm, err := t.Time()
+		if err != nil {
+			return "", fmt.Errorf("transaction %d: %v", t.TransactionID, err)
+		}
+		return tm.In(Eastern()).Format(dateLayout), nil
+	case TransactionFieldSettlementDate:
+		return t.SettlementDate, nil
+	case TransactionFieldID:
+		return strconv.FormatInt(t.TransactionID, 10), nil
+	case TransactionFieldSymbol:
+		return instrument.Symbol, nil
+	case TransactionFieldUnderlying:
+		return instrument.UnderlyingSymbol, nil
+	case TransactionFieldAssetType:
+		return instrument.AssetType, nil
+	case TransactionFieldType:
+		return t.Type, nil
+	case TransactionFieldAction:
+		return item.Instruction, nil
+	case TransactionFieldDescription:
+		return t.Description, nil
+	case TransactionFieldQuantity:
+		return number(item.Amount), nil
+	case TransactionFieldPrice:
+		return number(item.Price), nil
+	case TransactionFieldFees:
+		return number(t.Fees.Total()), nil
+	case TransactionFieldCommission:
+		return number(t.Fees.Commission), nil
+	case TransactionFieldNetAmount:
+		return number(t.NetAmount), nil
+	}
+	return "", fmt.Errorf("unknown transaction field %d", f)
+}
+
+// TransactionCSVWriter streams transactions to a CSV file, one row per
+// transaction, preceded by a header row of the column names.
+type TransactionCSVWriter struct {
+	w       *csv.Writer
+	layout  []TransactionColumn
+	started bool
+}
+
+// NewTransactionCSVWriter returns a writer of transactions to w with the
+// given column layout, or DefaultTransactionLayout if layout is nil.
+func NewTransactionCSVWriter(w io.Writer, layout []TransactionColumn) *TransactionCSVWriter {
+	if layout == nil {
+		layout = DefaultTransactionLayout
+	}
+	return &TransactionCSVWriter{w: csv.NewWriter(w), layout: layout}
+}
+
+// Write writes one transaction, and the header row before the first one.
+func (tw *TransactionCSVWriter) Write(t *Transaction) error {
+	if !tw.started {
+		header := make([]string, len(tw.layout))
+		for i, col := range tw.layout {
+			header[i] = col.Name
+		}
+		if err := tw.w.Write(header); err != nil {
+			return err
+		}
+		tw.started = true
+	}
+
+	row := make([]string, len(tw.layout))
+	for i, col := range tw.layout {
+		v, err := col.Field.text(t)
+		if err != nil {
+			return err
+		}
+		row[i] = v
+	}
+	return tw.w.Write(row)
+}
+
+// Flush writes any buffered rows to the underlying writer.
+func (tw *TransactionCSVWriter) Flush() error {
+	tw.w.Flush()
+	return tw.w.Error()
+}
+
+// WriteTransactionsCSV writes transactions to w as CSV with the given column
+// layout, or DefaultTransactionLayout if layout is nil.
+func WriteTransactionsCSV(w io.Writer, transactions []*Transaction, layout []TransactionColumn) error {
+	tw := NewTransactionCSVWriter(w, layout)
+	for _, t := range transactions {
+		if err := tw.Write(t); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
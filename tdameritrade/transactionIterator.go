@@ -0,0 +1,170 @@
+package tdameritrade
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// transactionChunkDays is the number of days requested at once by a
+// TransactionIterator, keeping every request within maxTransactionRange.
+const transactionChunkDays = 364
+
+// TransactionIterator walks the transactions of a date range of any length
+// in chronological order, oldest first. It requests the range in chunks the
+// API accepts and skips transactions seen before, e.g.
+//
+//	it := c.TransactionHistory.Iterate(ctx, accountID, opts)
+//	for it.Next() {
+//		t := it.Transaction()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type TransactionIterator struct {
+	s         *TransactionHistoryService
+	ctx       context.Context
+	accountID string
+	opts      TransactionHistoryOptions
+
+	next    time.Time // start of the next chunk
+	end     time.Time
+	buf     []*Transaction
+	cur     *Transaction
+	seen    map[int64]bool
+	err     error
+	started bool
+}
+
+// Iterate returns an iterator over the transactions of the account accountID
+// matching opts. opts.StartDate is required; opts.EndDate defaults to today.
+func (s *TransactionHistoryService) Iterate(ctx context.Context, accountID string, opts *TransactionHistoryOptions) *TransactionIterator {
+	it := &TransactionIterator{s: s, ctx: ctx, accountID: accountID, seen: make(map[int64]bool)}
+	if opts != nil {
+		it.opts = *opts
+	}
+	return it
+}
+
+func (it *TransactionIterator) start() error {
+	if it.opts.Type != "" && !it.opts.Type.valid() {
+		return fmt.Errorf("invalid type %q", it.opts.Type)
+	}
+	if it.opts.StartDate.IsZero() {
+		return fmt.Errorf("startDate is required")
+	}
+	it.next = truncateDay(it.opts.StartDate.Time)
+	it.end = truncateDay(time.Now())
+	if !it.opts.EndDate.IsZero() {
+		it.end = truncateDay(it.opts.EndDate.Time)
+	}
+	if it.end.Before(it.next) {
+		return fmt.Errorf("endDate is before startDate")
+	}
+	return nil
+}
+
+// Next advances to the next transaction, requesting the next chunk of the
+// range when needed. It returns false at the end of the range or on error.
+func (it *TransactionIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if !it.started {
+		it.started = true
+		if it.err = it.start(); it.err != nil {
+			return false
+		}
+	}
+
+	for len(it.buf) == 0 {
+		if it.next.After(it.end) {
+			it.cur = nil
+			return false
+		}
+		if it.err = it.fetch(); it.err != nil {
+			it.cur = nil
+			return false
+		}
+	}
+	it.cur, it.buf = it.buf[0], it.buf[1:]
+	return true
+}
+
+// fetch requests the next chunk of the range and queues its unseen
+// transactions, oldest first.
+func (it *TransactionIterator) fetch() error {
+	to := it.next.AddDate(0, 0, transactionChunkDays)
+	if to.After(it.end) {
+		to = it.end
+	}
+	opts := it.opts
+	opts.StartDate = Date{it.next}
+	opts.EndDate = Date{to}
+	transactions, _, err := it.s.GetTransactions(it.ctx, it.accountID, &opts)
+	if err != nil {
+		return err
+	}
+	it.next = to.AddDate(0, 0, 1)
+
+	for _, t := range transactions {
+		if it.seen[t.TransactionID] {
+			continue
+		}
+		it.seen[t.TransactionID] = true
+		it.buf = append(it.buf, t)
+	}
+	sortTransactions(it.buf)
+	return nil
+}
+
+// Transaction returns the current transaction.
+func (it *TransactionIterator) Transaction() *Transaction {
+	return it.cur
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *TransactionIterator) Err() error {
+	return it.err
+}
+
+// All drains the iterator and returns the remaining transactions.
+func (it *TransactionIterator) All() ([]*Transaction, error) {
+	var transactions []*Transaction
+	for it.Next() {
+		transactions = append(transactions, it.Transaction())
+	}
+	return transactions, it.Err()
+}
+
+// sortTransactions sorts transactions chronologically, breaking ties by id.
+// Transactions with an unparseable date keep their place among each other
+// and go last.
+func sortTransactions(transactions []*Transaction) {
+	times := make(map[*Transaction]time.Time, len(transactions))
+	for _, t := range transactions {
+		if tm, err := t.Time(); err == nil {
+			times[t] = tm
+		}
+	}
+	sort.SliceStable(transactions, func(i, j int) bool {
+		a, b := transactions[i], transactions[j]
+		ta, aok := times[a]
+		tb, bok := times[b]
+		switch {
+		case aok != bok:
+			return aok
+		case !aok:
+			return false
+		case !ta.Equal(tb):
+			return ta.Before(tb)
+		}
+		return a.TransactionID < b.TransactionID
+	})
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
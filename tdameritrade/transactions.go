@@ -0,0 +1,211 @@
+package tdameritrade
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/go-querystring/query"
+)
+
+// TransactionHistoryService handles communication with the transaction
+// history related methods of the TDAmeritrade API.
+//
+// TDAmeritrade API docs: https://developer.tdameritrade.com/transaction-history/apis
+type TransactionHistoryService struct {
+	client *Client
+}
+
+// TransactionType selects the transactions returned by GetTransactions.
+type TransactionType string
+
+const (
+	TransactionTypeAll         TransactionType = "ALL"
+	TransactionTypeTrade       TransactionType = "TRADE"
+	TransactionTypeBuyOnly     TransactionType = "BUY_ONLY"
+	TransactionTypeSellOnly    TransactionType = "SELL_ONLY"
+	TransactionTypeCashInOrOut TransactionType = "CASH_IN_OR_CASH_OUT"
+	TransactionTypeChecking    TransactionType = "CHECKING"
+	TransactionTypeDividend    TransactionType = "DIVIDEND"
+	TransactionTypeInterest    TransactionType = "INTEREST"
+	TransactionTypeOther       TransactionType = "OTHER"
+	TransactionTypeAdvisorFees TransactionType = "ADVISOR_FEES"
+)
+
+func (t TransactionType) String() string { return string(t) }
+
+// EncodeValues implements query.Encoder.
+func (t TransactionType) EncodeValues(key string, v *url.Values) error {
+	v.Set(key, t.String())
+	return nil
+}
+
+func (t TransactionType) valid() bool {
+	switch t {
+	case TransactionTypeAll, TransactionTypeTrade, TransactionTypeBuyOnly,
+		TransactionTypeSellOnly, TransactionTypeCashInOrOut, TransactionTypeChecking,
+		TransactionTypeDividend, TransactionTypeInterest, TransactionTypeOther,
+		TransactionTypeAdvisorFees:
+		return true
+	}
+	return false
+}
+
+// maxTransactionRange is the longest date range a single GetTransactions
+// request may span.
+const maxTransactionRange = 365 * 24 * time.Hour
+
+// TransactionHistoryOptions filter the transactions returned by
+// GetTransactions. Without dates the API returns the transactions of the
+// last year.
+type TransactionHistoryOptions struct {
+	Type      TransactionType `url:"type,omitempty"`
+	Symbol    string          `url:"symbol,omitempty"`
+	StartDate Date            `url:"startDate,omitempty"`
+	EndDate   Date            `url:"endDate,omitempty"`
+}
+
+func (opts *TransactionHistoryOptions) validate() error {
+	if opts.Type != "" && !opts.Type.valid() {
+		return fmt.Errorf("invalid type %q", opts.Type)
+	}
+	if !opts.StartDate.IsZero() && !opts.EndDate.IsZero() {
+		if opts.EndDate.Before(opts.StartDate.Time) {
+			return fmt.Errorf("endDate is before startDate")
+		}
+		if opts.EndDate.Sub(opts.StartDate.Time) > maxTransactionRange {
+			return fmt.Errorf("startDate and endDate must be at most a year apart")
+		}
+	}
+	return nil
+}
+
+// Transaction is an entry of the transaction history of an account.
+type Transaction struct {
+	Type                          string           `json:"type"`
+	ClearingReferenceNumber       string           `json:"clearingReferenceNumber"`
+	SubAccount                    string           `json:"subAccount"`
+	SettlementDate                string           `json:"settlementDate"`
+	OrderID                       string           `json:"orderId"`
+	SMA                           float64          `json:"sma"`
+	RequirementReallocationAmount float64          `json:"requirementReallocationAmount"`
+	DayTradeBuyingPowerEffect     float64          `json:"dayTradeBuyingPowerEffect"`
+	NetAmount                     float64          `json:"netAmount"`
+	TransactionDate               string           `json:"transactionDate"`
+	OrderDate                     string           `json:"orderDate"`
+	TransactionSubType            string           `json:"transactionSubType"`
+	TransactionID                 int64            `json:"transactionId"`
+	CashBalanceEffectFlag         bool             `json:"cashBalanceEffectFlag"`
+	Description                   string           `json:"description"`
+	ACHStatus                     string           `json:"achStatus"`
+	AccruedInterest               float64          `json:"accruedInterest"`
+	Fees                          TransactionFees  `json:"fees"`
+	TransactionItem               *TransactionItem `json:"transactionItem"`
+}
+
+// Time returns the time of the transaction.
+func (t *Transaction) Time() (time.Time, error) {
+	return time.Parse(timestampLayout, t.TransactionDate)
+}
+
+// TransactionFees are the fees charged for a transaction.
+type TransactionFees struct {
+	RFee          float64 `json:"rFee"`
+	AdditionalFee float64 `json:"additionalFee"`
+	CDSCFee       float64 `json:"cdscFee"`
+	RegFee        float64 `json:"regFee"`
+	OtherCharges  float64 `json:"otherCharges"`
+	Commission    float64 `json:"commission"`
+	OptRegFee     float64 `json:"optRegFee"`
+	SECFee        float64 `json:"secFee"`
+}
+
+// Total returns the sum of the fees.
+func (f TransactionFees) Total() float64 {
+	return f.RFee + f.AdditionalFee + f.CDSCFee + f.RegFee + f.OtherCharges + f.Commission + f.OptRegFee + f.SECFee
+}
+
+// TransactionItem is the security moved by a transaction. Amount is the
+// quantity, in shares or contracts.
+type TransactionItem struct {
+	AccountID            int64                  `json:"accountId"`
+	Amount               float64                `json:"amount"`
+	Price                float64                `json:"price"`
+	Cost                 float64                `json:"cost"`
+	ParentOrderKey       int64                  `json:"parentOrderKey"`
+	ParentChildIndicator string                 `json:"parentChildIndicator"`
+	Instruction          string                 `json:"instruction"`
+	PositionEffect       string                 `json:"positionEffect"`
+	Instrument           *TransactionInstrument `json:"instrument"`
+}
+
+// TransactionInstrument describes the security of a TransactionItem.
+type TransactionInstrument struct {
+	Symbol               string  `json:"symbol"`
+	UnderlyingSymbol     string  `json:"underlyingSymbol"`
+	OptionExpirationDate string  `json:"optionExpirationDate"`
+	OptionStrikePrice    float64 `json:"optionStrikePrice"`
+	PutCall              string  `json:"putCall"`
+	Cusip                string  `json:"cusip"`
+	Description          string  `json:"description"`
+	AssetType            string  `json:"assetType"`
+	BondMaturityDate     string  `json:"bondMaturityDate"`
+	BondInterestRate     float64 `json:"bondInterestRate"`
+}
+
+// GetTransactions returns the transactions of the account accountID matching
+// opts, which may be nil.
+// TDAmeritrade API Docs: https://developer.tdameritrade.com/transaction-history/apis/get/accounts/%7BaccountId%7D/transactions-0
+func (s *TransactionHistoryService) GetTransactions(ctx context.Context, accountID string, opts *TransactionHistoryOptions) ([]*Transaction, *Response, error) {
+	u, err := transactionsURL(accountID, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req, err := s.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var transactions []*Transaction
+	resp, err := s.client.Do(ctx, req, &transactions)
+	if err != nil {
+		return nil, resp, err
+	}
+	return transactions, resp, nil
+}
+
+// GetTransactionsRaw is GetTransactions returning the undecoded response
+// body.
+func (s *TransactionHistoryService) GetTransactionsRaw(ctx context.Context, accountID string, opts *TransactionHistoryOptions) (json.RawMessage, *Response, error) {
+	u, err := transactionsURL(accountID, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.client.getRaw(ctx, u)
+}
+
+func transactionsURL(accountID string, opts *TransactionHistoryOptions) (string, error) {
+	u := fmt.Sprintf("accounts/%s/transactions", accountID)
+	if opts != nil {
+		if err := opts.validate(); err != nil {
+			return "", err
+		}
+		q, err := query.Values(opts)
+		if err != nil {
+			return "", err
+		}
+		if len(q) > 0 {
+			u = fmt.Sprintf("%s?%s", u, q.Encode())
+		}
+	}
+	return u, nil
+}
+
+// GetTransaction returns the transaction transactionID.
+// TDAmeritrade API Docs: https://developer.tdameritrade.com/transaction-history/apis/get/accounts/%7BaccountId%7D/transactions/%7BtransactionId%7D-0
+func (s *TransactionHistoryService) GetTransaction(ctx context.Context, accountID string, transactionID int64) (*Transaction, *Response, error) {
+	return Get[Transaction](ctx, s.client, fmt.Sprintf("accounts/%s/transactions/%d", accountID, transactionID))
+}
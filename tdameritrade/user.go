@@ -1 +1,203 @@
 package tdameritrade
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// UserService handles communication with the user info and preferences
+// related methods of the TDAmeritrade API.
+//
+// TDAmeritrade API docs: https://developer.tdameritrade.com/user-principal/apis
+type UserService struct {
+	client *Client
+}
+
+// UserPrincipalsOptions select the optional fields returned with the user
+// principals.
+type UserPrincipalsOptions struct {
+	StreamerSubscriptionKeys bool // include the keys to subscribe to the streamer
+	StreamerConnectionInfo   bool // include StreamerInfo
+	Preferences              bool // include the preferences of every account
+	SurrogateIDs             bool // include the surrogate ids of every account
+}
+
+// fields returns the value of the fields query parameter, or "" if no
+// optional field is selected.
+func (opts *UserPrincipalsOptions) fields() string {
+	if opts == nil {
+		return ""
+	}
+	var fields []string
+	if opts.StreamerSubscriptionKeys {
+		fields = append(fields, "streamerSubscriptionKeys")
+	}
+	if opts.StreamerConnectionInfo {
+		fields = append(fields, "streamerConnectionInfo")
+	}
+	if opts.Preferences {
+		fields = append(fields, "preferences")
+	}
+	if opts.SurrogateIDs {
+		fields = append(fields, "surrogateIds")
+	}
+	return strings.Join(fields, ",")
+}
+
+type UserPrincipals struct {
+	AuthToken                string                    `json:"authToken"`
+	UserID                   string                    `json:"userId"`
+	UserCdDomainID           string                    `json:"userCdDomainId"`
+	PrimaryAccountID         string                    `json:"primaryAccountId"`
+	LastLoginTime            string                    `json:"lastLoginTime"`
+	TokenExpirationTime      string                    `json:"tokenExpirationTime"`
+	LoginTime                string                    `json:"loginTime"`
+	AccessLevel              string                    `json:"accessLevel"`
+	StalePassword            bool                      `json:"stalePassword"`
+	StreamerInfo             *StreamerInfo             `json:"streamerInfo,omitempty"`
+	ProfessionalStatus       string                    `json:"professionalStatus"`
+	Quotes                   *QuoteDelays              `json:"quotes,omitempty"`
+	StreamerSubscriptionKeys *StreamerSubscriptionKeys `json:"streamerSubscriptionKeys,omitempty"`
+	ExchangeAgreements       map[string]string         `json:"exchangeAgreements,omitempty"`
+	Accounts                 []*UserAccount            `json:"accounts"`
+}
+
+// StreamerInfo holds what is needed to connect and log in to the streamer.
+type StreamerInfo struct {
+	StreamerBinaryURL string `json:"streamerBinaryUrl"`
+	StreamerSocketURL string `json:"streamerSocketUrl"`
+	Token             string `json:"token"`
+	TokenTimestamp    string `json:"tokenTimestamp"`
+	UserGroup         string `json:"userGroup"`
+	AccessLevel       string `json:"accessLevel"`
+	ACL               string `json:"acl"`
+	AppID             string `json:"appId"`
+}
+
+// TokenTime returns TokenTimestamp parsed, which the streamer login expects
+// in epoch milliseconds.
+func (s *StreamerInfo) TokenTime() (time.Time, error) {
+	return time.Parse(timestampLayout, s.TokenTimestamp)
+}
+
+// QuoteDelays tells which exchanges the user receives delayed quotes from.
+type QuoteDelays struct {
+	IsNyseDelayed   bool `json:"isNyseDelayed"`
+	IsNasdaqDelayed bool `json:"isNasdaqDelayed"`
+	IsOpraDelayed   bool `json:"isOpraDelayed"`
+	IsAmexDelayed   bool `json:"isAmexDelayed"`
+	IsCmeDelayed    bool `json:"isCmeDelayed"`
+	IsIceDelayed    bool `json:"isIceDelayed"`
+	IsForexDelayed  bool `json:"isForexDelayed"`
+}
+
+type StreamerSubscriptionKeys struct {
+	Keys []struct {
+		Key string `json:"key"`
+	} `json:"keys"`
+}
+
+// UserAccount is an account the user has access to, as listed in the user
+// principals.
+type UserAccount struct {
+	AccountID         string                `json:"accountId"`
+	Description       string                `json:"description"`
+	DisplayName       string                `json:"displayName"`
+	AccountCdDomainID string                `json:"accountCdDomainId"`
+	Company           string                `json:"company"`
+	Segment           string                `json:"segment"`
+	SurrogateIDs      map[string]string     `json:"surrogateIds,omitempty"`
+	Preferences       *Preferences          `json:"preferences,omitempty"`
+	ACL               string                `json:"acl"`
+	Authorizations    AccountAuthorizations `json:"authorizations"`
+}
+
+// Preferences are the trading preferences of an account.
+type Preferences struct {
+	ExpressTrading                   bool    `json:"expressTrading"`
+	DirectOptionsRouting             bool    `json:"directOptionsRouting"`
+	DirectEquityRouting              bool    `json:"directEquityRouting"`
+	DefaultEquityOrderLegInstruction string  `json:"defaultEquityOrderLegInstruction"`
+	DefaultEquityOrderType           string  `json:"defaultEquityOrderType"`
+	DefaultEquityOrderPriceLinkType  string  `json:"defaultEquityOrderPriceLinkType"`
+	DefaultEquityOrderDuration       string  `json:"defaultEquityOrderDuration"`
+	DefaultEquityOrderMarketSession  string  `json:"defaultEquityOrderMarketSession"`
+	DefaultEquityQuantity            float64 `json:"defaultEquityQuantity"`
+	MutualFundTaxLotMethod           string  `json:"mutualFundTaxLotMethod"`
+	OptionTaxLotMethod               string  `json:"optionTaxLotMethod"`
+	EquityTaxLotMethod               string  `json:"equityTaxLotMethod"`
+	DefaultAdvancedToolLaunch        string  `json:"defaultAdvancedToolLaunch"`
+	AuthTokenTimeout                 string  `json:"authTokenTimeout"`
+}
+
+type AccountAuthorizations struct {
+	Apex               bool   `json:"apex"`
+	LevelTwoQuotes     bool   `json:"levelTwoQuotes"`
+	StockTrading       bool   `json:"stockTrading"`
+	MarginTrading      bool   `json:"marginTrading"`
+	StreamingNews      bool   `json:"streamingNews"`
+	OptionTradingLevel string `json:"optionTradingLevel"`
+	StreamerAccess     bool   `json:"streamerAccess"`
+	AdvancedMargin     bool   `json:"advancedMargin"`
+	ScottradeAccount   bool   `json:"scottradeAccount"`
+}
+
+// GetUserPrincipals returns the user details, with the optional fields
+// selected by opts. The streamer subscription keys and connection info are
+// needed to log in to the streamer.
+// TDAmeritrade API Docs: https://developer.tdameritrade.com/user-principal/apis/get/userprincipals-0
+func (s *UserService) GetUserPrincipals(ctx context.Context, opts *UserPrincipalsOptions) (*UserPrincipals, *Response, error) {
+	req, err := s.client.NewRequest("GET", principalsURL(opts), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	principals := new(UserPrincipals)
+	resp, err := s.client.Do(ctx, req, principals)
+	if err != nil {
+		return nil, resp, err
+	}
+	if s.client.StreamerURL != "" && principals.StreamerInfo != nil {
+		principals.StreamerInfo.StreamerSocketURL = s.client.StreamerURL
+	}
+	return principals, resp, nil
+}
+
+// GetUserPrincipalsRaw is GetUserPrincipals returning the undecoded
+// response body, without the StreamerURL override.
+func (s *UserService) GetUserPrincipalsRaw(ctx context.Context, opts *UserPrincipalsOptions) (json.RawMessage, *Response, error) {
+	return s.client.getRaw(ctx, principalsURL(opts))
+}
+
+func principalsURL(opts *UserPrincipalsOptions) string {
+	u := "userprincipals"
+	if fields := opts.fields(); fields != "" {
+		u = fmt.Sprintf("%s?fields=%s", u, fields)
+	}
+	return u
+}
+
+// GetPreferences returns the preferences of the account accountID.
+// TDAmeritrade API Docs: https://developer.tdameritrade.com/user-principal/apis/get/accounts/%7BaccountId%7D/preferences-0
+func (s *UserService) GetPreferences(ctx context.Context, accountID string) (*Preferences, *Response, error) {
+	return Get[Preferences](ctx, s.client, fmt.Sprintf("accounts/%s/preferences", accountID))
+}
+
+// UpdatePreferences replaces the preferences of the account accountID with
+// prefs. Every field is sent, so change the result of GetPreferences rather
+// than a zero Preferences to update only some of them.
+// TDAmeritrade API Docs: https://developer.tdameritrade.com/user-principal/apis/put/accounts/%7BaccountId%7D/preferences-0
+func (s *UserService) UpdatePreferences(ctx context.Context, accountID string, prefs *Preferences) (*Response, error) {
+	if prefs == nil {
+		return nil, fmt.Errorf("preferences are nil")
+	}
+	u := fmt.Sprintf("accounts/%s/preferences", accountID)
+	req, err := s.client.NewRequest("PUT", u, prefs)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.Do(ctx, req, nil)
+}
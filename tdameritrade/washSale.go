@@ -0,0 +1,150 @@
+package tdameritrade
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// washSaleWindow is how long before and after a loss a replacement purchase
+// makes it a wash sale.
+const washSaleWindow = 30 * 24 * time.Hour
+
+// optionShares is the number of shares an option contract stands for when
+// a call replaces sold stock.
+const optionShares = 100
+
+// WashSale is a loss that may be disallowed because a substantially
+// identical position was opened within 30 days before or after it.
+type WashSale struct {
+	Loss        *RealizedTrade
+	Replacement *Transaction
+
+	// Quantity is the part of the loss's quantity the replacement covers,
+	// and DisallowedLoss the matching part of the loss, as a positive
+	// amount.
+	Quantity       float64
+	DisallowedLoss float64
+}
+
+// FindWashSales flags the losing trades of report that are potential wash
+// sales: a long position closed at a loss with a buy of a substantially
+// identical security within 30 days before or after, or a short position
+// closed at a loss with a new short sale in that window. Stock is replaced
+// by the same stock or by calls on it, counted as 100 shares a contract; an
+// option is replaced by an option of the same underlying, type and strike,
+// whatever its expiration. Every replacement is used once, for the earliest
+// losses first. The purchase that opened the losing lot is not a
+// replacement.
+//
+// The result is a starting point for year-end review, not tax advice: the
+// rules have more cases, such as purchases in other accounts.
+func FindWashSales(report *RealizedPLReport, transactions []*Transaction) []*WashSale {
+	type candidate struct {
+		t         *Transaction
+		time      time.Time
+		remaining float64
+	}
+	var candidates []*candidate
+	for _, t := range transactions {
+		item := t.TransactionItem
+		if item == nil || item.Instrument == nil || item.Amount <= 0 || optionRemoval(t) != "" {
+			continue
+		}
+		switch strings.ToUpper(item.Instruction) {
+		case "BUY", "SELL":
+		default:
+			continue
+		}
+		tm, err := t.Time()
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates, &candidate{t, tm, item.Amount})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].time.Before(candidates[j].time) })
+
+	losses := make([]*RealizedTrade, 0, len(report.Trades))
+	for _, trade := range report.Trades {
+		if trade.Gain < 0 {
+			losses = append(losses, trade)
+		}
+	}
+	sort.SliceStable(losses, func(i, j int) bool { return losses[i].Closed.Before(losses[j].Closed) })
+
+	var sales []*WashSale
+	for _, loss := range losses {
+		remaining := loss.Quantity
+		for _, c := range candidates {
+			if remaining <= quantityEpsilon {
+				break
+			}
+			if c.remaining <= quantityEpsilon || c.t.TransactionID == loss.OpenTransactionID ||
+				c.t.TransactionID == loss.CloseTransactionID ||
+				c.time.Before(loss.Closed.Add(-washSaleWindow)) || c.time.After(loss.Closed.Add(washSaleWindow)) {
+				continue
+			}
+			// A long loss is replaced by buying, a short one by selling.
+			buy := strings.ToUpper(c.t.TransactionItem.Instruction) == "BUY"
+			if buy != loss.Long {
+				continue
+			}
+			ratio, ok := replacementRatio(loss, c.t.TransactionItem.Instrument)
+			if !ok {
+				continue
+			}
+
+			n := math.Min(remaining, c.remaining*ratio)
+			c.remaining -= n / ratio
+			remaining -= n
+			sales = append(sales, &WashSale{
+				Loss:           loss,
+				Replacement:    c.t,
+				Quantity:       n,
+				DisallowedLoss: -loss.Gain * n / loss.Quantity,
+			})
+		}
+	}
+	return sales
+}
+
+// replacementRatio reports whether instrument is substantially identical to
+// the security of loss and how many units of that security one unit of
+// instrument replaces.
+func replacementRatio(loss *RealizedTrade, instrument *TransactionInstrument) (float64, bool) {
+	if instrument.Symbol == loss.Symbol {
+		return 1, true
+	}
+	lossOption, lossErr := parseOptionOrOCC(loss.Symbol)
+	option, ok := transactionOption(instrument)
+	switch {
+	case lossErr != nil && ok:
+		// Stock replaced by calls on it.
+		if option.Underlying == loss.Symbol && option.PutCall == ContractTypeCall && loss.Long {
+			return optionShares, true
+		}
+	case lossErr == nil && ok:
+		if option.Underlying == lossOption.Underlying && option.PutCall == lossOption.PutCall &&
+			math.Abs(option.Strike-lossOption.Strike) < 1e-9 {
+			return 1, true
+		}
+	}
+	return 0, false
+}
+
+// transactionOption returns the contract of instrument, or false if it is
+// not an option.
+func transactionOption(instrument *TransactionInstrument) (*OptionSymbol, bool) {
+	if s, err := parseOptionOrOCC(instrument.Symbol); err == nil {
+		return s, true
+	}
+	if instrument.AssetType != "OPTION" || instrument.UnderlyingSymbol == "" {
+		return nil, false
+	}
+	return &OptionSymbol{
+		Underlying: instrument.UnderlyingSymbol,
+		PutCall:    ContractType(strings.ToUpper(instrument.PutCall)),
+		Strike:     instrument.OptionStrikePrice,
+	}, true
+}
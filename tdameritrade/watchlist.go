@@ -1 +1,199 @@
 package tdameritrade
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// WatchlistService handles communication with the watchlist related methods
+// of the TDAmeritrade API.
+//
+// TDAmeritrade API docs: https://developer.tdameritrade.com/watchlist/apis
+type WatchlistService struct {
+	client *Client
+}
+
+// Watchlist is a named list of instruments of an account.
+type Watchlist struct {
+	Name           string           `json:"name,omitempty"`
+	WatchlistID    string           `json:"watchlistId,omitempty"`
+	AccountID      string           `json:"accountId,omitempty"`
+	Status         string           `json:"status,omitempty"`
+	WatchlistItems []*WatchlistItem `json:"watchlistItems"`
+}
+
+// WatchlistItem is one instrument of a watchlist, with an optional position
+// the user recorded for it.
+type WatchlistItem struct {
+	SequenceID    int                  `json:"sequenceId,omitempty"`
+	Quantity      float64              `json:"quantity,omitempty"`
+	AveragePrice  float64              `json:"averagePrice,omitempty"`
+	Commission    float64              `json:"commission,omitempty"`
+	PurchasedDate string               `json:"purchasedDate,omitempty"`
+	Instrument    *WatchlistInstrument `json:"instrument"`
+	Status        string               `json:"status,omitempty"`
+}
+
+// WatchlistInstrument is the instrument of a watchlist item. AssetType is one
+// of EQUITY, OPTION, MUTUAL_FUND, FIXED_INCOME and INDEX.
+type WatchlistInstrument struct {
+	Symbol      string `json:"symbol"`
+	Description string `json:"description,omitempty"`
+	AssetType   string `json:"assetType"`
+}
+
+// Symbols returns the symbols of the watchlist, in order and without
+// duplicates.
+func (w *Watchlist) Symbols() []string {
+	seen := make(map[string]bool, len(w.WatchlistItems))
+	var symbols []string
+	for _, item := range w.WatchlistItems {
+		if item == nil || item.Instrument == nil || item.Instrument.Symbol == "" || seen[item.Instrument.Symbol] {
+			continue
+		}
+		seen[item.Instrument.Symbol] = true
+		symbols = append(symbols, item.Instrument.Symbol)
+	}
+	return symbols
+}
+
+// GetWatchlist returns the watchlist watchlistID of the account accountID.
+// TDAmeritrade API Docs: https://developer.tdameritrade.com/watchlist/apis/get/accounts/%7BaccountId%7D/watchlists/%7BwatchlistId%7D-0
+func (s *WatchlistService) GetWatchlist(ctx context.Context, accountID, watchlistID string) (*Watchlist, *Response, error) {
+	u := fmt.Sprintf("accounts/%s/watchlists/%s", accountID, watchlistID)
+	req, err := s.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	watchlist := new(Watchlist)
+	resp, err := s.client.Do(ctx, req, watchlist)
+	if err != nil {
+		return nil, resp, err
+	}
+	return watchlist, resp, nil
+}
+
+// GetWatchlistsForAccount returns all the watchlists of the account
+// accountID.
+// TDAmeritrade API Docs: https://developer.tdameritrade.com/watchlist/apis/get/accounts/%7BaccountId%7D/watchlists-0
+func (s *WatchlistService) GetWatchlistsForAccount(ctx context.Context, accountID string) ([]*Watchlist, *Response, error) {
+	u := fmt.Sprintf("accounts/%s/watchlists", accountID)
+	req, err := s.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var watchlists []*Watchlist
+	resp, err := s.client.Do(ctx, req, &watchlists)
+	if err != nil {
+		return nil, resp, err
+	}
+	return watchlists, resp, nil
+}
+
+// maxQuoteSymbols is the largest number of symbols requested in one quotes
+// call, keeping the request URL within the limits of the API.
+const maxQuoteSymbols = 300
+
+// GetWatchlistQuotes fetches the watchlist watchlistID of the account
+// accountID and quotes for all of its instruments. Large watchlists are
+// quoted in several requests; the Response returned is that of the last
+// one. The result is keyed by the symbols of the watchlist: instruments the
+// quotes API knows by another symbol, such as indexes without their $
+// prefix, are translated, and fixed income instruments, which have no
+// quotes, are reported as missing.
+func (s *WatchlistService) GetWatchlistQuotes(ctx context.Context, accountID, watchlistID string) (*QuotesResult, *Response, error) {
+	watchlist, resp, err := s.GetWatchlist(ctx, accountID, watchlistID)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	result := &QuotesResult{
+		Quotes: make(Quotes),
+		Errors: make(SymbolErrors),
+	}
+	var quoteSymbols []string
+	watchlistSymbol := make(map[string]string) // by quote symbol
+	for _, item := range watchlist.WatchlistItems {
+		if item == nil || item.Instrument == nil || item.Instrument.Symbol == "" {
+			continue
+		}
+		symbol := item.Instrument.Symbol
+		if _, ok := result.Errors[symbol]; ok {
+			continue
+		}
+		quoteSymbol, err := item.Instrument.quoteSymbol()
+		if err != nil {
+			result.Missing = append(result.Missing, symbol)
+			result.Errors[symbol] = err
+			continue
+		}
+		if _, ok := watchlistSymbol[quoteSymbol]; ok {
+			continue
+		}
+		watchlistSymbol[quoteSymbol] = symbol
+		quoteSymbols = append(quoteSymbols, quoteSymbol)
+	}
+
+	for len(quoteSymbols) > 0 {
+		chunk := quoteSymbols
+		if len(chunk) > maxQuoteSymbols {
+			chunk = chunk[:maxQuoteSymbols]
+		}
+		quoteSymbols = quoteSymbols[len(chunk):]
+
+		var chunkResult *QuotesResult
+		chunkResult, resp, err = s.client.Quotes.GetQuotesResult(ctx, chunk)
+		if err != nil {
+			return nil, resp, err
+		}
+		for quoteSymbol, quote := range chunkResult.Quotes {
+			result.Quotes[watchlistSymbol[quoteSymbol]] = quote
+		}
+		for _, quoteSymbol := range chunkResult.Missing {
+			symbol := watchlistSymbol[quoteSymbol]
+			result.Missing = append(result.Missing, symbol)
+			result.Errors[symbol] = chunkResult.Errors[quoteSymbol]
+		}
+	}
+	return result, resp, nil
+}
+
+// quoteSymbol returns the symbol the quotes API knows the instrument by.
+func (i *WatchlistInstrument) quoteSymbol() (string, error) {
+	switch i.AssetType {
+	case "FIXED_INCOME":
+		return "", fmt.Errorf("%s instruments have no quotes", i.AssetType)
+	case "INDEX":
+		if !strings.HasPrefix(i.Symbol, "$") {
+			return "$" + i.Symbol, nil
+		}
+	case "FUTURE":
+		return FuturesSymbol(i.Symbol), nil
+	}
+	return i.Symbol, nil
+}
+
+// GetWatchlistOptionChains fetches the watchlist watchlistID of the account
+// accountID and the option chains of its equities, using the same opts for
+// every chain. The chains are fetched with OptionChainService.OptionChains
+// and returned the same way: keyed by symbol, with a SymbolErrors for the
+// symbols that failed. Other instruments of the watchlist are skipped.
+func (s *WatchlistService) GetWatchlistOptionChains(ctx context.Context, accountID, watchlistID string, opts *OptionChainOptions) (map[string]*OptionChain, error) {
+	watchlist, _, err := s.GetWatchlist(ctx, accountID, watchlistID)
+	if err != nil {
+		return nil, err
+	}
+	var symbols []string
+	for _, item := range watchlist.WatchlistItems {
+		if item != nil && item.Instrument != nil && item.Instrument.AssetType == "EQUITY" && item.Instrument.Symbol != "" {
+			symbols = append(symbols, item.Instrument.Symbol)
+		}
+	}
+	if len(symbols) == 0 {
+		return map[string]*OptionChain{}, nil
+	}
+	return s.client.OptionChain.OptionChains(ctx, symbols, opts)
+}
@@ -0,0 +1,208 @@
+package tdameritrade
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// ReadSymbolList reads a list of instruments kept outside of the API, e.g.
+// in a file under version control. It accepts two formats:
+//
+// A JSON array of symbols or of instruments:
+//
+//	["AAPL", "$SPX.X", {"symbol": "VFIAX", "assetType": "MUTUAL_FUND"}]
+//
+// Or text with one instrument per line, a symbol optionally followed by its
+// asset type, where blank lines and everything after a # are ignored:
+//
+//	# tech
+//	AAPL
+//	VFIAX MUTUAL_FUND
+//
+// Symbols are upper-cased. An instrument without an asset type is an OPTION
+// if its symbol is an option symbol, an INDEX if it starts with $ and an
+// EQUITY otherwise. Duplicate symbols are dropped.
+func ReadSymbolList(r io.Reader) ([]*WatchlistInstrument, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var instruments []*WatchlistInstrument
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var entries []json.RawMessage
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return nil, fmt.Errorf("symbol list: %v", err)
+		}
+		for i, entry := range entries {
+			instrument := new(WatchlistInstrument)
+			if err := json.Unmarshal(entry, &instrument.Symbol); err != nil {
+				if err := json.Unmarshal(entry, instrument); err != nil {
+					return nil, fmt.Errorf("symbol list: entry %d: %v", i+1, err)
+				}
+			}
+			instruments = append(instruments, instrument)
+		}
+	} else {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for line := 1; scanner.Scan(); line++ {
+			text := scanner.Text()
+			if i := strings.Index(text, "#"); i >= 0 {
+				text = text[:i]
+			}
+			fields := strings.Fields(text)
+			switch len(fields) {
+			case 0:
+				continue
+			case 1:
+				instruments = append(instruments, &WatchlistInstrument{Symbol: fields[0]})
+			case 2:
+				instruments = append(instruments, &WatchlistInstrument{Symbol: fields[0], AssetType: fields[1]})
+			default:
+				return nil, fmt.Errorf("symbol list: line %d: want a symbol and an optional asset type", line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	seen := make(map[string]bool, len(instruments))
+	list := instruments[:0]
+	for _, instrument := range instruments {
+		instrument.Symbol = strings.ToUpper(strings.TrimSpace(instrument.Symbol))
+		instrument.AssetType = strings.ToUpper(instrument.AssetType)
+		if instrument.Symbol == "" {
+			return nil, fmt.Errorf("symbol list: empty symbol")
+		}
+		if seen[instrument.Symbol] {
+			continue
+		}
+		seen[instrument.Symbol] = true
+		if instrument.AssetType == "" {
+			instrument.AssetType = guessAssetType(instrument.Symbol)
+		}
+		list = append(list, instrument)
+	}
+	return list, nil
+}
+
+func guessAssetType(symbol string) string {
+	if _, err := parseOptionOrOCC(symbol); err == nil {
+		return "OPTION"
+	}
+	if strings.HasPrefix(symbol, "$") {
+		return "INDEX"
+	}
+	return "EQUITY"
+}
+
+// WatchlistDiff is the difference between a watchlist and a symbol list:
+// Add are the instruments of the list missing from the watchlist, in list
+// order, and Remove the items of the watchlist that are not on the list.
+type WatchlistDiff struct {
+	Add    []*WatchlistInstrument
+	Remove []*WatchlistItem
+}
+
+// Empty reports whether the watchlist already matches the list.
+func (d *WatchlistDiff) Empty() bool {
+	return len(d.Add) == 0 && len(d.Remove) == 0
+}
+
+// DiffWatchlist compares the items of w with instruments by symbol, ignoring
+// case. Duplicate items of w beyond the first are removed.
+func DiffWatchlist(w *Watchlist, instruments []*WatchlistInstrument) *WatchlistDiff {
+	wanted := make(map[string]bool, len(instruments))
+	for _, instrument := range instruments {
+		wanted[strings.ToUpper(instrument.Symbol)] = true
+	}
+
+	diff := new(WatchlistDiff)
+	present := make(map[string]bool, len(w.WatchlistItems))
+	for _, item := range w.WatchlistItems {
+		if item == nil || item.Instrument == nil {
+			continue
+		}
+		symbol := strings.ToUpper(item.Instrument.Symbol)
+		if !wanted[symbol] || present[symbol] {
+			diff.Remove = append(diff.Remove, item)
+			continue
+		}
+		present[symbol] = true
+	}
+	for _, instrument := range instruments {
+		symbol := strings.ToUpper(instrument.Symbol)
+		if !present[symbol] {
+			present[symbol] = true
+			diff.Add = append(diff.Add, instrument)
+		}
+	}
+	return diff
+}
+
+// UpdateWatchlist partially updates the watchlist watchlistID of the account
+// accountID: the name if set, items with a sequence id are updated and items
+// without one are appended.
+// TDAmeritrade API Docs: https://developer.tdameritrade.com/watchlist/apis/patch/accounts/%7BaccountId%7D/watchlists/%7BwatchlistId%7D-0
+func (s *WatchlistService) UpdateWatchlist(ctx context.Context, accountID, watchlistID string, watchlist *Watchlist) (*Response, error) {
+	if watchlist == nil {
+		return nil, fmt.Errorf("watchlist is nil")
+	}
+	u := fmt.Sprintf("accounts/%s/watchlists/%s", accountID, watchlistID)
+	req, err := s.client.NewRequest("PATCH", u, watchlist)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.Do(ctx, req, nil)
+}
+
+// SyncWatchlist makes the watchlist watchlistID of the account accountID
+// hold the instruments of a symbol list, such as one read by ReadSymbolList,
+// and returns the changes made. Items already on the watchlist keep their
+// place and recorded position; new ones are appended in list order. The
+// changes are applied with a single partial update listing the items to
+// keep, unchanged, and the new ones, which drops the items left out; no
+// request is made if the watchlist already matches. The Response returned is
+// that of the last request made.
+func (s *WatchlistService) SyncWatchlist(ctx context.Context, accountID, watchlistID string, instruments []*WatchlistInstrument) (*WatchlistDiff, *Response, error) {
+	watchlist, resp, err := s.GetWatchlist(ctx, accountID, watchlistID)
+	if err != nil {
+		return nil, resp, err
+	}
+	diff := DiffWatchlist(watchlist, instruments)
+	if diff.Empty() {
+		return diff, resp, nil
+	}
+
+	removed := make(map[*WatchlistItem]bool, len(diff.Remove))
+	for _, item := range diff.Remove {
+		removed[item] = true
+	}
+	update := &Watchlist{
+		Name:           watchlist.Name,
+		WatchlistID:    watchlistID,
+		WatchlistItems: []*WatchlistItem{},
+	}
+	for _, item := range watchlist.WatchlistItems {
+		if item != nil && item.Instrument != nil && !removed[item] {
+			cp := *item
+			update.WatchlistItems = append(update.WatchlistItems, &cp)
+		}
+	}
+	for _, instrument := range diff.Add {
+		update.WatchlistItems = append(update.WatchlistItems, &WatchlistItem{Instrument: instrument})
+	}
+
+	resp, err = s.UpdateWatchlist(ctx, accountID, watchlistID, update)
+	if err != nil {
+		return nil, resp, err
+	}
+	return diff, resp, nil
+}
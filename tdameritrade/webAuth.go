@@ -0,0 +1,195 @@
+package tdameritrade
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// defaultStateTTL is how long a web user has to complete the login.
+const defaultStateTTL = 10 * time.Minute
+
+// ErrInvalidState is returned by WebAuth.HandleCallback for a callback that
+// does not answer an authorization the same user started, or answers it too
+// late or twice. It may be a forged request.
+var ErrInvalidState = errors.New("invalid or expired authorization state")
+
+// WebAuth runs the authorization of the users of a web app and hands out a
+// Client per user. Every authorization gets a random state bound to the
+// user who started it, which the callback must bring back, so that one user
+// can't be made to complete another's login. Tokens are kept in the
+// TokenStore of each user and saved again whenever they are refreshed. A
+// WebAuth is safe for concurrent use.
+type WebAuth struct {
+	ctx         context.Context
+	clientID    string
+	redirectURL string
+	store       func(userID string) TokenStore
+
+	// StateTTL is how long an authorization may take. Defaults to 10
+	// minutes.
+	StateTTL time.Duration
+
+	// Endpoints are those of the deployment of the app, such as
+	// SchwabEndpoints, and ClientSecret its secret, if it has one.
+	// Endpoints default to TDAmeritradeEndpoints. Set them before use.
+	Endpoints    *Endpoints
+	ClientSecret string
+
+	mu      sync.Mutex
+	states  map[string]authState
+	clients map[string]*Client
+}
+
+// authState is an authorization under way.
+type authState struct {
+	userID  string
+	expires time.Time
+}
+
+// NewWebAuth returns a WebAuth for the app with the consumer key clientID
+// whose callback is served at redirectURL. store returns the token store of
+// a user, such as a database row or an EncryptedFileStore per user. ctx is
+// used for token requests, like for NewTokenSource.
+func NewWebAuth(ctx context.Context, clientID, redirectURL string, store func(userID string) TokenStore) *WebAuth {
+	return &WebAuth{
+		ctx:         ctx,
+		clientID:    clientID,
+		redirectURL: redirectURL,
+		store:       store,
+		states:      make(map[string]authState),
+		clients:     make(map[string]*Client),
+	}
+}
+
+// AuthURL starts the authorization of the user userID and returns the login
+// URL to redirect the user to.
+func (a *WebAuth) AuthURL(userID string) (string, error) {
+	state, err := randomState()
+	if err != nil {
+		return "", err
+	}
+	ttl := a.StateTTL
+	if ttl <= 0 {
+		ttl = defaultStateTTL
+	}
+
+	a.mu.Lock()
+	now := time.Now()
+	for s, st := range a.states {
+		if now.After(st.expires) {
+			delete(a.states, s)
+		}
+	}
+	a.states[state] = authState{userID: userID, expires: now.Add(ttl)}
+	a.mu.Unlock()
+
+	return a.endpoints().AuthCodeURL(a.clientID, a.redirectURL, state), nil
+}
+
+// HandleCallback completes the authorization of the user userID, the user
+// of the session r belongs to, from r, the request to the callback. It
+// checks the state, exchanges the code and stores the token. It returns
+// ErrInvalidState if the state is unknown, expired, already used or was
+// issued to another user.
+func (a *WebAuth) HandleCallback(ctx context.Context, userID string, r *http.Request) error {
+	q := r.URL.Query()
+	state := q.Get("state")
+
+	a.mu.Lock()
+	st, ok := a.states[state]
+	delete(a.states, state)
+	a.mu.Unlock()
+	if !ok || time.Now().After(st.expires) || subtle.ConstantTimeCompare([]byte(st.userID), []byte(userID)) != 1 {
+		return ErrInvalidState
+	}
+	if e := q.Get("error"); e != "" {
+		return fmt.Errorf("authorization failed: %s", e)
+	}
+	code := q.Get("code")
+	if code == "" {
+		return fmt.Errorf("authorization failed: no code in the callback")
+	}
+
+	token, err := a.endpoints().ExchangeCode(ctx, a.clientID, a.ClientSecret, a.redirectURL, code)
+	if err != nil {
+		return err
+	}
+	if err := a.store(userID).Save(&StoredToken{Token: token, RefreshExpiry: RefreshTokenExpiry(token)}); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	delete(a.clients, userID)
+	a.mu.Unlock()
+	return nil
+}
+
+// Client returns the client of the user userID, built from the stored token
+// on first use. It returns ErrNoToken if the user has not authorized the app
+// yet.
+func (a *WebAuth) Client(userID string) (*Client, error) {
+	a.mu.Lock()
+	c, ok := a.clients[userID]
+	a.mu.Unlock()
+	if ok {
+		return c, nil
+	}
+
+	store := a.store(userID)
+	stored, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	e := a.endpoints()
+	ts := e.NewTokenSource(a.ctx, a.clientID, a.ClientSecret, stored.Token)
+	ts.RefreshExpiry = stored.RefreshExpiry
+	ts.OnRefresh = func(t *oauth2.Token) {
+		// A failed save only costs a refresh on the next start.
+		_ = store.Save(&StoredToken{Token: t, RefreshExpiry: ts.RefreshExpiry})
+	}
+	c, err = NewClient(ts.Client(), WithEndpoints(e))
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if existing, ok := a.clients[userID]; ok {
+		return existing, nil
+	}
+	a.clients[userID] = c
+	return c, nil
+}
+
+// Forget drops the client of the user userID, e.g. on logout. The stored
+// token is left alone.
+func (a *WebAuth) Forget(userID string) {
+	a.mu.Lock()
+	delete(a.clients, userID)
+	a.mu.Unlock()
+}
+
+// endpoints returns the endpoints of the app.
+func (a *WebAuth) endpoints() Endpoints {
+	if a.Endpoints != nil {
+		return *a.Endpoints
+	}
+	return TDAmeritradeEndpoints
+}
+
+// randomState returns an unguessable OAuth state parameter.
+func randomState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}